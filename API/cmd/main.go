@@ -1,51 +1,96 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
-	"inventario/internal/infrastructure/repository"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/security"
+	"inventario/internal/infrastructure/storage"
 	"inventario/internal/interface/handler"
 	"inventario/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
-	// Initialize MySQL connection
-	db, err := sql.Open("mysql", os.Getenv("MYSQL_DSN"))
-	if err != nil {
-		log.Fatalf("Failed to connect to MySQL: %v", err)
+	// Initialize storage
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = os.Getenv("MYSQL_DSN")
 	}
-	defer db.Close()
-
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping MySQL: %v", err)
+	store, err := storage.Open(storage.Config{
+		Driver:  stringFromEnv("DB_DRIVER", storage.DriverMySQL),
+		DSN:     dsn,
+		Migrate: boolFromEnv("MIGRATE_ON_STARTUP", false),
+	})
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
 	}
+	defer store.Close()
 
 	// Initialize repositories
-	productRepo := repository.NewMySQLProductRepository(db)
-	userRepo := repository.NewMySQLUserRepository(db)
-	stockRepo := repository.NewMySQLStockRepository(db)
-	providerRepo := repository.NewMySQLProviderRepository(db)
+	productRepo := store.Products
+	userRepo := store.Users
+	stockRepo := store.Stocks
+	stockMovementRepo := store.StockMovements
+	providerRepo := store.Providers
+	warehouseRepo := store.Warehouses
+	locationRepo := store.Locations
+	transferRepo := store.Transfers
+	purchaseOrderRepo := store.PurchaseOrders
+	stockBalanceRepo := store.StockBalances
+	unitOfWork := store.UnitOfWork
+
+	// Initialize password hashing
+	passwordHasher := security.NewPBKDF2Hasher(intFromEnv("PASSWORD_HASH_ITERATIONS", security.DefaultPBKDF2Iterations))
+	passwordPolicy := domain.DefaultPasswordPolicy()
+	passwordPolicy.MinLength = intFromEnv("PASSWORD_MIN_LENGTH", passwordPolicy.MinLength)
+	passwordPolicy.RequireUpper = boolFromEnv("PASSWORD_REQUIRE_UPPER", passwordPolicy.RequireUpper)
+	passwordPolicy.RequireLower = boolFromEnv("PASSWORD_REQUIRE_LOWER", passwordPolicy.RequireLower)
+	passwordPolicy.RequireDigit = boolFromEnv("PASSWORD_REQUIRE_DIGIT", passwordPolicy.RequireDigit)
+	passwordPolicy.RequireSymbol = boolFromEnv("PASSWORD_REQUIRE_SYMBOL", passwordPolicy.RequireSymbol)
+
+	// Initialize token service
+	tokenService, err := security.NewHMACTokenService([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		log.Fatalf("Failed to initialize token service: %v", err)
+	}
 
 	// Initialize use cases
-	productUseCase := usecase.NewProductUseCase(productRepo)
-	userUseCase := usecase.NewUserUseCase(userRepo)
-	stockUseCase := usecase.NewStockUseCase(stockRepo)
-	providerUseCase := usecase.NewProviderUseCase(providerRepo)
+	authUseCase := usecase.NewAuthUseCase(userRepo, passwordHasher, tokenService,
+		durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		durationFromEnv("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+	)
+	productUseCase := usecase.NewProductUseCase(productRepo, stockRepo, unitOfWork)
+	userUseCase := usecase.NewUserUseCase(userRepo, passwordHasher, passwordPolicy, stockRepo, unitOfWork)
+	stockUseCase := usecase.NewStockUseCase(stockRepo, stockMovementRepo, productRepo, stockBalanceRepo, unitOfWork, boolFromEnv("STOCK_AUDIT_TRUST_CLIENT", false))
+	providerUseCase := usecase.NewProviderUseCase(providerRepo, stockRepo, unitOfWork)
+	warehouseUseCase := usecase.NewWarehouseUseCase(warehouseRepo, locationRepo)
+	locationUseCase := usecase.NewLocationUseCase(locationRepo, warehouseRepo)
+	transferUseCase := usecase.NewTransferUseCase(transferRepo, stockRepo, locationRepo, unitOfWork)
+	purchaseOrderUseCase := usecase.NewPurchaseOrderUseCase(purchaseOrderRepo, providerRepo, productRepo, stockRepo, unitOfWork)
+	stockBalanceUseCase := usecase.NewStockBalanceUseCase(stockBalanceRepo, productRepo, locationRepo)
+	importUseCase := usecase.NewImportUseCase(unitOfWork)
 
 	// Initialize handlers
+	authHandler := handler.NewAuthHandler(authUseCase)
 	productHandler := handler.NewProductHandler(productUseCase)
 	userHandler := handler.NewUserHandler(userUseCase)
 	stockHandler := handler.NewStockHandler(stockUseCase)
 	providerHandler := handler.NewProviderHandler(providerUseCase)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseUseCase)
+	locationHandler := handler.NewLocationHandler(locationUseCase)
+	transferHandler := handler.NewTransferHandler(transferUseCase)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderUseCase)
+	stockBalanceHandler := handler.NewStockBalanceHandler(stockBalanceUseCase)
+	csvHandler := handler.NewCSVHandler(importUseCase, productUseCase, providerUseCase, stockUseCase)
+	xlsxHandler := handler.NewXLSXHandler(productUseCase, stockUseCase)
 
 	// Initialize router
 	r := chi.NewRouter()
@@ -53,45 +98,131 @@ func main() {
 	// Middleware
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(handler.RequestTimeout(durationFromEnv("REQUEST_TIMEOUT", 30*time.Second)))
+	// Updates and deletions of versioned records may have to name the version
+	// they are based on
+	ifMatch := handler.RequireIfMatch(boolFromEnv("REQUIRE_IF_MATCH", false))
 
 	// Routes
 	r.Route("/api", func(r chi.Router) {
-		// Product routes
-		r.Route("/products", func(r chi.Router) {
-			r.Post("/", productHandler.CreateProduct)
-			r.Get("/", productHandler.GetAllProducts)
-			r.Get("/{id}", productHandler.GetProduct)
-			r.Put("/{id}", productHandler.UpdateProduct)
-			r.Delete("/{id}", productHandler.DeleteProduct)
+		// Auth routes
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/login", authHandler.Login)
+			r.Post("/refresh", authHandler.Refresh)
 		})
 
-		// User routes
-		r.Route("/users", func(r chi.Router) {
-			r.Post("/", userHandler.CreateUser)
-			r.Get("/", userHandler.GetAllUsers)
-			r.Get("/{id}", userHandler.GetUser)
-			r.Put("/{id}", userHandler.UpdateUser)
-			r.Delete("/{id}", userHandler.DeleteUser)
-		})
+		// Every other route requires an authenticated user
+		r.Group(func(r chi.Router) {
+			r.Use(authHandler.Authenticate)
 
-		// Stock routes
-		r.Route("/stocks", func(r chi.Router) {
-			r.Post("/", stockHandler.CreateStock)
-			r.Get("/", stockHandler.GetAllStocks)
-			r.Get("/{id}", stockHandler.GetStock)
-			r.Put("/{id}", stockHandler.UpdateStock)
-			r.Delete("/{id}", stockHandler.DeleteStock)
-			r.Get("/product/{productId}", stockHandler.GetStocksByProductID)
-			r.Get("/serial/{serial}", stockHandler.GetStockBySerial)
-		})
+			// Product routes
+			r.Route("/products", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermProductsWrite)).Post("/", productHandler.CreateProduct)
+				r.With(handler.RequirePermission(domain.PermProductsRead)).Get("/", productHandler.GetAllProducts)
+				r.With(handler.RequirePermission(domain.PermProductsWrite)).Post("/import", csvHandler.ImportProducts)
+				r.With(handler.RequirePermission(domain.PermProductsRead), handler.WithoutRequestTimeout).Get("/export", csvHandler.ExportProducts)
+				r.With(handler.RequirePermission(domain.PermProductsRead)).Get("/{id}", productHandler.GetProduct)
+				r.With(handler.RequirePermission(domain.PermProductsWrite), ifMatch).Put("/{id}", productHandler.UpdateProduct)
+				r.With(handler.RequirePermission(domain.PermProductsWrite), ifMatch).Patch("/{id}", productHandler.PatchProduct)
+				r.With(handler.RequirePermission(domain.PermProductsWrite), ifMatch).Delete("/{id}", productHandler.DeleteProduct)
+				r.With(handler.RequirePermission(domain.PermProductsWrite)).Post("/{id}/restore", productHandler.RestoreProduct)
+			})
+
+			// User routes
+			r.Route("/users", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermUsersWrite)).Post("/", userHandler.CreateUser)
+				r.With(handler.RequirePermission(domain.PermUsersRead)).Get("/", userHandler.GetAllUsers)
+				r.With(handler.RequirePermission(domain.PermUsersRead)).Get("/{id}", userHandler.GetUser)
+				r.With(handler.RequirePermission(domain.PermUsersWrite), ifMatch).Put("/{id}", userHandler.UpdateUser)
+				r.With(handler.RequirePermission(domain.PermUsersWrite), ifMatch).Patch("/{id}", userHandler.PatchUser)
+				r.With(handler.RequirePermission(domain.PermUsersDelete), ifMatch).Delete("/{id}", userHandler.DeleteUser)
+				r.With(handler.RequirePermission(domain.PermUsersDelete)).Post("/{id}/restore", userHandler.RestoreUser)
+				r.Post("/{id}/password", userHandler.ChangePassword)
+			})
+
+			// Stock routes
+			r.Route("/stocks", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/", stockHandler.CreateStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/bulk", stockHandler.CreateStocks)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/", stockHandler.GetAllStocks)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/import", csvHandler.ImportStocks)
+				r.With(handler.RequirePermission(domain.PermStocksRead), handler.WithoutRequestTimeout).Get("/export", csvHandler.ExportStocks)
+				r.With(handler.RequirePermission(domain.PermStocksRead), handler.WithoutRequestTimeout).Get("/export.xlsx", xlsxHandler.ExportStocks)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/on-hand", stockHandler.GetOnHand)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/{id}", stockHandler.GetStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite), ifMatch).Put("/{id}", stockHandler.UpdateStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite), ifMatch).Patch("/{id}", stockHandler.PatchStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite), ifMatch).Delete("/{id}", stockHandler.DeleteStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/{id}/restore", stockHandler.RestoreStock)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/{id}/transitions", stockHandler.TransitionStock)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/{id}/history", stockHandler.GetStockHistory)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/product/{productId}", stockHandler.GetStocksByProductID)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/product/{productId}/locations", stockHandler.GetStockLocationsByProductID)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/serial/{serial}", stockHandler.GetStockBySerial)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/serial/{serial}/history", stockHandler.GetStockHistoryBySerial)
+			})
+
+			// Quantity-tracked stock routes
+			r.Route("/stock-balances", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/", stockBalanceHandler.GetStockBalances)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/movements", stockBalanceHandler.GetBalanceMovements)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/receive", stockBalanceHandler.ReceiveQuantity)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/issue", stockBalanceHandler.IssueQuantity)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/adjust", stockBalanceHandler.AdjustQuantity)
+			})
+
+			// Provider routes
+			r.Route("/providers", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermProvidersWrite)).Post("/", providerHandler.CreateProvider)
+				r.With(handler.RequirePermission(domain.PermProvidersRead)).Get("/", providerHandler.GetAllProviders)
+				r.With(handler.RequirePermission(domain.PermProvidersWrite)).Post("/import", csvHandler.ImportProviders)
+				r.With(handler.RequirePermission(domain.PermProvidersRead), handler.WithoutRequestTimeout).Get("/export", csvHandler.ExportProviders)
+				r.With(handler.RequirePermission(domain.PermProvidersRead)).Get("/{id}", providerHandler.GetProvider)
+				r.With(handler.RequirePermission(domain.PermProvidersWrite), ifMatch).Put("/{id}", providerHandler.UpdateProvider)
+				r.With(handler.RequirePermission(domain.PermProvidersWrite), ifMatch).Patch("/{id}", providerHandler.PatchProvider)
+				r.With(handler.RequirePermission(domain.PermProvidersWrite), ifMatch).Delete("/{id}", providerHandler.DeleteProvider)
+				r.With(handler.RequirePermission(domain.PermProvidersWrite)).Post("/{id}/restore", providerHandler.RestoreProvider)
+			})
 
-		// Provider routes
-		r.Route("/providers", func(r chi.Router) {
-			r.Post("/", providerHandler.CreateProvider)
-			r.Get("/", providerHandler.GetAllProviders)
-			r.Get("/{id}", providerHandler.GetProvider)
-			r.Put("/{id}", providerHandler.UpdateProvider)
-			r.Delete("/{id}", providerHandler.DeleteProvider)
+			// Warehouse routes
+			r.Route("/warehouses", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Post("/", warehouseHandler.CreateWarehouse)
+				r.With(handler.RequirePermission(domain.PermWarehousesRead)).Get("/", warehouseHandler.GetAllWarehouses)
+				r.With(handler.RequirePermission(domain.PermWarehousesRead)).Get("/{id}", warehouseHandler.GetWarehouse)
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Put("/{id}", warehouseHandler.UpdateWarehouse)
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Delete("/{id}", warehouseHandler.DeleteWarehouse)
+				r.With(handler.RequirePermission(domain.PermWarehousesRead)).Get("/{id}/locations", warehouseHandler.GetWarehouseLocations)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/{id}/stocks", stockHandler.GetStocksByWarehouse)
+			})
+
+			// Location routes
+			r.Route("/locations", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Post("/", locationHandler.CreateLocation)
+				r.With(handler.RequirePermission(domain.PermWarehousesRead)).Get("/", locationHandler.GetAllLocations)
+				r.With(handler.RequirePermission(domain.PermWarehousesRead)).Get("/{id}", locationHandler.GetLocation)
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Put("/{id}", locationHandler.UpdateLocation)
+				r.With(handler.RequirePermission(domain.PermWarehousesWrite)).Delete("/{id}", locationHandler.DeleteLocation)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/{id}/stocks", stockHandler.GetStocksByLocation)
+			})
+
+			// Transfer routes
+			r.Route("/transfers", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/", transferHandler.CreateTransfer)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/", transferHandler.GetAllTransfers)
+				r.With(handler.RequirePermission(domain.PermStocksRead)).Get("/{id}", transferHandler.GetTransfer)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/{id}/dispatch", transferHandler.DispatchTransfer)
+				r.With(handler.RequirePermission(domain.PermStocksWrite)).Post("/{id}/receive", transferHandler.ReceiveTransfer)
+			})
+
+			// Purchase order routes
+			r.Route("/purchase-orders", func(r chi.Router) {
+				r.With(handler.RequirePermission(domain.PermPurchasesWrite)).Post("/", purchaseOrderHandler.CreatePurchaseOrder)
+				r.With(handler.RequirePermission(domain.PermPurchasesRead)).Get("/", purchaseOrderHandler.GetAllPurchaseOrders)
+				r.With(handler.RequirePermission(domain.PermPurchasesRead)).Get("/{id}", purchaseOrderHandler.GetPurchaseOrder)
+				r.With(handler.RequirePermission(domain.PermPurchasesWrite)).Post("/{id}/submit", purchaseOrderHandler.SubmitPurchaseOrder)
+				r.With(handler.RequirePermission(domain.PermPurchasesWrite)).Post("/{id}/cancel", purchaseOrderHandler.CancelPurchaseOrder)
+				r.With(handler.RequirePermission(domain.PermPurchasesWrite), handler.RequirePermission(domain.PermStocksWrite)).Post("/{id}/receive", purchaseOrderHandler.ReceivePurchaseOrder)
+			})
 		})
 	})
 
@@ -106,3 +237,54 @@ func main() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// stringFromEnv reads a string from the environment, falling back to def when
+// the variable is unset
+func stringFromEnv(key string, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// durationFromEnv reads a time.Duration such as "15m" from the environment,
+// falling back to def when the variable is unset
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration for %s: %v", key, err)
+	}
+	return d
+}
+
+// boolFromEnv reads a boolean from the environment, falling back to def when
+// the variable is unset
+func boolFromEnv(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid boolean for %s: %v", key, err)
+	}
+	return b
+}
+
+// intFromEnv reads an integer from the environment, falling back to def when
+// the variable is unset
+func intFromEnv(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid integer for %s: %v", key, err)
+	}
+	return i
+}
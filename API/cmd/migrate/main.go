@@ -0,0 +1,102 @@
+// Command migrate applies, reverts and lists the schema migrations.
+//
+//	go run ./cmd/migrate [-driver mysql|sqlite] [-dsn DSN] up|down [steps]|status
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"inventario/internal/infrastructure/migration"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	defaultDriver, defaultDSN := os.Getenv("DB_DRIVER"), os.Getenv("DB_DSN")
+	if defaultDriver == "" {
+		defaultDriver = "mysql"
+	}
+	if defaultDSN == "" {
+		defaultDSN = os.Getenv("MYSQL_DSN")
+	}
+	driver := flag.String("driver", defaultDriver, "database driver: mysql or sqlite (defaults to $DB_DRIVER)")
+	dsn := flag.String("dsn", defaultDSN, "data source name (defaults to $DB_DSN or $MYSQL_DSN)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: migrate [-driver mysql|sqlite] [-dsn DSN] up|down [steps]|status")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var dialect migration.Dialect
+	var driverName string
+	switch *driver {
+	case "mysql":
+		dialect, driverName = migration.MySQL, "mysql"
+	case "sqlite":
+		dialect, driverName = migration.SQLite, "sqlite3"
+	default:
+		log.Fatalf("Unknown driver %q", *driver)
+	}
+
+	db, err := sql.Open(driverName, *dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migration.NewMigrator(db, dialect)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", flag.Arg(1))
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,52 @@
+// Command purge removes for good the products, providers, users and stock
+// units soft deleted longer ago than the retention window.
+//
+//	go run ./cmd/purge [-driver mysql|sqlite] [-dsn DSN] [-retention 720h]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"inventario/internal/infrastructure/storage"
+	"inventario/internal/usecase"
+)
+
+func main() {
+	defaultDriver, defaultDSN := os.Getenv("DB_DRIVER"), os.Getenv("DB_DSN")
+	if defaultDriver == "" {
+		defaultDriver = storage.DriverMySQL
+	}
+	if defaultDSN == "" {
+		defaultDSN = os.Getenv("MYSQL_DSN")
+	}
+	driver := flag.String("driver", defaultDriver, "database driver: mysql or sqlite (defaults to $DB_DRIVER)")
+	dsn := flag.String("dsn", defaultDSN, "data source name (defaults to $DB_DSN or $MYSQL_DSN)")
+	retention := flag.Duration("retention", 30*24*time.Hour, "how long deleted records are kept before being purged")
+	flag.Parse()
+
+	if *driver != storage.DriverMySQL && *driver != storage.DriverSQLite {
+		log.Fatalf("Unknown driver %q", *driver)
+	}
+	if *retention < 0 {
+		log.Fatalf("Invalid retention %v", *retention)
+	}
+
+	store, err := storage.Open(storage.Config{Driver: *driver, DSN: *dsn})
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	purged, err := usecase.NewPurgeUseCase(store.Stocks, store.Products, store.Providers, store.Users).Purge(context.Background(), *retention)
+	for _, p := range purged {
+		fmt.Printf("purged %d %s\n", p.Count, p.Table)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// TokenType distinguishes short-lived access tokens from refresh tokens
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
+// TokenClaims holds the information carried by a signed token
+type TokenClaims struct {
+	UserID    int64
+	Type      TokenType
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// AuthTokens is the pair of tokens issued after a successful login or refresh
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         *User  `json:"user"`
+}
+
+// ITokenService defines the interface for issuing and verifying signed tokens
+type ITokenService interface {
+	Generate(userID int64, tokenType TokenType, ttl time.Duration) (string, error)
+	Parse(token string) (*TokenClaims, error)
+}
+
+// InvalidCredentialsError represents a failed login attempt
+type InvalidCredentialsError struct{}
+
+func (e *InvalidCredentialsError) Error() string {
+	return "invalid email or password"
+}
+
+// InvalidTokenError represents a missing, malformed, expired or tampered token
+type InvalidTokenError struct {
+	Reason string
+}
+
+func (e *InvalidTokenError) Error() string {
+	if e.Reason != "" {
+		return "invalid token: " + e.Reason
+	}
+	return "invalid token"
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -26,3 +27,109 @@ type ProductNotFoundError struct {
 func (e *ProductNotFoundError) Error() string {
 	return fmt.Sprintf("product with ID %d not found", e.ProductID)
 }
+
+// InvalidTrackingModeError represents an unknown product tracking mode
+type InvalidTrackingModeError struct {
+	Mode TrackingMode
+}
+
+func (e *InvalidTrackingModeError) Error() string {
+	return fmt.Sprintf("invalid tracking mode: %s", e.Mode)
+}
+
+// TrackingModeChangeError represents an attempt to change how an existing
+// product is tracked
+type TrackingModeChangeError struct {
+	ProductID int64
+}
+
+func (e *TrackingModeChangeError) Error() string {
+	return fmt.Sprintf("tracking mode of product with ID %d cannot be changed", e.ProductID)
+}
+
+// TrackingModeMismatchError represents an operation that does not apply to
+// the way a product is tracked, e.g. registering a serial for a product
+// tracked by quantity
+type TrackingModeMismatchError struct {
+	ProductID int64
+	Mode      TrackingMode
+}
+
+func (e *TrackingModeMismatchError) Error() string {
+	return fmt.Sprintf("product with ID %d uses %s tracking", e.ProductID, e.Mode)
+}
+
+// ForeignKeyError represents a write rejected by a foreign key: the entity
+// refers to a record that does not exist or, when InUse, it is still
+// referred to by other records and cannot be deleted
+type ForeignKeyError struct {
+	Entity string
+	ID     int64
+	InUse  bool
+}
+
+func (e *ForeignKeyError) Error() string {
+	if e.InUse {
+		return fmt.Sprintf("%s with ID %d is still in use", e.Entity, e.ID)
+	}
+	return fmt.Sprintf("%s refers to a record that does not exist", e.Entity)
+}
+
+// RequiredFieldError represents a write rejected because a required column
+// was left empty
+type RequiredFieldError struct {
+	Entity string
+	// Field is the empty field, when known
+	Field string
+}
+
+func (e *RequiredFieldError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s is missing required field %s", e.Entity, e.Field)
+	}
+	return fmt.Sprintf("%s is missing a required field", e.Entity)
+}
+
+// VersionMismatchError represents a change based on a version of the entity
+// that has been modified since
+type VersionMismatchError struct {
+	Entity  string
+	ID      int64
+	Version int64
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("%s with ID %d has been modified since version %d", e.Entity, e.ID, e.Version)
+}
+
+// Dependent counts the records of one table that refer to an entity
+type Dependent struct {
+	Table string `json:"table"`
+	Count int64  `json:"count"`
+}
+
+// EntityInUseError represents a deletion blocked by the records that still
+// refer to the entity
+type EntityInUseError struct {
+	Entity     string
+	ID         int64
+	Dependents []Dependent
+}
+
+func (e *EntityInUseError) Error() string {
+	blockers := make([]string, 0, len(e.Dependents))
+	for _, d := range e.Dependents {
+		blockers = append(blockers, fmt.Sprintf("%d %s", d.Count, d.Table))
+	}
+	return fmt.Sprintf("%s with ID %d is referred to by %s", e.Entity, e.ID, strings.Join(blockers, ", "))
+}
+
+// InvalidReassignmentError represents a reassignment whose target cannot
+// take over the references of the deleted entity
+type InvalidReassignmentError struct {
+	Reason string
+}
+
+func (e *InvalidReassignmentError) Error() string {
+	return "invalid reassignment: " + e.Reason
+}
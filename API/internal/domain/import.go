@@ -0,0 +1,40 @@
+package domain
+
+// MaxImportErrors is the most row errors an import report lists; later ones
+// are only counted
+const MaxImportErrors = 1000
+
+// ImportRowError tells why the record on a line of an import was not created
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport is the outcome of an import: how many records it read, how
+// many it created, or would have created in a dry run, and why the others
+// failed
+type ImportReport struct {
+	DryRun  bool `json:"dry_run"`
+	Rows    int  `json:"rows"`
+	Created int  `json:"created"`
+	Failed  int  `json:"failed"`
+	// IgnoredColumns are the columns of the file matching no field
+	IgnoredColumns []string         `json:"ignored_columns,omitempty"`
+	Errors         []ImportRowError `json:"errors"`
+}
+
+// DryRunUnsupportedError is returned for a dry run on a storage backend that
+// cannot roll back the records it creates
+type DryRunUnsupportedError struct{}
+
+func (e *DryRunUnsupportedError) Error() string {
+	return "dry runs are not supported by this storage backend"
+}
+
+// AddError records that the record on line failed
+func (r *ImportReport) AddError(line int, err error) {
+	r.Failed++
+	if len(r.Errors) < MaxImportErrors {
+		r.Errors = append(r.Errors, ImportRowError{Line: line, Error: err.Error()})
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import "strings"
+
+const (
+	// DefaultPageLimit is the page size of listings that do not ask for one
+	DefaultPageLimit = 50
+	// MaxPageLimit caps the page size a client may ask for
+	MaxPageLimit = 500
+)
+
+// Fields listings may be sorted by, as accepted in ?sort=
+var (
+	ProductSortFields  = []string{"id", "name", "code", "created_at", "updated_at"}
+	UserSortFields     = []string{"id", "name", "email", "role", "created_at", "updated_at"}
+	ProviderSortFields = []string{"id", "name", "email", "created_at", "updated_at"}
+	StockSortFields    = []string{"id", "product_id", "serial", "status", "batch", "purchase_date", "created_at", "updated_at"}
+)
+
+// SortField orders a listing by one field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions pages and sorts a listing. Pages are selected by Offset, or,
+// when AfterID is set, by returning the rows that follow that ID, which is
+// only possible when sorting by id. A zero Limit asks for DefaultPageLimit
+// rows; no listing returns every row at once.
+type ListOptions struct {
+	Limit   int
+	Offset  int
+	AfterID int64
+	Sort    []SortField
+}
+
+// ParseSort reads a sort expression such as "-purchase_date,serial", where a
+// leading "-" sorts that field in descending order
+func ParseSort(expr string) []SortField {
+	var fields []SortField
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Normalize applies the default page size, caps it at MaxPageLimit and checks
+// the sort fields against allowed
+func (o *ListOptions) Normalize(allowed []string) error {
+	switch {
+	case o.Limit < 0:
+		return &InvalidListOptionsError{Reason: "limit must not be negative"}
+	case o.Offset < 0:
+		return &InvalidListOptionsError{Reason: "offset must not be negative"}
+	}
+	o.Limit = o.PageLimit()
+
+	for _, field := range o.Sort {
+		if !ContainsField(allowed, field.Field) {
+			return &InvalidListOptionsError{Reason: "cannot sort by " + field.Field}
+		}
+	}
+
+	if o.AfterID != 0 {
+		if o.Offset != 0 {
+			return &InvalidListOptionsError{Reason: "after_id and offset cannot be combined"}
+		}
+		if len(o.Sort) > 1 || (len(o.Sort) == 1 && o.Sort[0].Field != "id") {
+			return &InvalidListOptionsError{Reason: "after_id requires sorting by id"}
+		}
+	}
+	return nil
+}
+
+// PageLimit is the page size Normalize settles on for these options
+func (o ListOptions) PageLimit() int {
+	switch {
+	case o.Limit <= 0:
+		return DefaultPageLimit
+	case o.Limit > MaxPageLimit:
+		return MaxPageLimit
+	}
+	return o.Limit
+}
+
+// SortedDesc reports whether the listing is sorted by id in descending order,
+// which makes AfterID select smaller IDs
+func (o ListOptions) SortedDesc() bool {
+	return len(o.Sort) == 1 && o.Sort[0].Field == "id" && o.Sort[0].Desc
+}
+
+// ContainsField reports whether field is one of fields
+func ContainsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidListOptionsError represents unusable paging or sorting parameters
+type InvalidListOptionsError struct {
+	Reason string
+}
+
+func (e *InvalidListOptionsError) Error() string {
+	return "invalid list options: " + e.Reason
+}
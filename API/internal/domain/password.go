@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// IPasswordHasher defines the interface for hashing and verifying passwords
+type IPasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the stored hash and whether the
+	// stored hash uses an outdated format and should be replaced
+	Verify(hash, password string) (match bool, needsRehash bool, err error)
+}
+
+// PasswordPolicy describes the requirements a new password must meet
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy returns the policy used when none is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+	}
+}
+
+// Validate checks password against the policy
+func (p PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var reasons []string
+	if len([]rune(password)) < p.MinLength {
+		reasons = append(reasons, "at least "+strconv.Itoa(p.MinLength)+" characters")
+	}
+	if p.RequireUpper && !hasUpper {
+		reasons = append(reasons, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		reasons = append(reasons, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		reasons = append(reasons, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		reasons = append(reasons, "a symbol")
+	}
+
+	if len(reasons) > 0 {
+		return &WeakPasswordError{Reasons: reasons}
+	}
+	return nil
+}
+
+// WeakPasswordError represents a password that does not meet the password policy
+type WeakPasswordError struct {
+	Reasons []string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return "password must contain " + strings.Join(e.Reasons, ", ")
+}
+
+// IncorrectPasswordError represents a password change with a wrong current password
+type IncorrectPasswordError struct{}
+
+func (e *IncorrectPasswordError) Error() string {
+	return "current password is incorrect"
+}
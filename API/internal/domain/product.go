@@ -2,24 +2,56 @@ package domain
 
 import "time"
 
+// TrackingMode is how the inventory of a product is counted
+type TrackingMode string
+
+const (
+	// TrackingSerialized products are held as units with a unique serial
+	TrackingSerialized TrackingMode = "serialized"
+	// TrackingQuantity products, such as cables or screws, are held as a
+	// quantity per location and batch
+	TrackingQuantity TrackingMode = "quantity"
+)
+
+// IsValid reports whether m is a known tracking mode
+func (m TrackingMode) IsValid() bool {
+	return m == TrackingSerialized || m == TrackingQuantity
+}
+
 // Product represents the core product entity in the domain
 type Product struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Code      string    `json:"code"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	ImageURL  string    `json:"image_url"`
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	Code         string       `json:"code"`
+	TrackingMode TrackingMode `json:"tracking_mode"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	ImageURL     string       `json:"image_url"`
+	// Version is bumped by every update, see VersionMismatchError
+	Version int64 `json:"version"`
+	SoftDeleted
+}
+
+// ProductFilter narrows down product listings. Zero values match everything;
+// Name matches any part of the product name.
+type ProductFilter struct {
+	Name         string
+	Code         string
+	TrackingMode TrackingMode
+	// IncludeDeleted also lists soft-deleted products
+	IncludeDeleted bool
+	ListOptions
 }
 
-// NewProduct creates a new Product instance with default values
+// NewProduct creates a new serialized Product instance with default values
 func NewProduct(name, code, imageURL string) *Product {
 	now := time.Now()
 	return &Product{
-		Name:      name,
-		Code:      code,
-		ImageURL:  imageURL,
-		CreatedAt: now,
-		UpdatedAt: now,
+		Name:         name,
+		Code:         code,
+		TrackingMode: TrackingSerialized,
+		ImageURL:     imageURL,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 }
@@ -1,10 +1,31 @@
 package domain
 
+import (
+	"context"
+	"time"
+)
+
 // IProductRepository defines the interface for product persistence operations
 type IProductRepository interface {
-	Create(product *Product) error
-	GetAll() ([]Product, error)
-	GetByID(id int64) (*Product, error)
-	Update(product *Product) error
-	Delete(id int64) error
+	Create(ctx context.Context, product *Product) error
+	GetAll(ctx context.Context, filter ProductFilter) ([]Product, error)
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	GetByID(ctx context.Context, id int64) (*Product, error)
+	// Update saves the product if it is still at product.Version, which it bumps. A
+	// product modified since gives a VersionMismatchError.
+	Update(ctx context.Context, product *Product) error
+	// Patch saves only the given fields of the product, named as in its JSON,
+	// checking and bumping its version like Update
+	Patch(ctx context.Context, product *Product, fields []string) error
+	// Delete soft deletes the product, recording the user who deleted it
+	Delete(ctx context.Context, id, deletedBy int64) error
+	// Restore brings back a soft-deleted product
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes the products soft deleted before the given time
+	// that nothing refers to anymore, and returns how many it removed
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// Dependents counts the records that still refer to the product. In a unit
+	// of work it locks the product until the end of it, so that no reference is
+	// added before a deletion that follows.
+	Dependents(ctx context.Context, id int64) ([]Dependent, error)
 }
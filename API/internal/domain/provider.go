@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Provider struct {
 	ID        int64     `json:"id"`
@@ -10,6 +13,19 @@ type Provider struct {
 	Address   string    `json:"address"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Version is bumped by every update, see VersionMismatchError
+	Version int64 `json:"version"`
+	SoftDeleted
+}
+
+// ProviderFilter narrows down provider listings. Zero values match
+// everything; Name matches any part of the provider name.
+type ProviderFilter struct {
+	Name  string
+	Email string
+	// IncludeDeleted also lists soft-deleted providers
+	IncludeDeleted bool
+	ListOptions
 }
 
 type ProviderNotFoundError struct {
@@ -29,9 +45,25 @@ func (e *ProviderAlreadyExistsError) Error() string {
 }
 
 type IProviderRepository interface {
-	Create(provider *Provider) error
-	GetByID(id int64) (*Provider, error)
-	GetAll() ([]Provider, error)
-	Update(provider *Provider) error
-	Delete(id int64) error
+	Create(ctx context.Context, provider *Provider) error
+	GetByID(ctx context.Context, id int64) (*Provider, error)
+	GetAll(ctx context.Context, filter ProviderFilter) ([]Provider, error)
+	Count(ctx context.Context, filter ProviderFilter) (int64, error)
+	// Update saves the provider if it is still at provider.Version, which it bumps. A
+	// provider modified since gives a VersionMismatchError.
+	Update(ctx context.Context, provider *Provider) error
+	// Patch saves only the given fields of the provider, named as in its JSON,
+	// checking and bumping its version like Update
+	Patch(ctx context.Context, provider *Provider, fields []string) error
+	// Delete soft deletes the provider, recording the user who deleted it
+	Delete(ctx context.Context, id, deletedBy int64) error
+	// Restore brings back a soft-deleted provider
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes the providers soft deleted before the given time
+	// that nothing refers to anymore, and returns how many it removed
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// Dependents counts the records that still refer to the provider. In a unit
+	// of work it locks the provider until the end of it, so that no reference is
+	// added before a deletion that follows.
+	Dependents(ctx context.Context, id int64) ([]Dependent, error)
 }
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// PurchaseOrderStatus is the stage of a purchase order
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderOrdered           PurchaseOrderStatus = "ordered"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// purchaseOrderTransitions lists the statuses an order may move to from each status
+var purchaseOrderTransitions = map[PurchaseOrderStatus][]PurchaseOrderStatus{
+	PurchaseOrderDraft:             {PurchaseOrderOrdered, PurchaseOrderCancelled},
+	PurchaseOrderOrdered:           {PurchaseOrderPartiallyReceived, PurchaseOrderReceived, PurchaseOrderCancelled},
+	PurchaseOrderPartiallyReceived: {PurchaseOrderPartiallyReceived, PurchaseOrderReceived, PurchaseOrderCancelled},
+	PurchaseOrderReceived:          {},
+	PurchaseOrderCancelled:         {},
+}
+
+// IsValid reports whether s is a known purchase order status
+func (s PurchaseOrderStatus) IsValid() bool {
+	_, ok := purchaseOrderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to status to
+func (s PurchaseOrderStatus) CanTransitionTo(to PurchaseOrderStatus) bool {
+	for _, allowed := range purchaseOrderTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReceive reports whether goods may be received against an order in status s
+func (s PurchaseOrderStatus) CanReceive() bool {
+	return s == PurchaseOrderOrdered || s == PurchaseOrderPartiallyReceived
+}
+
+// PurchaseOrderLine is the quantity of a product expected from the provider
+type PurchaseOrderLine struct {
+	ID               int64    `json:"id"`
+	Product          *Product `json:"product"`
+	ExpectedQuantity int64    `json:"expected_quantity"`
+	ReceivedQuantity int64    `json:"received_quantity"`
+	UnitCost         float64  `json:"unit_cost"`
+}
+
+// Pending returns the quantity still to be received
+func (l *PurchaseOrderLine) Pending() int64 {
+	return l.ExpectedQuantity - l.ReceivedQuantity
+}
+
+// PurchaseOrder is an order of products placed with a provider
+type PurchaseOrder struct {
+	ID            int64               `json:"id"`
+	Provider      *Provider           `json:"provider"`
+	Status        PurchaseOrderStatus `json:"status"`
+	Notes         string              `json:"notes"`
+	Lines         []PurchaseOrderLine `json:"lines"`
+	CreatedByUser *User               `json:"created_by_user"`
+	OrderedAt     *time.Time          `json:"ordered_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// Line returns the line with the given ID, or nil
+func (o *PurchaseOrder) Line(id int64) *PurchaseOrderLine {
+	for i := range o.Lines {
+		if o.Lines[i].ID == id {
+			return &o.Lines[i]
+		}
+	}
+	return nil
+}
+
+// ReceiptStatus is the status of the order given the quantities received so far
+func (o *PurchaseOrder) ReceiptStatus() PurchaseOrderStatus {
+	for _, line := range o.Lines {
+		if line.Pending() > 0 {
+			return PurchaseOrderPartiallyReceived
+		}
+	}
+	return PurchaseOrderReceived
+}
+
+// PurchaseOrderFilter narrows down purchase order listings
+type PurchaseOrderFilter struct {
+	Status     PurchaseOrderStatus
+	ProviderID int64
+}
+
+// ReceivedLine holds the units created when receiving goods for an order line
+type ReceivedLine struct {
+	LineID int64
+	Stocks []*Stock
+}
+
+type PurchaseOrderNotFoundError struct {
+	PurchaseOrderID int64
+}
+
+func (e *PurchaseOrderNotFoundError) Error() string {
+	return "purchase order with ID " + strconv.FormatInt(e.PurchaseOrderID, 10) + " not found"
+}
+
+// InvalidPurchaseOrderError represents a purchase order or receipt that cannot be accepted
+type InvalidPurchaseOrderError struct {
+	Reason string
+}
+
+func (e *InvalidPurchaseOrderError) Error() string {
+	return "invalid purchase order: " + e.Reason
+}
+
+type InvalidPurchaseOrderStatusError struct {
+	Status PurchaseOrderStatus
+}
+
+func (e *InvalidPurchaseOrderStatusError) Error() string {
+	return "invalid purchase order status: " + string(e.Status)
+}
+
+type InvalidPurchaseOrderTransitionError struct {
+	From PurchaseOrderStatus
+	To   PurchaseOrderStatus
+}
+
+func (e *InvalidPurchaseOrderTransitionError) Error() string {
+	return "cannot move purchase order from " + string(e.From) + " to " + string(e.To)
+}
+
+// OverReceiptError represents receiving more units than a line still expects
+type OverReceiptError struct {
+	LineID  int64
+	Pending int64
+}
+
+func (e *OverReceiptError) Error() string {
+	return "line " + strconv.FormatInt(e.LineID, 10) + " only expects " + strconv.FormatInt(e.Pending, 10) + " more units"
+}
+
+type IPurchaseOrderRepository interface {
+	Create(ctx context.Context, order *PurchaseOrder) error
+	GetByID(ctx context.Context, id int64) (*PurchaseOrder, error)
+	GetAll(ctx context.Context, filter PurchaseOrderFilter) ([]PurchaseOrder, error)
+	// UpdateStatus persists a status change that does not receive any goods
+	UpdateStatus(ctx context.Context, order *PurchaseOrder) error
+	// Receive creates the received units, records their creation in the stock
+	// ledger, adds them to the received quantity of their lines and stores the
+	// new order status, all in one transaction
+	Receive(ctx context.Context, order *PurchaseOrder, lines []ReceivedLine, reason string) error
+}
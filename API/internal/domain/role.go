@@ -0,0 +1,100 @@
+package domain
+
+// Roles that can be assigned to a user
+const (
+	RoleAdmin     = "admin"
+	RoleWarehouse = "warehouse"
+	RoleViewer    = "viewer"
+)
+
+// Permission identifies an operation guarded by role-based authorization
+type Permission string
+
+const (
+	PermProductsRead    Permission = "products:read"
+	PermProductsWrite   Permission = "products:write"
+	PermProvidersRead   Permission = "providers:read"
+	PermProvidersWrite  Permission = "providers:write"
+	PermStocksRead      Permission = "stocks:read"
+	PermStocksWrite     Permission = "stocks:write"
+	PermStocksScrap     Permission = "stocks:scrap"
+	PermWarehousesRead  Permission = "warehouses:read"
+	PermWarehousesWrite Permission = "warehouses:write"
+	PermPurchasesRead   Permission = "purchases:read"
+	PermPurchasesWrite  Permission = "purchases:write"
+	PermUsersRead       Permission = "users:read"
+	PermUsersWrite      Permission = "users:write"
+	PermUsersDelete     Permission = "users:delete"
+	// PermDeletedRead lets listings include soft-deleted records
+	PermDeletedRead Permission = "deleted:read"
+)
+
+// rolePermissions is the permission matrix for every known role
+var rolePermissions = map[string]map[Permission]bool{
+	RoleAdmin: {
+		PermProductsRead:    true,
+		PermProductsWrite:   true,
+		PermProvidersRead:   true,
+		PermProvidersWrite:  true,
+		PermStocksRead:      true,
+		PermStocksWrite:     true,
+		PermStocksScrap:     true,
+		PermWarehousesRead:  true,
+		PermWarehousesWrite: true,
+		PermPurchasesRead:   true,
+		PermPurchasesWrite:  true,
+		PermUsersRead:       true,
+		PermUsersWrite:      true,
+		PermUsersDelete:     true,
+		PermDeletedRead:     true,
+	},
+	RoleWarehouse: {
+		PermProductsRead:    true,
+		PermProductsWrite:   true,
+		PermProvidersRead:   true,
+		PermProvidersWrite:  true,
+		PermStocksRead:      true,
+		PermStocksWrite:     true,
+		PermWarehousesRead:  true,
+		PermWarehousesWrite: true,
+		PermPurchasesRead:   true,
+		PermPurchasesWrite:  true,
+		PermUsersRead:       true,
+	},
+	RoleViewer: {
+		PermProductsRead:   true,
+		PermProvidersRead:  true,
+		PermStocksRead:     true,
+		PermWarehousesRead: true,
+		PermPurchasesRead:  true,
+	},
+}
+
+// IsValidRole reports whether role is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role is granted permission
+func HasPermission(role string, permission Permission) bool {
+	return rolePermissions[role][permission]
+}
+
+// InvalidRoleError represents an error when a user is given an unknown role
+type InvalidRoleError struct {
+	Role string
+}
+
+func (e *InvalidRoleError) Error() string {
+	return "invalid role: " + e.Role
+}
+
+// ForbiddenError represents an error when a user lacks the permission for an operation
+type ForbiddenError struct {
+	Permission Permission
+}
+
+func (e *ForbiddenError) Error() string {
+	return "forbidden: missing permission " + string(e.Permission)
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// SoftDeleted records the deletion of a product, user, provider or stock.
+// Deleted rows are hidden from reads unless a filter asks for them, can be
+// restored, and are only removed for good by a purge. Both fields are nil
+// while the row is live.
+type SoftDeleted struct {
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *int64     `json:"deleted_by,omitempty"`
+}
+
+// IsDeleted reports whether the row has been soft deleted
+func (s SoftDeleted) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
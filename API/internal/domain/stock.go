@@ -1,21 +1,104 @@
 package domain
 
 import (
+	"context"
 	"strconv"
 	"time"
 )
 
+// StockStatus is the lifecycle state of a serialized unit
+type StockStatus string
+
+const (
+	StockAvailable StockStatus = "available"
+	StockReserved  StockStatus = "reserved"
+	StockSold      StockStatus = "sold"
+	StockInRepair  StockStatus = "in_repair"
+	StockScrapped  StockStatus = "scrapped"
+)
+
+// stockTransitions lists the statuses each status may move to
+var stockTransitions = map[StockStatus][]StockStatus{
+	StockAvailable: {StockReserved, StockSold, StockInRepair, StockScrapped},
+	StockReserved:  {StockAvailable, StockSold},
+	StockSold:      {StockAvailable, StockInRepair},
+	StockInRepair:  {StockAvailable, StockScrapped},
+	StockScrapped:  {},
+}
+
+// IsValid reports whether s is a known stock status
+func (s StockStatus) IsValid() bool {
+	_, ok := stockTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a unit in status s may move to status to
+func (s StockStatus) CanTransitionTo(to StockStatus) bool {
+	for _, allowed := range stockTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOnHand reports whether a unit in status s still counts as held in stock
+func (s StockStatus) IsOnHand() bool {
+	return s != StockSold && s != StockScrapped
+}
+
+// TransitionPermission returns the permission required to move a unit to status to
+func TransitionPermission(to StockStatus) Permission {
+	if to == StockScrapped {
+		return PermStocksScrap
+	}
+	return PermStocksWrite
+}
+
 type Stock struct {
-	ID            int64     `json:"id"`
-	Product       *Product  `json:"product"`
-	Serial        string    `json:"serial"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	CreatedByUser *User     `json:"created_by_user"`
-	UpdatedByUser *User     `json:"updated_by_user"`
-	Batch         string    `json:"batch"`
-	PurchaseDate  time.Time `json:"purchase_date"`
-	Provider      *Provider `json:"provider"`
+	ID              int64       `json:"id"`
+	Product         *Product    `json:"product"`
+	Serial          string      `json:"serial"`
+	Status          StockStatus `json:"status"`
+	StatusChangedAt time.Time   `json:"status_changed_at"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	CreatedByUser   *User       `json:"created_by_user"`
+	UpdatedByUser   *User       `json:"updated_by_user"`
+	Batch           string      `json:"batch"`
+	PurchaseDate    time.Time   `json:"purchase_date"`
+	Provider        *Provider   `json:"provider"`
+	Location        *Location   `json:"location"`
+	// Version is bumped by every update, see VersionMismatchError
+	Version int64 `json:"version"`
+	SoftDeleted
+}
+
+// StockReference is a reference from a unit to another entity
+type StockReference string
+
+const (
+	StockProductReference  StockReference = "product"
+	StockProviderReference StockReference = "provider"
+	// StockUserReference is the user who last updated a unit. The user who
+	// created it is history and is never reassigned.
+	StockUserReference StockReference = "user"
+)
+
+// StockFilter narrows down stock listings. Zero values match everything;
+// both purchase date bounds are inclusive.
+type StockFilter struct {
+	Status          StockStatus
+	WarehouseID     int64
+	LocationID      int64
+	ProductID       int64
+	ProviderID      int64
+	Batch           string
+	PurchasedAfter  time.Time
+	PurchasedBefore time.Time
+	// IncludeDeleted also lists soft-deleted units
+	IncludeDeleted bool
+	ListOptions
 }
 
 type StockNotFoundError struct {
@@ -38,12 +121,88 @@ func (e *StockAlreadyExistsError) Error() string {
 	return "stock already exists"
 }
 
+// StockLocationChangeError represents an attempt to move a unit that already
+// has a location without going through a transfer
+type StockLocationChangeError struct {
+	StockID int64
+}
+
+func (e *StockLocationChangeError) Error() string {
+	return "stock with ID " + strconv.FormatInt(e.StockID, 10) + " can only change location through a transfer"
+}
+
+// AuditUserMismatchError represents an attempt to attribute a stock change to
+// a user other than the authenticated caller
+type AuditUserMismatchError struct {
+	ClaimedUserID int64
+}
+
+func (e *AuditUserMismatchError) Error() string {
+	return "audit user " + strconv.FormatInt(e.ClaimedUserID, 10) + " does not match the authenticated user"
+}
+
+// MissingAuditUserError represents a stock change without a user to attribute it to
+type MissingAuditUserError struct{}
+
+func (e *MissingAuditUserError) Error() string {
+	return "stock changes require an authenticated user"
+}
+
+// InvalidStockStatusError represents an unknown stock status
+type InvalidStockStatusError struct {
+	Status StockStatus
+}
+
+func (e *InvalidStockStatusError) Error() string {
+	return "invalid stock status: " + string(e.Status)
+}
+
+// InvalidStockTransitionError represents a status change not allowed by the lifecycle
+type InvalidStockTransitionError struct {
+	From StockStatus
+	To   StockStatus
+}
+
+func (e *InvalidStockTransitionError) Error() string {
+	return "cannot move stock from " + string(e.From) + " to " + string(e.To)
+}
+
 type IStockRepository interface {
-	Create(stock *Stock) error
-	GetByID(id int64) (*Stock, error)
-	GetAll() ([]Stock, error)
-	Update(stock *Stock) error
-	Delete(id int64) error
-	GetByProductID(productID int64) ([]Stock, error)
-	GetBySerial(serial string) (*Stock, error)
+	Create(ctx context.Context, stock *Stock) error
+	// CreateMany stores the new units in one transaction using multi-row
+	// inserts and sets their IDs. A serial in use gives a
+	// StockAlreadyExistsError and stores none of them.
+	CreateMany(ctx context.Context, stocks []*Stock) error
+	// ExistingSerials returns those of serials carried by a unit, including
+	// soft-deleted ones, whose serials stay reserved
+	ExistingSerials(ctx context.Context, serials []string) ([]string, error)
+	GetByID(ctx context.Context, id int64) (*Stock, error)
+	GetAll(ctx context.Context, filter StockFilter) ([]Stock, error)
+	Count(ctx context.Context, filter StockFilter) (int64, error)
+	// Update saves the unit if it is still at stock.Version, which it bumps. A
+	// unit modified since gives a VersionMismatchError.
+	Update(ctx context.Context, stock *Stock) error
+	// Patch saves only the given fields of the unit, named as in its update
+	// requests ("product_id", "serial", "batch", "purchase_date",
+	// "provider_id", "location_id"), together with who updated it. It checks
+	// and bumps the version like Update.
+	Patch(ctx context.Context, stock *Stock, fields []string) error
+	// UpdateStatus saves the status of the unit, checking and bumping its
+	// version like Update
+	UpdateStatus(ctx context.Context, stock *Stock) error
+	// Delete soft deletes the unit, recording the user who deleted it
+	Delete(ctx context.Context, id, deletedBy int64) error
+	// Restore brings back a soft-deleted unit
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes the units soft deleted before the given time
+	// that nothing refers to anymore, and returns how many it removed
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	GetByProductID(ctx context.Context, productID int64, filter StockFilter) ([]Stock, error)
+	GetBySerial(ctx context.Context, serial string) (*Stock, error)
+	CountByLocation(ctx context.Context, productID int64) ([]LocationStockCount, error)
+	OnHandByProduct(ctx context.Context, filter StockBalanceFilter) ([]ProductOnHand, error)
+	// Reassign points the units referring to fromID through ref at toID,
+	// records an update movement by actor for each one and returns how many
+	// changed
+	Reassign(ctx context.Context, ref StockReference, fromID, toID int64, actor *User, reason string) (int64, error)
 }
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// StockBalance is the on-hand quantity of a quantity-tracked product at a
+// location, kept per batch
+type StockBalance struct {
+	ID        int64     `json:"id"`
+	Product   *Product  `json:"product"`
+	Location  *Location `json:"location"`
+	Batch     string    `json:"batch"`
+	Quantity  int64     `json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockBalanceFilter narrows down balance, balance movement and on-hand
+// listings. Zero values match everything.
+type StockBalanceFilter struct {
+	ProductID   int64
+	WarehouseID int64
+	LocationID  int64
+	Batch       string
+}
+
+// BalanceMovementType is the kind of change applied to a stock balance
+type BalanceMovementType string
+
+const (
+	BalanceReceive BalanceMovementType = "receive"
+	BalanceIssue   BalanceMovementType = "issue"
+	BalanceAdjust  BalanceMovementType = "adjust"
+)
+
+// BalanceMovement is an append-only ledger entry describing one change to a
+// stock balance. Quantity is signed: issues are negative, adjustments may go
+// either way. BalanceAfter is the balance once the movement was applied.
+type BalanceMovement struct {
+	ID           int64               `json:"id"`
+	Product      *Product            `json:"product"`
+	Location     *Location           `json:"location"`
+	Batch        string              `json:"batch"`
+	Type         BalanceMovementType `json:"type"`
+	Quantity     int64               `json:"quantity"`
+	BalanceAfter int64               `json:"balance_after"`
+	Reason       string              `json:"reason"`
+	Actor        *User               `json:"actor"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// ProductOnHand is the quantity of a product held in stock. Serialized
+// products count their units that have not been sold or scrapped.
+type ProductOnHand struct {
+	Product  *Product `json:"product"`
+	Quantity int64    `json:"quantity"`
+}
+
+// InvalidQuantityError represents a balance movement with an unusable quantity
+type InvalidQuantityError struct {
+	Reason string
+}
+
+func (e *InvalidQuantityError) Error() string {
+	return "invalid quantity: " + e.Reason
+}
+
+// InsufficientQuantityError represents a movement that would leave a balance
+// below zero
+type InsufficientQuantityError struct {
+	ProductID int64
+	Available int64
+	Requested int64
+}
+
+func (e *InsufficientQuantityError) Error() string {
+	return "insufficient quantity of product with ID " + strconv.FormatInt(e.ProductID, 10) +
+		": " + strconv.FormatInt(e.Available, 10) + " available, " +
+		strconv.FormatInt(e.Requested, 10) + " requested"
+}
+
+type IStockBalanceRepository interface {
+	GetAll(ctx context.Context, filter StockBalanceFilter) ([]StockBalance, error)
+	// Apply adds movement.Quantity to the matching balance and records the
+	// movement atomically, failing with InsufficientQuantityError instead of
+	// going below zero
+	Apply(ctx context.Context, movement *BalanceMovement) error
+	GetMovements(ctx context.Context, filter StockBalanceFilter) ([]BalanceMovement, error)
+	CountByLocation(ctx context.Context, productID int64) ([]LocationStockCount, error)
+	OnHandByProduct(ctx context.Context, filter StockBalanceFilter) ([]ProductOnHand, error)
+}
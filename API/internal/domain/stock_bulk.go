@@ -0,0 +1,57 @@
+package domain
+
+// MaxBulkStocks is the most units a single bulk creation may carry
+const MaxBulkStocks = 1000
+
+// BulkMode tells what a bulk creation does when some of its units are rejected
+type BulkMode string
+
+const (
+	// BulkAllOrNothing creates no unit at all when any of them is rejected
+	BulkAllOrNothing BulkMode = "all_or_nothing"
+	// BulkBestEffort creates the units that were not rejected
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+// IsValid reports whether the mode is known
+func (m BulkMode) IsValid() bool {
+	return m == BulkAllOrNothing || m == BulkBestEffort
+}
+
+// BulkItemStatus is the outcome of one unit of a bulk creation
+type BulkItemStatus string
+
+const (
+	BulkItemCreated  BulkItemStatus = "created"
+	BulkItemRejected BulkItemStatus = "rejected"
+	// BulkItemSkipped units were valid but not created, because another unit
+	// of an all-or-nothing creation was rejected
+	BulkItemSkipped BulkItemStatus = "skipped"
+)
+
+// BulkStockItem is the outcome of one serial of a bulk creation
+type BulkStockItem struct {
+	Serial  string         `json:"serial"`
+	Status  BulkItemStatus `json:"status"`
+	StockID int64          `json:"stock_id,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// BulkStockReport is the outcome of a bulk creation, with one item per serial
+// in the order they were given
+type BulkStockReport struct {
+	Mode     BulkMode        `json:"mode"`
+	Created  int             `json:"created"`
+	Rejected int             `json:"rejected"`
+	Skipped  int             `json:"skipped"`
+	Items    []BulkStockItem `json:"items"`
+}
+
+// InvalidBulkStockError represents a bulk creation that cannot be attempted at all
+type InvalidBulkStockError struct {
+	Reason string
+}
+
+func (e *InvalidBulkStockError) Error() string {
+	return "invalid bulk stock creation: " + e.Reason
+}
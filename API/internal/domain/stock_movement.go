@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StockMovementType is the kind of change recorded in the stock ledger
+type StockMovementType string
+
+const (
+	MovementCreate     StockMovementType = "create"
+	MovementUpdate     StockMovementType = "update"
+	MovementTransition StockMovementType = "transition"
+	MovementDelete     StockMovementType = "delete"
+	MovementRestore    StockMovementType = "restore"
+	MovementTransfer   StockMovementType = "transfer"
+)
+
+// StockSnapshot is the state of a unit as recorded before or after a movement
+type StockSnapshot struct {
+	ProductID    int64       `json:"product_id"`
+	Serial       string      `json:"serial"`
+	Status       StockStatus `json:"status"`
+	Batch        string      `json:"batch"`
+	PurchaseDate time.Time   `json:"purchase_date"`
+	ProviderID   int64       `json:"provider_id"`
+	LocationID   int64       `json:"location_id,omitempty"`
+}
+
+// SnapshotOf captures the persisted fields of a stock
+func SnapshotOf(stock *Stock) *StockSnapshot {
+	if stock == nil {
+		return nil
+	}
+	snapshot := &StockSnapshot{
+		Serial:       stock.Serial,
+		Status:       stock.Status,
+		Batch:        stock.Batch,
+		PurchaseDate: stock.PurchaseDate,
+	}
+	if stock.Product != nil {
+		snapshot.ProductID = stock.Product.ID
+	}
+	if stock.Provider != nil {
+		snapshot.ProviderID = stock.Provider.ID
+	}
+	if stock.Location != nil {
+		snapshot.LocationID = stock.Location.ID
+	}
+	return snapshot
+}
+
+// StockMovement is an append-only ledger entry describing one change to a unit
+type StockMovement struct {
+	ID        int64             `json:"id"`
+	StockID   int64             `json:"stock_id"`
+	Serial    string            `json:"serial"`
+	Type      StockMovementType `json:"type"`
+	Actor     *User             `json:"actor"`
+	Before    *StockSnapshot    `json:"before"`
+	After     *StockSnapshot    `json:"after"`
+	Reason    string            `json:"reason"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// NewStockMovement builds a ledger entry for a change made by actor
+func NewStockMovement(movementType StockMovementType, actor *User, before, after *Stock, reason string) *StockMovement {
+	movement := &StockMovement{
+		Type:      movementType,
+		Actor:     actor,
+		Before:    SnapshotOf(before),
+		After:     SnapshotOf(after),
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if after != nil {
+		movement.StockID = after.ID
+		movement.Serial = after.Serial
+	} else if before != nil {
+		movement.StockID = before.ID
+		movement.Serial = before.Serial
+	}
+	return movement
+}
+
+// IStockMovementRepository defines the interface for the stock ledger. Entries
+// are never updated or deleted.
+type IStockMovementRepository interface {
+	Create(ctx context.Context, movement *StockMovement) error
+	// CreateMany appends the movements in one transaction using multi-row
+	// inserts. Their IDs are not set.
+	CreateMany(ctx context.Context, movements []*StockMovement) error
+	GetByStockID(ctx context.Context, stockID int64) ([]StockMovement, error)
+	// GetBySerial returns the full history of every unit that ever carried serial
+	GetBySerial(ctx context.Context, serial string) ([]StockMovement, error)
+}
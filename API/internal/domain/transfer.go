@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// TransferStatus is the stage of a transfer document
+type TransferStatus string
+
+const (
+	TransferDraft     TransferStatus = "draft"
+	TransferInTransit TransferStatus = "in_transit"
+	TransferReceived  TransferStatus = "received"
+)
+
+// transferTransitions lists the statuses a transfer may move to from each status
+var transferTransitions = map[TransferStatus][]TransferStatus{
+	TransferDraft:     {TransferInTransit},
+	TransferInTransit: {TransferReceived},
+	TransferReceived:  {},
+}
+
+// IsValid reports whether s is a known transfer status
+func (s TransferStatus) IsValid() bool {
+	_, ok := transferTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a transfer in status s may move to status to
+func (s TransferStatus) CanTransitionTo(to TransferStatus) bool {
+	for _, allowed := range transferTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransferItem is a unit moved by a transfer
+type TransferItem struct {
+	StockID int64  `json:"stock_id"`
+	Serial  string `json:"serial"`
+}
+
+// Transfer is a document moving a set of units from one location to another.
+// Units stay at the source until the transfer is received.
+type Transfer struct {
+	ID            int64          `json:"id"`
+	Source        *Location      `json:"source"`
+	Destination   *Location      `json:"destination"`
+	Status        TransferStatus `json:"status"`
+	Items         []TransferItem `json:"items"`
+	Notes         string         `json:"notes"`
+	CreatedByUser *User          `json:"created_by_user"`
+	DispatchedAt  *time.Time     `json:"dispatched_at"`
+	ReceivedAt    *time.Time     `json:"received_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TransferFilter narrows down transfer listings
+type TransferFilter struct {
+	Status TransferStatus
+}
+
+type TransferNotFoundError struct {
+	TransferID int64
+}
+
+func (e *TransferNotFoundError) Error() string {
+	return "transfer with ID " + strconv.FormatInt(e.TransferID, 10) + " not found"
+}
+
+// InvalidTransferError represents a transfer document that cannot be created
+type InvalidTransferError struct {
+	Reason string
+}
+
+func (e *InvalidTransferError) Error() string {
+	return "invalid transfer: " + e.Reason
+}
+
+type InvalidTransferStatusError struct {
+	Status TransferStatus
+}
+
+func (e *InvalidTransferStatusError) Error() string {
+	return "invalid transfer status: " + string(e.Status)
+}
+
+type InvalidTransferTransitionError struct {
+	From TransferStatus
+	To   TransferStatus
+}
+
+func (e *InvalidTransferTransitionError) Error() string {
+	return "cannot move transfer from " + string(e.From) + " to " + string(e.To)
+}
+
+// TransferConflictError represents a unit that is not at the source of a
+// transfer, e.g. because another transfer moved it first
+type TransferConflictError struct {
+	Serial string
+}
+
+func (e *TransferConflictError) Error() string {
+	return "stock with serial " + e.Serial + " is not at the transfer source"
+}
+
+type ITransferRepository interface {
+	Create(ctx context.Context, transfer *Transfer) error
+	GetByID(ctx context.Context, id int64) (*Transfer, error)
+	GetAll(ctx context.Context, filter TransferFilter) ([]Transfer, error)
+	// UpdateStatus persists a status change that does not move any unit
+	UpdateStatus(ctx context.Context, transfer *Transfer) error
+	// Receive moves every unit of an in-transit transfer to its destination,
+	// appends movements to the stock ledger and marks the transfer received,
+	// all in one transaction. A unit that has left the source aborts the
+	// whole transfer with a TransferConflictError.
+	Receive(ctx context.Context, transfer *Transfer, actor *User, movements []*StockMovement) error
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+// UnitOfWork runs several repository calls as one atomic operation
+type UnitOfWork interface {
+	// Do calls fn with a context carrying a transaction, which every repository
+	// call made with that context joins. The transaction is rolled back when fn
+	// returns an error or panics and committed otherwise. A Do nested in another
+	// one joins the outer transaction in a savepoint: a failing nested Do only
+	// rolls back its own writes, and the others go with the outer transaction.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	// CanRollBack reports whether a failing Do undoes the writes of fn
+	CanRollBack() bool
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
@@ -11,16 +14,46 @@ type User struct {
 	Password  string    `json:"-"` // The "-" tag ensures the password is never sent in JSON responses
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Version is bumped by every update, see VersionMismatchError
+	Version int64 `json:"version"`
+	SoftDeleted
+}
+
+// UserFilter narrows down user listings. Zero values match everything; Name
+// matches any part of the user name.
+type UserFilter struct {
+	Name  string
+	Email string
+	Role  string
+	// IncludeDeleted also lists soft-deleted users
+	IncludeDeleted bool
+	ListOptions
 }
 
 // IUserRepository defines the interface for user persistence operations
 type IUserRepository interface {
-	Create(user *User) error
-	GetAll() ([]User, error)
-	GetByID(id int64) (*User, error)
-	Update(user *User) error
-	Delete(id int64) error
-	GetByEmail(email string) (*User, error)
+	Create(ctx context.Context, user *User) error
+	GetAll(ctx context.Context, filter UserFilter) ([]User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	GetByID(ctx context.Context, id int64) (*User, error)
+	// Update saves the user if it is still at user.Version, which it bumps. A
+	// user modified since gives a VersionMismatchError.
+	Update(ctx context.Context, user *User) error
+	// Patch saves only the given fields of the user, named as in its JSON plus
+	// "password", checking and bumping its version like Update
+	Patch(ctx context.Context, user *User, fields []string) error
+	// Delete soft deletes the user, recording the user who deleted it
+	Delete(ctx context.Context, id, deletedBy int64) error
+	// Restore brings back a soft-deleted user
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes the users soft deleted before the given time
+	// that nothing refers to anymore, and returns how many it removed
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	// Dependents counts the records that still refer to the user. In a unit
+	// of work it locks the user until the end of it, so that no reference is
+	// added before a deletion that follows.
+	Dependents(ctx context.Context, id int64) ([]Dependent, error)
 }
 
 // UserNotFoundError represents an error when a user is not found
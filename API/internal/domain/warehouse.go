@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Warehouse is a physical site holding stock
+type Warehouse struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Location is a bin or shelf inside a warehouse
+type Location struct {
+	ID          int64      `json:"id"`
+	Warehouse   *Warehouse `json:"warehouse"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LocationStockCount is the on-hand quantity of a product at a location.
+// A nil Location groups the units that have not been put away yet.
+type LocationStockCount struct {
+	Location *Location `json:"location"`
+	Quantity int64     `json:"quantity"`
+}
+
+type WarehouseNotFoundError struct {
+	WarehouseID int64
+}
+
+func (e *WarehouseNotFoundError) Error() string {
+	return "warehouse with ID " + strconv.FormatInt(e.WarehouseID, 10) + " not found"
+}
+
+type WarehouseAlreadyExistsError struct {
+	Code string
+}
+
+func (e *WarehouseAlreadyExistsError) Error() string {
+	return "warehouse with code " + e.Code + " already exists"
+}
+
+type LocationNotFoundError struct {
+	LocationID int64
+}
+
+func (e *LocationNotFoundError) Error() string {
+	return "location with ID " + strconv.FormatInt(e.LocationID, 10) + " not found"
+}
+
+type LocationAlreadyExistsError struct {
+	Code string
+}
+
+func (e *LocationAlreadyExistsError) Error() string {
+	return "location with code " + e.Code + " already exists in this warehouse"
+}
+
+type IWarehouseRepository interface {
+	Create(ctx context.Context, warehouse *Warehouse) error
+	GetByID(ctx context.Context, id int64) (*Warehouse, error)
+	GetAll(ctx context.Context) ([]Warehouse, error)
+	Update(ctx context.Context, warehouse *Warehouse) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type ILocationRepository interface {
+	Create(ctx context.Context, location *Location) error
+	GetByID(ctx context.Context, id int64) (*Location, error)
+	GetAll(ctx context.Context) ([]Location, error)
+	GetByWarehouseID(ctx context.Context, warehouseID int64) ([]Location, error)
+	Update(ctx context.Context, location *Location) error
+	Delete(ctx context.Context, id int64) error
+}
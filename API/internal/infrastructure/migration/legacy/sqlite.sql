@@ -0,0 +1,130 @@
+-- Upgrades a database created from the cmd/schema.sql script that predates
+-- the migrations to the schema of 0001, whose CREATE TABLE IF NOT EXISTS
+-- statements would leave its tables as they are. Runs right before 0001.
+
+-- Track the lifecycle state of stocks. Existing units are available since they
+-- were created; SQLite needs a constant default to add a NOT NULL column.
+ALTER TABLE stocks ADD COLUMN status TEXT NOT NULL DEFAULT 'available';
+ALTER TABLE stocks ADD COLUMN status_changed_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+UPDATE stocks SET status_changed_at = created_at;
+CREATE INDEX IF NOT EXISTS idx_stocks_status ON stocks (status);
+
+-- Create stock_movements table (append-only ledger, kept after a stock is deleted)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stock_id INTEGER NOT NULL,
+    serial TEXT NOT NULL,
+    movement_type TEXT NOT NULL,
+    actor_user_id INTEGER NULL,
+    before_state TEXT NULL,
+    after_state TEXT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_stock ON stock_movements (stock_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_serial ON stock_movements (serial);
+
+-- Create warehouses table
+CREATE TABLE IF NOT EXISTS warehouses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Create locations table (bins inside a warehouse)
+CREATE TABLE IF NOT EXISTS locations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id),
+    code TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE (warehouse_id, code)
+);
+
+-- Place stocks in a location; units received before stay unplaced
+ALTER TABLE stocks ADD COLUMN location_id INTEGER NULL REFERENCES locations(id);
+
+-- Create transfers table (moves units between locations)
+CREATE TABLE IF NOT EXISTS transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_location_id INTEGER NOT NULL REFERENCES locations(id),
+    destination_location_id INTEGER NOT NULL REFERENCES locations(id),
+    status TEXT NOT NULL DEFAULT 'draft',
+    notes TEXT NOT NULL,
+    created_by_user_id INTEGER NULL REFERENCES users(id),
+    dispatched_at DATETIME NULL,
+    received_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_status ON transfers (status);
+
+-- Create transfer_items table (units listed on a transfer)
+CREATE TABLE IF NOT EXISTS transfer_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transfer_id INTEGER NOT NULL REFERENCES transfers(id),
+    stock_id INTEGER NOT NULL,
+    serial TEXT NOT NULL,
+    UNIQUE (transfer_id, stock_id)
+);
+
+-- Create purchase_orders table
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider_id INTEGER NOT NULL REFERENCES providers(id),
+    status TEXT NOT NULL DEFAULT 'draft',
+    notes TEXT NOT NULL,
+    created_by_user_id INTEGER NULL REFERENCES users(id),
+    ordered_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders (status);
+
+-- Create purchase_order_lines table
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id),
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    expected_quantity INTEGER NOT NULL,
+    received_quantity INTEGER NOT NULL DEFAULT 0,
+    unit_cost NUMERIC NOT NULL
+);
+
+-- Track products either unit by unit or by quantity; existing products keep
+-- their serialized units
+ALTER TABLE products ADD COLUMN tracking_mode TEXT NOT NULL DEFAULT 'serialized';
+
+-- Create stock_balances table (on-hand quantity of quantity-tracked products)
+CREATE TABLE IF NOT EXISTS stock_balances (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    location_id INTEGER NOT NULL REFERENCES locations(id),
+    batch TEXT NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at DATETIME NOT NULL,
+    UNIQUE (product_id, location_id, batch)
+);
+
+-- Create stock_balance_movements table (append-only ledger of balance changes)
+CREATE TABLE IF NOT EXISTS stock_balance_movements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    location_id INTEGER NOT NULL REFERENCES locations(id),
+    batch TEXT NOT NULL DEFAULT '',
+    movement_type TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    balance_after INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    actor_user_id INTEGER NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_balance_movements_product ON stock_balance_movements (product_id);
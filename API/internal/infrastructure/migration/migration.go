@@ -0,0 +1,421 @@
+// Package migration applies the versioned database schema embedded in the
+// binary. Each dialect has its own directory of NNNN_name.up.sql and
+// NNNN_name.down.sql files, applied in version order and recorded in the
+// schema_migrations table together with the checksum of their up script.
+// A database created from the cmd/schema.sql script that predates the
+// migrations is upgraded by the legacy script of its dialect before 0001.
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql legacy/*.sql
+var files embed.FS
+
+// Dialect selects the SQL flavour of the migrations
+type Dialect string
+
+const (
+	MySQL  Dialect = "mysql"
+	SQLite Dialect = "sqlite"
+)
+
+// lockName is the MySQL advisory lock held while migrating, so that several
+// instances starting at once do not apply the same migration twice
+const lockName = "inventario_schema_migrations"
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// ChecksumMismatchError is returned when an applied migration no longer
+// matches the embedded script, i.e. the script was edited after release
+type ChecksumMismatchError struct {
+	Version int64
+	Name    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("migration %04d_%s was modified after being applied", e.Version, e.Name)
+}
+
+// UnknownMigrationError is returned when the database has a migration this
+// binary does not know about, i.e. it was migrated by a newer version
+type UnknownMigrationError struct {
+	Version int64
+}
+
+func (e *UnknownMigrationError) Error() string {
+	return fmt.Sprintf("database has unknown migration %04d applied", e.Version)
+}
+
+// Load reads the embedded migrations of a dialect in version order
+func Load(dialect Dialect) ([]Migration, error) {
+	dir := string(dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unknown migration dialect %q", dialect)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", name)
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %04d has two names: %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and reverts the migrations of one dialect on a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Status lists every known migration and when it was applied. It fails if the
+// applied migrations do not match the embedded ones.
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+	err := m.withConn(func(conn *sql.Conn) error {
+		applied, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if a, ok := applied[migration.Version]; ok {
+				appliedAt := a.appliedAt
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending returns the migrations that have not been applied yet, after
+// verifying the checksums of those that have
+func (m *Migrator) Pending() ([]Migration, error) {
+	var pending []Migration
+	err := m.withConn(func(conn *sql.Conn) error {
+		applied, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+		pending = m.pending(applied)
+		return nil
+	})
+	return pending, err
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. Each migration runs in its own transaction; note that MySQL
+// commits DDL statements implicitly, so a failing MySQL migration may be
+// left half applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	err := m.withConn(func(conn *sql.Conn) error {
+		applied, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.pending(applied) {
+			script := migration.Up
+			if migration.Version == 1 {
+				legacy, err := m.legacySchema(conn)
+				if err != nil {
+					return err
+				}
+				script = legacy + script
+			}
+			err := m.run(conn, script, func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					INSERT INTO schema_migrations (version, name, checksum, applied_at)
+					VALUES (?, ?, ?, ?)
+				`, migration.Version, migration.Name, migration.Checksum, time.Now().UTC())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones it reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withConn(func(conn *sql.Conn) error {
+		applied, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %04d_%s cannot be reverted", migration.Version, migration.Name)
+			}
+			err := m.run(conn, migration.Down, func(tx *sql.Tx) error {
+				_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// withConn runs fn on a single connection, holding the migration lock on
+// MySQL, after making sure schema_migrations exists
+func (m *Migrator) withConn(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect == MySQL {
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 60)", lockName).Scan(&locked); err != nil {
+			return err
+		}
+		if locked.Int64 != 1 {
+			return fmt.Errorf("timed out waiting for the migration lock")
+		}
+		defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// verify reads the applied migrations and checks them against the embedded
+// ones
+func (m *Migrator) verify(conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(context.Background(), "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, a := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return nil, &UnknownMigrationError{Version: version}
+		}
+		if migration.Checksum != a.checksum {
+			return nil, &ChecksumMismatchError{Version: version, Name: migration.Name}
+		}
+	}
+	return applied, nil
+}
+
+// legacySchema returns the script upgrading a database created from
+// cmd/schema.sql, recognised by a stocks table without the status column of
+// 0001, or an empty script for any other database
+func (m *Migrator) legacySchema(conn *sql.Conn) (string, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(column_name = 'status'), 0)
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'stocks'
+	`
+	if m.dialect == SQLite {
+		query = "SELECT COUNT(*), COALESCE(SUM(name = 'status'), 0) FROM pragma_table_info('stocks')"
+	}
+	var columns, status int
+	if err := conn.QueryRowContext(context.Background(), query).Scan(&columns, &status); err != nil {
+		return "", err
+	}
+	if columns == 0 || status > 0 {
+		return "", nil
+	}
+	script, err := fs.ReadFile(files, path.Join("legacy", string(m.dialect)+".sql"))
+	return string(script) + "\n", err
+}
+
+func (m *Migrator) pending(applied map[int64]appliedMigration) []Migration {
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// run executes the statements of a script and then record in one transaction
+func (m *Migrator) run(conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits a script into statements ending in a semicolon at the
+// end of a line, dropping comment lines. Neither driver runs several
+// statements per Exec by default.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			statements = append(statements, statement)
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,260 @@
+package migration
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	for _, dialect := range []Dialect{MySQL, SQLite} {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dialect, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: expected migrations", dialect)
+		}
+		for i, m := range migrations {
+			if i > 0 && m.Version <= migrations[i-1].Version {
+				t.Errorf("%s: migrations out of order at %04d", dialect, m.Version)
+			}
+			if m.Down == "" {
+				t.Errorf("%s: migration %04d_%s has no down script", dialect, m.Version, m.Name)
+			}
+		}
+	}
+
+	mysql, _ := Load(MySQL)
+	sqlite, _ := Load(SQLite)
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("expected both dialects to have the same migrations, got %d and %d", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d differs: %04d_%s vs %04d_%s", i, mysql[i].Version, mysql[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+
+	if _, err := Load("postgres"); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+}
+
+// published are the checksums of released migrations, which databases
+// already record in schema_migrations
+var published = map[Dialect]map[int64]string{
+	MySQL: {
+		1: "a601c43786c381998efe3daf7fab822550671e4f029f9aec18885d210ae04e16",
+		2: "5b50b3a250eec8a6bcb56fa525870dfa2a0fedd143d67add3043e531b07bfe12",
+		3: "7283385754946446739eb7db6ae8b7e2426de2c125ddcfb77e421531d0fea887",
+	},
+	SQLite: {
+		1: "c02808feb0a4da9ad8c8681459306379b249086e3891e978ffd843d311b1a799",
+		2: "3115551153cc114d867545a854ed66e55f15406130a0413bc59fff8a1d666623",
+		3: "d9ce40283e17eb131d953d41f39a1a68ba144cce1dc84e39d7034b07f4691f9d",
+	},
+}
+
+func TestPublishedChecksums(t *testing.T) {
+	for dialect, checksums := range published {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", dialect, err)
+		}
+		for _, m := range migrations {
+			if checksum, ok := checksums[m.Version]; ok && m.Checksum != checksum {
+				t.Errorf("%s: migration %04d_%s was modified after release", dialect, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := NewMigrator(db, SQLite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != len(migrator.migrations) {
+		t.Errorf("expected %d migrations applied, got %d", len(migrator.migrations), len(applied))
+	}
+	if _, err := db.Exec("SELECT id, serial, location_id FROM stocks"); err != nil {
+		t.Errorf("expected stocks table: %v", err)
+	}
+
+	applied, err = migrator.Up()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no migrations on second run, got %d", len(applied))
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("expected migration %04d to be applied", s.Version)
+		}
+	}
+
+	reverted, err := migrator.Down(len(migrator.migrations))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reverted) != len(migrator.migrations) {
+		t.Errorf("expected %d migrations reverted, got %d", len(migrator.migrations), len(reverted))
+	}
+	if _, err := db.Exec("SELECT id FROM stocks"); err == nil {
+		t.Error("expected stocks table to be dropped")
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != len(migrator.migrations) {
+		t.Errorf("expected %d pending migrations, got %d", len(migrator.migrations), len(pending))
+	}
+}
+
+func TestUpFromSchemaWithoutMigrations(t *testing.T) {
+	db := openSQLite(t)
+	// A database created from the schema that predates the migrations
+	baseline, err := os.ReadFile(filepath.Join("testdata", "sqlite_schema.sql"))
+	if err != nil {
+		t.Fatalf("failed to read the baseline: %v", err)
+	}
+	for _, statement := range splitStatements(string(baseline)) {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to create the baseline schema: %v", err)
+		}
+	}
+	for _, statement := range []string{
+		"INSERT INTO products (name, code, created_at, updated_at) VALUES ('Monitor', 'MON', '2024-03-01 10:00:00', '2024-03-01 10:00:00')",
+		"INSERT INTO users (name, email, role, password, created_at, updated_at) VALUES ('ana', 'ana@example.com', 'admin', 'x', '2024-03-01 10:00:00', '2024-03-01 10:00:00')",
+		"INSERT INTO providers (name, email, phone, address, created_at, updated_at) VALUES ('acme', 'sales@acme.example', '', '', '2024-03-01 10:00:00', '2024-03-01 10:00:00')",
+		"INSERT INTO stocks (product_id, serial, created_at, updated_at, created_by_user_id, updated_by_user_id, batch, purchase_date, provider_id) VALUES (1, 'SN-1', '2024-03-01 10:00:00', '2024-03-01 10:00:00', 1, 1, 'B1', '2024-03-01 00:00:00', 1)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to insert baseline rows: %v", err)
+		}
+	}
+
+	migrator, err := NewMigrator(db, SQLite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var status, trackingMode string
+	var statusChangedAt time.Time
+	var locationID sql.NullInt64
+	var version int64
+	err = db.QueryRow(`
+		SELECT s.status, s.status_changed_at, s.location_id, s.version, p.tracking_mode
+		FROM stocks s JOIN products p ON p.id = s.product_id
+		WHERE s.serial = 'SN-1'
+	`).Scan(&status, &statusChangedAt, &locationID, &version, &trackingMode)
+	if err != nil {
+		t.Fatalf("expected the unit to survive the upgrade: %v", err)
+	}
+	if status != "available" || !statusChangedAt.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) || locationID.Valid || version != 1 || trackingMode != "serialized" {
+		t.Errorf("unexpected upgraded unit: status %q since %v, location %v, version %d, tracking %q",
+			status, statusChangedAt, locationID, version, trackingMode)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper string
+		check  func(error) bool
+	}{
+		{
+			name:   "modified migration",
+			tamper: "UPDATE schema_migrations SET checksum = 'x' WHERE version = 1",
+			check: func(err error) bool {
+				_, ok := err.(*ChecksumMismatchError)
+				return ok
+			},
+		},
+		{
+			name:   "migration from a newer version",
+			tamper: "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (9999, 'future', 'x', CURRENT_TIMESTAMP)",
+			check: func(err error) bool {
+				_, ok := err.(*UnknownMigrationError)
+				return ok
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t)
+			migrator, err := NewMigrator(db, SQLite)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := migrator.Up(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := db.Exec(tt.tamper); err != nil {
+				t.Fatalf("failed to tamper: %v", err)
+			}
+
+			if _, err := migrator.Up(); !tt.check(err) {
+				t.Errorf("unexpected error from Up: %v", err)
+			}
+			if _, err := migrator.Pending(); !tt.check(err) {
+				t.Errorf("unexpected error from Pending: %v", err)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Create a table
+CREATE TABLE a (
+    id INTEGER, -- inline comments stay
+    name TEXT
+);
+
+CREATE INDEX idx_a ON a (name);
+INSERT INTO a VALUES (1, 'x;y')`
+
+	statements := splitStatements(script)
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %q", len(statements), statements)
+	}
+	if statements[1] != "CREATE INDEX idx_a ON a (name)" {
+		t.Errorf("unexpected statement %q", statements[1])
+	}
+	if statements[2] != "INSERT INTO a VALUES (1, 'x;y')" {
+		t.Errorf("unexpected statement %q", statements[2])
+	}
+}
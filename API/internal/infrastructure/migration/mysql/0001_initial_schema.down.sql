@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS stock_balance_movements;
+DROP TABLE IF EXISTS stock_balances;
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS transfer_items;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stocks;
+DROP TABLE IF EXISTS locations;
+DROP TABLE IF EXISTS warehouses;
+DROP TABLE IF EXISTS providers;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,178 @@
+-- Create products table
+CREATE TABLE IF NOT EXISTS products (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    tracking_mode VARCHAR(20) NOT NULL DEFAULT 'serialized',
+    image_url TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    role VARCHAR(50) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Create providers table
+CREATE TABLE IF NOT EXISTS providers (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    phone VARCHAR(50) NOT NULL,
+    address TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Create warehouses table
+CREATE TABLE IF NOT EXISTS warehouses (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Create locations table (bins inside a warehouse)
+CREATE TABLE IF NOT EXISTS locations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    warehouse_id BIGINT NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE KEY uq_locations_warehouse_code (warehouse_id, code),
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+
+-- Create stocks table
+CREATE TABLE IF NOT EXISTS stocks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    product_id BIGINT NOT NULL,
+    serial VARCHAR(100) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'available',
+    status_changed_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    created_by_user_id BIGINT NOT NULL,
+    updated_by_user_id BIGINT NOT NULL,
+    batch VARCHAR(50) NOT NULL,
+    purchase_date DATETIME NOT NULL,
+    provider_id BIGINT NOT NULL,
+    location_id BIGINT NULL,
+    INDEX idx_stocks_status (status),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (created_by_user_id) REFERENCES users(id),
+    FOREIGN KEY (updated_by_user_id) REFERENCES users(id),
+    FOREIGN KEY (provider_id) REFERENCES providers(id),
+    FOREIGN KEY (location_id) REFERENCES locations(id)
+);
+
+-- Create stock_movements table (append-only ledger, kept after a stock is deleted)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    stock_id BIGINT NOT NULL,
+    serial VARCHAR(100) NOT NULL,
+    movement_type VARCHAR(20) NOT NULL,
+    actor_user_id BIGINT NULL,
+    before_state JSON NULL,
+    after_state JSON NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_stock_movements_stock (stock_id),
+    INDEX idx_stock_movements_serial (serial)
+);
+
+-- Create transfers table (moves units between locations)
+CREATE TABLE IF NOT EXISTS transfers (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    source_location_id BIGINT NOT NULL,
+    destination_location_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    notes TEXT NOT NULL,
+    created_by_user_id BIGINT NULL,
+    dispatched_at DATETIME NULL,
+    received_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_transfers_status (status),
+    FOREIGN KEY (source_location_id) REFERENCES locations(id),
+    FOREIGN KEY (destination_location_id) REFERENCES locations(id),
+    FOREIGN KEY (created_by_user_id) REFERENCES users(id)
+);
+
+-- Create transfer_items table (units listed on a transfer)
+CREATE TABLE IF NOT EXISTS transfer_items (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    transfer_id BIGINT NOT NULL,
+    stock_id BIGINT NOT NULL,
+    serial VARCHAR(100) NOT NULL,
+    UNIQUE KEY uq_transfer_items_stock (transfer_id, stock_id),
+    FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+);
+
+-- Create purchase_orders table
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    provider_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    notes TEXT NOT NULL,
+    created_by_user_id BIGINT NULL,
+    ordered_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_purchase_orders_status (status),
+    FOREIGN KEY (provider_id) REFERENCES providers(id),
+    FOREIGN KEY (created_by_user_id) REFERENCES users(id)
+);
+
+-- Create purchase_order_lines table
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    purchase_order_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    expected_quantity BIGINT NOT NULL,
+    received_quantity BIGINT NOT NULL DEFAULT 0,
+    unit_cost DECIMAL(12, 2) NOT NULL,
+    FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+-- Create stock_balances table (on-hand quantity of quantity-tracked products)
+CREATE TABLE IF NOT EXISTS stock_balances (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    product_id BIGINT NOT NULL,
+    location_id BIGINT NOT NULL,
+    batch VARCHAR(50) NOT NULL DEFAULT '',
+    quantity BIGINT NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    UNIQUE KEY uq_stock_balances_key (product_id, location_id, batch),
+    CONSTRAINT chk_stock_balances_quantity CHECK (quantity >= 0),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (location_id) REFERENCES locations(id)
+);
+
+-- Create stock_balance_movements table (append-only ledger of balance changes)
+CREATE TABLE IF NOT EXISTS stock_balance_movements (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    product_id BIGINT NOT NULL,
+    location_id BIGINT NOT NULL,
+    batch VARCHAR(50) NOT NULL DEFAULT '',
+    movement_type VARCHAR(20) NOT NULL,
+    quantity BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    actor_user_id BIGINT NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_stock_balance_movements_product (product_id),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (location_id) REFERENCES locations(id)
+);
@@ -0,0 +1,19 @@
+ALTER TABLE stocks
+    DROP INDEX idx_stocks_deleted_at,
+    DROP COLUMN deleted_by_user_id,
+    DROP COLUMN deleted_at;
+
+ALTER TABLE providers
+    DROP INDEX idx_providers_deleted_at,
+    DROP COLUMN deleted_by_user_id,
+    DROP COLUMN deleted_at;
+
+ALTER TABLE users
+    DROP INDEX idx_users_deleted_at,
+    DROP COLUMN deleted_by_user_id,
+    DROP COLUMN deleted_at;
+
+ALTER TABLE products
+    DROP INDEX idx_products_deleted_at,
+    DROP COLUMN deleted_by_user_id,
+    DROP COLUMN deleted_at;
@@ -0,0 +1,21 @@
+-- Soft delete products, users, providers and stocks. deleted_by_user_id has no
+-- foreign key so that purging a user keeps the deletions it made.
+ALTER TABLE products
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by_user_id BIGINT NULL,
+    ADD INDEX idx_products_deleted_at (deleted_at);
+
+ALTER TABLE users
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by_user_id BIGINT NULL,
+    ADD INDEX idx_users_deleted_at (deleted_at);
+
+ALTER TABLE providers
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by_user_id BIGINT NULL,
+    ADD INDEX idx_providers_deleted_at (deleted_at);
+
+ALTER TABLE stocks
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by_user_id BIGINT NULL,
+    ADD INDEX idx_stocks_deleted_at (deleted_at);
@@ -0,0 +1,7 @@
+ALTER TABLE stocks DROP COLUMN version;
+
+ALTER TABLE providers DROP COLUMN version;
+
+ALTER TABLE users DROP COLUMN version;
+
+ALTER TABLE products DROP COLUMN version;
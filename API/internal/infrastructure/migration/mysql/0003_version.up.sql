@@ -0,0 +1,9 @@
+-- Version products, users, providers and stocks for optimistic locking: every
+-- update bumps the version, and only applies to the version it was based on.
+ALTER TABLE products ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE providers ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE stocks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_stocks_deleted_at;
+ALTER TABLE stocks DROP COLUMN deleted_by_user_id;
+ALTER TABLE stocks DROP COLUMN deleted_at;
+
+DROP INDEX IF EXISTS idx_providers_deleted_at;
+ALTER TABLE providers DROP COLUMN deleted_by_user_id;
+ALTER TABLE providers DROP COLUMN deleted_at;
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_by_user_id;
+ALTER TABLE users DROP COLUMN deleted_at;
+
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE products DROP COLUMN deleted_by_user_id;
+ALTER TABLE products DROP COLUMN deleted_at;
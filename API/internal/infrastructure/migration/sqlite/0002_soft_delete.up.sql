@@ -0,0 +1,17 @@
+-- Soft delete products, users, providers and stocks. deleted_by_user_id has no
+-- foreign key so that purging a user keeps the deletions it made.
+ALTER TABLE products ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE products ADD COLUMN deleted_by_user_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+ALTER TABLE users ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN deleted_by_user_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+ALTER TABLE providers ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE providers ADD COLUMN deleted_by_user_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_providers_deleted_at ON providers (deleted_at);
+
+ALTER TABLE stocks ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE stocks ADD COLUMN deleted_by_user_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_stocks_deleted_at ON stocks (deleted_at);
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"inventario/internal/domain"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+// constraint is the kind of database constraint a statement violated
+type constraint int
+
+const (
+	noConstraint constraint = iota
+	// uniqueConstraint is a duplicate UNIQUE or PRIMARY KEY value
+	uniqueConstraint
+	// foreignKeyConstraint is a reference to a row that does not exist. SQLite
+	// reports a deleted row that is still referenced the same way.
+	foreignKeyConstraint
+	// referencedConstraint is a deleted or updated row that other rows still
+	// reference
+	referencedConstraint
+	// notNullConstraint is a NULL stored in a NOT NULL column
+	notNullConstraint
+)
+
+// MySQL server error numbers of the constraints above
+const (
+	mysqlErrDuplicateEntry   = 1062
+	mysqlErrRowIsReferenced  = 1451
+	mysqlErrNoReferencedRow  = 1452
+	mysqlErrBadNullForColumn = 1048
+)
+
+// violatedConstraint classifies err from the MySQL or SQLite driver
+func violatedConstraint(err error) constraint {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDuplicateEntry:
+			return uniqueConstraint
+		case mysqlErrNoReferencedRow:
+			return foreignKeyConstraint
+		case mysqlErrRowIsReferenced:
+			return referencedConstraint
+		case mysqlErrBadNullForColumn:
+			return notNullConstraint
+		}
+		return noConstraint
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return uniqueConstraint
+		case sqlite3.ErrConstraintForeignKey:
+			return foreignKeyConstraint
+		case sqlite3.ErrConstraintNotNull:
+			return notNullConstraint
+		}
+	}
+	return noConstraint
+}
+
+// writeError maps the error of inserting or updating the entity with the
+// given ID: a duplicate to exists, a missing reference to a ForeignKeyError
+// and a missing required column to a RequiredFieldError. Other errors are
+// returned unchanged.
+func writeError(err error, entity string, id int64, exists error) error {
+	switch violatedConstraint(err) {
+	case uniqueConstraint:
+		if exists != nil {
+			return exists
+		}
+	case foreignKeyConstraint:
+		return &domain.ForeignKeyError{Entity: entity, ID: id}
+	case referencedConstraint:
+		return &domain.ForeignKeyError{Entity: entity, ID: id, InUse: true}
+	case notNullConstraint:
+		return &domain.RequiredFieldError{Entity: entity}
+	}
+	return err
+}
+
+// deleteError maps the error of deleting the entity with the given ID: any
+// foreign key failure means it is still in use
+func deleteError(err error, entity string, id int64) error {
+	switch violatedConstraint(err) {
+	case foreignKeyConstraint, referencedConstraint:
+		return &domain.ForeignKeyError{Entity: entity, ID: id, InUse: true}
+	}
+	return err
+}
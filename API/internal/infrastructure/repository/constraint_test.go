@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"inventario/internal/domain"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestViolatedConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want constraint
+	}{
+		{"mysql duplicate entry", &mysql.MySQLError{Number: 1062}, uniqueConstraint},
+		{"mysql missing parent", &mysql.MySQLError{Number: 1452}, foreignKeyConstraint},
+		{"mysql row referenced", &mysql.MySQLError{Number: 1451}, referencedConstraint},
+		{"mysql null column", &mysql.MySQLError{Number: 1048}, notNullConstraint},
+		{"mysql other", &mysql.MySQLError{Number: 1064}, noConstraint},
+		{"wrapped mysql", fmt.Errorf("inserting: %w", &mysql.MySQLError{Number: 1062}), uniqueConstraint},
+		{"sqlite unique", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}, uniqueConstraint},
+		{"sqlite primary key", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}, uniqueConstraint},
+		{"sqlite foreign key", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}, foreignKeyConstraint},
+		{"sqlite not null", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}, notNullConstraint},
+		{"sqlite check", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintCheck}, noConstraint},
+		{"other error", errors.New("Error 1062: Duplicate entry"), noConstraint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violatedConstraint(tt.err); got != tt.want {
+				t.Errorf("expected constraint %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteAndDeleteError(t *testing.T) {
+	exists := &domain.ProductAlreadyExistsError{Code: "CBL"}
+	if err := writeError(&mysql.MySQLError{Number: 1062}, "product", 1, exists); err != exists {
+		t.Errorf("expected the AlreadyExists error, got %v", err)
+	}
+
+	var fk *domain.ForeignKeyError
+	if err := writeError(&mysql.MySQLError{Number: 1452}, "stock", 1, nil); !errors.As(err, &fk) || fk.InUse {
+		t.Errorf("expected a missing reference, got %v", err)
+	}
+	if err := writeError(&mysql.MySQLError{Number: 1048}, "stock", 1, nil); !errors.As(err, new(*domain.RequiredFieldError)) {
+		t.Errorf("expected a RequiredFieldError, got %v", err)
+	}
+	if err := writeError(nil, "stock", 1, nil); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	// SQLite reports a referenced row like a missing one
+	sqliteFK := sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}
+	for _, err := range []error{&mysql.MySQLError{Number: 1451}, sqliteFK} {
+		if err := deleteError(err, "product", 7); !errors.As(err, &fk) || !fk.InUse || fk.ID != 7 {
+			t.Errorf("expected product 7 to be in use, got %v", err)
+		}
+	}
+	other := errors.New("connection refused")
+	if err := deleteError(other, "product", 7); err != other {
+		t.Errorf("expected the error unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"inventario/internal/infrastructure/repository/repositorytest"
+	"inventario/internal/infrastructure/storage"
+)
+
+func TestSQLiteRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) *storage.Storage {
+		s, err := storage.Open(storage.Config{Driver: storage.DriverSQLiteMemory})
+		if err != nil {
+			t.Fatalf("opening storage: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+func TestMemoryRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) *storage.Storage {
+		s, err := storage.Open(storage.Config{Driver: storage.DriverMemory})
+		if err != nil {
+			t.Fatalf("opening storage: %v", err)
+		}
+		return s
+	})
+}
+
+// TestMySQLRepositories runs the suite against the MySQL database in
+// $TEST_MYSQL_DSN, which it migrates and empties before every test. The DSN
+// needs parseTime=true.
+func TestMySQLRepositories(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN not set")
+	}
+
+	s, err := storage.Open(storage.Config{Driver: storage.DriverMySQL, DSN: dsn, Migrate: true})
+	if err != nil {
+		t.Fatalf("opening storage: %v", err)
+	}
+	defer s.Close()
+
+	repositorytest.Run(t, func(t *testing.T) *storage.Storage {
+		truncateMySQL(t, s)
+		return s
+	})
+}
+
+// truncateMySQL empties every table but the migration history and resets
+// their auto-increment counters
+func truncateMySQL(t *testing.T, s *storage.Storage) {
+	t.Helper()
+	rows, err := s.DB.Query(`
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name <> 'schema_migrations'
+	`)
+	if err != nil {
+		t.Fatalf("listing tables: %v", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			t.Fatalf("listing tables: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	// Foreign key checks are per session, so everything runs on one connection
+	ctx := context.Background()
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("truncating tables: %v", err)
+	}
+	defer conn.Close()
+	statements := []string{"SET FOREIGN_KEY_CHECKS = 0"}
+	for _, table := range tables {
+		statements = append(statements, "TRUNCATE TABLE `"+table+"`")
+	}
+	statements = append(statements, "SET FOREIGN_KEY_CHECKS = 1")
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("truncating tables: %v", err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"inventario/internal/domain"
+	"strings"
+	"time"
+)
+
+// reference is a table holding foreign keys to an entity, and the condition
+// matching the rows that refer to it with "?" standing for its ID
+type reference struct {
+	table string
+	where string
+}
+
+// The foreign keys to products, providers and users, shared by the MySQL and
+// SQLite repositories. References without a foreign key, like the actor of a
+// stock movement, are history and do not block a deletion, and neither do
+// soft-deleted units nor the user who created a unit.
+var (
+	productReferences = []reference{
+		{"stocks", "product_id = ? AND deleted_at IS NULL"},
+		{"purchase_order_lines", "product_id = ?"},
+		{"stock_balances", "product_id = ?"},
+		{"stock_balance_movements", "product_id = ?"},
+	}
+	providerReferences = []reference{
+		{"stocks", "provider_id = ? AND deleted_at IS NULL"},
+		{"purchase_orders", "provider_id = ?"},
+	}
+	userReferences = []reference{
+		{"stocks", "updated_by_user_id = ? AND deleted_at IS NULL"},
+		{"transfers", "created_by_user_id = ?"},
+		{"purchase_orders", "created_by_user_id = ?"},
+	}
+)
+
+// countDependents counts the rows of every table in refs that refer to id,
+// leaving out the tables with none
+func countDependents(ctx context.Context, q queryer, refs []reference, id int64) ([]domain.Dependent, error) {
+	var dependents []domain.Dependent
+	for _, ref := range refs {
+		args := make([]interface{}, strings.Count(ref.where, "?"))
+		for i := range args {
+			args[i] = id
+		}
+
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", ref.table, ref.where)
+		if err := q.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			dependents = append(dependents, domain.Dependent{Table: ref.table, Count: count})
+		}
+	}
+	return dependents, nil
+}
+
+// lockForDependents locks the row of the entity id in table until the end of
+// the transaction, so that MySQL makes rows adding a reference to it wait
+// for the deletion that counted its dependents. A missing row is left to the
+// deletion to report. SQLite needs no lock, as it lets a single transaction
+// write at a time.
+func lockForDependents(ctx context.Context, q queryer, table string, id int64) error {
+	var locked int64
+	err := q.QueryRowContext(ctx, fmt.Sprintf("SELECT id FROM %s WHERE id = ? FOR UPDATE", table), id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// stockReferenceColumns lists the column of stocks behind each reference
+var stockReferenceColumns = map[domain.StockReference]string{
+	domain.StockProductReference:  "product_id",
+	domain.StockProviderReference: "provider_id",
+	domain.StockUserReference:     "updated_by_user_id",
+}
+
+// reassignStocks points the units referring to fromID through ref at toID and
+// records the change of each one in the ledger on behalf of actor. It should
+// run in a transaction.
+func reassignStocks(ctx context.Context, conn dbConn, ref domain.StockReference, fromID, toID int64, actor *domain.User, reason string, now time.Time) (int64, error) {
+	column, ok := stockReferenceColumns[ref]
+	if !ok {
+		return 0, fmt.Errorf("unknown stock reference %q", ref)
+	}
+
+	rows, err := conn.QueryContext(ctx, stockSelect+" WHERE s."+column+" = ? ORDER BY s.id", fromID)
+	if err != nil {
+		return 0, err
+	}
+	var stocks []*domain.Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stocks = append(stocks, stock)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	movements := make([]*domain.StockMovement, 0, len(stocks))
+	for _, before := range stocks {
+		result, err := conn.ExecContext(ctx,
+			"UPDATE stocks SET "+column+" = ?, updated_at = ?, version = version + 1 WHERE id = ? AND "+column+" = ?",
+			toID, now, before.ID, fromID)
+		if err != nil {
+			return 0, writeError(err, "stock", before.ID, nil)
+		}
+		changed, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if changed == 0 {
+			continue
+		}
+
+		after := *before
+		switch ref {
+		case domain.StockProductReference:
+			after.Product = &domain.Product{ID: toID}
+		case domain.StockProviderReference:
+			after.Provider = &domain.Provider{ID: toID}
+		case domain.StockUserReference:
+			after.UpdatedByUser = &domain.User{ID: toID}
+		}
+		movement := domain.NewStockMovement(domain.MovementUpdate, actor, before, &after, reason)
+		movement.CreatedAt = now
+		movements = append(movements, movement)
+	}
+
+	if err := insertStockMovements(ctx, conn, movements); err != nil {
+		return 0, err
+	}
+	return int64(len(movements)), nil
+}
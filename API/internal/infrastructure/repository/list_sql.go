@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"inventario/internal/domain"
+	"strconv"
+	"strings"
+)
+
+// whereClause joins conditions into a WHERE clause, or returns an empty
+// string when there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// likeContains builds a LIKE pattern matching any value containing s. The
+// "!" escape works the same in MySQL and SQLite, which has no default one.
+func likeContains(s string) string {
+	s = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+	return "%" + s + "%"
+}
+
+// containsCondition is the condition matched by a likeContains pattern
+func containsCondition(column string) string {
+	return column + " LIKE ? ESCAPE '!'"
+}
+
+// withKeyset narrows a WHERE clause built by one of the filter clauses to the
+// rows following opts.AfterID
+func withKeyset(where string, args []interface{}, opts domain.ListOptions, prefix string) (string, []interface{}) {
+	if opts.AfterID == 0 {
+		return where, args
+	}
+
+	condition := prefix + "id > ?"
+	if opts.SortedDesc() {
+		condition = prefix + "id < ?"
+	}
+	if where == "" {
+		where = " WHERE " + condition
+	} else {
+		where += " AND " + condition
+	}
+	return where, append(args, opts.AfterID)
+}
+
+// pageClause builds the ORDER BY and LIMIT clauses of a listing. Only fields
+// in allowed are used, and the id is always the last sort key so that pages
+// are stable.
+func pageClause(opts domain.ListOptions, prefix string, allowed []string) string {
+	var order []string
+	sortedByID := false
+	for _, field := range opts.Sort {
+		if !domain.ContainsField(allowed, field.Field) {
+			continue
+		}
+		column := prefix + field.Field
+		if field.Desc {
+			column += " DESC"
+		}
+		order = append(order, column)
+		sortedByID = sortedByID || field.Field == "id"
+	}
+	if !sortedByID {
+		order = append(order, prefix+"id")
+	}
+
+	clause := " ORDER BY " + strings.Join(order, ", ")
+	if opts.Limit > 0 {
+		clause += " LIMIT " + strconv.Itoa(opts.Limit)
+		if opts.Offset > 0 {
+			clause += " OFFSET " + strconv.Itoa(opts.Offset)
+		}
+	}
+	return clause
+}
+
+// productFilterClause builds the WHERE clause for a product filter
+func productFilterClause(filter domain.ProductFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted(""))
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, containsCondition("name"))
+		args = append(args, likeContains(filter.Name))
+	}
+	if filter.Code != "" {
+		conditions = append(conditions, "code = ?")
+		args = append(args, filter.Code)
+	}
+	if filter.TrackingMode != "" {
+		conditions = append(conditions, "tracking_mode = ?")
+		args = append(args, filter.TrackingMode)
+	}
+	return whereClause(conditions), args
+}
+
+// userFilterClause builds the WHERE clause for a user filter
+func userFilterClause(filter domain.UserFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted(""))
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, containsCondition("name"))
+		args = append(args, likeContains(filter.Name))
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, filter.Email)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, filter.Role)
+	}
+	return whereClause(conditions), args
+}
+
+// providerFilterClause builds the WHERE clause for a provider filter
+func providerFilterClause(filter domain.ProviderFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted(""))
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, containsCondition("name"))
+		args = append(args, likeContains(filter.Name))
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, filter.Email)
+	}
+	return whereClause(conditions), args
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"inventario/internal/domain"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memoryPage orders items and cuts the page selected by opts, the way
+// withKeyset and pageClause do in SQL. id returns the ID of an item and field
+// the value of one of the allowed sort fields.
+func memoryPage[T any](items []T, opts domain.ListOptions, allowed []string, id func(*T) int64, field func(*T, string) interface{}) []T {
+	if opts.AfterID != 0 {
+		kept := items[:0]
+		for i := range items {
+			itemID := id(&items[i])
+			if (opts.SortedDesc() && itemID < opts.AfterID) || (!opts.SortedDesc() && itemID > opts.AfterID) {
+				kept = append(kept, items[i])
+			}
+		}
+		items = kept
+	}
+
+	var order []domain.SortField
+	sortedByID := false
+	for _, f := range opts.Sort {
+		if domain.ContainsField(allowed, f.Field) {
+			order = append(order, f)
+			sortedByID = sortedByID || f.Field == "id"
+		}
+	}
+	if !sortedByID {
+		order = append(order, domain.SortField{Field: "id"})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, f := range order {
+			var c int
+			if f.Field == "id" {
+				c = compareValues(id(&items[i]), id(&items[j]))
+			} else {
+				c = compareValues(field(&items[i], f.Field), field(&items[j], f.Field))
+			}
+			if c != 0 {
+				return (c < 0) != f.Desc
+			}
+		}
+		return false
+	})
+
+	if opts.Limit <= 0 {
+		return items
+	}
+	if opts.Offset >= len(items) {
+		return nil
+	}
+	items = items[opts.Offset:]
+	if len(items) > opts.Limit {
+		items = items[:opts.Limit]
+	}
+	return items
+}
+
+// compareValues compares two sort values of the same type
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// memoryContains matches s the way containsCondition does: case-insensitively
+// for ASCII, as both MySQL and SQLite compare with LIKE
+func memoryContains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryLocationRepository keeps locations in memory. Codes are unique within
+// a warehouse, and reads fill in the warehouse from the given repository, as
+// the MySQL join does. Deleting a location does not check for units still
+// placed there.
+type MemoryLocationRepository struct {
+	locations  map[int64]domain.Location
+	nextID     int64
+	mutex      sync.RWMutex
+	warehouses domain.IWarehouseRepository
+}
+
+func NewMemoryLocationRepository(warehouses domain.IWarehouseRepository) *MemoryLocationRepository {
+	return &MemoryLocationRepository{
+		locations:  make(map[int64]domain.Location),
+		nextID:     1,
+		warehouses: warehouses,
+	}
+}
+
+func (r *MemoryLocationRepository) Create(ctx context.Context, location *domain.Location) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.codeTaken(location.Warehouse.ID, location.Code, 0) {
+		return &domain.LocationAlreadyExistsError{Code: location.Code}
+	}
+
+	now := time.Now().UTC()
+	location.ID = r.nextID
+	location.CreatedAt = now
+	location.UpdatedAt = now
+	r.nextID++
+
+	r.locations[location.ID] = stripLocation(*location)
+	return nil
+}
+
+func (r *MemoryLocationRepository) GetByID(ctx context.Context, id int64) (*domain.Location, error) {
+	r.mutex.RLock()
+	location, exists := r.locations[id]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return nil, nil
+	}
+	return r.join(ctx, location)
+}
+
+// GetAll lists the locations by warehouse code, then by location code
+func (r *MemoryLocationRepository) GetAll(ctx context.Context) ([]domain.Location, error) {
+	return r.list(ctx, func(domain.Location) bool { return true })
+}
+
+func (r *MemoryLocationRepository) GetByWarehouseID(ctx context.Context, warehouseID int64) ([]domain.Location, error) {
+	return r.list(ctx, func(l domain.Location) bool { return l.Warehouse.ID == warehouseID })
+}
+
+func (r *MemoryLocationRepository) Update(ctx context.Context, location *domain.Location) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.locations[location.ID]
+	if !exists {
+		return &domain.LocationNotFoundError{LocationID: location.ID}
+	}
+	if r.codeTaken(location.Warehouse.ID, location.Code, location.ID) {
+		return &domain.LocationAlreadyExistsError{Code: location.Code}
+	}
+
+	location.CreatedAt = existing.CreatedAt
+	location.UpdatedAt = time.Now().UTC()
+	r.locations[location.ID] = stripLocation(*location)
+	return nil
+}
+
+func (r *MemoryLocationRepository) Delete(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.locations[id]; !exists {
+		return &domain.LocationNotFoundError{LocationID: id}
+	}
+	delete(r.locations, id)
+	return nil
+}
+
+// list returns the joined locations kept by keep, by warehouse code, then by
+// location code
+func (r *MemoryLocationRepository) list(ctx context.Context, keep func(domain.Location) bool) ([]domain.Location, error) {
+	r.mutex.RLock()
+	var stored []domain.Location
+	for _, location := range r.locations {
+		if keep(location) {
+			stored = append(stored, location)
+		}
+	}
+	r.mutex.RUnlock()
+
+	locations := make([]domain.Location, 0, len(stored))
+	for _, location := range stored {
+		joined, err := r.join(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, *joined)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.Warehouse.Code != b.Warehouse.Code {
+			return a.Warehouse.Code < b.Warehouse.Code
+		}
+		return a.Code < b.Code
+	})
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	return locations, nil
+}
+
+// codeTaken reports whether a location of the warehouse other than exceptID
+// uses code
+func (r *MemoryLocationRepository) codeTaken(warehouseID int64, code string, exceptID int64) bool {
+	for id, location := range r.locations {
+		if id != exceptID && location.Warehouse.ID == warehouseID && location.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// join fills in the warehouse of a stored location
+func (r *MemoryLocationRepository) join(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	warehouse, err := r.warehouses.GetByID(ctx, location.Warehouse.ID)
+	if err != nil {
+		return nil, err
+	}
+	if warehouse != nil {
+		location.Warehouse = warehouse
+	}
+	return &location, nil
+}
+
+// stripLocation keeps only the ID of the warehouse of a location, so that the
+// store does not share pointers with callers
+func stripLocation(location domain.Location) domain.Location {
+	var warehouseID int64
+	if location.Warehouse != nil {
+		warehouseID = location.Warehouse.ID
+	}
+	location.Warehouse = &domain.Warehouse{ID: warehouseID}
+	return location
+}
@@ -1,85 +1,241 @@
 package repository
 
 import (
+	"context"
+	"fmt"
 	"inventario/internal/domain"
 	"sync"
+	"time"
 )
 
 type MemoryProductRepository struct {
-	products map[int64]*domain.Product
+	products map[int64]domain.Product
+	nextID   int64
 	mutex    sync.RWMutex
 }
 
 func NewMemoryProductRepository() *MemoryProductRepository {
 	return &MemoryProductRepository{
-		products: make(map[int64]*domain.Product),
+		products: make(map[int64]domain.Product),
+		nextID:   1,
 	}
 }
 
-func (r *MemoryProductRepository) Create(product *domain.Product) error {
+func (r *MemoryProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[product.ID]; exists {
+	if r.codeTaken(product.Code, 0) {
 		return &domain.ProductAlreadyExistsError{
 			Code: product.Code,
 		}
 	}
 
-	r.products[product.ID] = product
+	now := time.Now().UTC()
+	product.ID = r.nextID
+	product.SoftDeleted = domain.SoftDeleted{}
+	product.Version = 1
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.nextID++
+
+	r.products[product.ID] = *product
 	return nil
 }
 
-func (r *MemoryProductRepository) GetByID(id int64) (*domain.Product, error) {
+func (r *MemoryProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	product, exists := r.products[id]
-	if !exists {
-		return nil, &domain.ProductNotFoundError{
-			ProductID: id,
-		}
+	if !exists || product.IsDeleted() {
+		return nil, nil
 	}
-
-	return product, nil
+	return &product, nil
 }
 
-func (r *MemoryProductRepository) GetAll() ([]*domain.Product, error) {
+func (r *MemoryProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	products := make([]*domain.Product, 0, len(r.products))
-	for _, product := range r.products {
-		products = append(products, product)
+	products := r.matching(filter)
+	products = memoryPage(products, filter.ListOptions, domain.ProductSortFields,
+		func(p *domain.Product) int64 { return p.ID },
+		func(p *domain.Product, field string) interface{} {
+			switch field {
+			case "name":
+				return p.Name
+			case "code":
+				return p.Code
+			case "created_at":
+				return p.CreatedAt
+			default:
+				return p.UpdatedAt
+			}
+		})
+	if len(products) == 0 {
+		return nil, nil
 	}
-
 	return products, nil
 }
 
-func (r *MemoryProductRepository) Update(product *domain.Product) error {
+func (r *MemoryProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *MemoryProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[product.ID]; !exists {
+	existing, exists := r.products[product.ID]
+	if !exists || existing.IsDeleted() {
 		return &domain.ProductNotFoundError{
 			ProductID: product.ID,
 		}
 	}
+	if product.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "product", ID: product.ID, Version: product.Version}
+	}
+	if r.codeTaken(product.Code, product.ID) {
+		return &domain.ProductAlreadyExistsError{
+			Code: product.Code,
+		}
+	}
 
-	r.products[product.ID] = product
+	product.CreatedAt = existing.CreatedAt
+	product.SoftDeleted = existing.SoftDeleted
+	product.UpdatedAt = time.Now().UTC()
+	product.Version++
+	r.products[product.ID] = *product
 	return nil
 }
 
-func (r *MemoryProductRepository) Delete(id int64) error {
+// Patch saves the given fields of the product, see Update
+func (r *MemoryProductRepository) Patch(ctx context.Context, product *domain.Product, fields []string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.products[id]; !exists {
+	existing, exists := r.products[product.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.ProductNotFoundError{ProductID: product.ID}
+	}
+	if product.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "product", ID: product.ID, Version: product.Version}
+	}
+
+	patched := existing
+	for _, field := range fields {
+		switch field {
+		case "name":
+			patched.Name = product.Name
+		case "code":
+			patched.Code = product.Code
+		case "tracking_mode":
+			patched.TrackingMode = product.TrackingMode
+		case "image_url":
+			patched.ImageURL = product.ImageURL
+		default:
+			return fmt.Errorf("cannot patch %s of products", field)
+		}
+	}
+	if r.codeTaken(patched.Code, product.ID) {
+		return &domain.ProductAlreadyExistsError{Code: patched.Code}
+	}
+
+	now := time.Now().UTC()
+	patched.UpdatedAt = now
+	patched.Version++
+	r.products[product.ID] = patched
+	product.UpdatedAt = now
+	product.Version = patched.Version
+	return nil
+}
+
+func (r *MemoryProductRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product, exists := r.products[id]
+	if !exists || product.IsDeleted() {
 		return &domain.ProductNotFoundError{
 			ProductID: id,
 		}
 	}
 
-	delete(r.products, id)
+	product.SoftDeleted = softDeletedNow(deletedBy)
+	product.Version++
+	r.products[id] = product
 	return nil
 }
+
+func (r *MemoryProductRepository) Restore(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product, exists := r.products[id]
+	if !exists || !product.IsDeleted() {
+		return &domain.ProductNotFoundError{
+			ProductID: id,
+		}
+	}
+
+	product.SoftDeleted = domain.SoftDeleted{}
+	product.Version++
+	r.products[id] = product
+	return nil
+}
+
+// Purge removes every product soft deleted before the given time, as nothing
+// refers to them in memory
+func (r *MemoryProductRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var purged int64
+	for id, product := range r.products {
+		if purgeable(product.SoftDeleted, before) {
+			delete(r.products, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Dependents finds nothing: the in-memory repositories do not enforce
+// references between each other
+func (r *MemoryProductRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return nil, nil
+}
+
+// codeTaken reports whether a product other than exceptID uses code
+func (r *MemoryProductRepository) codeTaken(code string, exceptID int64) bool {
+	for id, p := range r.products {
+		if id != exceptID && p.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryProductRepository) matching(filter domain.ProductFilter) []domain.Product {
+	var products []domain.Product
+	for _, p := range r.products {
+		if !filter.IncludeDeleted && p.IsDeleted() {
+			continue
+		}
+		if filter.Name != "" && !memoryContains(p.Name, filter.Name) {
+			continue
+		}
+		if filter.Code != "" && p.Code != filter.Code {
+			continue
+		}
+		if filter.TrackingMode != "" && p.TrackingMode != filter.TrackingMode {
+			continue
+		}
+		products = append(products, p)
+	}
+	return products
+}
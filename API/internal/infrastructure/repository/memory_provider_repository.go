@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inventario/internal/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProviderRepository keeps providers in memory. Emails are unique and
+// compared case-insensitively, as with the MySQL collation.
+type MemoryProviderRepository struct {
+	providers map[int64]domain.Provider
+	nextID    int64
+	mutex     sync.RWMutex
+}
+
+func NewMemoryProviderRepository() *MemoryProviderRepository {
+	return &MemoryProviderRepository{
+		providers: make(map[int64]domain.Provider),
+		nextID:    1,
+	}
+}
+
+func (r *MemoryProviderRepository) Create(ctx context.Context, provider *domain.Provider) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.emailTaken(provider.Email, 0) {
+		return &domain.ProviderAlreadyExistsError{Email: provider.Email}
+	}
+
+	now := time.Now().UTC()
+	provider.ID = r.nextID
+	provider.SoftDeleted = domain.SoftDeleted{}
+	provider.Version = 1
+	provider.CreatedAt = now
+	provider.UpdatedAt = now
+	r.nextID++
+
+	r.providers[provider.ID] = *provider
+	return nil
+}
+
+func (r *MemoryProviderRepository) GetByID(ctx context.Context, id int64) (*domain.Provider, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	provider, exists := r.providers[id]
+	if !exists || provider.IsDeleted() {
+		return nil, nil
+	}
+	return &provider, nil
+}
+
+func (r *MemoryProviderRepository) GetAll(ctx context.Context, filter domain.ProviderFilter) ([]domain.Provider, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	providers := r.matching(filter)
+	providers = memoryPage(providers, filter.ListOptions, domain.ProviderSortFields,
+		func(p *domain.Provider) int64 { return p.ID },
+		func(p *domain.Provider, field string) interface{} {
+			switch field {
+			case "name":
+				return p.Name
+			case "email":
+				return p.Email
+			case "created_at":
+				return p.CreatedAt
+			default:
+				return p.UpdatedAt
+			}
+		})
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return providers, nil
+}
+
+func (r *MemoryProviderRepository) Count(ctx context.Context, filter domain.ProviderFilter) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *MemoryProviderRepository) Update(ctx context.Context, provider *domain.Provider) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.providers[provider.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.ProviderNotFoundError{ProviderID: provider.ID}
+	}
+	if provider.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "provider", ID: provider.ID, Version: provider.Version}
+	}
+	if r.emailTaken(provider.Email, provider.ID) {
+		return &domain.ProviderAlreadyExistsError{Email: provider.Email}
+	}
+
+	provider.CreatedAt = existing.CreatedAt
+	provider.SoftDeleted = existing.SoftDeleted
+	provider.UpdatedAt = time.Now().UTC()
+	provider.Version++
+	r.providers[provider.ID] = *provider
+	return nil
+}
+
+// Patch saves the given fields of the provider, see Update
+func (r *MemoryProviderRepository) Patch(ctx context.Context, provider *domain.Provider, fields []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.providers[provider.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.ProviderNotFoundError{ProviderID: provider.ID}
+	}
+	if provider.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "provider", ID: provider.ID, Version: provider.Version}
+	}
+
+	patched := existing
+	for _, field := range fields {
+		switch field {
+		case "name":
+			patched.Name = provider.Name
+		case "email":
+			patched.Email = provider.Email
+		case "phone":
+			patched.Phone = provider.Phone
+		case "address":
+			patched.Address = provider.Address
+		default:
+			return fmt.Errorf("cannot patch %s of providers", field)
+		}
+	}
+	if r.emailTaken(patched.Email, provider.ID) {
+		return &domain.ProviderAlreadyExistsError{Email: patched.Email}
+	}
+
+	now := time.Now().UTC()
+	patched.UpdatedAt = now
+	patched.Version++
+	r.providers[provider.ID] = patched
+	provider.UpdatedAt = now
+	provider.Version = patched.Version
+	return nil
+}
+
+func (r *MemoryProviderRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	provider, exists := r.providers[id]
+	if !exists || provider.IsDeleted() {
+		return &domain.ProviderNotFoundError{ProviderID: id}
+	}
+
+	provider.SoftDeleted = softDeletedNow(deletedBy)
+	provider.Version++
+	r.providers[id] = provider
+	return nil
+}
+
+func (r *MemoryProviderRepository) Restore(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	provider, exists := r.providers[id]
+	if !exists || !provider.IsDeleted() {
+		return &domain.ProviderNotFoundError{ProviderID: id}
+	}
+
+	provider.SoftDeleted = domain.SoftDeleted{}
+	provider.Version++
+	r.providers[id] = provider
+	return nil
+}
+
+// Purge removes every provider soft deleted before the given time, as nothing
+// refers to them in memory
+func (r *MemoryProviderRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var purged int64
+	for id, provider := range r.providers {
+		if purgeable(provider.SoftDeleted, before) {
+			delete(r.providers, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Dependents finds nothing: the in-memory repositories do not enforce
+// references between each other
+func (r *MemoryProviderRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return nil, nil
+}
+
+// emailTaken reports whether a provider other than exceptID uses email
+func (r *MemoryProviderRepository) emailTaken(email string, exceptID int64) bool {
+	for id, provider := range r.providers {
+		if id != exceptID && strings.EqualFold(provider.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryProviderRepository) matching(filter domain.ProviderFilter) []domain.Provider {
+	var providers []domain.Provider
+	for _, provider := range r.providers {
+		if !filter.IncludeDeleted && provider.IsDeleted() {
+			continue
+		}
+		if filter.Name != "" && !memoryContains(provider.Name, filter.Name) {
+			continue
+		}
+		if filter.Email != "" && !strings.EqualFold(provider.Email, filter.Email) {
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryPurchaseOrderRepository keeps purchase orders in memory. Reads fill in
+// the provider and the products of the lines from the given repositories, and
+// receiving goods creates the units in the given stock repository and records
+// their creation in the given ledger.
+type MemoryPurchaseOrderRepository struct {
+	orders     map[int64]domain.PurchaseOrder
+	nextID     int64
+	nextLineID int64
+	mutex      sync.RWMutex
+	products   domain.IProductRepository
+	providers  domain.IProviderRepository
+	stocks     *MemoryStockRepository
+	movements  domain.IStockMovementRepository
+}
+
+func NewMemoryPurchaseOrderRepository(products domain.IProductRepository, providers domain.IProviderRepository, stocks *MemoryStockRepository, movements domain.IStockMovementRepository) *MemoryPurchaseOrderRepository {
+	return &MemoryPurchaseOrderRepository{
+		orders:     make(map[int64]domain.PurchaseOrder),
+		nextID:     1,
+		nextLineID: 1,
+		products:   products,
+		providers:  providers,
+		stocks:     stocks,
+		movements:  movements,
+	}
+}
+
+func (r *MemoryPurchaseOrderRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range order.Lines {
+		order.Lines[i].ID = r.nextLineID
+		r.nextLineID++
+	}
+
+	now := time.Now().UTC()
+	order.ID = r.nextID
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	r.nextID++
+
+	r.orders[order.ID] = stripPurchaseOrder(*order)
+	return nil
+}
+
+func (r *MemoryPurchaseOrderRepository) GetByID(ctx context.Context, id int64) (*domain.PurchaseOrder, error) {
+	r.mutex.RLock()
+	order, exists := r.orders[id]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return nil, nil
+	}
+	return r.join(ctx, order)
+}
+
+func (r *MemoryPurchaseOrderRepository) GetAll(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	r.mutex.RLock()
+	var orders []domain.PurchaseOrder
+	for _, order := range r.orders {
+		switch {
+		case filter.Status != "" && order.Status != filter.Status,
+			filter.ProviderID != 0 && order.Provider.ID != filter.ProviderID:
+			continue
+		}
+		orders = append(orders, order)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+	for i := range orders {
+		joined, err := r.join(ctx, orders[i])
+		if err != nil {
+			return nil, err
+		}
+		orders[i] = *joined
+	}
+	return orders, nil
+}
+
+func (r *MemoryPurchaseOrderRepository) UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.orders[order.ID]
+	if !exists {
+		return &domain.PurchaseOrderNotFoundError{PurchaseOrderID: order.ID}
+	}
+
+	now := time.Now().UTC()
+	existing.Status = order.Status
+	existing.OrderedAt = copyTime(order.OrderedAt)
+	existing.UpdatedAt = now
+	r.orders[order.ID] = existing
+
+	order.UpdatedAt = now
+	return nil
+}
+
+// Receive creates the received units and books them against their order
+// lines. order already carries the new quantities and status; as in
+// receivePurchaseOrder, the stored order is checked first so that a receipt
+// racing with another one is rejected before anything is written.
+func (r *MemoryPurchaseOrderRepository) Receive(ctx context.Context, order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.orders[order.ID]
+	if !exists {
+		return &domain.PurchaseOrderNotFoundError{PurchaseOrderID: order.ID}
+	}
+	if !existing.Status.CanReceive() {
+		return &domain.InvalidPurchaseOrderTransitionError{From: existing.Status, To: order.Status}
+	}
+
+	received := make(map[int64]int64)
+	var stocks []*domain.Stock
+	for _, line := range lines {
+		stored := existing.Line(line.LineID)
+		if stored == nil {
+			return &domain.OverReceiptError{LineID: line.LineID}
+		}
+		received[line.LineID] += int64(len(line.Stocks))
+		if received[line.LineID] > stored.Pending() {
+			return &domain.OverReceiptError{LineID: line.LineID, Pending: stored.Pending()}
+		}
+		stocks = append(stocks, line.Stocks...)
+	}
+
+	if err := r.stocks.CreateMany(ctx, stocks); err != nil {
+		return err
+	}
+	for _, stock := range stocks {
+		movement := domain.NewStockMovement(domain.MovementCreate, stock.CreatedByUser, nil, stock, reason)
+		if err := r.movements.Create(ctx, movement); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	existing.Lines = append([]domain.PurchaseOrderLine(nil), existing.Lines...)
+	for lineID, quantity := range received {
+		existing.Line(lineID).ReceivedQuantity += quantity
+	}
+	existing.Status = order.Status
+	existing.UpdatedAt = now
+	r.orders[order.ID] = existing
+
+	order.UpdatedAt = now
+	return nil
+}
+
+// join fills in the provider and line products of a stored order
+func (r *MemoryPurchaseOrderRepository) join(ctx context.Context, order domain.PurchaseOrder) (*domain.PurchaseOrder, error) {
+	provider, err := r.providers.GetByID(ctx, order.Provider.ID)
+	if err != nil {
+		return nil, err
+	}
+	if provider != nil {
+		order.Provider = provider
+	}
+
+	order.Lines = append([]domain.PurchaseOrderLine(nil), order.Lines...)
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		product, err := r.products.GetByID(ctx, line.Product.ID)
+		if err != nil {
+			return nil, err
+		}
+		if product != nil {
+			line.Product = &domain.Product{ID: product.ID, Name: product.Name, Code: product.Code}
+		}
+	}
+	order.OrderedAt = copyTime(order.OrderedAt)
+	return &order, nil
+}
+
+// stripPurchaseOrder keeps only the IDs of the related entities of an order and
+// copies its lines, so that the store does not share memory with callers
+func stripPurchaseOrder(order domain.PurchaseOrder) domain.PurchaseOrder {
+	order.Provider = &domain.Provider{ID: providerIDOf(order.Provider)}
+	order.CreatedByUser = actorRef(order.CreatedByUser)
+	order.OrderedAt = copyTime(order.OrderedAt)
+	lines := make([]domain.PurchaseOrderLine, len(order.Lines))
+	for i, line := range order.Lines {
+		line.Product = &domain.Product{ID: productIDOf(line.Product)}
+		lines[i] = line
+	}
+	order.Lines = lines
+	return order
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"inventario/internal/domain"
+	"time"
+)
+
+// softDeletedNow is the deletion record of a row deleted now by deletedBy,
+// which is left nil when zero, as the SQL repositories store NULL
+func softDeletedNow(deletedBy int64) domain.SoftDeleted {
+	now := time.Now().UTC()
+	deletion := domain.SoftDeleted{DeletedAt: &now}
+	if deletedBy != 0 {
+		deletion.DeletedBy = &deletedBy
+	}
+	return deletion
+}
+
+// purgeable reports whether a row with the given deletion record was soft
+// deleted before the given time
+func purgeable(deletion domain.SoftDeleted, before time.Time) bool {
+	return deletion.DeletedAt != nil && deletion.DeletedAt.Before(before)
+}
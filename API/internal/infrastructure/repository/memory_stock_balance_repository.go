@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// balanceKey identifies the balance of a product batch at a location
+type balanceKey struct {
+	productID  int64
+	locationID int64
+	batch      string
+}
+
+// MemoryStockBalanceRepository keeps the balances of quantity-tracked products
+// and their ledger in memory. Reads fill in the product and the location from
+// the given repositories, as the MySQL joins do.
+type MemoryStockBalanceRepository struct {
+	balances       map[balanceKey]domain.StockBalance
+	movements      []domain.BalanceMovement
+	nextID         int64
+	nextMovementID int64
+	mutex          sync.RWMutex
+	products       domain.IProductRepository
+	locations      domain.ILocationRepository
+}
+
+func NewMemoryStockBalanceRepository(products domain.IProductRepository, locations domain.ILocationRepository) *MemoryStockBalanceRepository {
+	return &MemoryStockBalanceRepository{
+		balances:       make(map[balanceKey]domain.StockBalance),
+		nextID:         1,
+		nextMovementID: 1,
+		products:       products,
+		locations:      locations,
+	}
+}
+
+func (r *MemoryStockBalanceRepository) GetAll(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	match, err := r.matcher(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	var balances []domain.StockBalance
+	for key, balance := range r.balances {
+		if match(key) {
+			balances = append(balances, balance)
+		}
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].ID < balances[j].ID
+	})
+	for i := range balances {
+		balance := &balances[i]
+		if balance.Product, err = r.product(ctx, balance.Product.ID); err != nil {
+			return nil, err
+		}
+		if balance.Location, err = r.location(ctx, balance.Location.ID); err != nil {
+			return nil, err
+		}
+	}
+	return balances, nil
+}
+
+// Apply adds movement.Quantity to its balance, creating the balance on the
+// first receipt, and appends the movement to the ledger. An issue that would
+// take the balance below zero fails with an InsufficientQuantityError.
+func (r *MemoryStockBalanceRepository) Apply(ctx context.Context, movement *domain.BalanceMovement) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := balanceKey{productID: movement.Product.ID, locationID: movement.Location.ID, batch: movement.Batch}
+	balance, exists := r.balances[key]
+	if balance.Quantity+movement.Quantity < 0 {
+		return &domain.InsufficientQuantityError{
+			ProductID: key.productID,
+			Available: balance.Quantity,
+			Requested: -movement.Quantity,
+		}
+	}
+
+	now := time.Now().UTC()
+	if !exists {
+		balance = domain.StockBalance{
+			ID:       r.nextID,
+			Product:  &domain.Product{ID: key.productID},
+			Location: &domain.Location{ID: key.locationID},
+			Batch:    key.batch,
+		}
+		r.nextID++
+	}
+	balance.Quantity += movement.Quantity
+	balance.UpdatedAt = now
+	r.balances[key] = balance
+
+	movement.ID = r.nextMovementID
+	movement.BalanceAfter = balance.Quantity
+	movement.CreatedAt = now
+	r.nextMovementID++
+
+	stored := *movement
+	stored.Product = &domain.Product{ID: key.productID}
+	stored.Location = &domain.Location{ID: key.locationID}
+	stored.Actor = actorRef(movement.Actor)
+	r.movements = append(r.movements, stored)
+	return nil
+}
+
+func (r *MemoryStockBalanceRepository) GetMovements(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	match, err := r.matcher(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	var movements []domain.BalanceMovement
+	for _, movement := range r.movements {
+		if match(balanceKey{productID: movement.Product.ID, locationID: movement.Location.ID, batch: movement.Batch}) {
+			movements = append(movements, movement)
+		}
+	}
+	r.mutex.RUnlock()
+
+	for i := range movements {
+		movement := &movements[i]
+		if movement.Product, err = r.product(ctx, movement.Product.ID); err != nil {
+			return nil, err
+		}
+		if movement.Location, err = r.location(ctx, movement.Location.ID); err != nil {
+			return nil, err
+		}
+		movement.Actor = actorRef(movement.Actor)
+	}
+	return movements, nil
+}
+
+// CountByLocation sums the batches of a product held at each location, by
+// warehouse code, then by location code
+func (r *MemoryStockBalanceRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	quantities := make(map[int64]int64)
+	r.mutex.RLock()
+	for key, balance := range r.balances {
+		if key.productID == productID && balance.Quantity > 0 {
+			quantities[key.locationID] += balance.Quantity
+		}
+	}
+	r.mutex.RUnlock()
+
+	var counts []domain.LocationStockCount
+	for locationID, quantity := range quantities {
+		location, err := r.location(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, domain.LocationStockCount{Location: location, Quantity: quantity})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i].Location, counts[j].Location
+		if a.Warehouse.Code != b.Warehouse.Code {
+			return a.Warehouse.Code < b.Warehouse.Code
+		}
+		return a.Code < b.Code
+	})
+	return counts, nil
+}
+
+// OnHandByProduct sums the balances of every product matching filter that
+// has some quantity left, by product ID
+func (r *MemoryStockBalanceRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	match, err := r.matcher(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int64]int64)
+	r.mutex.RLock()
+	for key, balance := range r.balances {
+		if match(key) {
+			quantities[key.productID] += balance.Quantity
+		}
+	}
+	r.mutex.RUnlock()
+
+	var onHand []domain.ProductOnHand
+	for productID, quantity := range quantities {
+		if quantity <= 0 {
+			continue
+		}
+		product, err := r.product(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		onHand = append(onHand, domain.ProductOnHand{Product: product, Quantity: quantity})
+	}
+	sort.Slice(onHand, func(i, j int) bool {
+		return onHand[i].Product.ID < onHand[j].Product.ID
+	})
+	return onHand, nil
+}
+
+// matcher returns whether a balance key matches filter, the way
+// balanceFilterClause does in SQL
+func (r *MemoryStockBalanceRepository) matcher(ctx context.Context, filter domain.StockBalanceFilter) (func(balanceKey) bool, error) {
+	var warehouseLocations map[int64]bool
+	if filter.WarehouseID != 0 {
+		locations, err := r.locations.GetByWarehouseID(ctx, filter.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		warehouseLocations = make(map[int64]bool, len(locations))
+		for _, location := range locations {
+			warehouseLocations[location.ID] = true
+		}
+	}
+
+	return func(key balanceKey) bool {
+		switch {
+		case filter.ProductID != 0 && key.productID != filter.ProductID,
+			filter.WarehouseID != 0 && !warehouseLocations[key.locationID],
+			filter.LocationID != 0 && key.locationID != filter.LocationID,
+			filter.Batch != "" && key.batch != filter.Batch:
+			return false
+		}
+		return true
+	}, nil
+}
+
+// product loads the joined columns of a product
+func (r *MemoryStockBalanceRepository) product(ctx context.Context, id int64) (*domain.Product, error) {
+	product, err := r.products.GetByID(ctx, id)
+	if err != nil || product == nil {
+		return &domain.Product{ID: id}, err
+	}
+	return &domain.Product{ID: product.ID, Name: product.Name, Code: product.Code, TrackingMode: product.TrackingMode}, nil
+}
+
+// location loads a location with its warehouse
+func (r *MemoryStockBalanceRepository) location(ctx context.Context, id int64) (*domain.Location, error) {
+	location, err := r.locations.GetByID(ctx, id)
+	if err != nil || location == nil {
+		return &domain.Location{ID: id, Warehouse: &domain.Warehouse{}}, err
+	}
+	return location, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sync"
+	"time"
+)
+
+// MemoryStockMovementRepository keeps the stock ledger in memory, in the
+// order the movements were appended. Reads fill in the actor from the given
+// users, as the MySQL join does.
+type MemoryStockMovementRepository struct {
+	movements []domain.StockMovement
+	nextID    int64
+	mutex     sync.RWMutex
+	users     domain.IUserRepository
+}
+
+func NewMemoryStockMovementRepository(users domain.IUserRepository) *MemoryStockMovementRepository {
+	return &MemoryStockMovementRepository{
+		nextID: 1,
+		users:  users,
+	}
+}
+
+func (r *MemoryStockMovementRepository) Create(ctx context.Context, movement *domain.StockMovement) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	movement.CreatedAt = time.Now().UTC()
+	movement.ID = r.append(*movement)
+	return nil
+}
+
+// CreateMany appends the movements, leaving their IDs unset as the SQL
+// repositories do
+func (r *MemoryStockMovementRepository) CreateMany(ctx context.Context, movements []*domain.StockMovement) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now().UTC()
+	for _, movement := range movements {
+		movement.CreatedAt = now
+		r.append(*movement)
+	}
+	return nil
+}
+
+func (r *MemoryStockMovementRepository) GetByStockID(ctx context.Context, stockID int64) ([]domain.StockMovement, error) {
+	return r.list(ctx, func(m domain.StockMovement) bool { return m.StockID == stockID })
+}
+
+// GetBySerial returns the full history of every unit that ever carried serial
+func (r *MemoryStockMovementRepository) GetBySerial(ctx context.Context, serial string) ([]domain.StockMovement, error) {
+	r.mutex.RLock()
+	stockIDs := make(map[int64]bool)
+	for _, movement := range r.movements {
+		if movement.Serial == serial {
+			stockIDs[movement.StockID] = true
+		}
+	}
+	r.mutex.RUnlock()
+
+	return r.list(ctx, func(m domain.StockMovement) bool { return stockIDs[m.StockID] })
+}
+
+// append stores a copy of movement under the next ID and returns the ID
+func (r *MemoryStockMovementRepository) append(movement domain.StockMovement) int64 {
+	movement.ID = r.nextID
+	movement.Actor = actorRef(movement.Actor)
+	movement.Before = copySnapshot(movement.Before)
+	movement.After = copySnapshot(movement.After)
+	r.nextID++
+
+	r.movements = append(r.movements, movement)
+	return movement.ID
+}
+
+// list returns the movements kept by keep in ledger order, with their actors
+func (r *MemoryStockMovementRepository) list(ctx context.Context, keep func(domain.StockMovement) bool) ([]domain.StockMovement, error) {
+	r.mutex.RLock()
+	var movements []domain.StockMovement
+	for _, movement := range r.movements {
+		if keep(movement) {
+			movement.Before = copySnapshot(movement.Before)
+			movement.After = copySnapshot(movement.After)
+			movements = append(movements, movement)
+		}
+	}
+	r.mutex.RUnlock()
+
+	for i := range movements {
+		if movements[i].Actor == nil {
+			continue
+		}
+		actor, err := r.users.GetByID(ctx, movements[i].Actor.ID)
+		if err != nil {
+			return nil, err
+		}
+		if actor != nil {
+			movements[i].Actor = &domain.User{ID: actor.ID, Name: actor.Name, Email: actor.Email, Role: actor.Role}
+		} else {
+			movements[i].Actor = &domain.User{ID: movements[i].Actor.ID}
+		}
+	}
+	return movements, nil
+}
+
+// actorRef keeps only the ID of the user behind a change, or nil when unknown,
+// as the SQL repositories store NULL
+func actorRef(actor *domain.User) *domain.User {
+	if actor == nil || actor.ID == 0 {
+		return nil
+	}
+	return &domain.User{ID: actor.ID}
+}
+
+func copySnapshot(snapshot *domain.StockSnapshot) *domain.StockSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	copied := *snapshot
+	return &copied
+}
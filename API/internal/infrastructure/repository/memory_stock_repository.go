@@ -0,0 +1,631 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStockRepository keeps stock units in memory. Serials are unique, and
+// reads fill in the product, provider, audit users and location from the
+// given repositories, as the MySQL joins do. locations may be nil, in which
+// case units only carry their location ID and warehouse filters match nothing.
+// Reassignments are recorded in the given ledger.
+type MemoryStockRepository struct {
+	stocks    map[int64]domain.Stock
+	nextID    int64
+	mutex     sync.RWMutex
+	products  domain.IProductRepository
+	users     domain.IUserRepository
+	providers domain.IProviderRepository
+	locations domain.ILocationRepository
+	movements domain.IStockMovementRepository
+}
+
+func NewMemoryStockRepository(products domain.IProductRepository, users domain.IUserRepository, providers domain.IProviderRepository, locations domain.ILocationRepository, movements domain.IStockMovementRepository) *MemoryStockRepository {
+	return &MemoryStockRepository{
+		stocks:    make(map[int64]domain.Stock),
+		nextID:    1,
+		products:  products,
+		users:     users,
+		providers: providers,
+		locations: locations,
+		movements: movements,
+	}
+}
+
+func (r *MemoryStockRepository) Create(ctx context.Context, stock *domain.Stock) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.serialTaken(stock.Serial, 0) {
+		return &domain.StockAlreadyExistsError{Serial: stock.Serial}
+	}
+
+	if stock.Status == "" {
+		stock.Status = domain.StockAvailable
+	}
+	now := time.Now().UTC()
+	stock.ID = r.nextID
+	stock.SoftDeleted = domain.SoftDeleted{}
+	stock.Version = 1
+	stock.StatusChangedAt = now
+	stock.CreatedAt = now
+	stock.UpdatedAt = now
+	r.nextID++
+
+	r.stocks[stock.ID] = stripStock(*stock)
+	return nil
+}
+
+func (r *MemoryStockRepository) CreateMany(ctx context.Context, stocks []*domain.Stock) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	serials := make(map[string]bool, len(stocks))
+	for _, stock := range stocks {
+		if serials[stock.Serial] || r.serialTaken(stock.Serial, 0) {
+			return &domain.StockAlreadyExistsError{Serial: stock.Serial}
+		}
+		serials[stock.Serial] = true
+	}
+
+	now := time.Now().UTC()
+	for _, stock := range stocks {
+		if stock.Status == "" {
+			stock.Status = domain.StockAvailable
+		}
+		stock.ID = r.nextID
+		stock.SoftDeleted = domain.SoftDeleted{}
+		stock.Version = 1
+		stock.StatusChangedAt = now
+		stock.CreatedAt = now
+		stock.UpdatedAt = now
+		r.nextID++
+
+		r.stocks[stock.ID] = stripStock(*stock)
+	}
+	return nil
+}
+
+func (r *MemoryStockRepository) ExistingSerials(ctx context.Context, serials []string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var existing []string
+	for _, serial := range serials {
+		if r.serialTaken(serial, 0) {
+			existing = append(existing, serial)
+		}
+	}
+	return existing, nil
+}
+
+func (r *MemoryStockRepository) GetByID(ctx context.Context, id int64) (*domain.Stock, error) {
+	r.mutex.RLock()
+	stock, exists := r.stocks[id]
+	r.mutex.RUnlock()
+
+	if !exists || stock.IsDeleted() {
+		return nil, nil
+	}
+	return r.join(ctx, stock)
+}
+
+func (r *MemoryStockRepository) GetBySerial(ctx context.Context, serial string) (*domain.Stock, error) {
+	r.mutex.RLock()
+	var found *domain.Stock
+	for _, stock := range r.stocks {
+		if stock.Serial == serial && !stock.IsDeleted() {
+			found = &stock
+			break
+		}
+	}
+	r.mutex.RUnlock()
+
+	if found == nil {
+		return nil, nil
+	}
+	return r.join(ctx, *found)
+}
+
+func (r *MemoryStockRepository) GetAll(ctx context.Context, filter domain.StockFilter) ([]domain.Stock, error) {
+	stocks, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stocks = memoryPage(stocks, filter.ListOptions, domain.StockSortFields,
+		func(s *domain.Stock) int64 { return s.ID },
+		func(s *domain.Stock, field string) interface{} {
+			switch field {
+			case "product_id":
+				return s.Product.ID
+			case "serial":
+				return s.Serial
+			case "status":
+				return string(s.Status)
+			case "batch":
+				return s.Batch
+			case "purchase_date":
+				return s.PurchaseDate
+			case "created_at":
+				return s.CreatedAt
+			default:
+				return s.UpdatedAt
+			}
+		})
+	if len(stocks) == 0 {
+		return nil, nil
+	}
+
+	for i := range stocks {
+		joined, err := r.join(ctx, stocks[i])
+		if err != nil {
+			return nil, err
+		}
+		stocks[i] = *joined
+	}
+	return stocks, nil
+}
+
+func (r *MemoryStockRepository) Count(ctx context.Context, filter domain.StockFilter) (int64, error) {
+	stocks, err := r.matching(ctx, filter)
+	return int64(len(stocks)), err
+}
+
+func (r *MemoryStockRepository) GetByProductID(ctx context.Context, productID int64, filter domain.StockFilter) ([]domain.Stock, error) {
+	filter.ProductID = productID
+	return r.GetAll(ctx, filter)
+}
+
+func (r *MemoryStockRepository) Update(ctx context.Context, stock *domain.Stock) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.stocks[stock.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.StockNotFoundError{StockID: stock.ID}
+	}
+	if stock.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "stock", ID: stock.ID, Version: stock.Version}
+	}
+	if r.serialTaken(stock.Serial, stock.ID) {
+		return &domain.StockAlreadyExistsError{Serial: stock.Serial}
+	}
+
+	now := time.Now().UTC()
+	stock.Version++
+	updated := stripStock(*stock)
+	updated.Status = existing.Status
+	updated.StatusChangedAt = existing.StatusChangedAt
+	updated.CreatedAt = existing.CreatedAt
+	updated.CreatedByUser = existing.CreatedByUser
+	updated.SoftDeleted = existing.SoftDeleted
+	updated.UpdatedAt = now
+	r.stocks[stock.ID] = updated
+
+	stock.UpdatedAt = now
+	return nil
+}
+
+// Patch saves the given fields of the unit and who updated it, see Update
+func (r *MemoryStockRepository) Patch(ctx context.Context, stock *domain.Stock, fields []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.stocks[stock.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.StockNotFoundError{StockID: stock.ID}
+	}
+	if stock.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "stock", ID: stock.ID, Version: stock.Version}
+	}
+
+	patched := existing
+	given := stripStock(*stock)
+	for _, field := range fields {
+		switch field {
+		case "product_id":
+			patched.Product = given.Product
+		case "serial":
+			patched.Serial = given.Serial
+		case "batch":
+			patched.Batch = given.Batch
+		case "purchase_date":
+			patched.PurchaseDate = given.PurchaseDate
+		case "provider_id":
+			patched.Provider = given.Provider
+		case "location_id":
+			patched.Location = given.Location
+		default:
+			return fmt.Errorf("cannot patch %s of stocks", field)
+		}
+	}
+	if r.serialTaken(patched.Serial, stock.ID) {
+		return &domain.StockAlreadyExistsError{Serial: patched.Serial}
+	}
+
+	now := time.Now().UTC()
+	patched.UpdatedByUser = given.UpdatedByUser
+	patched.UpdatedAt = now
+	patched.Version++
+	r.stocks[stock.ID] = patched
+	stock.UpdatedAt = now
+	stock.Version = patched.Version
+	return nil
+}
+
+func (r *MemoryStockRepository) UpdateStatus(ctx context.Context, stock *domain.Stock) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.stocks[stock.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.StockNotFoundError{StockID: stock.ID}
+	}
+	if stock.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "stock", ID: stock.ID, Version: stock.Version}
+	}
+
+	now := time.Now().UTC()
+	existing.Status = stock.Status
+	existing.StatusChangedAt = now
+	existing.UpdatedAt = now
+	existing.UpdatedByUser = userRef(stock.UpdatedByUser)
+	existing.Version++
+	r.stocks[stock.ID] = existing
+
+	stock.Version = existing.Version
+	stock.StatusChangedAt = now
+	stock.UpdatedAt = now
+	return nil
+}
+
+func (r *MemoryStockRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stock, exists := r.stocks[id]
+	if !exists || stock.IsDeleted() {
+		return &domain.StockNotFoundError{StockID: id}
+	}
+
+	stock.SoftDeleted = softDeletedNow(deletedBy)
+	stock.Version++
+	r.stocks[id] = stock
+	return nil
+}
+
+func (r *MemoryStockRepository) Restore(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stock, exists := r.stocks[id]
+	if !exists || !stock.IsDeleted() {
+		return &domain.StockNotFoundError{StockID: id}
+	}
+
+	stock.SoftDeleted = domain.SoftDeleted{}
+	stock.Version++
+	r.stocks[id] = stock
+	return nil
+}
+
+// Purge removes every unit soft deleted before the given time
+func (r *MemoryStockRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var purged int64
+	for id, stock := range r.stocks {
+		if purgeable(stock.SoftDeleted, before) {
+			delete(r.stocks, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryStockRepository) Reassign(ctx context.Context, ref domain.StockReference, fromID, toID int64, actor *domain.User, reason string) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ids := make([]int64, 0, len(r.stocks))
+	for id := range r.stocks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	now := time.Now().UTC()
+	var changed int64
+	for _, id := range ids {
+		before := r.stocks[id]
+		after := before
+		switch ref {
+		case domain.StockProductReference:
+			if before.Product.ID != fromID {
+				continue
+			}
+			after.Product = &domain.Product{ID: toID}
+		case domain.StockProviderReference:
+			if before.Provider.ID != fromID {
+				continue
+			}
+			after.Provider = &domain.Provider{ID: toID}
+		case domain.StockUserReference:
+			if before.UpdatedByUser.ID != fromID {
+				continue
+			}
+			after.UpdatedByUser = &domain.User{ID: toID}
+		default:
+			return 0, fmt.Errorf("unknown stock reference %q", ref)
+		}
+
+		after.UpdatedAt = now
+		after.Version++
+		movement := domain.NewStockMovement(domain.MovementUpdate, actor, &before, &after, reason)
+		movement.CreatedAt = now
+		if err := r.movements.Create(ctx, movement); err != nil {
+			return changed, err
+		}
+		r.stocks[id] = after
+		changed++
+	}
+	return changed, nil
+}
+
+func (r *MemoryStockRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	quantities := make(map[int64]int64)
+	r.mutex.RLock()
+	for _, stock := range r.stocks {
+		if stock.Product.ID == productID && stock.Status.IsOnHand() && !stock.IsDeleted() {
+			quantities[locationIDOf(stock)]++
+		}
+	}
+	r.mutex.RUnlock()
+
+	counts := make([]domain.LocationStockCount, 0, len(quantities))
+	for locationID, quantity := range quantities {
+		location, err := r.location(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, domain.LocationStockCount{Location: location, Quantity: quantity})
+	}
+
+	// Units that have not been put away come first, as NULLs do in SQL
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i].Location, counts[j].Location
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		if a.Warehouse != nil && b.Warehouse != nil && a.Warehouse.Code != b.Warehouse.Code {
+			return a.Warehouse.Code < b.Warehouse.Code
+		}
+		return a.Code < b.Code
+	})
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	return counts, nil
+}
+
+func (r *MemoryStockRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	stocks, err := r.matching(ctx, domain.StockFilter{
+		ProductID:   filter.ProductID,
+		WarehouseID: filter.WarehouseID,
+		LocationID:  filter.LocationID,
+		Batch:       filter.Batch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int64]int64)
+	for _, stock := range stocks {
+		if stock.Status.IsOnHand() {
+			quantities[stock.Product.ID]++
+		}
+	}
+
+	var onHand []domain.ProductOnHand
+	for productID, quantity := range quantities {
+		product, err := r.products.GetByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+		onHand = append(onHand, domain.ProductOnHand{
+			Product: &domain.Product{
+				ID:           product.ID,
+				Name:         product.Name,
+				Code:         product.Code,
+				TrackingMode: product.TrackingMode,
+			},
+			Quantity: quantity,
+		})
+	}
+	sort.Slice(onHand, func(i, j int) bool {
+		return onHand[i].Product.ID < onHand[j].Product.ID
+	})
+	return onHand, nil
+}
+
+// moveAll moves the units of items from one location to another on behalf of
+// actorID. Like the guarded UPDATE of receiveTransfer it fails with a
+// TransferConflictError when a unit is no longer at from, in which case no
+// unit is moved.
+func (r *MemoryStockRepository) moveAll(items []domain.TransferItem, from, to *domain.Location, actorID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, item := range items {
+		stock, exists := r.stocks[item.StockID]
+		if !exists || stock.IsDeleted() || locationIDOf(stock) != from.ID {
+			return &domain.TransferConflictError{Serial: item.Serial}
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, item := range items {
+		stock := r.stocks[item.StockID]
+		stock.Location = &domain.Location{ID: to.ID}
+		stock.UpdatedByUser = &domain.User{ID: actorID}
+		stock.UpdatedAt = now
+		stock.Version++
+		r.stocks[item.StockID] = stock
+	}
+	return nil
+}
+
+// serialTaken reports whether a unit other than exceptID uses serial
+func (r *MemoryStockRepository) serialTaken(serial string, exceptID int64) bool {
+	for id, stock := range r.stocks {
+		if id != exceptID && stock.Serial == serial {
+			return true
+		}
+	}
+	return false
+}
+
+// matching returns the stored units matching filter, without their joined data
+func (r *MemoryStockRepository) matching(ctx context.Context, filter domain.StockFilter) ([]domain.Stock, error) {
+	var warehouseLocations map[int64]bool
+	if filter.WarehouseID != 0 {
+		var err error
+		if warehouseLocations, err = r.warehouseLocations(ctx, filter.WarehouseID); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var stocks []domain.Stock
+	for _, stock := range r.stocks {
+		locationID := locationIDOf(stock)
+		switch {
+		case !filter.IncludeDeleted && stock.IsDeleted(),
+			filter.Status != "" && stock.Status != filter.Status,
+			filter.WarehouseID != 0 && !warehouseLocations[locationID],
+			filter.LocationID != 0 && locationID != filter.LocationID,
+			filter.ProductID != 0 && stock.Product.ID != filter.ProductID,
+			filter.ProviderID != 0 && stock.Provider.ID != filter.ProviderID,
+			filter.Batch != "" && stock.Batch != filter.Batch,
+			!filter.PurchasedAfter.IsZero() && stock.PurchaseDate.Before(filter.PurchasedAfter),
+			!filter.PurchasedBefore.IsZero() && stock.PurchaseDate.After(filter.PurchasedBefore):
+			continue
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, nil
+}
+
+// warehouseLocations returns the IDs of the locations of a warehouse
+func (r *MemoryStockRepository) warehouseLocations(ctx context.Context, warehouseID int64) (map[int64]bool, error) {
+	ids := make(map[int64]bool)
+	if r.locations == nil {
+		return ids, nil
+	}
+	locations, err := r.locations.GetByWarehouseID(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		ids[location.ID] = true
+	}
+	return ids, nil
+}
+
+// join fills in the related entities of a stored unit. Users carry no
+// password, as in the MySQL join.
+func (r *MemoryStockRepository) join(ctx context.Context, stock domain.Stock) (*domain.Stock, error) {
+	if product, err := r.products.GetByID(ctx, stock.Product.ID); err != nil {
+		return nil, err
+	} else if product != nil {
+		stock.Product = product
+	}
+	for _, user := range []**domain.User{&stock.CreatedByUser, &stock.UpdatedByUser} {
+		found, err := r.users.GetByID(ctx, (*user).ID)
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			found.Password = ""
+			*user = found
+		}
+	}
+	if provider, err := r.providers.GetByID(ctx, stock.Provider.ID); err != nil {
+		return nil, err
+	} else if provider != nil {
+		stock.Provider = provider
+	}
+
+	location, err := r.location(ctx, locationIDOf(stock))
+	if err != nil {
+		return nil, err
+	}
+	stock.Location = location
+	return &stock, nil
+}
+
+// location loads a location, or returns nil for a zero ID
+func (r *MemoryStockRepository) location(ctx context.Context, id int64) (*domain.Location, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	if r.locations != nil {
+		location, err := r.locations.GetByID(ctx, id)
+		if err != nil || location != nil {
+			return location, err
+		}
+	}
+	return &domain.Location{ID: id}, nil
+}
+
+// stripStock keeps only the IDs of the related entities of a unit, so that
+// the store does not share pointers with callers
+func stripStock(stock domain.Stock) domain.Stock {
+	stock.Product = &domain.Product{ID: productIDOf(stock.Product)}
+	stock.CreatedByUser = userRef(stock.CreatedByUser)
+	stock.UpdatedByUser = userRef(stock.UpdatedByUser)
+	stock.Provider = &domain.Provider{ID: providerIDOf(stock.Provider)}
+	if stock.Location != nil {
+		stock.Location = &domain.Location{ID: stock.Location.ID}
+	}
+	return stock
+}
+
+func productIDOf(product *domain.Product) int64 {
+	if product == nil {
+		return 0
+	}
+	return product.ID
+}
+
+func providerIDOf(provider *domain.Provider) int64 {
+	if provider == nil {
+		return 0
+	}
+	return provider.ID
+}
+
+func userRef(user *domain.User) *domain.User {
+	if user == nil {
+		return &domain.User{}
+	}
+	return &domain.User{ID: user.ID}
+}
+
+func locationIDOf(stock domain.Stock) int64 {
+	if stock.Location == nil {
+		return 0
+	}
+	return stock.Location.ID
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryTransferRepository keeps transfer documents in memory. Reads fill in
+// the source and destination from the given locations, and receiving a
+// transfer moves its units in the given stock repository and records their
+// movements in the given ledger.
+type MemoryTransferRepository struct {
+	transfers map[int64]domain.Transfer
+	nextID    int64
+	mutex     sync.RWMutex
+	locations domain.ILocationRepository
+	stocks    *MemoryStockRepository
+	movements domain.IStockMovementRepository
+}
+
+func NewMemoryTransferRepository(locations domain.ILocationRepository, stocks *MemoryStockRepository, movements domain.IStockMovementRepository) *MemoryTransferRepository {
+	return &MemoryTransferRepository{
+		transfers: make(map[int64]domain.Transfer),
+		nextID:    1,
+		locations: locations,
+		stocks:    stocks,
+		movements: movements,
+	}
+}
+
+func (r *MemoryTransferRepository) Create(ctx context.Context, transfer *domain.Transfer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now().UTC()
+	transfer.ID = r.nextID
+	transfer.CreatedAt = now
+	transfer.UpdatedAt = now
+	r.nextID++
+
+	r.transfers[transfer.ID] = stripTransfer(*transfer)
+	return nil
+}
+
+func (r *MemoryTransferRepository) GetByID(ctx context.Context, id int64) (*domain.Transfer, error) {
+	r.mutex.RLock()
+	transfer, exists := r.transfers[id]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return nil, nil
+	}
+	return r.join(ctx, transfer)
+}
+
+func (r *MemoryTransferRepository) GetAll(ctx context.Context, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	r.mutex.RLock()
+	var transfers []domain.Transfer
+	for _, transfer := range r.transfers {
+		if filter.Status == "" || transfer.Status == filter.Status {
+			transfers = append(transfers, transfer)
+		}
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].ID < transfers[j].ID
+	})
+	for i := range transfers {
+		joined, err := r.join(ctx, transfers[i])
+		if err != nil {
+			return nil, err
+		}
+		transfers[i] = *joined
+	}
+	return transfers, nil
+}
+
+func (r *MemoryTransferRepository) UpdateStatus(ctx context.Context, transfer *domain.Transfer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.transfers[transfer.ID]
+	if !exists {
+		return &domain.TransferNotFoundError{TransferID: transfer.ID}
+	}
+
+	now := time.Now().UTC()
+	existing.Status = transfer.Status
+	existing.DispatchedAt = copyTime(transfer.DispatchedAt)
+	existing.ReceivedAt = copyTime(transfer.ReceivedAt)
+	existing.UpdatedAt = now
+	r.transfers[transfer.ID] = existing
+
+	transfer.UpdatedAt = now
+	return nil
+}
+
+// Receive moves the units of an in-transit transfer to its destination,
+// records their movements and marks the transfer received. The transfer stays
+// locked meanwhile, so that it cannot be received twice.
+func (r *MemoryTransferRepository) Receive(ctx context.Context, transfer *domain.Transfer, actor *domain.User, movements []*domain.StockMovement) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.transfers[transfer.ID]
+	if !exists || existing.Status != domain.TransferInTransit {
+		return &domain.InvalidTransferTransitionError{From: transfer.Status, To: domain.TransferReceived}
+	}
+
+	if err := r.stocks.moveAll(transfer.Items, transfer.Source, transfer.Destination, actor.ID); err != nil {
+		return err
+	}
+	for _, movement := range movements {
+		if err := r.movements.Create(ctx, movement); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	existing.Status = domain.TransferReceived
+	existing.ReceivedAt = &now
+	existing.UpdatedAt = now
+	r.transfers[transfer.ID] = existing
+
+	transfer.Status = domain.TransferReceived
+	transfer.ReceivedAt = copyTime(&now)
+	transfer.UpdatedAt = now
+	return nil
+}
+
+// join fills in the locations of a stored transfer
+func (r *MemoryTransferRepository) join(ctx context.Context, transfer domain.Transfer) (*domain.Transfer, error) {
+	for _, location := range []**domain.Location{&transfer.Source, &transfer.Destination} {
+		found, err := r.locations.GetByID(ctx, (*location).ID)
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			*location = found
+		}
+	}
+	transfer.Items = append([]domain.TransferItem(nil), transfer.Items...)
+	transfer.DispatchedAt = copyTime(transfer.DispatchedAt)
+	transfer.ReceivedAt = copyTime(transfer.ReceivedAt)
+	return &transfer, nil
+}
+
+// stripTransfer keeps only the IDs of the related entities of a transfer and
+// copies its items, so that the store does not share memory with callers
+func stripTransfer(transfer domain.Transfer) domain.Transfer {
+	transfer.Source = &domain.Location{ID: transfer.Source.ID}
+	transfer.Destination = &domain.Location{ID: transfer.Destination.ID}
+	transfer.CreatedByUser = actorRef(transfer.CreatedByUser)
+	transfer.Items = append([]domain.TransferItem(nil), transfer.Items...)
+	transfer.DispatchedAt = copyTime(transfer.DispatchedAt)
+	transfer.ReceivedAt = copyTime(transfer.ReceivedAt)
+	return transfer
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inventario/internal/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository keeps users in memory. Emails are unique and compared
+// case-insensitively, as with the MySQL collation.
+type MemoryUserRepository struct {
+	users  map[int64]domain.User
+	nextID int64
+	mutex  sync.RWMutex
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:  make(map[int64]domain.User),
+		nextID: 1,
+	}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.emailTaken(user.Email, 0) {
+		return &domain.UserAlreadyExistsError{Email: user.Email}
+	}
+
+	now := time.Now().UTC()
+	user.ID = r.nextID
+	user.SoftDeleted = domain.SoftDeleted{}
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.nextID++
+
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists || user.IsDeleted() {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) && !user.IsDeleted() {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepository) GetAll(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := r.matching(filter)
+	users = memoryPage(users, filter.ListOptions, domain.UserSortFields,
+		func(u *domain.User) int64 { return u.ID },
+		func(u *domain.User, field string) interface{} {
+			switch field {
+			case "name":
+				return u.Name
+			case "email":
+				return u.Email
+			case "role":
+				return u.Role
+			case "created_at":
+				return u.CreatedAt
+			default:
+				return u.UpdatedAt
+			}
+		})
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Count(ctx context.Context, filter domain.UserFilter) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.UserNotFoundError{UserID: user.ID}
+	}
+	if user.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "user", ID: user.ID, Version: user.Version}
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return &domain.UserAlreadyExistsError{Email: user.Email}
+	}
+
+	user.CreatedAt = existing.CreatedAt
+	user.SoftDeleted = existing.SoftDeleted
+	user.UpdatedAt = time.Now().UTC()
+	user.Version++
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Patch saves the given fields of the user, see Update
+func (r *MemoryUserRepository) Patch(ctx context.Context, user *domain.User, fields []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists || existing.IsDeleted() {
+		return &domain.UserNotFoundError{UserID: user.ID}
+	}
+	if user.Version != existing.Version {
+		return &domain.VersionMismatchError{Entity: "user", ID: user.ID, Version: user.Version}
+	}
+
+	patched := existing
+	for _, field := range fields {
+		switch field {
+		case "name":
+			patched.Name = user.Name
+		case "email":
+			patched.Email = user.Email
+		case "role":
+			patched.Role = user.Role
+		case "password":
+			patched.Password = user.Password
+		default:
+			return fmt.Errorf("cannot patch %s of users", field)
+		}
+	}
+	if r.emailTaken(patched.Email, user.ID) {
+		return &domain.UserAlreadyExistsError{Email: patched.Email}
+	}
+
+	now := time.Now().UTC()
+	patched.UpdatedAt = now
+	patched.Version++
+	r.users[user.ID] = patched
+	user.UpdatedAt = now
+	user.Version = patched.Version
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[id]
+	if !exists || user.IsDeleted() {
+		return &domain.UserNotFoundError{UserID: id}
+	}
+
+	user.SoftDeleted = softDeletedNow(deletedBy)
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) Restore(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[id]
+	if !exists || !user.IsDeleted() {
+		return &domain.UserNotFoundError{UserID: id}
+	}
+
+	user.SoftDeleted = domain.SoftDeleted{}
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+// Purge removes every user soft deleted before the given time, as nothing
+// refers to them in memory
+func (r *MemoryUserRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var purged int64
+	for id, user := range r.users {
+		if purgeable(user.SoftDeleted, before) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Dependents finds nothing: the in-memory repositories do not enforce
+// references between each other
+func (r *MemoryUserRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return nil, nil
+}
+
+// emailTaken reports whether a user other than exceptID uses email
+func (r *MemoryUserRepository) emailTaken(email string, exceptID int64) bool {
+	for id, user := range r.users {
+		if id != exceptID && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) matching(filter domain.UserFilter) []domain.User {
+	var users []domain.User
+	for _, user := range r.users {
+		if !filter.IncludeDeleted && user.IsDeleted() {
+			continue
+		}
+		if filter.Name != "" && !memoryContains(user.Name, filter.Name) {
+			continue
+		}
+		if filter.Email != "" && !strings.EqualFold(user.Email, filter.Email) {
+			continue
+		}
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
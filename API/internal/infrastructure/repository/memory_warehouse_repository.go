@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryWarehouseRepository keeps warehouses in memory. Codes are unique;
+// deleting a warehouse does not check for locations still in it.
+type MemoryWarehouseRepository struct {
+	warehouses map[int64]domain.Warehouse
+	nextID     int64
+	mutex      sync.RWMutex
+}
+
+func NewMemoryWarehouseRepository() *MemoryWarehouseRepository {
+	return &MemoryWarehouseRepository{
+		warehouses: make(map[int64]domain.Warehouse),
+		nextID:     1,
+	}
+}
+
+func (r *MemoryWarehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.codeTaken(warehouse.Code, 0) {
+		return &domain.WarehouseAlreadyExistsError{Code: warehouse.Code}
+	}
+
+	now := time.Now().UTC()
+	warehouse.ID = r.nextID
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	r.nextID++
+
+	r.warehouses[warehouse.ID] = *warehouse
+	return nil
+}
+
+func (r *MemoryWarehouseRepository) GetByID(ctx context.Context, id int64) (*domain.Warehouse, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	warehouse, exists := r.warehouses[id]
+	if !exists {
+		return nil, nil
+	}
+	return &warehouse, nil
+}
+
+func (r *MemoryWarehouseRepository) GetAll(ctx context.Context) ([]domain.Warehouse, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var warehouses []domain.Warehouse
+	for _, warehouse := range r.warehouses {
+		warehouses = append(warehouses, warehouse)
+	}
+	sort.Slice(warehouses, func(i, j int) bool {
+		return warehouses[i].ID < warehouses[j].ID
+	})
+	return warehouses, nil
+}
+
+func (r *MemoryWarehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.warehouses[warehouse.ID]
+	if !exists {
+		return &domain.WarehouseNotFoundError{WarehouseID: warehouse.ID}
+	}
+	if r.codeTaken(warehouse.Code, warehouse.ID) {
+		return &domain.WarehouseAlreadyExistsError{Code: warehouse.Code}
+	}
+
+	warehouse.CreatedAt = existing.CreatedAt
+	warehouse.UpdatedAt = time.Now().UTC()
+	r.warehouses[warehouse.ID] = *warehouse
+	return nil
+}
+
+func (r *MemoryWarehouseRepository) Delete(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.warehouses[id]; !exists {
+		return &domain.WarehouseNotFoundError{WarehouseID: id}
+	}
+	delete(r.warehouses, id)
+	return nil
+}
+
+// codeTaken reports whether a warehouse other than exceptID uses code
+func (r *MemoryWarehouseRepository) codeTaken(code string, exceptID int64) bool {
+	for id, warehouse := range r.warehouses {
+		if id != exceptID && warehouse.Code == code {
+			return true
+		}
+	}
+	return false
+}
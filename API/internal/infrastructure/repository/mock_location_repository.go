@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockLocationRepository struct {
+	CreateFunc           func(*domain.Location) error
+	GetByIDFunc          func(int64) (*domain.Location, error)
+	GetAllFunc           func() ([]domain.Location, error)
+	GetByWarehouseIDFunc func(int64) ([]domain.Location, error)
+	UpdateFunc           func(*domain.Location) error
+	DeleteFunc           func(int64) error
+}
+
+func (m *MockLocationRepository) Create(ctx context.Context, location *domain.Location) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(location)
+	}
+	return nil
+}
+
+func (m *MockLocationRepository) GetByID(ctx context.Context, id int64) (*domain.Location, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockLocationRepository) GetAll(ctx context.Context) ([]domain.Location, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockLocationRepository) GetByWarehouseID(ctx context.Context, warehouseID int64) ([]domain.Location, error) {
+	if m.GetByWarehouseIDFunc != nil {
+		return m.GetByWarehouseIDFunc(warehouseID)
+	}
+	return nil, nil
+}
+
+func (m *MockLocationRepository) Update(ctx context.Context, location *domain.Location) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(location)
+	}
+	return nil
+}
+
+func (m *MockLocationRepository) Delete(ctx context.Context, id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
@@ -1,32 +1,39 @@
 package repository
 
 import (
+	"context"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MockProductRepository struct {
-	CreateFunc  func(*domain.Product) error
-	GetByIDFunc func(int64) (*domain.Product, error)
-	GetAllFunc  func() ([]*domain.Product, error)
-	UpdateFunc  func(*domain.Product) error
-	DeleteFunc  func(int64) error
+	CreateFunc     func(*domain.Product) error
+	GetByIDFunc    func(int64) (*domain.Product, error)
+	GetAllFunc     func() ([]*domain.Product, error)
+	CountFunc      func(domain.ProductFilter) (int64, error)
+	UpdateFunc     func(*domain.Product) error
+	PatchFunc      func(*domain.Product, []string) error
+	DeleteFunc     func(int64, int64) error
+	RestoreFunc    func(int64) error
+	PurgeFunc      func(time.Time) (int64, error)
+	DependentsFunc func(int64) ([]domain.Dependent, error)
 }
 
-func (m *MockProductRepository) Create(product *domain.Product) error {
+func (m *MockProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	if m.CreateFunc != nil {
 		return m.CreateFunc(product)
 	}
 	return nil
 }
 
-func (m *MockProductRepository) GetByID(id int64) (*domain.Product, error) {
+func (m *MockProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockProductRepository) GetAll() ([]domain.Product, error) {
+func (m *MockProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
 	if m.GetAllFunc != nil {
 		products, err := m.GetAllFunc()
 		if err != nil {
@@ -41,16 +48,51 @@ func (m *MockProductRepository) GetAll() ([]domain.Product, error) {
 	return nil, nil
 }
 
-func (m *MockProductRepository) Update(product *domain.Product) error {
+func (m *MockProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(product)
 	}
 	return nil
 }
 
-func (m *MockProductRepository) Delete(id int64) error {
+func (m *MockProductRepository) Patch(ctx context.Context, product *domain.Product, fields []string) error {
+	if m.PatchFunc != nil {
+		return m.PatchFunc(product, fields)
+	}
+	return nil
+}
+
+func (m *MockProductRepository) Delete(ctx context.Context, id, deletedBy int64) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(id, deletedBy)
 	}
 	return nil
 }
+
+func (m *MockProductRepository) Restore(ctx context.Context, id int64) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	return nil
+}
+
+func (m *MockProductRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	if m.CountFunc != nil {
+		return m.CountFunc(filter)
+	}
+	return 0, nil
+}
+
+func (m *MockProductRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	if m.DependentsFunc != nil {
+		return m.DependentsFunc(id)
+	}
+	return nil, nil
+}
@@ -1,48 +1,90 @@
 package repository
 
 import (
+	"context"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MockProviderRepository struct {
-	CreateFunc  func(*domain.Provider) error
-	GetByIDFunc func(int64) (*domain.Provider, error)
-	GetAllFunc  func() ([]domain.Provider, error)
-	UpdateFunc  func(*domain.Provider) error
-	DeleteFunc  func(int64) error
+	CreateFunc     func(*domain.Provider) error
+	GetByIDFunc    func(int64) (*domain.Provider, error)
+	GetAllFunc     func() ([]domain.Provider, error)
+	CountFunc      func(domain.ProviderFilter) (int64, error)
+	UpdateFunc     func(*domain.Provider) error
+	PatchFunc      func(*domain.Provider, []string) error
+	DeleteFunc     func(int64, int64) error
+	RestoreFunc    func(int64) error
+	PurgeFunc      func(time.Time) (int64, error)
+	DependentsFunc func(int64) ([]domain.Dependent, error)
 }
 
-func (m *MockProviderRepository) Create(provider *domain.Provider) error {
+func (m *MockProviderRepository) Create(ctx context.Context, provider *domain.Provider) error {
 	if m.CreateFunc != nil {
 		return m.CreateFunc(provider)
 	}
 	return nil
 }
 
-func (m *MockProviderRepository) GetByID(id int64) (*domain.Provider, error) {
+func (m *MockProviderRepository) GetByID(ctx context.Context, id int64) (*domain.Provider, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockProviderRepository) GetAll() ([]domain.Provider, error) {
+func (m *MockProviderRepository) GetAll(ctx context.Context, filter domain.ProviderFilter) ([]domain.Provider, error) {
 	if m.GetAllFunc != nil {
 		return m.GetAllFunc()
 	}
 	return nil, nil
 }
 
-func (m *MockProviderRepository) Update(provider *domain.Provider) error {
+func (m *MockProviderRepository) Update(ctx context.Context, provider *domain.Provider) error {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(provider)
 	}
 	return nil
 }
 
-func (m *MockProviderRepository) Delete(id int64) error {
+func (m *MockProviderRepository) Patch(ctx context.Context, provider *domain.Provider, fields []string) error {
+	if m.PatchFunc != nil {
+		return m.PatchFunc(provider, fields)
+	}
+	return nil
+}
+
+func (m *MockProviderRepository) Delete(ctx context.Context, id, deletedBy int64) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(id, deletedBy)
 	}
 	return nil
 }
+
+func (m *MockProviderRepository) Restore(ctx context.Context, id int64) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	return nil
+}
+
+func (m *MockProviderRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockProviderRepository) Count(ctx context.Context, filter domain.ProviderFilter) (int64, error) {
+	if m.CountFunc != nil {
+		return m.CountFunc(filter)
+	}
+	return 0, nil
+}
+
+func (m *MockProviderRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	if m.DependentsFunc != nil {
+		return m.DependentsFunc(id)
+	}
+	return nil, nil
+}
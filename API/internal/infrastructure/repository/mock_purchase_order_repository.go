@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockPurchaseOrderRepository struct {
+	CreateFunc       func(*domain.PurchaseOrder) error
+	GetByIDFunc      func(int64) (*domain.PurchaseOrder, error)
+	GetAllFunc       func(domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error)
+	UpdateStatusFunc func(*domain.PurchaseOrder) error
+	ReceiveFunc      func(*domain.PurchaseOrder, []domain.ReceivedLine, string) error
+}
+
+func (m *MockPurchaseOrderRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(order)
+	}
+	return nil
+}
+
+func (m *MockPurchaseOrderRepository) GetByID(ctx context.Context, id int64) (*domain.PurchaseOrder, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockPurchaseOrderRepository) GetAll(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc(filter)
+	}
+	return nil, nil
+}
+
+func (m *MockPurchaseOrderRepository) UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error {
+	if m.UpdateStatusFunc != nil {
+		return m.UpdateStatusFunc(order)
+	}
+	return nil
+}
+
+func (m *MockPurchaseOrderRepository) Receive(ctx context.Context, order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string) error {
+	if m.ReceiveFunc != nil {
+		return m.ReceiveFunc(order, lines, reason)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockStockBalanceRepository struct {
+	GetAllFunc          func(domain.StockBalanceFilter) ([]domain.StockBalance, error)
+	ApplyFunc           func(*domain.BalanceMovement) error
+	GetMovementsFunc    func(domain.StockBalanceFilter) ([]domain.BalanceMovement, error)
+	CountByLocationFunc func(int64) ([]domain.LocationStockCount, error)
+	OnHandByProductFunc func(domain.StockBalanceFilter) ([]domain.ProductOnHand, error)
+}
+
+func (m *MockStockBalanceRepository) GetAll(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc(filter)
+	}
+	return nil, nil
+}
+
+func (m *MockStockBalanceRepository) Apply(ctx context.Context, movement *domain.BalanceMovement) error {
+	if m.ApplyFunc != nil {
+		return m.ApplyFunc(movement)
+	}
+	return nil
+}
+
+func (m *MockStockBalanceRepository) GetMovements(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	if m.GetMovementsFunc != nil {
+		return m.GetMovementsFunc(filter)
+	}
+	return nil, nil
+}
+
+func (m *MockStockBalanceRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	if m.CountByLocationFunc != nil {
+		return m.CountByLocationFunc(productID)
+	}
+	return nil, nil
+}
+
+func (m *MockStockBalanceRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	if m.OnHandByProductFunc != nil {
+		return m.OnHandByProductFunc(filter)
+	}
+	return nil, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockStockMovementRepository struct {
+	CreateFunc       func(*domain.StockMovement) error
+	CreateManyFunc   func([]*domain.StockMovement) error
+	GetByStockIDFunc func(int64) ([]domain.StockMovement, error)
+	GetBySerialFunc  func(string) ([]domain.StockMovement, error)
+}
+
+func (m *MockStockMovementRepository) Create(ctx context.Context, movement *domain.StockMovement) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(movement)
+	}
+	return nil
+}
+
+func (m *MockStockMovementRepository) CreateMany(ctx context.Context, movements []*domain.StockMovement) error {
+	if m.CreateManyFunc != nil {
+		return m.CreateManyFunc(movements)
+	}
+	return nil
+}
+
+func (m *MockStockMovementRepository) GetByStockID(ctx context.Context, stockID int64) ([]domain.StockMovement, error) {
+	if m.GetByStockIDFunc != nil {
+		return m.GetByStockIDFunc(stockID)
+	}
+	return nil, nil
+}
+
+func (m *MockStockMovementRepository) GetBySerial(ctx context.Context, serial string) ([]domain.StockMovement, error) {
+	if m.GetBySerialFunc != nil {
+		return m.GetBySerialFunc(serial)
+	}
+	return nil, nil
+}
@@ -1,64 +1,146 @@
 package repository
 
 import (
+	"context"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MockStockRepository struct {
-	CreateFunc         func(*domain.Stock) error
-	GetByIDFunc        func(int64) (*domain.Stock, error)
-	GetAllFunc         func() ([]domain.Stock, error)
-	GetByProductIDFunc func(int64) ([]domain.Stock, error)
-	GetBySerialFunc    func(string) (*domain.Stock, error)
-	UpdateFunc         func(*domain.Stock) error
-	DeleteFunc         func(int64) error
+	CreateFunc          func(*domain.Stock) error
+	CreateManyFunc      func([]*domain.Stock) error
+	ExistingSerialsFunc func([]string) ([]string, error)
+	GetByIDFunc         func(int64) (*domain.Stock, error)
+	GetAllFunc          func(domain.StockFilter) ([]domain.Stock, error)
+	CountFunc           func(domain.StockFilter) (int64, error)
+	GetByProductIDFunc  func(int64, domain.StockFilter) ([]domain.Stock, error)
+	GetBySerialFunc     func(string) (*domain.Stock, error)
+	UpdateFunc          func(*domain.Stock) error
+	PatchFunc           func(*domain.Stock, []string) error
+	UpdateStatusFunc    func(*domain.Stock) error
+	DeleteFunc          func(int64, int64) error
+	RestoreFunc         func(int64) error
+	PurgeFunc           func(time.Time) (int64, error)
+	CountByLocationFunc func(int64) ([]domain.LocationStockCount, error)
+	OnHandByProductFunc func(domain.StockBalanceFilter) ([]domain.ProductOnHand, error)
+	ReassignFunc        func(domain.StockReference, int64, int64, *domain.User, string) (int64, error)
 }
 
-func (m *MockStockRepository) Create(stock *domain.Stock) error {
+func (m *MockStockRepository) Create(ctx context.Context, stock *domain.Stock) error {
 	if m.CreateFunc != nil {
 		return m.CreateFunc(stock)
 	}
 	return nil
 }
 
-func (m *MockStockRepository) GetByID(id int64) (*domain.Stock, error) {
+func (m *MockStockRepository) CreateMany(ctx context.Context, stocks []*domain.Stock) error {
+	if m.CreateManyFunc != nil {
+		return m.CreateManyFunc(stocks)
+	}
+	return nil
+}
+
+func (m *MockStockRepository) ExistingSerials(ctx context.Context, serials []string) ([]string, error) {
+	if m.ExistingSerialsFunc != nil {
+		return m.ExistingSerialsFunc(serials)
+	}
+	return nil, nil
+}
+
+func (m *MockStockRepository) GetByID(ctx context.Context, id int64) (*domain.Stock, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockStockRepository) GetAll() ([]domain.Stock, error) {
+func (m *MockStockRepository) GetAll(ctx context.Context, filter domain.StockFilter) ([]domain.Stock, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc()
+		return m.GetAllFunc(filter)
 	}
 	return nil, nil
 }
 
-func (m *MockStockRepository) GetByProductID(productID int64) ([]domain.Stock, error) {
+func (m *MockStockRepository) GetByProductID(ctx context.Context, productID int64, filter domain.StockFilter) ([]domain.Stock, error) {
 	if m.GetByProductIDFunc != nil {
-		return m.GetByProductIDFunc(productID)
+		return m.GetByProductIDFunc(productID, filter)
 	}
 	return nil, nil
 }
 
-func (m *MockStockRepository) GetBySerial(serial string) (*domain.Stock, error) {
+func (m *MockStockRepository) GetBySerial(ctx context.Context, serial string) (*domain.Stock, error) {
 	if m.GetBySerialFunc != nil {
 		return m.GetBySerialFunc(serial)
 	}
 	return nil, nil
 }
 
-func (m *MockStockRepository) Update(stock *domain.Stock) error {
+func (m *MockStockRepository) Update(ctx context.Context, stock *domain.Stock) error {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(stock)
 	}
 	return nil
 }
 
-func (m *MockStockRepository) Delete(id int64) error {
+func (m *MockStockRepository) Patch(ctx context.Context, stock *domain.Stock, fields []string) error {
+	if m.PatchFunc != nil {
+		return m.PatchFunc(stock, fields)
+	}
+	return nil
+}
+
+func (m *MockStockRepository) UpdateStatus(ctx context.Context, stock *domain.Stock) error {
+	if m.UpdateStatusFunc != nil {
+		return m.UpdateStatusFunc(stock)
+	}
+	return nil
+}
+
+func (m *MockStockRepository) Delete(ctx context.Context, id, deletedBy int64) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(id, deletedBy)
 	}
 	return nil
 }
+
+func (m *MockStockRepository) Restore(ctx context.Context, id int64) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	return nil
+}
+
+func (m *MockStockRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockStockRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	if m.CountByLocationFunc != nil {
+		return m.CountByLocationFunc(productID)
+	}
+	return nil, nil
+}
+
+func (m *MockStockRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	if m.OnHandByProductFunc != nil {
+		return m.OnHandByProductFunc(filter)
+	}
+	return nil, nil
+}
+
+func (m *MockStockRepository) Count(ctx context.Context, filter domain.StockFilter) (int64, error) {
+	if m.CountFunc != nil {
+		return m.CountFunc(filter)
+	}
+	return 0, nil
+}
+
+func (m *MockStockRepository) Reassign(ctx context.Context, ref domain.StockReference, fromID, toID int64, actor *domain.User, reason string) (int64, error) {
+	if m.ReassignFunc != nil {
+		return m.ReassignFunc(ref, fromID, toID, actor, reason)
+	}
+	return 0, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockTransferRepository struct {
+	CreateFunc       func(*domain.Transfer) error
+	GetByIDFunc      func(int64) (*domain.Transfer, error)
+	GetAllFunc       func(domain.TransferFilter) ([]domain.Transfer, error)
+	UpdateStatusFunc func(*domain.Transfer) error
+	ReceiveFunc      func(*domain.Transfer, *domain.User, []*domain.StockMovement) error
+}
+
+func (m *MockTransferRepository) Create(ctx context.Context, transfer *domain.Transfer) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(transfer)
+	}
+	return nil
+}
+
+func (m *MockTransferRepository) GetByID(ctx context.Context, id int64) (*domain.Transfer, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockTransferRepository) GetAll(ctx context.Context, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc(filter)
+	}
+	return nil, nil
+}
+
+func (m *MockTransferRepository) UpdateStatus(ctx context.Context, transfer *domain.Transfer) error {
+	if m.UpdateStatusFunc != nil {
+		return m.UpdateStatusFunc(transfer)
+	}
+	return nil
+}
+
+func (m *MockTransferRepository) Receive(ctx context.Context, transfer *domain.Transfer, actor *domain.User, movements []*domain.StockMovement) error {
+	if m.ReceiveFunc != nil {
+		return m.ReceiveFunc(transfer, actor, movements)
+	}
+	return nil
+}
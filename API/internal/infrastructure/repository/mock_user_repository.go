@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MockUserRepository struct {
@@ -9,32 +11,37 @@ type MockUserRepository struct {
 	GetByIDFunc    func(int64) (*domain.User, error)
 	GetByEmailFunc func(string) (*domain.User, error)
 	GetAllFunc     func() ([]*domain.User, error)
+	CountFunc      func(domain.UserFilter) (int64, error)
 	UpdateFunc     func(*domain.User) error
-	DeleteFunc     func(int64) error
+	PatchFunc      func(*domain.User, []string) error
+	DeleteFunc     func(int64, int64) error
+	RestoreFunc    func(int64) error
+	PurgeFunc      func(time.Time) (int64, error)
+	DependentsFunc func(int64) ([]domain.Dependent, error)
 }
 
-func (m *MockUserRepository) Create(user *domain.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) error {
 	if m.CreateFunc != nil {
 		return m.CreateFunc(user)
 	}
 	return nil
 }
 
-func (m *MockUserRepository) GetByID(id int64) (*domain.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
 	}
 	return nil, nil
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	if m.GetByEmailFunc != nil {
 		return m.GetByEmailFunc(email)
 	}
 	return nil, nil
 }
 
-func (m *MockUserRepository) GetAll() ([]domain.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
 	if m.GetAllFunc != nil {
 		users, err := m.GetAllFunc()
 		if err != nil {
@@ -49,16 +56,51 @@ func (m *MockUserRepository) GetAll() ([]domain.User, error) {
 	return nil, nil
 }
 
-func (m *MockUserRepository) Update(user *domain.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(user)
 	}
 	return nil
 }
 
-func (m *MockUserRepository) Delete(id int64) error {
+func (m *MockUserRepository) Patch(ctx context.Context, user *domain.User, fields []string) error {
+	if m.PatchFunc != nil {
+		return m.PatchFunc(user, fields)
+	}
+	return nil
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id, deletedBy int64) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(id, deletedBy)
 	}
 	return nil
 }
+
+func (m *MockUserRepository) Restore(ctx context.Context, id int64) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	return nil
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(before)
+	}
+	return 0, nil
+}
+
+func (m *MockUserRepository) Count(ctx context.Context, filter domain.UserFilter) (int64, error) {
+	if m.CountFunc != nil {
+		return m.CountFunc(filter)
+	}
+	return 0, nil
+}
+
+func (m *MockUserRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	if m.DependentsFunc != nil {
+		return m.DependentsFunc(id)
+	}
+	return nil, nil
+}
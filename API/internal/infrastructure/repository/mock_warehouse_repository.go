@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type MockWarehouseRepository struct {
+	CreateFunc  func(*domain.Warehouse) error
+	GetByIDFunc func(int64) (*domain.Warehouse, error)
+	GetAllFunc  func() ([]domain.Warehouse, error)
+	UpdateFunc  func(*domain.Warehouse) error
+	DeleteFunc  func(int64) error
+}
+
+func (m *MockWarehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(warehouse)
+	}
+	return nil
+}
+
+func (m *MockWarehouseRepository) GetByID(ctx context.Context, id int64) (*domain.Warehouse, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MockWarehouseRepository) GetAll(ctx context.Context) ([]domain.Warehouse, error) {
+	if m.GetAllFunc != nil {
+		return m.GetAllFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockWarehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(warehouse)
+	}
+	return nil
+}
+
+func (m *MockWarehouseRepository) Delete(ctx context.Context, id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
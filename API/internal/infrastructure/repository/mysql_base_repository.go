@@ -1,8 +1,8 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"time"
 )
 
@@ -20,8 +20,8 @@ func (r *MySQLBaseRepository) GetDB() *sql.DB {
 	return r.db
 }
 
-func (r *MySQLBaseRepository) BeginTx() (*sql.Tx, error) {
-	return r.db.Begin()
+func (r *MySQLBaseRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, nil)
 }
 
 func (r *MySQLBaseRepository) CommitTx(tx *sql.Tx) error {
@@ -65,8 +65,5 @@ func (r *MySQLBaseRepository) IsNotFound(err error) bool {
 }
 
 func (r *MySQLBaseRepository) IsDuplicateEntry(err error) bool {
-	if err == nil {
-		return false
-	}
-	return fmt.Sprintf("%v", err) == "Error 1062: Duplicate entry"
+	return violatedConstraint(err) == uniqueConstraint
 }
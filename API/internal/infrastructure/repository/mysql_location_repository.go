@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+)
+
+// mysqlLocationSelect loads a location together with its warehouse
+const mysqlLocationSelect = `
+	SELECT
+		l.id, l.code, l.description, l.created_at, l.updated_at,
+		w.id, w.code, w.name, w.address, w.created_at, w.updated_at
+	FROM locations l
+	JOIN warehouses w ON l.warehouse_id = w.id
+`
+
+type MySQLLocationRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLLocationRepository(db *sql.DB) *MySQLLocationRepository {
+	return &MySQLLocationRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLLocationRepository) Create(ctx context.Context, location *domain.Location) error {
+	query := `
+		INSERT INTO locations (warehouse_id, code, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	now := r.GetCurrentTimestamp()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
+		location.Warehouse.ID,
+		location.Code,
+		location.Description,
+		now,
+		now,
+	)
+	if err != nil {
+		return writeError(err, "location", location.ID, &domain.LocationAlreadyExistsError{Code: location.Code})
+	}
+
+	id, err := r.GetLastInsertID(result)
+	if err != nil {
+		return err
+	}
+
+	location.ID = id
+	location.CreatedAt = now
+	location.UpdatedAt = now
+	return nil
+}
+
+func (r *MySQLLocationRepository) GetByID(ctx context.Context, id int64) (*domain.Location, error) {
+	location, err := scanLocation(connFor(ctx, r.db).QueryRowContext(ctx, mysqlLocationSelect+" WHERE l.id = ?", id))
+	if err != nil {
+		if r.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return location, nil
+}
+
+func (r *MySQLLocationRepository) GetAll(ctx context.Context) ([]domain.Location, error) {
+	return r.queryLocations(ctx, mysqlLocationSelect+" ORDER BY w.code, l.code")
+}
+
+func (r *MySQLLocationRepository) GetByWarehouseID(ctx context.Context, warehouseID int64) ([]domain.Location, error) {
+	return r.queryLocations(ctx, mysqlLocationSelect+" WHERE l.warehouse_id = ? ORDER BY l.code", warehouseID)
+}
+
+func (r *MySQLLocationRepository) Update(ctx context.Context, location *domain.Location) error {
+	query := `
+		UPDATE locations
+		SET warehouse_id = ?, code = ?, description = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := r.GetCurrentTimestamp()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
+		location.Warehouse.ID,
+		location.Code,
+		location.Description,
+		now,
+		location.ID,
+	)
+	if err != nil {
+		return writeError(err, "location", location.ID, &domain.LocationAlreadyExistsError{Code: location.Code})
+	}
+
+	rows, err := r.GetRowsAffected(result)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.LocationNotFoundError{LocationID: location.ID}
+	}
+
+	location.UpdatedAt = now
+	return nil
+}
+
+func (r *MySQLLocationRepository) Delete(ctx context.Context, id int64) error {
+	query := "DELETE FROM locations WHERE id = ?"
+
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return deleteError(err, "location", id)
+	}
+
+	rows, err := r.GetRowsAffected(result)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.LocationNotFoundError{LocationID: id}
+	}
+
+	return nil
+}
+
+func (r *MySQLLocationRepository) queryLocations(ctx context.Context, query string, args ...interface{}) ([]domain.Location, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []domain.Location
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, *location)
+	}
+	return locations, rows.Err()
+}
+
+// scanLocation reads a row produced by a location select joined with its
+// warehouse. It is shared by the MySQL and SQLite repositories.
+func scanLocation(row rowScanner) (*domain.Location, error) {
+	location := domain.Location{Warehouse: &domain.Warehouse{}}
+	err := row.Scan(
+		&location.ID,
+		&location.Code,
+		&location.Description,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+		&location.Warehouse.ID,
+		&location.Warehouse.Code,
+		&location.Warehouse.Name,
+		&location.Warehouse.Address,
+		&location.Warehouse.CreatedAt,
+		&location.Warehouse.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
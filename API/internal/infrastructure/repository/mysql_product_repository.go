@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MySQLProductRepository struct {
@@ -15,25 +17,23 @@ func NewMySQLProductRepository(db *sql.DB) *MySQLProductRepository {
 	}
 }
 
-func (r *MySQLProductRepository) Create(product *domain.Product) error {
+func (r *MySQLProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	query := `
-		INSERT INTO products (name, code, image_url, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO products (name, code, tracking_mode, image_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		product.Name,
 		product.Code,
+		product.TrackingMode,
 		product.ImageURL,
 		now,
 		now,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.ProductAlreadyExistsError{Code: product.Code}
-		}
-		return err
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
 	}
 
 	id, err := r.GetLastInsertID(result)
@@ -42,26 +42,31 @@ func (r *MySQLProductRepository) Create(product *domain.Product) error {
 	}
 
 	product.ID = id
+	product.Version = 1
 	product.CreatedAt = now
 	product.UpdatedAt = now
 	return nil
 }
 
-func (r *MySQLProductRepository) GetByID(id int64) (*domain.Product, error) {
+func (r *MySQLProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
 	query := `
-		SELECT id, name, code, image_url, created_at, updated_at
+		SELECT id, name, code, tracking_mode, image_url, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM products
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var product domain.Product
-	err := r.db.QueryRow(query, id).Scan(
+	err := connFor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&product.ID,
 		&product.Name,
 		&product.Code,
+		&product.TrackingMode,
 		&product.ImageURL,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Version,
+		&product.DeletedAt,
+		&product.DeletedBy,
 	)
 	if err != nil {
 		if r.IsNotFound(err) {
@@ -72,14 +77,15 @@ func (r *MySQLProductRepository) GetByID(id int64) (*domain.Product, error) {
 	return &product, nil
 }
 
-func (r *MySQLProductRepository) GetAll() ([]domain.Product, error) {
+func (r *MySQLProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
 	query := `
-		SELECT id, name, code, image_url, created_at, updated_at
+		SELECT id, name, code, tracking_mode, image_url, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM products
-		ORDER BY id
 	`
 
-	rows, err := r.db.Query(query)
+	where, args := productFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query+where+pageClause(filter.ListOptions, "", domain.ProductSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,38 +98,48 @@ func (r *MySQLProductRepository) GetAll() ([]domain.Product, error) {
 			&product.ID,
 			&product.Name,
 			&product.Code,
+			&product.TrackingMode,
 			&product.ImageURL,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.Version,
+			&product.DeletedAt,
+			&product.DeletedBy,
 		)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, product)
 	}
-	return products, nil
+	return products, rows.Err()
+}
+
+func (r *MySQLProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	where, args := productFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *MySQLProductRepository) Update(product *domain.Product) error {
+func (r *MySQLProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	query := `
 		UPDATE products
-		SET name = ?, code = ?, image_url = ?, updated_at = ?
-		WHERE id = ?
+		SET name = ?, code = ?, tracking_mode = ?, image_url = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		product.Name,
 		product.Code,
+		product.TrackingMode,
 		product.ImageURL,
 		now,
 		product.ID,
+		product.Version,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.ProductAlreadyExistsError{Code: product.Code}
-		}
-		return err
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
 	}
 
 	rows, err := r.GetRowsAffected(result)
@@ -132,20 +148,25 @@ func (r *MySQLProductRepository) Update(product *domain.Product) error {
 	}
 
 	if rows == 0 {
-		return &domain.ProductNotFoundError{ProductID: product.ID}
+		return versionConflict(ctx, connFor(ctx, r.db), "products", "product", product.ID, product.Version, &domain.ProductNotFoundError{ProductID: product.ID})
 	}
 
 	product.UpdatedAt = now
+	product.Version++
 	return nil
 }
 
-func (r *MySQLProductRepository) Delete(id int64) error {
-	query := "DELETE FROM products WHERE id = ?"
-
-	result, err := r.db.Exec(query, id)
+// Patch saves the given fields of the product, see Update
+func (r *MySQLProductRepository) Patch(ctx context.Context, product *domain.Product, fields []string) error {
+	now := r.GetCurrentTimestamp()
+	query, args, err := patchStatement("products", productColumns(product), fields, now, product.ID, product.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
+	}
 
 	rows, err := r.GetRowsAffected(result)
 	if err != nil {
@@ -153,8 +174,44 @@ func (r *MySQLProductRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "products", "product", product.ID, product.Version, &domain.ProductNotFoundError{ProductID: product.ID})
+	}
+
+	product.UpdatedAt = now
+	product.Version++
+	return nil
+}
+
+func (r *MySQLProductRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "products", id, deletedBy, r.GetCurrentTimestamp())
+	if err != nil {
+		return err
+	}
+	if !deleted {
 		return &domain.ProductNotFoundError{ProductID: id}
 	}
+	return nil
+}
 
+func (r *MySQLProductRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "products", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.ProductNotFoundError{ProductID: id}
+	}
 	return nil
 }
+
+func (r *MySQLProductRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "products", before)
+}
+
+func (r *MySQLProductRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	conn := connFor(ctx, r.db)
+	if err := lockForDependents(ctx, conn, "products", id); err != nil {
+		return nil, err
+	}
+	return countDependents(ctx, conn, productReferences, id)
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MySQLProviderRepository struct {
@@ -15,14 +17,14 @@ func NewMySQLProviderRepository(db *sql.DB) *MySQLProviderRepository {
 	}
 }
 
-func (r *MySQLProviderRepository) Create(provider *domain.Provider) error {
+func (r *MySQLProviderRepository) Create(ctx context.Context, provider *domain.Provider) error {
 	query := `
 		INSERT INTO providers (name, email, phone, address, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		provider.Name,
 		provider.Email,
 		provider.Phone,
@@ -31,10 +33,7 @@ func (r *MySQLProviderRepository) Create(provider *domain.Provider) error {
 		now,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.ProviderAlreadyExistsError{Email: provider.Email}
-		}
-		return err
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
 	}
 
 	id, err := r.GetLastInsertID(result)
@@ -43,20 +42,21 @@ func (r *MySQLProviderRepository) Create(provider *domain.Provider) error {
 	}
 
 	provider.ID = id
+	provider.Version = 1
 	provider.CreatedAt = now
 	provider.UpdatedAt = now
 	return nil
 }
 
-func (r *MySQLProviderRepository) GetByID(id int64) (*domain.Provider, error) {
+func (r *MySQLProviderRepository) GetByID(ctx context.Context, id int64) (*domain.Provider, error) {
 	query := `
-		SELECT id, name, email, phone, address, created_at, updated_at
+		SELECT id, name, email, phone, address, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM providers
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var provider domain.Provider
-	err := r.db.QueryRow(query, id).Scan(
+	err := connFor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&provider.ID,
 		&provider.Name,
 		&provider.Email,
@@ -64,6 +64,9 @@ func (r *MySQLProviderRepository) GetByID(id int64) (*domain.Provider, error) {
 		&provider.Address,
 		&provider.CreatedAt,
 		&provider.UpdatedAt,
+		&provider.Version,
+		&provider.DeletedAt,
+		&provider.DeletedBy,
 	)
 	if err != nil {
 		if r.IsNotFound(err) {
@@ -74,14 +77,15 @@ func (r *MySQLProviderRepository) GetByID(id int64) (*domain.Provider, error) {
 	return &provider, nil
 }
 
-func (r *MySQLProviderRepository) GetAll() ([]domain.Provider, error) {
+func (r *MySQLProviderRepository) GetAll(ctx context.Context, filter domain.ProviderFilter) ([]domain.Provider, error) {
 	query := `
-		SELECT id, name, email, phone, address, created_at, updated_at
+		SELECT id, name, email, phone, address, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM providers
-		ORDER BY id
 	`
 
-	rows, err := r.db.Query(query)
+	where, args := providerFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query+where+pageClause(filter.ListOptions, "", domain.ProviderSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,36 +102,44 @@ func (r *MySQLProviderRepository) GetAll() ([]domain.Provider, error) {
 			&provider.Address,
 			&provider.CreatedAt,
 			&provider.UpdatedAt,
+			&provider.Version,
+			&provider.DeletedAt,
+			&provider.DeletedBy,
 		)
 		if err != nil {
 			return nil, err
 		}
 		providers = append(providers, provider)
 	}
-	return providers, nil
+	return providers, rows.Err()
+}
+
+func (r *MySQLProviderRepository) Count(ctx context.Context, filter domain.ProviderFilter) (int64, error) {
+	where, args := providerFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM providers"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *MySQLProviderRepository) Update(provider *domain.Provider) error {
+func (r *MySQLProviderRepository) Update(ctx context.Context, provider *domain.Provider) error {
 	query := `
 		UPDATE providers
-		SET name = ?, email = ?, phone = ?, address = ?, updated_at = ?
-		WHERE id = ?
+		SET name = ?, email = ?, phone = ?, address = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		provider.Name,
 		provider.Email,
 		provider.Phone,
 		provider.Address,
 		now,
 		provider.ID,
+		provider.Version,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.ProviderAlreadyExistsError{Email: provider.Email}
-		}
-		return err
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
 	}
 
 	rows, err := r.GetRowsAffected(result)
@@ -136,20 +148,25 @@ func (r *MySQLProviderRepository) Update(provider *domain.Provider) error {
 	}
 
 	if rows == 0 {
-		return &domain.ProviderNotFoundError{ProviderID: provider.ID}
+		return versionConflict(ctx, connFor(ctx, r.db), "providers", "provider", provider.ID, provider.Version, &domain.ProviderNotFoundError{ProviderID: provider.ID})
 	}
 
 	provider.UpdatedAt = now
+	provider.Version++
 	return nil
 }
 
-func (r *MySQLProviderRepository) Delete(id int64) error {
-	query := "DELETE FROM providers WHERE id = ?"
-
-	result, err := r.db.Exec(query, id)
+// Patch saves the given fields of the provider, see Update
+func (r *MySQLProviderRepository) Patch(ctx context.Context, provider *domain.Provider, fields []string) error {
+	now := r.GetCurrentTimestamp()
+	query, args, err := patchStatement("providers", providerColumns(provider), fields, now, provider.ID, provider.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
+	}
 
 	rows, err := r.GetRowsAffected(result)
 	if err != nil {
@@ -157,8 +174,44 @@ func (r *MySQLProviderRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "providers", "provider", provider.ID, provider.Version, &domain.ProviderNotFoundError{ProviderID: provider.ID})
+	}
+
+	provider.UpdatedAt = now
+	provider.Version++
+	return nil
+}
+
+func (r *MySQLProviderRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "providers", id, deletedBy, r.GetCurrentTimestamp())
+	if err != nil {
+		return err
+	}
+	if !deleted {
 		return &domain.ProviderNotFoundError{ProviderID: id}
 	}
+	return nil
+}
 
+func (r *MySQLProviderRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "providers", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.ProviderNotFoundError{ProviderID: id}
+	}
 	return nil
 }
+
+func (r *MySQLProviderRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "providers", before)
+}
+
+func (r *MySQLProviderRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	conn := connFor(ctx, r.db)
+	if err := lockForDependents(ctx, conn, "providers", id); err != nil {
+		return nil, err
+	}
+	return countDependents(ctx, conn, providerReferences, id)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+)
+
+type MySQLPurchaseOrderRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLPurchaseOrderRepository(db *sql.DB) *MySQLPurchaseOrderRepository {
+	return &MySQLPurchaseOrderRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLPurchaseOrderRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertPurchaseOrder(ctx, tx, order, r.GetCurrentTimestamp())
+	})
+}
+
+func (r *MySQLPurchaseOrderRepository) GetByID(ctx context.Context, id int64) (*domain.PurchaseOrder, error) {
+	return getPurchaseOrder(ctx, connFor(ctx, r.db), id)
+}
+
+func (r *MySQLPurchaseOrderRepository) GetAll(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	return getAllPurchaseOrders(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *MySQLPurchaseOrderRepository) UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error {
+	return updatePurchaseOrderStatus(ctx, connFor(ctx, r.db), order, r.GetCurrentTimestamp())
+}
+
+func (r *MySQLPurchaseOrderRepository) Receive(ctx context.Context, order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return receivePurchaseOrder(ctx, tx, order, lines, reason, r.GetCurrentTimestamp())
+	})
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+)
+
+type MySQLStockBalanceRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLStockBalanceRepository(db *sql.DB) *MySQLStockBalanceRepository {
+	return &MySQLStockBalanceRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLStockBalanceRepository) GetAll(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	return getAllStockBalances(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *MySQLStockBalanceRepository) Apply(ctx context.Context, movement *domain.BalanceMovement) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return applyBalanceMovement(ctx, tx, movement, r.GetCurrentTimestamp())
+	})
+}
+
+func (r *MySQLStockBalanceRepository) GetMovements(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	return getBalanceMovements(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *MySQLStockBalanceRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	return countBalancesByLocation(ctx, connFor(ctx, r.db), productID)
+}
+
+func (r *MySQLStockBalanceRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	return balancesOnHand(ctx, connFor(ctx, r.db), filter)
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"inventario/internal/domain"
+)
+
+const mysqlStockMovementSelect = `
+	SELECT
+		m.id, m.stock_id, m.serial, m.movement_type,
+		m.before_state, m.after_state, m.reason, m.created_at,
+		u.id, u.name, u.email, u.role
+	FROM stock_movements m
+	LEFT JOIN users u ON m.actor_user_id = u.id
+`
+
+type MySQLStockMovementRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLStockMovementRepository(db *sql.DB) *MySQLStockMovementRepository {
+	return &MySQLStockMovementRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLStockMovementRepository) Create(ctx context.Context, movement *domain.StockMovement) error {
+	movement.CreatedAt = r.GetCurrentTimestamp()
+	return insertStockMovement(ctx, connFor(ctx, r.db), movement)
+}
+
+func (r *MySQLStockMovementRepository) CreateMany(ctx context.Context, movements []*domain.StockMovement) error {
+	now := r.GetCurrentTimestamp()
+	for _, movement := range movements {
+		movement.CreatedAt = now
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertStockMovements(ctx, tx, movements)
+	})
+}
+
+func (r *MySQLStockMovementRepository) GetByStockID(ctx context.Context, stockID int64) ([]domain.StockMovement, error) {
+	return r.queryMovements(ctx, mysqlStockMovementSelect+" WHERE m.stock_id = ? ORDER BY m.id", stockID)
+}
+
+func (r *MySQLStockMovementRepository) GetBySerial(ctx context.Context, serial string) ([]domain.StockMovement, error) {
+	return r.queryMovements(ctx, mysqlStockMovementSelect+`
+		WHERE m.stock_id IN (SELECT stock_id FROM stock_movements WHERE serial = ?)
+		ORDER BY m.id`, serial)
+}
+
+func (r *MySQLStockMovementRepository) queryMovements(ctx context.Context, query string, args ...interface{}) ([]domain.StockMovement, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []domain.StockMovement
+	for rows.Next() {
+		var movement domain.StockMovement
+		var before, after sql.NullString
+		var userID sql.NullInt64
+		var userName, userEmail, userRole sql.NullString
+		err := rows.Scan(
+			&movement.ID,
+			&movement.StockID,
+			&movement.Serial,
+			&movement.Type,
+			&before,
+			&after,
+			&movement.Reason,
+			&movement.CreatedAt,
+			&userID,
+			&userName,
+			&userEmail,
+			&userRole,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			movement.Actor = &domain.User{
+				ID:    userID.Int64,
+				Name:  userName.String,
+				Email: userEmail.String,
+				Role:  userRole.String,
+			}
+		}
+		if err := decodeSnapshots(&movement, before, after); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertStockMovement appends movement to the ledger through exec, which may
+// be a transaction. It is shared by the MySQL and SQLite repositories.
+func insertStockMovement(ctx context.Context, exec execer, movement *domain.StockMovement) error {
+	before, after, err := encodeSnapshots(movement)
+	if err != nil {
+		return err
+	}
+
+	result, err := exec.ExecContext(ctx, `
+		INSERT INTO stock_movements (
+			stock_id, serial, movement_type, actor_user_id,
+			before_state, after_state, reason, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, movement.StockID, movement.Serial, movement.Type, actorID(movement.Actor),
+		before, after, movement.Reason, movement.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	movement.ID = id
+	return nil
+}
+
+// insertStockMovements appends movements to the ledger through exec with
+// multi-row inserts, which should run in a transaction. Their IDs are left
+// unset. It is shared by the MySQL and SQLite repositories.
+func insertStockMovements(ctx context.Context, exec execer, movements []*domain.StockMovement) error {
+	for _, r := range chunks(len(movements)) {
+		chunk := movements[r[0]:r[1]]
+		args := make([]interface{}, 0, len(chunk)*8)
+		for _, movement := range chunk {
+			before, after, err := encodeSnapshots(movement)
+			if err != nil {
+				return err
+			}
+			args = append(args, movement.StockID, movement.Serial, movement.Type, actorID(movement.Actor),
+				before, after, movement.Reason, movement.CreatedAt)
+		}
+
+		_, err := exec.ExecContext(ctx, `
+			INSERT INTO stock_movements (
+				stock_id, serial, movement_type, actor_user_id,
+				before_state, after_state, reason, created_at
+			)
+			VALUES `+placeholders("(?, ?, ?, ?, ?, ?, ?, ?)", len(chunk)), args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSnapshots serializes the before/after states of a movement as JSON
+func encodeSnapshots(movement *domain.StockMovement) (sql.NullString, sql.NullString, error) {
+	before, err := encodeSnapshot(movement.Before)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	after, err := encodeSnapshot(movement.After)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	return before, after, nil
+}
+
+func encodeSnapshot(snapshot *domain.StockSnapshot) (sql.NullString, error) {
+	if snapshot == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func decodeSnapshots(movement *domain.StockMovement, before, after sql.NullString) error {
+	if before.Valid {
+		movement.Before = &domain.StockSnapshot{}
+		if err := json.Unmarshal([]byte(before.String), movement.Before); err != nil {
+			return err
+		}
+	}
+	if after.Valid {
+		movement.After = &domain.StockSnapshot{}
+		if err := json.Unmarshal([]byte(after.String), movement.After); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// actorID returns the ID of the user behind a change, or NULL when unknown
+func actorID(actor *domain.User) sql.NullInt64 {
+	if actor == nil || actor.ID == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: actor.ID, Valid: true}
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MySQLStockRepository struct {
@@ -15,281 +17,89 @@ func NewMySQLStockRepository(db *sql.DB) *MySQLStockRepository {
 	}
 }
 
-func (r *MySQLStockRepository) Create(stock *domain.Stock) error {
-	query := `
-		INSERT INTO stocks (
-			product_id, serial, created_at, updated_at,
-			created_by_user_id, updated_by_user_id,
-			batch, purchase_date, provider_id
-		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
+func (r *MySQLStockRepository) Create(ctx context.Context, stock *domain.Stock) error {
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
-		stock.Product.ID,
-		stock.Serial,
-		now,
-		now,
-		stock.CreatedByUser.ID,
-		stock.UpdatedByUser.ID,
-		stock.Batch,
-		stock.PurchaseDate,
-		stock.Provider.ID,
-	)
-	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.StockAlreadyExistsError{Serial: stock.Serial}
-		}
-		return err
+	stock.StatusChangedAt = now
+	stock.CreatedAt = now
+	stock.UpdatedAt = now
+	if err := insertStock(ctx, connFor(ctx, r.db), stock); err != nil {
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
 	}
+	return nil
+}
 
-	id, err := r.GetLastInsertID(result)
+func (r *MySQLStockRepository) CreateMany(ctx context.Context, stocks []*domain.Stock) error {
+	now := r.GetCurrentTimestamp()
+	for _, stock := range stocks {
+		stock.StatusChangedAt = now
+		stock.CreatedAt = now
+		stock.UpdatedAt = now
+	}
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertStocks(ctx, tx, stocks)
+	})
 	if err != nil {
-		return err
+		return writeError(err, "stock", 0, &domain.StockAlreadyExistsError{})
 	}
-
-	stock.ID = id
-	stock.CreatedAt = now
-	stock.UpdatedAt = now
 	return nil
 }
 
-func (r *MySQLStockRepository) GetByID(id int64) (*domain.Stock, error) {
-	query := `
-		SELECT 
-			s.id, s.serial, s.created_at, s.updated_at,
-			s.batch, s.purchase_date,
-			p.id, p.name, p.code, p.image_url,
-			u1.id, u1.name, u1.email, u1.role,
-			u2.id, u2.name, u2.email, u2.role,
-			pr.id, pr.name, pr.email, pr.phone, pr.address
-		FROM stocks s
-		JOIN products p ON s.product_id = p.id
-		JOIN users u1 ON s.created_by_user_id = u1.id
-		JOIN users u2 ON s.updated_by_user_id = u2.id
-		JOIN providers pr ON s.provider_id = pr.id
-		WHERE s.id = ?
-	`
+func (r *MySQLStockRepository) ExistingSerials(ctx context.Context, serials []string) ([]string, error) {
+	return existingSerials(ctx, connFor(ctx, r.db), serials)
+}
 
-	var stock domain.Stock
-	err := r.db.QueryRow(query, id).Scan(
-		&stock.ID,
-		&stock.Serial,
-		&stock.CreatedAt,
-		&stock.UpdatedAt,
-		&stock.Batch,
-		&stock.PurchaseDate,
-		&stock.Product.ID,
-		&stock.Product.Name,
-		&stock.Product.Code,
-		&stock.Product.ImageURL,
-		&stock.CreatedByUser.ID,
-		&stock.CreatedByUser.Name,
-		&stock.CreatedByUser.Email,
-		&stock.CreatedByUser.Role,
-		&stock.UpdatedByUser.ID,
-		&stock.UpdatedByUser.Name,
-		&stock.UpdatedByUser.Email,
-		&stock.UpdatedByUser.Role,
-		&stock.Provider.ID,
-		&stock.Provider.Name,
-		&stock.Provider.Email,
-		&stock.Provider.Phone,
-		&stock.Provider.Address,
-	)
+func (r *MySQLStockRepository) GetByID(ctx context.Context, id int64) (*domain.Stock, error) {
+	stock, err := scanStock(connFor(ctx, r.db).QueryRowContext(ctx, stockSelect+" WHERE s.id = ? AND s.deleted_at IS NULL", id))
 	if err != nil {
 		if r.IsNotFound(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &stock, nil
+	return stock, nil
 }
 
-func (r *MySQLStockRepository) GetAll() ([]domain.Stock, error) {
-	query := `
-		SELECT 
-			s.id, s.serial, s.created_at, s.updated_at,
-			s.batch, s.purchase_date,
-			p.id, p.name, p.code, p.image_url,
-			u1.id, u1.name, u1.email, u1.role,
-			u2.id, u2.name, u2.email, u2.role,
-			pr.id, pr.name, pr.email, pr.phone, pr.address
-		FROM stocks s
-		JOIN products p ON s.product_id = p.id
-		JOIN users u1 ON s.created_by_user_id = u1.id
-		JOIN users u2 ON s.updated_by_user_id = u2.id
-		JOIN providers pr ON s.provider_id = pr.id
-		ORDER BY s.id
-	`
-
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var stocks []domain.Stock
-	for rows.Next() {
-		var stock domain.Stock
-		err := rows.Scan(
-			&stock.ID,
-			&stock.Serial,
-			&stock.CreatedAt,
-			&stock.UpdatedAt,
-			&stock.Batch,
-			&stock.PurchaseDate,
-			&stock.Product.ID,
-			&stock.Product.Name,
-			&stock.Product.Code,
-			&stock.Product.ImageURL,
-			&stock.CreatedByUser.ID,
-			&stock.CreatedByUser.Name,
-			&stock.CreatedByUser.Email,
-			&stock.CreatedByUser.Role,
-			&stock.UpdatedByUser.ID,
-			&stock.UpdatedByUser.Name,
-			&stock.UpdatedByUser.Email,
-			&stock.UpdatedByUser.Role,
-			&stock.Provider.ID,
-			&stock.Provider.Name,
-			&stock.Provider.Email,
-			&stock.Provider.Phone,
-			&stock.Provider.Address,
-		)
-		if err != nil {
-			return nil, err
-		}
-		stocks = append(stocks, stock)
-	}
-	return stocks, nil
+func (r *MySQLStockRepository) GetAll(ctx context.Context, filter domain.StockFilter) ([]domain.Stock, error) {
+	where, args := stockFilterClause(filter, "s.")
+	where, args = withKeyset(where, args, filter.ListOptions, "s.")
+	return r.queryStocks(ctx, stockSelect+where+pageClause(filter.ListOptions, "s.", domain.StockSortFields), args...)
 }
 
-func (r *MySQLStockRepository) GetByProductID(productID int64) ([]domain.Stock, error) {
-	query := `
-		SELECT 
-			s.id, s.serial, s.created_at, s.updated_at,
-			s.batch, s.purchase_date,
-			p.id, p.name, p.code, p.image_url,
-			u1.id, u1.name, u1.email, u1.role,
-			u2.id, u2.name, u2.email, u2.role,
-			pr.id, pr.name, pr.email, pr.phone, pr.address
-		FROM stocks s
-		JOIN products p ON s.product_id = p.id
-		JOIN users u1 ON s.created_by_user_id = u1.id
-		JOIN users u2 ON s.updated_by_user_id = u2.id
-		JOIN providers pr ON s.provider_id = pr.id
-		WHERE s.product_id = ?
-		ORDER BY s.id
-	`
-
-	rows, err := r.db.Query(query, productID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var stocks []domain.Stock
-	for rows.Next() {
-		var stock domain.Stock
-		err := rows.Scan(
-			&stock.ID,
-			&stock.Serial,
-			&stock.CreatedAt,
-			&stock.UpdatedAt,
-			&stock.Batch,
-			&stock.PurchaseDate,
-			&stock.Product.ID,
-			&stock.Product.Name,
-			&stock.Product.Code,
-			&stock.Product.ImageURL,
-			&stock.CreatedByUser.ID,
-			&stock.CreatedByUser.Name,
-			&stock.CreatedByUser.Email,
-			&stock.CreatedByUser.Role,
-			&stock.UpdatedByUser.ID,
-			&stock.UpdatedByUser.Name,
-			&stock.UpdatedByUser.Email,
-			&stock.UpdatedByUser.Role,
-			&stock.Provider.ID,
-			&stock.Provider.Name,
-			&stock.Provider.Email,
-			&stock.Provider.Phone,
-			&stock.Provider.Address,
-		)
-		if err != nil {
-			return nil, err
-		}
-		stocks = append(stocks, stock)
-	}
-	return stocks, nil
+func (r *MySQLStockRepository) Count(ctx context.Context, filter domain.StockFilter) (int64, error) {
+	where, args := stockFilterClause(filter, "s.")
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks s"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *MySQLStockRepository) GetBySerial(serial string) (*domain.Stock, error) {
-	query := `
-		SELECT 
-			s.id, s.serial, s.created_at, s.updated_at,
-			s.batch, s.purchase_date,
-			p.id, p.name, p.code, p.image_url,
-			u1.id, u1.name, u1.email, u1.role,
-			u2.id, u2.name, u2.email, u2.role,
-			pr.id, pr.name, pr.email, pr.phone, pr.address
-		FROM stocks s
-		JOIN products p ON s.product_id = p.id
-		JOIN users u1 ON s.created_by_user_id = u1.id
-		JOIN users u2 ON s.updated_by_user_id = u2.id
-		JOIN providers pr ON s.provider_id = pr.id
-		WHERE s.serial = ?
-	`
+func (r *MySQLStockRepository) GetByProductID(ctx context.Context, productID int64, filter domain.StockFilter) ([]domain.Stock, error) {
+	filter.ProductID = productID
+	return r.GetAll(ctx, filter)
+}
 
-	var stock domain.Stock
-	err := r.db.QueryRow(query, serial).Scan(
-		&stock.ID,
-		&stock.Serial,
-		&stock.CreatedAt,
-		&stock.UpdatedAt,
-		&stock.Batch,
-		&stock.PurchaseDate,
-		&stock.Product.ID,
-		&stock.Product.Name,
-		&stock.Product.Code,
-		&stock.Product.ImageURL,
-		&stock.CreatedByUser.ID,
-		&stock.CreatedByUser.Name,
-		&stock.CreatedByUser.Email,
-		&stock.CreatedByUser.Role,
-		&stock.UpdatedByUser.ID,
-		&stock.UpdatedByUser.Name,
-		&stock.UpdatedByUser.Email,
-		&stock.UpdatedByUser.Role,
-		&stock.Provider.ID,
-		&stock.Provider.Name,
-		&stock.Provider.Email,
-		&stock.Provider.Phone,
-		&stock.Provider.Address,
-	)
+func (r *MySQLStockRepository) GetBySerial(ctx context.Context, serial string) (*domain.Stock, error) {
+	stock, err := scanStock(connFor(ctx, r.db).QueryRowContext(ctx, stockSelect+" WHERE s.serial = ? AND s.deleted_at IS NULL", serial))
 	if err != nil {
 		if r.IsNotFound(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &stock, nil
+	return stock, nil
 }
 
-func (r *MySQLStockRepository) Update(stock *domain.Stock) error {
+func (r *MySQLStockRepository) Update(ctx context.Context, stock *domain.Stock) error {
 	query := `
 		UPDATE stocks
-		SET 
-			product_id = ?, serial = ?, updated_at = ?,
+		SET
+			product_id = ?, serial = ?, updated_at = ?, version = version + 1,
 			updated_by_user_id = ?, batch = ?, purchase_date = ?,
-			provider_id = ?
-		WHERE id = ?
+			provider_id = ?, location_id = ?
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		stock.Product.ID,
 		stock.Serial,
 		now,
@@ -297,13 +107,12 @@ func (r *MySQLStockRepository) Update(stock *domain.Stock) error {
 		stock.Batch,
 		stock.PurchaseDate,
 		stock.Provider.ID,
+		stockLocationID(stock),
 		stock.ID,
+		stock.Version,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.StockAlreadyExistsError{Serial: stock.Serial}
-		}
-		return err
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
 	}
 
 	rows, err := r.GetRowsAffected(result)
@@ -312,29 +121,159 @@ func (r *MySQLStockRepository) Update(stock *domain.Stock) error {
 	}
 
 	if rows == 0 {
-		return &domain.StockNotFoundError{StockID: stock.ID}
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
 	}
 
 	stock.UpdatedAt = now
+	stock.Version++
 	return nil
 }
 
-func (r *MySQLStockRepository) Delete(id int64) error {
-	query := "DELETE FROM stocks WHERE id = ?"
+// Patch saves the given fields of the unit and who updated it, see Update
+func (r *MySQLStockRepository) Patch(ctx context.Context, stock *domain.Stock, fields []string) error {
+	now := r.GetCurrentTimestamp()
+	query, args, err := patchStatement("stocks", stockColumns(stock), append([]string{"updated_by_user_id"}, fields...), now, stock.ID, stock.Version)
+	if err != nil {
+		return err
+	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
+	}
 
-	result, err := r.db.Exec(query, id)
+	rows, err := r.GetRowsAffected(result)
 	if err != nil {
 		return err
 	}
 
+	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
+	}
+
+	stock.UpdatedAt = now
+	stock.Version++
+	return nil
+}
+
+func (r *MySQLStockRepository) UpdateStatus(ctx context.Context, stock *domain.Stock) error {
+	query := `
+		UPDATE stocks
+		SET status = ?, status_changed_at = ?, updated_at = ?, updated_by_user_id = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
+
+	now := r.GetCurrentTimestamp()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
+		stock.Status,
+		now,
+		now,
+		stock.UpdatedByUser.ID,
+		stock.ID,
+		stock.Version,
+	)
+	if err != nil {
+		return writeError(err, "stock", stock.ID, nil)
+	}
+
 	rows, err := r.GetRowsAffected(result)
 	if err != nil {
 		return err
 	}
 
 	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
+	}
+
+	stock.StatusChangedAt = now
+	stock.UpdatedAt = now
+	stock.Version++
+	return nil
+}
+
+func (r *MySQLStockRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "stocks", id, deletedBy, r.GetCurrentTimestamp())
+	if err != nil {
+		return err
+	}
+	if !deleted {
 		return &domain.StockNotFoundError{StockID: id}
 	}
+	return nil
+}
 
+func (r *MySQLStockRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "stocks", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.StockNotFoundError{StockID: id}
+	}
 	return nil
 }
+
+func (r *MySQLStockRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "stocks", before)
+}
+
+func (r *MySQLStockRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	query := `
+		SELECT l.id, l.code, l.description, w.id, w.code, w.name, COUNT(*)
+		FROM stocks s
+		LEFT JOIN locations l ON s.location_id = l.id
+		LEFT JOIN warehouses w ON l.warehouse_id = w.id
+		WHERE s.product_id = ? AND s.status NOT IN (?, ?) AND s.deleted_at IS NULL
+		GROUP BY l.id, l.code, l.description, w.id, w.code, w.name
+		ORDER BY w.code, l.code
+	`
+
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, productID, domain.StockSold, domain.StockScrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []domain.LocationStockCount
+	for rows.Next() {
+		var count domain.LocationStockCount
+		var loc nullableLocation
+		if err := rows.Scan(loc.dest(&count.Quantity)...); err != nil {
+			return nil, err
+		}
+		count.Location = loc.location()
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+func (r *MySQLStockRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	return stocksOnHand(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *MySQLStockRepository) Reassign(ctx context.Context, ref domain.StockReference, fromID, toID int64, actor *domain.User, reason string) (int64, error) {
+	var changed int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		changed, err = reassignStocks(ctx, tx, ref, fromID, toID, actor, reason, r.GetCurrentTimestamp())
+		return err
+	})
+	return changed, err
+}
+
+func (r *MySQLStockRepository) queryStocks(ctx context.Context, query string, args ...interface{}) ([]domain.Stock, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []domain.Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *stock)
+	}
+	return stocks, rows.Err()
+}
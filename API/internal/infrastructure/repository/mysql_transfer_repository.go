@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+)
+
+type MySQLTransferRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLTransferRepository(db *sql.DB) *MySQLTransferRepository {
+	return &MySQLTransferRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLTransferRepository) Create(ctx context.Context, transfer *domain.Transfer) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertTransfer(ctx, tx, transfer, r.GetCurrentTimestamp())
+	})
+}
+
+func (r *MySQLTransferRepository) GetByID(ctx context.Context, id int64) (*domain.Transfer, error) {
+	return getTransfer(ctx, connFor(ctx, r.db), id)
+}
+
+func (r *MySQLTransferRepository) GetAll(ctx context.Context, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	return getAllTransfers(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *MySQLTransferRepository) UpdateStatus(ctx context.Context, transfer *domain.Transfer) error {
+	return updateTransferStatus(ctx, connFor(ctx, r.db), transfer, r.GetCurrentTimestamp())
+}
+
+func (r *MySQLTransferRepository) Receive(ctx context.Context, transfer *domain.Transfer, actor *domain.User, movements []*domain.StockMovement) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return receiveTransfer(ctx, tx, transfer, actor, movements, r.GetCurrentTimestamp())
+	})
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type MySQLUserRepository struct {
@@ -15,14 +17,14 @@ func NewMySQLUserRepository(db *sql.DB) *MySQLUserRepository {
 	}
 }
 
-func (r *MySQLUserRepository) Create(user *domain.User) error {
+func (r *MySQLUserRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
 		INSERT INTO users (name, email, password, role, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		user.Name,
 		user.Email,
 		user.Password,
@@ -31,10 +33,7 @@ func (r *MySQLUserRepository) Create(user *domain.User) error {
 		now,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.UserAlreadyExistsError{Email: user.Email}
-		}
-		return err
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
 	}
 
 	id, err := r.GetLastInsertID(result)
@@ -43,20 +42,21 @@ func (r *MySQLUserRepository) Create(user *domain.User) error {
 	}
 
 	user.ID = id
+	user.Version = 1
 	user.CreatedAt = now
 	user.UpdatedAt = now
 	return nil
 }
 
-func (r *MySQLUserRepository) GetByID(id int64) (*domain.User, error) {
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	query := `
-		SELECT id, name, email, password, role, created_at, updated_at
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var user domain.User
-	err := r.db.QueryRow(query, id).Scan(
+	err := connFor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
@@ -64,6 +64,9 @@ func (r *MySQLUserRepository) GetByID(id int64) (*domain.User, error) {
 		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
+		&user.DeletedAt,
+		&user.DeletedBy,
 	)
 	if err != nil {
 		if r.IsNotFound(err) {
@@ -74,15 +77,15 @@ func (r *MySQLUserRepository) GetByID(id int64) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *MySQLUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (r *MySQLUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, name, email, password, role, created_at, updated_at
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-		WHERE email = ?
+		WHERE email = ? AND deleted_at IS NULL
 	`
 
 	var user domain.User
-	err := r.db.QueryRow(query, email).Scan(
+	err := connFor(ctx, r.db).QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
@@ -90,6 +93,9 @@ func (r *MySQLUserRepository) GetByEmail(email string) (*domain.User, error) {
 		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
+		&user.DeletedAt,
+		&user.DeletedBy,
 	)
 	if err != nil {
 		if r.IsNotFound(err) {
@@ -100,14 +106,15 @@ func (r *MySQLUserRepository) GetByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *MySQLUserRepository) GetAll() ([]domain.User, error) {
+func (r *MySQLUserRepository) GetAll(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
 	query := `
-		SELECT id, name, email, password, role, created_at, updated_at
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-		ORDER BY id
 	`
 
-	rows, err := r.db.Query(query)
+	where, args := userFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query+where+pageClause(filter.ListOptions, "", domain.UserSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -124,36 +131,44 @@ func (r *MySQLUserRepository) GetAll() ([]domain.User, error) {
 			&user.Role,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.Version,
+			&user.DeletedAt,
+			&user.DeletedBy,
 		)
 		if err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
-	return users, nil
+	return users, rows.Err()
+}
+
+func (r *MySQLUserRepository) Count(ctx context.Context, filter domain.UserFilter) (int64, error) {
+	where, args := userFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *MySQLUserRepository) Update(user *domain.User) error {
+func (r *MySQLUserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET name = ?, email = ?, password = ?, role = ?, updated_at = ?
-		WHERE id = ?
+		SET name = ?, email = ?, password = ?, role = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
 	`
 
 	now := r.GetCurrentTimestamp()
-	result, err := r.db.Exec(query,
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
 		user.Name,
 		user.Email,
 		user.Password,
 		user.Role,
 		now,
 		user.ID,
+		user.Version,
 	)
 	if err != nil {
-		if r.IsDuplicateEntry(err) {
-			return &domain.UserAlreadyExistsError{Email: user.Email}
-		}
-		return err
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
 	}
 
 	rows, err := r.GetRowsAffected(result)
@@ -162,20 +177,25 @@ func (r *MySQLUserRepository) Update(user *domain.User) error {
 	}
 
 	if rows == 0 {
-		return &domain.UserNotFoundError{UserID: user.ID}
+		return versionConflict(ctx, connFor(ctx, r.db), "users", "user", user.ID, user.Version, &domain.UserNotFoundError{UserID: user.ID})
 	}
 
 	user.UpdatedAt = now
+	user.Version++
 	return nil
 }
 
-func (r *MySQLUserRepository) Delete(id int64) error {
-	query := "DELETE FROM users WHERE id = ?"
-
-	result, err := r.db.Exec(query, id)
+// Patch saves the given fields of the user, see Update
+func (r *MySQLUserRepository) Patch(ctx context.Context, user *domain.User, fields []string) error {
+	now := r.GetCurrentTimestamp()
+	query, args, err := patchStatement("users", userColumns(user), fields, now, user.ID, user.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
+	}
 
 	rows, err := r.GetRowsAffected(result)
 	if err != nil {
@@ -183,8 +203,44 @@ func (r *MySQLUserRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "users", "user", user.ID, user.Version, &domain.UserNotFoundError{UserID: user.ID})
+	}
+
+	user.UpdatedAt = now
+	user.Version++
+	return nil
+}
+
+func (r *MySQLUserRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "users", id, deletedBy, r.GetCurrentTimestamp())
+	if err != nil {
+		return err
+	}
+	if !deleted {
 		return &domain.UserNotFoundError{UserID: id}
 	}
+	return nil
+}
 
+func (r *MySQLUserRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "users", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.UserNotFoundError{UserID: id}
+	}
 	return nil
 }
+
+func (r *MySQLUserRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "users", before)
+}
+
+func (r *MySQLUserRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	conn := connFor(ctx, r.db)
+	if err := lockForDependents(ctx, conn, "users", id); err != nil {
+		return nil, err
+	}
+	return countDependents(ctx, conn, userReferences, id)
+}
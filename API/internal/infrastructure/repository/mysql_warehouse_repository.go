@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+)
+
+type MySQLWarehouseRepository struct {
+	*MySQLBaseRepository
+}
+
+func NewMySQLWarehouseRepository(db *sql.DB) *MySQLWarehouseRepository {
+	return &MySQLWarehouseRepository{
+		MySQLBaseRepository: NewMySQLBaseRepository(db),
+	}
+}
+
+func (r *MySQLWarehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, address, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	now := r.GetCurrentTimestamp()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
+		warehouse.Code,
+		warehouse.Name,
+		warehouse.Address,
+		now,
+		now,
+	)
+	if err != nil {
+		return writeError(err, "warehouse", warehouse.ID, &domain.WarehouseAlreadyExistsError{Code: warehouse.Code})
+	}
+
+	id, err := r.GetLastInsertID(result)
+	if err != nil {
+		return err
+	}
+
+	warehouse.ID = id
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+func (r *MySQLWarehouseRepository) GetByID(ctx context.Context, id int64) (*domain.Warehouse, error) {
+	query := `
+		SELECT id, code, name, address, created_at, updated_at
+		FROM warehouses
+		WHERE id = ?
+	`
+
+	var warehouse domain.Warehouse
+	err := connFor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&warehouse.ID,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.Address,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+	if err != nil {
+		if r.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *MySQLWarehouseRepository) GetAll(ctx context.Context) ([]domain.Warehouse, error) {
+	query := `
+		SELECT id, code, name, address, created_at, updated_at
+		FROM warehouses
+		ORDER BY id
+	`
+
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []domain.Warehouse
+	for rows.Next() {
+		var warehouse domain.Warehouse
+		err := rows.Scan(
+			&warehouse.ID,
+			&warehouse.Code,
+			&warehouse.Name,
+			&warehouse.Address,
+			&warehouse.CreatedAt,
+			&warehouse.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	return warehouses, rows.Err()
+}
+
+func (r *MySQLWarehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	query := `
+		UPDATE warehouses
+		SET code = ?, name = ?, address = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := r.GetCurrentTimestamp()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query,
+		warehouse.Code,
+		warehouse.Name,
+		warehouse.Address,
+		now,
+		warehouse.ID,
+	)
+	if err != nil {
+		return writeError(err, "warehouse", warehouse.ID, &domain.WarehouseAlreadyExistsError{Code: warehouse.Code})
+	}
+
+	rows, err := r.GetRowsAffected(result)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.WarehouseNotFoundError{WarehouseID: warehouse.ID}
+	}
+
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+func (r *MySQLWarehouseRepository) Delete(ctx context.Context, id int64) error {
+	query := "DELETE FROM warehouses WHERE id = ?"
+
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return deleteError(err, "warehouse", id)
+	}
+
+	rows, err := r.GetRowsAffected(result)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.WarehouseNotFoundError{WarehouseID: id}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"inventario/internal/domain"
+	"strings"
+	"time"
+)
+
+// The fields a patch can save, named after their columns, mapped to their
+// values in the entity. They are shared by the MySQL and SQLite repositories.
+
+func productColumns(product *domain.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          product.Name,
+		"code":          product.Code,
+		"tracking_mode": product.TrackingMode,
+		"image_url":     product.ImageURL,
+	}
+}
+
+func providerColumns(provider *domain.Provider) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    provider.Name,
+		"email":   provider.Email,
+		"phone":   provider.Phone,
+		"address": provider.Address,
+	}
+}
+
+func userColumns(user *domain.User) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     user.Name,
+		"email":    user.Email,
+		"role":     user.Role,
+		"password": user.Password,
+	}
+}
+
+func stockColumns(stock *domain.Stock) map[string]interface{} {
+	return map[string]interface{}{
+		"product_id":         stock.Product.ID,
+		"serial":             stock.Serial,
+		"batch":              stock.Batch,
+		"purchase_date":      stock.PurchaseDate,
+		"provider_id":        stock.Provider.ID,
+		"location_id":        stockLocationID(stock),
+		"updated_by_user_id": stock.UpdatedByUser.ID,
+	}
+}
+
+// patchStatement builds the UPDATE saving the given fields of the row id of
+// table, taken from columns, and its updated_at. Like the full updates it
+// only changes the row if it is still at version, which it bumps.
+func patchStatement(table string, columns map[string]interface{}, fields []string, updatedAt time.Time, id, version int64) (string, []interface{}, error) {
+	set := make([]string, 0, len(fields)+2)
+	args := make([]interface{}, 0, len(fields)+3)
+	for _, field := range fields {
+		value, ok := columns[field]
+		if !ok {
+			return "", nil, fmt.Errorf("cannot patch %s of %s", field, table)
+		}
+		set = append(set, field+" = ?")
+		args = append(args, value)
+	}
+	set = append(set, "updated_at = ?", "version = version + 1")
+	args = append(args, updatedAt, id, version)
+
+	query := "UPDATE " + table + " SET " + strings.Join(set, ", ") + " WHERE id = ? AND version = ? AND deleted_at IS NULL"
+	return query, args, nil
+}
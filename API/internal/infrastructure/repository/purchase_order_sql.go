@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+// purchaseOrderSelect loads a purchase order together with its provider.
+// MySQL and SQLite share it, as they share the purchase order helpers below.
+const purchaseOrderSelect = `
+	SELECT
+		po.id, po.status, po.notes, po.created_by_user_id,
+		po.ordered_at, po.created_at, po.updated_at,
+		pr.id, pr.name, pr.email, pr.phone, pr.address
+	FROM purchase_orders po
+	JOIN providers pr ON po.provider_id = pr.id
+`
+
+func scanPurchaseOrder(row rowScanner) (*domain.PurchaseOrder, error) {
+	order := domain.PurchaseOrder{Provider: &domain.Provider{}}
+	var createdByUserID sql.NullInt64
+	var orderedAt sql.NullTime
+
+	err := row.Scan(
+		&order.ID, &order.Status, &order.Notes, &createdByUserID,
+		&orderedAt, &order.CreatedAt, &order.UpdatedAt,
+		&order.Provider.ID, &order.Provider.Name, &order.Provider.Email,
+		&order.Provider.Phone, &order.Provider.Address,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdByUserID.Valid {
+		order.CreatedByUser = &domain.User{ID: createdByUserID.Int64}
+	}
+	if orderedAt.Valid {
+		order.OrderedAt = &orderedAt.Time
+	}
+	return &order, nil
+}
+
+func getPurchaseOrder(ctx context.Context, q queryer, id int64) (*domain.PurchaseOrder, error) {
+	order, err := scanPurchaseOrder(q.QueryRowContext(ctx, purchaseOrderSelect+" WHERE po.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Lines, err = loadPurchaseOrderLines(ctx, q, order.ID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func getAllPurchaseOrders(ctx context.Context, q queryer, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions = append(conditions, "po.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.ProviderID != 0 {
+		conditions = append(conditions, "po.provider_id = ?")
+		args = append(args, filter.ProviderID)
+	}
+
+	query := purchaseOrderSelect
+	for i, condition := range conditions {
+		if i == 0 {
+			query += " WHERE " + condition
+		} else {
+			query += " AND " + condition
+		}
+	}
+
+	rows, err := q.QueryContext(ctx, query+" ORDER BY po.id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []domain.PurchaseOrder
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if orders[i].Lines, err = loadPurchaseOrderLines(ctx, q, orders[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+func loadPurchaseOrderLines(ctx context.Context, q queryer, orderID int64) ([]domain.PurchaseOrderLine, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.id, l.expected_quantity, l.received_quantity, l.unit_cost,
+			p.id, p.name, p.code
+		FROM purchase_order_lines l
+		JOIN products p ON l.product_id = p.id
+		WHERE l.purchase_order_id = ?
+		ORDER BY l.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []domain.PurchaseOrderLine
+	for rows.Next() {
+		line := domain.PurchaseOrderLine{Product: &domain.Product{}}
+		err := rows.Scan(
+			&line.ID, &line.ExpectedQuantity, &line.ReceivedQuantity, &line.UnitCost,
+			&line.Product.ID, &line.Product.Name, &line.Product.Code,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// insertPurchaseOrder stores a purchase order and its lines
+func insertPurchaseOrder(ctx context.Context, tx *sql.Tx, order *domain.PurchaseOrder, now time.Time) error {
+	var createdBy sql.NullInt64
+	if order.CreatedByUser != nil {
+		createdBy = nullableID(order.CreatedByUser.ID)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO purchase_orders (
+			provider_id, status, notes, created_by_user_id, ordered_at,
+			created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, order.Provider.ID, order.Status, order.Notes, createdBy, order.OrderedAt, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO purchase_order_lines (
+				purchase_order_id, product_id, expected_quantity, received_quantity, unit_cost
+			)
+			VALUES (?, ?, ?, ?, ?)
+		`, id, line.Product.ID, line.ExpectedQuantity, line.ReceivedQuantity, line.UnitCost)
+		if err != nil {
+			return err
+		}
+		if line.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	order.ID = id
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	return nil
+}
+
+func updatePurchaseOrderStatus(ctx context.Context, exec execer, order *domain.PurchaseOrder, now time.Time) error {
+	result, err := exec.ExecContext(ctx, `
+		UPDATE purchase_orders
+		SET status = ?, ordered_at = ?, updated_at = ?
+		WHERE id = ?
+	`, order.Status, order.OrderedAt, now, order.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.PurchaseOrderNotFoundError{PurchaseOrderID: order.ID}
+	}
+
+	order.UpdatedAt = now
+	return nil
+}
+
+// receivePurchaseOrder creates the received units and books them against
+// their order lines. order already carries the new quantities and status;
+// the guards below reject receipts that raced with another one.
+func receivePurchaseOrder(ctx context.Context, tx *sql.Tx, order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string, now time.Time) error {
+	for _, received := range lines {
+		for _, stock := range received.Stocks {
+			stock.StatusChangedAt = now
+			stock.CreatedAt = now
+			stock.UpdatedAt = now
+			if err := insertStock(ctx, tx, stock); err != nil {
+				return err
+			}
+
+			movement := domain.NewStockMovement(domain.MovementCreate, stock.CreatedByUser, nil, stock, reason)
+			movement.CreatedAt = now
+			if err := insertStockMovement(ctx, tx, movement); err != nil {
+				return err
+			}
+		}
+
+		quantity := len(received.Stocks)
+		result, err := tx.ExecContext(ctx, `
+			UPDATE purchase_order_lines
+			SET received_quantity = received_quantity + ?
+			WHERE id = ? AND purchase_order_id = ? AND received_quantity + ? <= expected_quantity
+		`, quantity, received.LineID, order.ID, quantity)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			var pending int64
+			err := tx.QueryRowContext(ctx,
+				"SELECT expected_quantity - received_quantity FROM purchase_order_lines WHERE id = ?",
+				received.LineID,
+			).Scan(&pending)
+			if err != nil {
+				return err
+			}
+			return &domain.OverReceiptError{LineID: received.LineID, Pending: pending}
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE purchase_orders
+		SET status = ?, updated_at = ?
+		WHERE id = ? AND status IN (?, ?)
+	`, order.Status, now, order.ID, domain.PurchaseOrderOrdered, domain.PurchaseOrderPartiallyReceived)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		var current domain.PurchaseOrderStatus
+		if err := tx.QueryRowContext(ctx, "SELECT status FROM purchase_orders WHERE id = ?", order.ID).Scan(&current); err != nil {
+			return err
+		}
+		return &domain.InvalidPurchaseOrderTransitionError{From: current, To: order.Status}
+	}
+
+	order.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositorytest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+)
+
+// testBulkInserts checks that many units and movements can be stored at once,
+// more than fit in a single multi-row insert, and that a serial in use stores
+// none of the units
+func testBulkInserts(t *testing.T, newBackend NewBackend) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s := backend(t, newBackend, stocks, products, users, providers)
+	f := newStockFixture(t, s)
+	deleted := f.stock(f.products[0], f.providers[0], "SN-DELETED", "B0", purchased)
+	mustNot(t, s.Stocks.Create(ctx, deleted))
+	mustNot(t, s.Stocks.Delete(ctx, deleted.ID, f.user.ID))
+
+	var units []*domain.Stock
+	for i := 0; i < 120; i++ {
+		units = append(units, f.stock(f.products[1], f.providers[1], fmt.Sprintf("SN-%03d", i), "B1", purchased))
+	}
+	mustNot(t, s.Stocks.CreateMany(ctx, units))
+
+	t.Run("CreateMany", func(t *testing.T) {
+		ids := map[int64]bool{}
+		for _, unit := range units {
+			if unit.ID == 0 || ids[unit.ID] || unit.Version != 1 || unit.CreatedAt.IsZero() {
+				t.Fatalf("expected a new ID, version and timestamps, got %+v", unit)
+			}
+			ids[unit.ID] = true
+		}
+		for _, unit := range []*domain.Stock{units[0], units[60], units[119]} {
+			got, err := s.Stocks.GetBySerial(ctx, unit.Serial)
+			mustNot(t, err)
+			if got == nil || got.ID != unit.ID || got.Batch != "B1" || got.Product.ID != f.products[1].ID || got.Status != domain.StockAvailable {
+				t.Errorf("expected %s to be stored as unit %d, got %+v", unit.Serial, unit.ID, got)
+			}
+		}
+	})
+
+	t.Run("ExistingSerials", func(t *testing.T) {
+		existing, err := s.Stocks.ExistingSerials(ctx, []string{"SN-NEW", "SN-005", "SN-DELETED", "SN-119"})
+		mustNot(t, err)
+		if want := []string{"SN-005", "SN-DELETED", "SN-119"}; !reflect.DeepEqual(existing, want) {
+			t.Errorf("expected %v, got %v", want, existing)
+		}
+		none, err := s.Stocks.ExistingSerials(ctx, []string{"SN-NEW"})
+		mustNot(t, err)
+		if len(none) != 0 {
+			t.Errorf("expected no existing serial, got %v", none)
+		}
+	})
+
+	t.Run("DuplicateSerial", func(t *testing.T) {
+		batch := []*domain.Stock{
+			f.stock(f.products[1], f.providers[1], "SN-NEW", "B2", purchased),
+			f.stock(f.products[1], f.providers[1], "SN-DELETED", "B2", purchased),
+		}
+		var exists *domain.StockAlreadyExistsError
+		expectError(t, s.Stocks.CreateMany(ctx, batch), &exists)
+		got, err := s.Stocks.GetBySerial(ctx, "SN-NEW")
+		mustNot(t, err)
+		if got != nil {
+			t.Errorf("expected no unit to be stored, got %+v", got)
+		}
+	})
+
+	t.Run("Movements", func(t *testing.T) {
+		if isNil(s.StockMovements) {
+			t.Skip("repository not provided by this backend")
+		}
+		movements := make([]*domain.StockMovement, len(units))
+		for i, unit := range units {
+			movements[i] = domain.NewStockMovement(domain.MovementCreate, f.user, nil, unit, "bulk")
+		}
+		mustNot(t, s.StockMovements.CreateMany(ctx, movements))
+
+		for _, unit := range []*domain.Stock{units[0], units[119]} {
+			history, err := s.StockMovements.GetByStockID(ctx, unit.ID)
+			mustNot(t, err)
+			if len(history) != 1 || history[0].Type != domain.MovementCreate || history[0].Reason != "bulk" || history[0].Serial != unit.Serial {
+				t.Errorf("expected the creation of %s, got %+v", unit.Serial, history)
+			}
+			if len(history) == 1 && (history[0].After == nil || history[0].After.Batch != "B1") {
+				t.Errorf("expected a snapshot of %s, got %+v", unit.Serial, history[0].After)
+			}
+		}
+	})
+}
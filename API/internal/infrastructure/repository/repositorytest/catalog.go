@@ -0,0 +1,324 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"inventario/internal/domain"
+)
+
+func testProducts(t *testing.T, newBackend NewBackend) {
+	productID := func(p *domain.Product) int64 { return p.ID }
+	newProduct := func(name, code string) *domain.Product {
+		return &domain.Product{Name: name, Code: code, TrackingMode: domain.TrackingSerialized, ImageURL: "https://example.com/" + code}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := backend(t, newBackend, products).Products
+
+		first, second := newProduct("laptop", "LPT"), newProduct("monitor", "MON")
+		mustNot(t, repo.Create(ctx, first))
+		mustNot(t, repo.Create(ctx, second))
+		if first.ID == 0 || second.ID <= first.ID {
+			t.Errorf("expected increasing IDs, got %d and %d", first.ID, second.ID)
+		}
+		if first.CreatedAt.IsZero() || first.UpdatedAt.IsZero() {
+			t.Error("expected timestamps to be set")
+		}
+
+		got, err := repo.GetByID(ctx, first.ID)
+		mustNot(t, err)
+		if got == nil || got.Name != "laptop" || got.Code != "LPT" || got.TrackingMode != domain.TrackingSerialized || got.ImageURL != first.ImageURL {
+			t.Errorf("expected stored product, got %+v", got)
+		}
+
+		got, err = repo.GetByID(ctx, second.ID+100)
+		if got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing product, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		repo := backend(t, newBackend, products).Products
+
+		mustNot(t, repo.Create(ctx, newProduct("laptop", "LPT")))
+		other := newProduct("monitor", "MON")
+		mustNot(t, repo.Create(ctx, other))
+
+		var exists *domain.ProductAlreadyExistsError
+		expectError(t, repo.Create(ctx, newProduct("laptop 2", "LPT")), &exists)
+		other.Code = "LPT"
+		expectError(t, repo.Update(ctx, other), &exists)
+
+		count, err := repo.Count(ctx, domain.ProductFilter{})
+		mustNot(t, err)
+		if count != 2 {
+			t.Errorf("expected duplicates not to be stored, got %d products", count)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repo := backend(t, newBackend, products).Products
+
+		product := newProduct("laptop", "LPT")
+		mustNot(t, repo.Create(ctx, product))
+		product.Name = "notebook"
+		product.TrackingMode = domain.TrackingQuantity
+		mustNot(t, repo.Update(ctx, product))
+
+		got, err := repo.GetByID(ctx, product.ID)
+		mustNot(t, err)
+		if got == nil || got.Name != "notebook" || got.TrackingMode != domain.TrackingQuantity {
+			t.Errorf("expected updated product, got %+v", got)
+		}
+
+		var notFound *domain.ProductNotFoundError
+		expectError(t, repo.Update(ctx, &domain.Product{ID: product.ID + 100, Name: "x", Code: "X", TrackingMode: domain.TrackingSerialized}), &notFound)
+
+		mustNot(t, repo.Delete(ctx, product.ID, 0))
+		if got, err := repo.GetByID(ctx, product.ID); got != nil || err != nil {
+			t.Errorf("expected deleted product to be gone, got %+v, %v", got, err)
+		}
+		expectError(t, repo.Delete(ctx, product.ID, 0), &notFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := backend(t, newBackend, products).Products
+
+		var ids []int64
+		for _, p := range []*domain.Product{newProduct("mouse", "MSE"), newProduct("laptop", "LPT"), newProduct("mousepad", "MPD")} {
+			mustNot(t, repo.Create(ctx, p))
+			ids = append(ids, p.ID)
+		}
+		bulk := newProduct("cable", "CBL")
+		bulk.TrackingMode = domain.TrackingQuantity
+		mustNot(t, repo.Create(ctx, bulk))
+		ids = append(ids, bulk.ID)
+
+		list := func(filter domain.ProductFilter) []int64 {
+			t.Helper()
+			got, err := repo.GetAll(ctx, filter)
+			mustNot(t, err)
+			return idsOf(got, productID)
+		}
+
+		expectIDs(t, "default order", list(domain.ProductFilter{}), ids...)
+		expectIDs(t, "sorted by name", list(domain.ProductFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "name"}}}}), ids[3], ids[1], ids[0], ids[2])
+		expectIDs(t, "sorted by id desc", list(domain.ProductFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "id", Desc: true}}}}), ids[3], ids[2], ids[1], ids[0])
+		expectIDs(t, "offset page", list(domain.ProductFilter{ListOptions: domain.ListOptions{Limit: 2, Offset: 1}}), ids[1], ids[2])
+		expectIDs(t, "keyset page", list(domain.ProductFilter{ListOptions: domain.ListOptions{Limit: 2, AfterID: ids[1]}}), ids[2], ids[3])
+		expectIDs(t, "name filter", list(domain.ProductFilter{Name: "MOUSE"}), ids[0], ids[2])
+		expectIDs(t, "code filter", list(domain.ProductFilter{Code: "LPT"}), ids[1])
+		expectIDs(t, "tracking mode filter", list(domain.ProductFilter{TrackingMode: domain.TrackingQuantity}), ids[3])
+		expectIDs(t, "no match", list(domain.ProductFilter{Name: "printer"}))
+
+		count, err := repo.Count(ctx, domain.ProductFilter{Name: "mouse", ListOptions: domain.ListOptions{Limit: 1}})
+		mustNot(t, err)
+		if count != 2 {
+			t.Errorf("expected count to ignore paging, got %d", count)
+		}
+	})
+}
+
+func testUsers(t *testing.T, newBackend NewBackend) {
+	userID := func(u *domain.User) int64 { return u.ID }
+	newUser := func(name, email, role string) *domain.User {
+		return &domain.User{Name: name, Email: email, Password: "hash-" + email, Role: role}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := backend(t, newBackend, users).Users
+
+		user := newUser("ana", "ana@example.com", domain.RoleAdmin)
+		mustNot(t, repo.Create(ctx, user))
+		if user.ID == 0 || user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+			t.Errorf("expected ID and timestamps to be set, got %+v", user)
+		}
+
+		for _, get := range []func() (*domain.User, error){
+			func() (*domain.User, error) { return repo.GetByID(ctx, user.ID) },
+			func() (*domain.User, error) { return repo.GetByEmail(ctx, "ana@example.com") },
+		} {
+			got, err := get()
+			mustNot(t, err)
+			if got == nil || got.ID != user.ID || got.Name != "ana" || got.Role != domain.RoleAdmin || got.Password != "hash-ana@example.com" {
+				t.Errorf("expected stored user, got %+v", got)
+			}
+		}
+
+		if got, err := repo.GetByID(ctx, user.ID+100); got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing user, got %+v, %v", got, err)
+		}
+		if got, err := repo.GetByEmail(ctx, "nobody@example.com"); got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing email, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		repo := backend(t, newBackend, users).Users
+
+		mustNot(t, repo.Create(ctx, newUser("ana", "ana@example.com", domain.RoleAdmin)))
+		other := newUser("bob", "bob@example.com", domain.RoleViewer)
+		mustNot(t, repo.Create(ctx, other))
+
+		var exists *domain.UserAlreadyExistsError
+		expectError(t, repo.Create(ctx, newUser("ana 2", "ana@example.com", domain.RoleViewer)), &exists)
+		other.Email = "ana@example.com"
+		expectError(t, repo.Update(ctx, other), &exists)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repo := backend(t, newBackend, users).Users
+
+		user := newUser("ana", "ana@example.com", domain.RoleViewer)
+		mustNot(t, repo.Create(ctx, user))
+		user.Role = domain.RoleWarehouse
+		user.Password = "new-hash"
+		mustNot(t, repo.Update(ctx, user))
+
+		got, err := repo.GetByID(ctx, user.ID)
+		mustNot(t, err)
+		if got == nil || got.Role != domain.RoleWarehouse || got.Password != "new-hash" {
+			t.Errorf("expected updated user, got %+v", got)
+		}
+
+		var notFound *domain.UserNotFoundError
+		expectError(t, repo.Update(ctx, &domain.User{ID: user.ID + 100, Name: "x", Email: "x@example.com", Role: domain.RoleViewer}), &notFound)
+
+		mustNot(t, repo.Delete(ctx, user.ID, 0))
+		if got, err := repo.GetByID(ctx, user.ID); got != nil || err != nil {
+			t.Errorf("expected deleted user to be gone, got %+v, %v", got, err)
+		}
+		expectError(t, repo.Delete(ctx, user.ID, 0), &notFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := backend(t, newBackend, users).Users
+
+		var ids []int64
+		for _, u := range []*domain.User{
+			newUser("carla", "carla@example.com", domain.RoleViewer),
+			newUser("ana", "ana@example.com", domain.RoleAdmin),
+			newUser("bruno", "bruno@example.com", domain.RoleViewer),
+		} {
+			mustNot(t, repo.Create(ctx, u))
+			ids = append(ids, u.ID)
+		}
+
+		list := func(filter domain.UserFilter) []int64 {
+			t.Helper()
+			got, err := repo.GetAll(ctx, filter)
+			mustNot(t, err)
+			return idsOf(got, userID)
+		}
+
+		expectIDs(t, "default order", list(domain.UserFilter{}), ids...)
+		expectIDs(t, "sorted by email desc", list(domain.UserFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "email", Desc: true}}}}), ids[0], ids[2], ids[1])
+		expectIDs(t, "sorted by role then name", list(domain.UserFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "role"}, {Field: "name"}}}}), ids[1], ids[2], ids[0])
+		expectIDs(t, "keyset page", list(domain.UserFilter{ListOptions: domain.ListOptions{Limit: 1, AfterID: ids[0]}}), ids[1])
+		expectIDs(t, "role filter", list(domain.UserFilter{Role: domain.RoleViewer}), ids[0], ids[2])
+		expectIDs(t, "email filter", list(domain.UserFilter{Email: "ana@example.com"}), ids[1])
+
+		count, err := repo.Count(ctx, domain.UserFilter{Role: domain.RoleViewer})
+		mustNot(t, err)
+		if count != 2 {
+			t.Errorf("expected 2 viewers, got %d", count)
+		}
+	})
+}
+
+func testProviders(t *testing.T, newBackend NewBackend) {
+	providerID := func(p *domain.Provider) int64 { return p.ID }
+	newProvider := func(name, email string) *domain.Provider {
+		return &domain.Provider{Name: name, Email: email, Phone: "555-0100", Address: "Main St 1"}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := backend(t, newBackend, providers).Providers
+
+		provider := newProvider("acme", "sales@acme.example")
+		mustNot(t, repo.Create(ctx, provider))
+		if provider.ID == 0 || provider.CreatedAt.IsZero() || provider.UpdatedAt.IsZero() {
+			t.Errorf("expected ID and timestamps to be set, got %+v", provider)
+		}
+
+		got, err := repo.GetByID(ctx, provider.ID)
+		mustNot(t, err)
+		if got == nil || got.Name != "acme" || got.Email != "sales@acme.example" || got.Phone != "555-0100" || got.Address != "Main St 1" {
+			t.Errorf("expected stored provider, got %+v", got)
+		}
+
+		if got, err := repo.GetByID(ctx, provider.ID+100); got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing provider, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		repo := backend(t, newBackend, providers).Providers
+
+		mustNot(t, repo.Create(ctx, newProvider("acme", "sales@acme.example")))
+		other := newProvider("globex", "sales@globex.example")
+		mustNot(t, repo.Create(ctx, other))
+
+		var exists *domain.ProviderAlreadyExistsError
+		expectError(t, repo.Create(ctx, newProvider("acme 2", "sales@acme.example")), &exists)
+		other.Email = "sales@acme.example"
+		expectError(t, repo.Update(ctx, other), &exists)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repo := backend(t, newBackend, providers).Providers
+
+		provider := newProvider("acme", "sales@acme.example")
+		mustNot(t, repo.Create(ctx, provider))
+		provider.Phone = "555-0199"
+		mustNot(t, repo.Update(ctx, provider))
+
+		got, err := repo.GetByID(ctx, provider.ID)
+		mustNot(t, err)
+		if got == nil || got.Phone != "555-0199" {
+			t.Errorf("expected updated provider, got %+v", got)
+		}
+
+		var notFound *domain.ProviderNotFoundError
+		expectError(t, repo.Update(ctx, &domain.Provider{ID: provider.ID + 100, Name: "x", Email: "x@example.com"}), &notFound)
+
+		mustNot(t, repo.Delete(ctx, provider.ID, 0))
+		if got, err := repo.GetByID(ctx, provider.ID); got != nil || err != nil {
+			t.Errorf("expected deleted provider to be gone, got %+v, %v", got, err)
+		}
+		expectError(t, repo.Delete(ctx, provider.ID, 0), &notFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := backend(t, newBackend, providers).Providers
+
+		var ids []int64
+		for _, p := range []*domain.Provider{
+			newProvider("initech", "info@initech.example"),
+			newProvider("acme", "sales@acme.example"),
+			newProvider("acme labs", "labs@acme.example"),
+		} {
+			mustNot(t, repo.Create(ctx, p))
+			ids = append(ids, p.ID)
+		}
+
+		list := func(filter domain.ProviderFilter) []int64 {
+			t.Helper()
+			got, err := repo.GetAll(ctx, filter)
+			mustNot(t, err)
+			return idsOf(got, providerID)
+		}
+
+		expectIDs(t, "default order", list(domain.ProviderFilter{}), ids...)
+		expectIDs(t, "sorted by name desc", list(domain.ProviderFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "name", Desc: true}}}}), ids[0], ids[2], ids[1])
+		expectIDs(t, "keyset page desc", list(domain.ProviderFilter{ListOptions: domain.ListOptions{AfterID: ids[2], Sort: []domain.SortField{{Field: "id", Desc: true}}}}), ids[1], ids[0])
+		expectIDs(t, "name filter", list(domain.ProviderFilter{Name: "acme"}), ids[1], ids[2])
+		expectIDs(t, "past the last page", list(domain.ProviderFilter{ListOptions: domain.ListOptions{Limit: 2, Offset: 3}}))
+
+		count, err := repo.Count(ctx, domain.ProviderFilter{Name: "acme"})
+		mustNot(t, err)
+		if count != 2 {
+			t.Errorf("expected 2 matching providers, got %d", count)
+		}
+	})
+}
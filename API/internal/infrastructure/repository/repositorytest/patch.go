@@ -0,0 +1,125 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+)
+
+// testPatches checks that patches of products, users, providers and units
+// save only the fields they name, as of the version they are based on
+func testPatches(t *testing.T, newBackend NewBackend) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s := backend(t, newBackend, stocks, products, users, providers)
+	f := newStockFixture(t, s)
+	admin := &domain.User{Name: "root", Email: "root@example.com", Password: "secret-hash", Role: domain.RoleAdmin}
+	mustNot(t, s.Users.Create(ctx, admin))
+	stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", purchased)
+	mustNot(t, s.Stocks.Create(ctx, stock))
+	mustNot(t, s.Stocks.Create(ctx, f.stock(f.products[0], f.providers[0], "SN-2", "B1", purchased)))
+
+	expectStale := func(t *testing.T, err error, entity string) {
+		t.Helper()
+		var mismatch *domain.VersionMismatchError
+		expectError(t, err, &mismatch)
+		if mismatch.Entity != entity {
+			t.Errorf("expected a stale %s, got %+v", entity, mismatch)
+		}
+	}
+	expectRejected := func(t *testing.T, err error) {
+		t.Helper()
+		if err == nil || errors.As(err, new(*domain.VersionMismatchError)) {
+			t.Errorf("expected an unknown field to be rejected, got %v", err)
+		}
+	}
+
+	t.Run("product", func(t *testing.T) {
+		product, err := s.Products.GetByID(ctx, f.products[0].ID)
+		mustNot(t, err)
+		product.Name, product.Code = "laptop", "IGNORED"
+		mustNot(t, s.Products.Patch(ctx, product, []string{"name"}))
+		if product.Version != 2 {
+			t.Errorf("expected the patch to report version 2, got %d", product.Version)
+		}
+		got, err := s.Products.GetByID(ctx, product.ID)
+		mustNot(t, err)
+		if got.Name != "laptop" || got.Code != "LPT" || got.Version != 2 {
+			t.Errorf("expected only the name to be saved, got %+v", got)
+		}
+
+		got.Code = "MON"
+		var exists *domain.ProductAlreadyExistsError
+		expectError(t, s.Products.Patch(ctx, got, []string{"code"}), &exists)
+		got.Version = 1
+		expectStale(t, s.Products.Patch(ctx, got, []string{"name"}), "product")
+		got.Version = 2
+		expectRejected(t, s.Products.Patch(ctx, got, []string{"created_at"}))
+	})
+
+	t.Run("provider", func(t *testing.T) {
+		provider, err := s.Providers.GetByID(ctx, f.providers[0].ID)
+		mustNot(t, err)
+		provider.Phone, provider.Name = "555-0100", "IGNORED"
+		mustNot(t, s.Providers.Patch(ctx, provider, []string{"phone"}))
+		got, err := s.Providers.GetByID(ctx, provider.ID)
+		mustNot(t, err)
+		if got.Phone != "555-0100" || got.Name != "acme" || got.Version != 2 {
+			t.Errorf("expected only the phone to be saved, got %+v", got)
+		}
+
+		got.Email = f.providers[1].Email
+		var exists *domain.ProviderAlreadyExistsError
+		expectError(t, s.Providers.Patch(ctx, got, []string{"email"}), &exists)
+		got.Version = 1
+		expectStale(t, s.Providers.Patch(ctx, got, []string{"phone"}), "provider")
+		got.Version = 2
+		expectRejected(t, s.Providers.Patch(ctx, got, []string{"id"}))
+	})
+
+	t.Run("user", func(t *testing.T) {
+		user, err := s.Users.GetByID(ctx, f.user.ID)
+		mustNot(t, err)
+		user.Role, user.Name = domain.RoleViewer, "IGNORED"
+		mustNot(t, s.Users.Patch(ctx, user, []string{"role"}))
+		got, err := s.Users.GetByID(ctx, user.ID)
+		mustNot(t, err)
+		if got.Role != domain.RoleViewer || got.Name != "ana" || got.Password != "secret-hash" || got.Version != 2 {
+			t.Errorf("expected only the role to be saved, got %+v", got)
+		}
+
+		got.Email = admin.Email
+		var exists *domain.UserAlreadyExistsError
+		expectError(t, s.Users.Patch(ctx, got, []string{"email"}), &exists)
+		got.Version = 1
+		expectStale(t, s.Users.Patch(ctx, got, []string{"role"}), "user")
+		got.Version = 2
+		expectRejected(t, s.Users.Patch(ctx, got, []string{"version"}))
+	})
+
+	t.Run("stock", func(t *testing.T) {
+		unit, err := s.Stocks.GetByID(ctx, stock.ID)
+		mustNot(t, err)
+		unit.Batch, unit.Provider, unit.Serial = "B2", f.providers[1], "IGNORED"
+		unit.UpdatedByUser = admin
+		mustNot(t, s.Stocks.Patch(ctx, unit, []string{"batch", "provider_id"}))
+		got, err := s.Stocks.GetByID(ctx, stock.ID)
+		mustNot(t, err)
+		if got.Batch != "B2" || got.Provider.ID != f.providers[1].ID || got.Serial != "SN-1" || !got.PurchaseDate.Equal(purchased) {
+			t.Errorf("expected only the batch and provider to be saved, got %+v", got)
+		}
+		if got.UpdatedByUser.ID != admin.ID || got.Version != 2 {
+			t.Errorf("expected the patch to be recorded as by %d at version 2, got %d at %d", admin.ID, got.UpdatedByUser.ID, got.Version)
+		}
+
+		got.Serial = "SN-2"
+		var exists *domain.StockAlreadyExistsError
+		expectError(t, s.Stocks.Patch(ctx, got, []string{"serial"}), &exists)
+		got.Version = 1
+		expectStale(t, s.Stocks.Patch(ctx, got, []string{"batch"}), "stock")
+		got.Version = 2
+		expectRejected(t, s.Stocks.Patch(ctx, got, []string{"status"}))
+	})
+}
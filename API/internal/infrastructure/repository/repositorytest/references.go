@@ -0,0 +1,107 @@
+package repositorytest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+)
+
+// testReferences checks the foreign keys, which only the SQL backends enforce
+func testReferences(t *testing.T, newBackend NewBackend) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s := backend(t, newBackend, stocks, products, users, providers, locations, warehouses)
+	if s.DB == nil {
+		t.Skip("references are not enforced by this backend")
+	}
+	f := newStockFixture(t, s)
+	wh := newWarehouses(t, s, "WH-A")
+	location := &domain.Location{Warehouse: wh[0], Code: "R1"}
+	mustNot(t, s.Locations.Create(ctx, location))
+
+	var missing *domain.ForeignKeyError
+	orphan := f.stock(&domain.Product{ID: f.products[1].ID + 100}, f.providers[0], "SN-1", "B1", purchased)
+	expectError(t, s.Stocks.Create(ctx, orphan), &missing)
+	if missing.InUse || missing.Entity != "stock" {
+		t.Errorf("expected a missing reference of a stock, got %+v", missing)
+	}
+	expectError(t, s.Locations.Create(ctx, &domain.Location{Warehouse: &domain.Warehouse{ID: wh[0].ID + 100}, Code: "R2"}), &missing)
+
+	stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", purchased)
+	stock.Location = location
+	mustNot(t, s.Stocks.Create(ctx, stock))
+	stock.Provider = &domain.Provider{ID: f.providers[1].ID + 100}
+	expectError(t, s.Stocks.Update(ctx, stock), &missing)
+
+	for _, deleted := range []struct {
+		entity string
+		id     int64
+		delete func(int64) error
+	}{
+		{"location", location.ID, func(id int64) error { return s.Locations.Delete(ctx, id) }},
+		{"warehouse", wh[0].ID, func(id int64) error { return s.Warehouses.Delete(ctx, id) }},
+	} {
+		var inUse *domain.ForeignKeyError
+		expectError(t, deleted.delete(deleted.id), &inUse)
+		if !inUse.InUse || inUse.Entity != deleted.entity || inUse.ID != deleted.id {
+			t.Errorf("expected %s %d to be in use, got %+v", deleted.entity, deleted.id, inUse)
+		}
+	}
+
+	// Dependents lists what a product, provider or user is still used by
+	for _, d := range []struct {
+		entity     string
+		dependents func(int64) ([]domain.Dependent, error)
+		id         int64
+	}{
+		{"product", func(id int64) ([]domain.Dependent, error) { return s.Products.Dependents(ctx, id) }, f.products[0].ID},
+		{"provider", func(id int64) ([]domain.Dependent, error) { return s.Providers.Dependents(ctx, id) }, f.providers[0].ID},
+		{"user", func(id int64) ([]domain.Dependent, error) { return s.Users.Dependents(ctx, id) }, f.user.ID},
+	} {
+		got, err := d.dependents(d.id)
+		mustNot(t, err)
+		if !reflect.DeepEqual(got, []domain.Dependent{{Table: "stocks", Count: 1}}) {
+			t.Errorf("expected the unit to depend on %s %d, got %+v", d.entity, d.id, got)
+		}
+	}
+	if got, err := s.Products.Dependents(ctx, f.products[1].ID); err != nil || len(got) != 0 {
+		t.Errorf("expected no dependents, got %+v, %v", got, err)
+	}
+
+	// Soft deletion leaves the references alone, while a purge keeps the rows
+	// still referred to until their units are purged too
+	later := time.Now().Add(time.Hour)
+	mustNot(t, s.Products.Delete(ctx, f.products[0].ID, f.user.ID))
+	mustNot(t, s.Products.Delete(ctx, f.products[1].ID, f.user.ID))
+	mustNot(t, s.Providers.Delete(ctx, f.providers[0].ID, f.user.ID))
+	mustNot(t, s.Users.Delete(ctx, f.user.ID, 0))
+	for _, p := range []struct {
+		table string
+		purge func(time.Time) (int64, error)
+		want  int64
+	}{
+		{"products", func(before time.Time) (int64, error) { return s.Products.Purge(ctx, before) }, 1},
+		{"providers", func(before time.Time) (int64, error) { return s.Providers.Purge(ctx, before) }, 0},
+		{"users", func(before time.Time) (int64, error) { return s.Users.Purge(ctx, before) }, 0},
+	} {
+		if got, err := p.purge(later); err != nil || got != p.want {
+			t.Errorf("expected %d %s purged, got %d, %v", p.want, p.table, got, err)
+		}
+	}
+	if got, err := s.Products.GetByID(ctx, f.products[0].ID); got != nil || err != nil {
+		t.Errorf("expected the referenced product to stay deleted, got %+v, %v", got, err)
+	}
+	mustNot(t, s.Products.Restore(ctx, f.products[0].ID))
+	var notFound *domain.ProductNotFoundError
+	expectError(t, s.Products.Restore(ctx, f.products[1].ID), &notFound)
+
+	mustNot(t, s.Stocks.Delete(ctx, stock.ID, 0))
+	if got, err := s.Stocks.Purge(ctx, later); err != nil || got != 1 {
+		t.Errorf("expected the unit to be purged, got %d, %v", got, err)
+	}
+	if got, err := s.Providers.Purge(ctx, later); err != nil || got != 1 {
+		t.Errorf("expected the provider to be purged with its unit, got %d, %v", got, err)
+	}
+}
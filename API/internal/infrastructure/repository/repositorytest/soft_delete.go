@@ -0,0 +1,181 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+)
+
+// testSoftDelete checks that deleted products, users, providers and units are
+// hidden from reads, listed on request, restorable and purged in time
+func testSoftDelete(t *testing.T, newBackend NewBackend) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s := backend(t, newBackend, stocks, products, users, providers)
+	f := newStockFixture(t, s)
+	stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", purchased)
+	mustNot(t, s.Stocks.Create(ctx, stock))
+	admin := &domain.User{Name: "root", Email: "root@example.com", Password: "secret-hash", Role: domain.RoleAdmin}
+	mustNot(t, s.Users.Create(ctx, admin))
+
+	entities := []struct {
+		entity  string
+		id      int64
+		delete  func(int64) error
+		restore func(int64) error
+		purge   func(time.Time) (int64, error)
+		// live reports whether the row is returned by the default reads
+		live func(int64) (bool, error)
+		// listed returns the deletion of the row as listed with the
+		// deleted rows included, and whether it was listed at all
+		listed func(int64) (domain.SoftDeleted, bool, error)
+	}{
+		{
+			entity:  "stock",
+			id:      stock.ID,
+			delete:  func(id int64) error { return s.Stocks.Delete(ctx, id, admin.ID) },
+			restore: func(id int64) error { return s.Stocks.Restore(ctx, id) },
+			purge:   func(before time.Time) (int64, error) { return s.Stocks.Purge(ctx, before) },
+			live: func(id int64) (bool, error) {
+				byID, err := s.Stocks.GetByID(ctx, id)
+				if err != nil {
+					return false, err
+				}
+				bySerial, err := s.Stocks.GetBySerial(ctx, "SN-1")
+				if err != nil {
+					return false, err
+				}
+				count, err := s.Stocks.Count(ctx, domain.StockFilter{})
+				return byID != nil && bySerial != nil && count == 1, err
+			},
+			listed: func(id int64) (domain.SoftDeleted, bool, error) {
+				list, err := s.Stocks.GetAll(ctx, domain.StockFilter{IncludeDeleted: true})
+				for _, item := range list {
+					if item.ID == id {
+						return item.SoftDeleted, true, err
+					}
+				}
+				return domain.SoftDeleted{}, false, err
+			},
+		},
+		{
+			entity:  "product",
+			id:      f.products[1].ID,
+			delete:  func(id int64) error { return s.Products.Delete(ctx, id, admin.ID) },
+			restore: func(id int64) error { return s.Products.Restore(ctx, id) },
+			purge:   func(before time.Time) (int64, error) { return s.Products.Purge(ctx, before) },
+			live: func(id int64) (bool, error) {
+				got, err := s.Products.GetByID(ctx, id)
+				if err != nil {
+					return false, err
+				}
+				count, err := s.Products.Count(ctx, domain.ProductFilter{})
+				return got != nil && count == 2, err
+			},
+			listed: func(id int64) (domain.SoftDeleted, bool, error) {
+				list, err := s.Products.GetAll(ctx, domain.ProductFilter{IncludeDeleted: true})
+				for _, item := range list {
+					if item.ID == id {
+						return item.SoftDeleted, true, err
+					}
+				}
+				return domain.SoftDeleted{}, false, err
+			},
+		},
+		{
+			entity:  "provider",
+			id:      f.providers[1].ID,
+			delete:  func(id int64) error { return s.Providers.Delete(ctx, id, admin.ID) },
+			restore: func(id int64) error { return s.Providers.Restore(ctx, id) },
+			purge:   func(before time.Time) (int64, error) { return s.Providers.Purge(ctx, before) },
+			live: func(id int64) (bool, error) {
+				got, err := s.Providers.GetByID(ctx, id)
+				if err != nil {
+					return false, err
+				}
+				count, err := s.Providers.Count(ctx, domain.ProviderFilter{})
+				return got != nil && count == 2, err
+			},
+			listed: func(id int64) (domain.SoftDeleted, bool, error) {
+				list, err := s.Providers.GetAll(ctx, domain.ProviderFilter{IncludeDeleted: true})
+				for _, item := range list {
+					if item.ID == id {
+						return item.SoftDeleted, true, err
+					}
+				}
+				return domain.SoftDeleted{}, false, err
+			},
+		},
+		{
+			entity:  "user",
+			id:      f.user.ID,
+			delete:  func(id int64) error { return s.Users.Delete(ctx, id, admin.ID) },
+			restore: func(id int64) error { return s.Users.Restore(ctx, id) },
+			purge:   func(before time.Time) (int64, error) { return s.Users.Purge(ctx, before) },
+			live: func(id int64) (bool, error) {
+				byID, err := s.Users.GetByID(ctx, id)
+				if err != nil {
+					return false, err
+				}
+				byEmail, err := s.Users.GetByEmail(ctx, f.user.Email)
+				if err != nil {
+					return false, err
+				}
+				count, err := s.Users.Count(ctx, domain.UserFilter{})
+				return byID != nil && byEmail != nil && count == 2, err
+			},
+			listed: func(id int64) (domain.SoftDeleted, bool, error) {
+				list, err := s.Users.GetAll(ctx, domain.UserFilter{IncludeDeleted: true})
+				for _, item := range list {
+					if item.ID == id {
+						return item.SoftDeleted, true, err
+					}
+				}
+				return domain.SoftDeleted{}, false, err
+			},
+		},
+	}
+
+	for _, e := range entities {
+		t.Run(e.entity, func(t *testing.T) {
+			expectNotFound := func(err error) {
+				t.Helper()
+				if err == nil || !errors.As(err, new(*domain.StockNotFoundError)) && !errors.As(err, new(*domain.ProductNotFoundError)) &&
+					!errors.As(err, new(*domain.ProviderNotFoundError)) && !errors.As(err, new(*domain.UserNotFoundError)) {
+					t.Errorf("expected the %s not to be found, got %v", e.entity, err)
+				}
+			}
+
+			expectNotFound(e.restore(e.id))
+			mustNot(t, e.delete(e.id))
+			if live, err := e.live(e.id); err != nil || live {
+				t.Errorf("expected the deleted %s to be hidden, got %v, %v", e.entity, live, err)
+			}
+			deletion, listed, err := e.listed(e.id)
+			mustNot(t, err)
+			if !listed || !deletion.IsDeleted() || deletion.DeletedBy == nil || *deletion.DeletedBy != admin.ID {
+				t.Errorf("expected the deleted %s to be listed as deleted by %d, got %v, %+v", e.entity, admin.ID, listed, deletion)
+			}
+			expectNotFound(e.delete(e.id))
+
+			mustNot(t, e.restore(e.id))
+			if live, err := e.live(e.id); err != nil || !live {
+				t.Errorf("expected the restored %s to be back, got %v, %v", e.entity, live, err)
+			}
+			if deletion, _, err := e.listed(e.id); err != nil || deletion.IsDeleted() {
+				t.Errorf("expected the restored %s not to be deleted, got %+v, %v", e.entity, deletion, err)
+			}
+
+			mustNot(t, e.delete(e.id))
+			if purged, err := e.purge(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+				t.Errorf("expected a recent deletion to be kept, got %d, %v", purged, err)
+			}
+			if purged, err := e.purge(time.Now().Add(time.Hour)); err != nil || purged != 1 {
+				t.Errorf("expected the %s to be purged, got %d, %v", e.entity, purged, err)
+			}
+			expectNotFound(e.restore(e.id))
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/storage"
+)
+
+// stockFixture holds the rows a stock unit refers to
+type stockFixture struct {
+	products  []*domain.Product
+	user      *domain.User
+	providers []*domain.Provider
+}
+
+func newStockFixture(t *testing.T, s *storage.Storage) *stockFixture {
+	t.Helper()
+	f := &stockFixture{user: &domain.User{Name: "ana", Email: "ana@example.com", Password: "secret-hash", Role: domain.RoleWarehouse}}
+	mustNot(t, s.Users.Create(ctx, f.user))
+	for _, code := range []string{"LPT", "MON"} {
+		product := &domain.Product{Name: "product " + code, Code: code, TrackingMode: domain.TrackingSerialized}
+		mustNot(t, s.Products.Create(ctx, product))
+		f.products = append(f.products, product)
+	}
+	for _, name := range []string{"acme", "globex"} {
+		provider := &domain.Provider{Name: name, Email: "sales@" + name + ".example"}
+		mustNot(t, s.Providers.Create(ctx, provider))
+		f.providers = append(f.providers, provider)
+	}
+	return f
+}
+
+func (f *stockFixture) stock(product *domain.Product, provider *domain.Provider, serial, batch string, purchased time.Time) *domain.Stock {
+	return &domain.Stock{
+		Product:       product,
+		Serial:        serial,
+		CreatedByUser: f.user,
+		UpdatedByUser: f.user,
+		Batch:         batch,
+		PurchaseDate:  purchased,
+		Provider:      provider,
+	}
+}
+
+func testStocks(t *testing.T, newBackend NewBackend) {
+	stockID := func(s *domain.Stock) int64 { return s.ID }
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := backend(t, newBackend, stocks, products, users, providers)
+		f := newStockFixture(t, s)
+
+		stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", day(5))
+		mustNot(t, s.Stocks.Create(ctx, stock))
+		if stock.ID == 0 || stock.CreatedAt.IsZero() || stock.UpdatedAt.IsZero() || stock.StatusChangedAt.IsZero() {
+			t.Errorf("expected ID and timestamps to be set, got %+v", stock)
+		}
+		if stock.Status != domain.StockAvailable {
+			t.Errorf("expected new units to be available, got %q", stock.Status)
+		}
+
+		for _, get := range []func() (*domain.Stock, error){
+			func() (*domain.Stock, error) { return s.Stocks.GetByID(ctx, stock.ID) },
+			func() (*domain.Stock, error) { return s.Stocks.GetBySerial(ctx, "SN-1") },
+		} {
+			got, err := get()
+			mustNot(t, err)
+			if got == nil {
+				t.Fatal("expected stored unit, got nil")
+			}
+			if got.ID != stock.ID || got.Serial != "SN-1" || got.Batch != "B1" || got.Status != domain.StockAvailable {
+				t.Errorf("expected stored unit, got %+v", got)
+			}
+			if got.PurchaseDate.UTC().Format("2006-01-02") != "2024-03-05" {
+				t.Errorf("expected purchase date 2024-03-05, got %v", got.PurchaseDate)
+			}
+			if got.Product == nil || got.Product.ID != f.products[0].ID || got.Product.Code != "LPT" {
+				t.Errorf("expected joined product, got %+v", got.Product)
+			}
+			if got.Provider == nil || got.Provider.ID != f.providers[0].ID || got.Provider.Name != "acme" {
+				t.Errorf("expected joined provider, got %+v", got.Provider)
+			}
+			if got.CreatedByUser == nil || got.CreatedByUser.ID != f.user.ID || got.UpdatedByUser == nil || got.UpdatedByUser.ID != f.user.ID {
+				t.Errorf("expected audit users, got %+v and %+v", got.CreatedByUser, got.UpdatedByUser)
+			}
+			if got.CreatedByUser != nil && got.CreatedByUser.Password != "" {
+				t.Error("expected audit users to carry no password")
+			}
+			if got.Location != nil {
+				t.Errorf("expected no location, got %+v", got.Location)
+			}
+		}
+
+		if got, err := s.Stocks.GetByID(ctx, stock.ID+100); got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing unit, got %+v, %v", got, err)
+		}
+		if got, err := s.Stocks.GetBySerial(ctx, "SN-404"); got != nil || err != nil {
+			t.Errorf("expected nil, nil for a missing serial, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s := backend(t, newBackend, stocks, products, users, providers)
+		f := newStockFixture(t, s)
+
+		mustNot(t, s.Stocks.Create(ctx, f.stock(f.products[0], f.providers[0], "SN-1", "B1", day(5))))
+		other := f.stock(f.products[0], f.providers[0], "SN-2", "B1", day(5))
+		mustNot(t, s.Stocks.Create(ctx, other))
+
+		var exists *domain.StockAlreadyExistsError
+		expectError(t, s.Stocks.Create(ctx, f.stock(f.products[1], f.providers[1], "SN-1", "B2", day(6))), &exists)
+		other.Serial = "SN-1"
+		expectError(t, s.Stocks.Update(ctx, other), &exists)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		s := backend(t, newBackend, stocks, products, users, providers)
+		f := newStockFixture(t, s)
+
+		stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", day(5))
+		mustNot(t, s.Stocks.Create(ctx, stock))
+		stock.Product = f.products[1]
+		stock.Provider = f.providers[1]
+		stock.Batch = "B2"
+		stock.PurchaseDate = day(7)
+		mustNot(t, s.Stocks.Update(ctx, stock))
+
+		got, err := s.Stocks.GetByID(ctx, stock.ID)
+		mustNot(t, err)
+		if got == nil || got.Product.ID != f.products[1].ID || got.Provider.ID != f.providers[1].ID || got.Batch != "B2" ||
+			got.PurchaseDate.UTC().Format("2006-01-02") != "2024-03-07" {
+			t.Errorf("expected updated unit, got %+v", got)
+		}
+		if got != nil && got.Status != domain.StockAvailable {
+			t.Errorf("expected Update to keep the status, got %q", got.Status)
+		}
+
+		stock.Status = domain.StockReserved
+		mustNot(t, s.Stocks.UpdateStatus(ctx, stock))
+		if got, err := s.Stocks.GetByID(ctx, stock.ID); err != nil || got == nil || got.Status != domain.StockReserved {
+			t.Errorf("expected reserved unit, got %+v, %v", got, err)
+		}
+
+		var notFound *domain.StockNotFoundError
+		missing := f.stock(f.products[0], f.providers[0], "SN-404", "B1", day(5))
+		missing.ID = stock.ID + 100
+		expectError(t, s.Stocks.Update(ctx, missing), &notFound)
+		missing.Status = domain.StockSold
+		expectError(t, s.Stocks.UpdateStatus(ctx, missing), &notFound)
+
+		mustNot(t, s.Stocks.Delete(ctx, stock.ID, f.user.ID))
+		if got, err := s.Stocks.GetByID(ctx, stock.ID); got != nil || err != nil {
+			t.Errorf("expected deleted unit to be gone, got %+v, %v", got, err)
+		}
+		expectError(t, s.Stocks.Delete(ctx, stock.ID, f.user.ID), &notFound)
+	})
+
+	t.Run("Reassign", func(t *testing.T) {
+		s := backend(t, newBackend, stocks, products, users, providers, stockMovements)
+		f := newStockFixture(t, s)
+		other := &domain.User{Name: "bea", Email: "bea@example.com", Password: "secret-hash", Role: domain.RoleWarehouse}
+		mustNot(t, s.Users.Create(ctx, other))
+
+		first := f.stock(f.products[0], f.providers[0], "SN-1", "B1", day(5))
+		second := f.stock(f.products[1], f.providers[0], "SN-2", "B1", day(5))
+		second.UpdatedByUser = other
+		for _, stock := range []*domain.Stock{first, second} {
+			mustNot(t, s.Stocks.Create(ctx, stock))
+		}
+
+		for _, r := range []struct {
+			ref      domain.StockReference
+			from, to int64
+			want     int64
+		}{
+			{domain.StockProductReference, f.products[0].ID, f.products[1].ID, 1},
+			{domain.StockProviderReference, f.providers[0].ID, f.providers[1].ID, 2},
+			// Only the last editor is reassigned, never the creator
+			{domain.StockUserReference, other.ID, f.user.ID, 1},
+			{domain.StockUserReference, f.user.ID, other.ID, 2},
+			{domain.StockProductReference, f.products[0].ID, f.products[1].ID, 0},
+		} {
+			changed, err := s.Stocks.Reassign(ctx, r.ref, r.from, r.to, f.user, "reassigned")
+			mustNot(t, err)
+			if changed != r.want {
+				t.Errorf("reassigning %s %d: expected %d units, got %d", r.ref, r.from, r.want, changed)
+			}
+		}
+
+		for _, stock := range []*domain.Stock{first, second} {
+			got, err := s.Stocks.GetByID(ctx, stock.ID)
+			mustNot(t, err)
+			if got.Product.ID != f.products[1].ID || got.Provider.ID != f.providers[1].ID ||
+				got.CreatedByUser.ID != f.user.ID || got.UpdatedByUser.ID != other.ID {
+				t.Errorf("expected references but the creator to be moved, got %+v", got)
+			}
+
+			// Each unit has one update per reassignment that changed it
+			history, err := s.StockMovements.GetByStockID(ctx, stock.ID)
+			mustNot(t, err)
+			if len(history) != 3 {
+				t.Fatalf("expected 3 movements of unit %d, got %+v", stock.ID, history)
+			}
+			for _, movement := range history {
+				if movement.Type != domain.MovementUpdate || movement.Reason != "reassigned" ||
+					movement.Actor == nil || movement.Actor.ID != f.user.ID {
+					t.Errorf("expected an update by %d, got %+v", f.user.ID, movement)
+				}
+			}
+		}
+		history, err := s.StockMovements.GetByStockID(ctx, first.ID)
+		mustNot(t, err)
+		if before, after := history[0].Before, history[0].After; before == nil || after == nil ||
+			before.ProductID != f.products[0].ID || after.ProductID != f.products[1].ID {
+			t.Errorf("expected the product change in the ledger, got %+v", history[0])
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		s := backend(t, newBackend, stocks, products, users, providers)
+		f := newStockFixture(t, s)
+
+		var ids []int64
+		for _, stock := range []*domain.Stock{
+			f.stock(f.products[0], f.providers[0], "SN-C", "B1", day(1)),
+			f.stock(f.products[1], f.providers[0], "SN-A", "B2", day(10)),
+			f.stock(f.products[0], f.providers[1], "SN-B", "B1", day(20)),
+		} {
+			mustNot(t, s.Stocks.Create(ctx, stock))
+			ids = append(ids, stock.ID)
+		}
+		sold := &domain.Stock{ID: ids[2], Version: 1, Status: domain.StockSold, UpdatedByUser: f.user}
+		mustNot(t, s.Stocks.UpdateStatus(ctx, sold))
+
+		list := func(filter domain.StockFilter) []int64 {
+			t.Helper()
+			got, err := s.Stocks.GetAll(ctx, filter)
+			mustNot(t, err)
+			return idsOf(got, stockID)
+		}
+
+		expectIDs(t, "default order", list(domain.StockFilter{}), ids...)
+		expectIDs(t, "sorted by serial", list(domain.StockFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "serial"}}}}), ids[1], ids[2], ids[0])
+		expectIDs(t, "sorted by purchase date desc", list(domain.StockFilter{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "purchase_date", Desc: true}}}}), ids[2], ids[1], ids[0])
+		expectIDs(t, "offset page", list(domain.StockFilter{ListOptions: domain.ListOptions{Limit: 1, Offset: 2}}), ids[2])
+		expectIDs(t, "product filter", list(domain.StockFilter{ProductID: f.products[0].ID}), ids[0], ids[2])
+		expectIDs(t, "provider filter", list(domain.StockFilter{ProviderID: f.providers[1].ID}), ids[2])
+		expectIDs(t, "status filter", list(domain.StockFilter{Status: domain.StockSold}), ids[2])
+		expectIDs(t, "batch filter", list(domain.StockFilter{Batch: "B1"}), ids[0], ids[2])
+		expectIDs(t, "purchase date range", list(domain.StockFilter{PurchasedAfter: day(10), PurchasedBefore: day(20)}), ids[1], ids[2])
+
+		byProduct, err := s.Stocks.GetByProductID(ctx, f.products[0].ID, domain.StockFilter{Status: domain.StockAvailable})
+		mustNot(t, err)
+		expectIDs(t, "by product", idsOf(byProduct, stockID), ids[0])
+
+		count, err := s.Stocks.Count(ctx, domain.StockFilter{Batch: "B1", ListOptions: domain.ListOptions{Limit: 1}})
+		mustNot(t, err)
+		if count != 2 {
+			t.Errorf("expected count to ignore paging, got %d", count)
+		}
+
+		onHand, err := s.Stocks.OnHandByProduct(ctx, domain.StockBalanceFilter{})
+		mustNot(t, err)
+		if len(onHand) != 2 || onHand[0].Product.ID != f.products[0].ID || onHand[0].Quantity != 1 ||
+			onHand[1].Product.ID != f.products[1].ID || onHand[1].Quantity != 1 {
+			t.Errorf("expected one unit on hand of each product, got %+v", onHand)
+		}
+
+		counts, err := s.Stocks.CountByLocation(ctx, f.products[0].ID)
+		mustNot(t, err)
+		if len(counts) != 1 || counts[0].Location != nil || counts[0].Quantity != 1 {
+			t.Errorf("expected one unit not put away, got %+v", counts)
+		}
+	})
+}
+
+func testStockMovements(t *testing.T, newBackend NewBackend) {
+	movementID := func(m *domain.StockMovement) int64 { return m.ID }
+
+	s := backend(t, newBackend, stockMovements, users)
+	actor := &domain.User{Name: "ana", Email: "ana@example.com", Password: "x", Role: domain.RoleWarehouse}
+	mustNot(t, s.Users.Create(ctx, actor))
+
+	unit := &domain.Stock{ID: 7, Serial: "SN-1", Status: domain.StockAvailable, Product: &domain.Product{ID: 1}, Provider: &domain.Provider{ID: 1}}
+	moved := *unit
+	moved.Status = domain.StockSold
+	// A later unit reuses the serial of a deleted one
+	reused := &domain.Stock{ID: 9, Serial: "SN-1", Status: domain.StockAvailable, Product: &domain.Product{ID: 1}, Provider: &domain.Provider{ID: 1}}
+
+	var ids []int64
+	for _, m := range []*domain.StockMovement{
+		domain.NewStockMovement(domain.MovementCreate, actor, nil, unit, ""),
+		domain.NewStockMovement(domain.MovementTransition, actor, unit, &moved, "sold"),
+		domain.NewStockMovement(domain.MovementDelete, actor, &moved, nil, "cleanup"),
+		domain.NewStockMovement(domain.MovementCreate, actor, nil, reused, ""),
+	} {
+		mustNot(t, s.StockMovements.Create(ctx, m))
+		if m.ID == 0 {
+			t.Fatal("expected movement ID to be set")
+		}
+		ids = append(ids, m.ID)
+	}
+
+	byStock, err := s.StockMovements.GetByStockID(ctx, 7)
+	mustNot(t, err)
+	expectIDs(t, "by stock", idsOf(byStock, movementID), ids[0], ids[1], ids[2])
+	if len(byStock) == 3 {
+		transition := byStock[1]
+		if transition.Type != domain.MovementTransition || transition.Reason != "sold" || transition.Serial != "SN-1" {
+			t.Errorf("expected stored transition, got %+v", transition)
+		}
+		if transition.Actor == nil || transition.Actor.ID != actor.ID {
+			t.Errorf("expected actor %d, got %+v", actor.ID, transition.Actor)
+		}
+		if transition.Before == nil || transition.Before.Status != domain.StockAvailable || transition.After == nil || transition.After.Status != domain.StockSold {
+			t.Errorf("expected snapshots, got %+v and %+v", transition.Before, transition.After)
+		}
+		if byStock[2].After != nil {
+			t.Errorf("expected no snapshot after a delete, got %+v", byStock[2].After)
+		}
+	}
+
+	bySerial, err := s.StockMovements.GetBySerial(ctx, "SN-1")
+	mustNot(t, err)
+	expectIDs(t, "by serial", idsOf(bySerial, movementID), ids...)
+
+	none, err := s.StockMovements.GetByStockID(ctx, 404)
+	mustNot(t, err)
+	expectIDs(t, "missing stock", idsOf(none, movementID))
+}
@@ -0,0 +1,119 @@
+// Package repositorytest is a conformance suite for the domain repository
+// interfaces. Every backend runs it, so that the use cases can rely on the
+// same semantics whichever storage is configured:
+//
+//   - GetByID and lookups by natural key return nil, nil when nothing matches
+//   - Update and Delete of a missing row return the entity's NotFound error
+//   - a duplicate code, email or serial returns the entity's AlreadyExists
+//     error, both on create and on update
+//   - a write referring to a missing row returns a domain.ForeignKeyError, and
+//     deleting a row still referred to returns one with InUse set
+//   - Create assigns increasing IDs and the timestamps
+//   - listings are ordered by ID unless sorted otherwise, are paged as
+//     domain.ListOptions describes and are empty rather than an error when
+//     nothing matches
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"inventario/internal/infrastructure/storage"
+)
+
+// ctx is the context of every repository call in the suite
+var ctx = context.Background()
+
+// NewBackend returns the empty repositories of one backend. It is called once
+// per test; repositories left nil skip their part of the suite.
+type NewBackend func(t *testing.T) *storage.Storage
+
+// Run runs the suite of every repository of the backend
+func Run(t *testing.T, newBackend NewBackend) {
+	t.Run("Products", func(t *testing.T) { testProducts(t, newBackend) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newBackend) })
+	t.Run("Providers", func(t *testing.T) { testProviders(t, newBackend) })
+	t.Run("Stocks", func(t *testing.T) { testStocks(t, newBackend) })
+	t.Run("StockMovements", func(t *testing.T) { testStockMovements(t, newBackend) })
+	t.Run("Warehouses", func(t *testing.T) { testWarehouses(t, newBackend) })
+	t.Run("Locations", func(t *testing.T) { testLocations(t, newBackend) })
+	t.Run("Transfers", func(t *testing.T) { testTransfers(t, newBackend) })
+	t.Run("PurchaseOrders", func(t *testing.T) { testPurchaseOrders(t, newBackend) })
+	t.Run("References", func(t *testing.T) { testReferences(t, newBackend) })
+	t.Run("SoftDelete", func(t *testing.T) { testSoftDelete(t, newBackend) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newBackend) })
+	t.Run("Patches", func(t *testing.T) { testPatches(t, newBackend) })
+	t.Run("BulkInserts", func(t *testing.T) { testBulkInserts(t, newBackend) })
+}
+
+// backend returns a fresh backend, skipping the test when one of the
+// repositories it needs is missing
+func backend(t *testing.T, newBackend NewBackend, needs ...func(*storage.Storage) interface{}) *storage.Storage {
+	t.Helper()
+	s := newBackend(t)
+	for _, need := range needs {
+		if isNil(need(s)) {
+			t.Skip("repository not provided by this backend")
+		}
+	}
+	return s
+}
+
+func isNil(repo interface{}) bool {
+	if repo == nil {
+		return true
+	}
+	v := reflect.ValueOf(repo)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+func products(s *storage.Storage) interface{}       { return s.Products }
+func users(s *storage.Storage) interface{}          { return s.Users }
+func providers(s *storage.Storage) interface{}      { return s.Providers }
+func stocks(s *storage.Storage) interface{}         { return s.Stocks }
+func stockMovements(s *storage.Storage) interface{} { return s.StockMovements }
+func warehouses(s *storage.Storage) interface{}     { return s.Warehouses }
+func locations(s *storage.Storage) interface{}      { return s.Locations }
+func transfers(s *storage.Storage) interface{}      { return s.Transfers }
+func purchaseOrders(s *storage.Storage) interface{} { return s.PurchaseOrders }
+
+// mustNot fails the test on an unexpected error
+func mustNot(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// expectError fails the test unless err is of the type target points to
+func expectError(t *testing.T, err error, target interface{}) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected %T, got nil", reflect.ValueOf(target).Elem().Interface())
+	}
+	if !errors.As(err, target) {
+		t.Fatalf("expected %T, got %T: %v", reflect.ValueOf(target).Elem().Interface(), err, err)
+	}
+}
+
+// expectIDs fails the test unless got lists exactly the IDs in want, in order
+func expectIDs(t *testing.T, what string, got []int64, want ...int64) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: expected IDs %v, got %v", what, want, got)
+	}
+}
+
+// idsOf returns the IDs of items
+func idsOf[T any](items []T, id func(*T) int64) []int64 {
+	var ids []int64
+	for i := range items {
+		ids = append(ids, id(&items[i]))
+	}
+	return ids
+}
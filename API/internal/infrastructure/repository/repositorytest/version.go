@@ -0,0 +1,162 @@
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"inventario/internal/domain"
+)
+
+// testVersions checks that every write bumps the version of products, users,
+// providers and units, and that updates based on a stale version are refused
+func testVersions(t *testing.T, newBackend NewBackend) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s := backend(t, newBackend, stocks, products, users, providers)
+	f := newStockFixture(t, s)
+	stock := f.stock(f.products[0], f.providers[0], "SN-1", "B1", purchased)
+	mustNot(t, s.Stocks.Create(ctx, stock))
+
+	entities := []struct {
+		entity string
+		id     int64
+		// update saves the row as of version and returns the version it
+		// reports afterwards
+		update  func(id, version int64) (int64, error)
+		version func(id int64) (int64, error)
+		delete  func(int64) error
+		restore func(int64) error
+	}{
+		{
+			entity: "stock",
+			id:     stock.ID,
+			update: func(id, version int64) (int64, error) {
+				updated := f.stock(f.products[0], f.providers[0], "SN-1", "B2", purchased)
+				updated.ID, updated.Version = id, version
+				err := s.Stocks.Update(ctx, updated)
+				return updated.Version, err
+			},
+			version: func(id int64) (int64, error) {
+				got, err := s.Stocks.GetByID(ctx, id)
+				if got == nil {
+					return 0, err
+				}
+				return got.Version, err
+			},
+			delete:  func(id int64) error { return s.Stocks.Delete(ctx, id, f.user.ID) },
+			restore: func(id int64) error { return s.Stocks.Restore(ctx, id) },
+		},
+		{
+			entity: "product",
+			id:     f.products[0].ID,
+			update: func(id, version int64) (int64, error) {
+				updated := &domain.Product{ID: id, Version: version, Name: "laptop", Code: "LPT", TrackingMode: domain.TrackingSerialized}
+				err := s.Products.Update(ctx, updated)
+				return updated.Version, err
+			},
+			version: func(id int64) (int64, error) {
+				got, err := s.Products.GetByID(ctx, id)
+				if got == nil {
+					return 0, err
+				}
+				return got.Version, err
+			},
+			delete:  func(id int64) error { return s.Products.Delete(ctx, id, f.user.ID) },
+			restore: func(id int64) error { return s.Products.Restore(ctx, id) },
+		},
+		{
+			entity: "provider",
+			id:     f.providers[0].ID,
+			update: func(id, version int64) (int64, error) {
+				updated := &domain.Provider{ID: id, Version: version, Name: "acme", Email: "sales@acme.example"}
+				err := s.Providers.Update(ctx, updated)
+				return updated.Version, err
+			},
+			version: func(id int64) (int64, error) {
+				got, err := s.Providers.GetByID(ctx, id)
+				if got == nil {
+					return 0, err
+				}
+				return got.Version, err
+			},
+			delete:  func(id int64) error { return s.Providers.Delete(ctx, id, f.user.ID) },
+			restore: func(id int64) error { return s.Providers.Restore(ctx, id) },
+		},
+		{
+			entity: "user",
+			id:     f.user.ID,
+			update: func(id, version int64) (int64, error) {
+				updated := &domain.User{ID: id, Version: version, Name: "ana", Email: "ana@example.com", Password: "secret-hash", Role: domain.RoleAdmin}
+				err := s.Users.Update(ctx, updated)
+				return updated.Version, err
+			},
+			version: func(id int64) (int64, error) {
+				got, err := s.Users.GetByID(ctx, id)
+				if got == nil {
+					return 0, err
+				}
+				return got.Version, err
+			},
+			delete:  func(id int64) error { return s.Users.Delete(ctx, id, 0) },
+			restore: func(id int64) error { return s.Users.Restore(ctx, id) },
+		},
+	}
+
+	for _, e := range entities {
+		t.Run(e.entity, func(t *testing.T) {
+			expectVersion := func(want int64) {
+				t.Helper()
+				if got, err := e.version(e.id); err != nil || got != want {
+					t.Errorf("expected the %s at version %d, got %d, %v", e.entity, want, got, err)
+				}
+			}
+			expectVersion(1)
+
+			got, err := e.update(e.id, 1)
+			mustNot(t, err)
+			if got != 2 {
+				t.Errorf("expected the update to report version 2, got %d", got)
+			}
+			expectVersion(2)
+
+			var mismatch *domain.VersionMismatchError
+			_, err = e.update(e.id, 1)
+			expectError(t, err, &mismatch)
+			if mismatch.Entity != e.entity || mismatch.ID != e.id || mismatch.Version != 1 {
+				t.Errorf("expected a stale version 1 of %s %d, got %+v", e.entity, e.id, mismatch)
+			}
+			expectVersion(2)
+
+			// A missing row is still not found, whatever the version
+			_, err = e.update(e.id+100, 1)
+			if err == nil || errors.As(err, new(*domain.VersionMismatchError)) {
+				t.Errorf("expected the missing %s not to be found, got %v", e.entity, err)
+			}
+
+			mustNot(t, e.delete(e.id))
+			mustNot(t, e.restore(e.id))
+			expectVersion(4)
+		})
+	}
+
+	t.Run("status and reassignment", func(t *testing.T) {
+		current, err := s.Stocks.GetByID(ctx, stock.ID)
+		mustNot(t, err)
+		stale := *current
+
+		current.Status = domain.StockReserved
+		current.UpdatedByUser = f.user
+		mustNot(t, s.Stocks.UpdateStatus(ctx, current))
+		stale.Status = domain.StockSold
+		stale.UpdatedByUser = f.user
+		var mismatch *domain.VersionMismatchError
+		expectError(t, s.Stocks.UpdateStatus(ctx, &stale), &mismatch)
+
+		_, err = s.Stocks.Reassign(ctx, domain.StockProductReference, f.products[0].ID, f.products[1].ID, f.user, "")
+		mustNot(t, err)
+		if got, err := s.Stocks.GetByID(ctx, stock.ID); err != nil || got == nil || got.Version != current.Version+1 {
+			t.Errorf("expected the reassignment to bump version %d, got %+v, %v", current.Version, got, err)
+		}
+	})
+}
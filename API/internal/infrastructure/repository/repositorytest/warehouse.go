@@ -0,0 +1,218 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/storage"
+)
+
+func testWarehouses(t *testing.T, newBackend NewBackend) {
+	warehouseID := func(w *domain.Warehouse) int64 { return w.ID }
+
+	s := backend(t, newBackend, warehouses)
+	repo := s.Warehouses
+
+	var ids []int64
+	for _, code := range []string{"WH-B", "WH-A"} {
+		warehouse := &domain.Warehouse{Code: code, Name: "warehouse " + code, Address: "Main St 1"}
+		mustNot(t, repo.Create(ctx, warehouse))
+		if warehouse.ID == 0 || warehouse.CreatedAt.IsZero() || warehouse.UpdatedAt.IsZero() {
+			t.Errorf("expected ID and timestamps to be set, got %+v", warehouse)
+		}
+		ids = append(ids, warehouse.ID)
+	}
+
+	got, err := repo.GetByID(ctx, ids[0])
+	mustNot(t, err)
+	if got == nil || got.Code != "WH-B" || got.Name != "warehouse WH-B" || got.Address != "Main St 1" {
+		t.Errorf("expected stored warehouse, got %+v", got)
+	}
+	if got, err := repo.GetByID(ctx, ids[1]+100); got != nil || err != nil {
+		t.Errorf("expected nil, nil for a missing warehouse, got %+v, %v", got, err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	mustNot(t, err)
+	expectIDs(t, "default order", idsOf(all, warehouseID), ids...)
+
+	var exists *domain.WarehouseAlreadyExistsError
+	expectError(t, repo.Create(ctx, &domain.Warehouse{Code: "WH-A", Name: "copy"}), &exists)
+	second := &domain.Warehouse{ID: ids[1], Code: "WH-B", Name: "renamed"}
+	expectError(t, repo.Update(ctx, second), &exists)
+
+	second.Code = "WH-C"
+	mustNot(t, repo.Update(ctx, second))
+	if got, err := repo.GetByID(ctx, ids[1]); err != nil || got == nil || got.Code != "WH-C" || got.Name != "renamed" {
+		t.Errorf("expected updated warehouse, got %+v, %v", got, err)
+	}
+
+	var notFound *domain.WarehouseNotFoundError
+	expectError(t, repo.Update(ctx, &domain.Warehouse{ID: ids[1] + 100, Code: "WH-X", Name: "x"}), &notFound)
+	mustNot(t, repo.Delete(ctx, ids[1]))
+	if got, err := repo.GetByID(ctx, ids[1]); got != nil || err != nil {
+		t.Errorf("expected deleted warehouse to be gone, got %+v, %v", got, err)
+	}
+	expectError(t, repo.Delete(ctx, ids[1]), &notFound)
+}
+
+// newWarehouses stores one warehouse per code
+func newWarehouses(t *testing.T, s *storage.Storage, codes ...string) []*domain.Warehouse {
+	t.Helper()
+	var stored []*domain.Warehouse
+	for _, code := range codes {
+		warehouse := &domain.Warehouse{Code: code, Name: "warehouse " + code}
+		mustNot(t, s.Warehouses.Create(ctx, warehouse))
+		stored = append(stored, warehouse)
+	}
+	return stored
+}
+
+func testLocations(t *testing.T, newBackend NewBackend) {
+	locationID := func(l *domain.Location) int64 { return l.ID }
+
+	s := backend(t, newBackend, locations, warehouses)
+	repo := s.Locations
+	wh := newWarehouses(t, s, "WH-B", "WH-A")
+
+	var ids []int64
+	for _, l := range []struct {
+		warehouse *domain.Warehouse
+		code      string
+	}{{wh[0], "R2"}, {wh[0], "R1"}, {wh[1], "R2"}} {
+		location := &domain.Location{Warehouse: l.warehouse, Code: l.code, Description: "rack " + l.code}
+		mustNot(t, repo.Create(ctx, location))
+		if location.ID == 0 || location.CreatedAt.IsZero() || location.UpdatedAt.IsZero() {
+			t.Errorf("expected ID and timestamps to be set, got %+v", location)
+		}
+		ids = append(ids, location.ID)
+	}
+
+	got, err := repo.GetByID(ctx, ids[0])
+	mustNot(t, err)
+	if got == nil || got.Code != "R2" || got.Description != "rack R2" || got.Warehouse == nil || got.Warehouse.Code != "WH-B" {
+		t.Errorf("expected stored location with its warehouse, got %+v", got)
+	}
+	if got, err := repo.GetByID(ctx, ids[2]+100); got != nil || err != nil {
+		t.Errorf("expected nil, nil for a missing location, got %+v, %v", got, err)
+	}
+
+	// Locations list by warehouse code, then by location code
+	all, err := repo.GetAll(ctx)
+	mustNot(t, err)
+	expectIDs(t, "all locations", idsOf(all, locationID), ids[2], ids[1], ids[0])
+	byWarehouse, err := repo.GetByWarehouseID(ctx, wh[0].ID)
+	mustNot(t, err)
+	expectIDs(t, "by warehouse", idsOf(byWarehouse, locationID), ids[1], ids[0])
+
+	// Codes are unique within a warehouse only
+	var exists *domain.LocationAlreadyExistsError
+	expectError(t, repo.Create(ctx, &domain.Location{Warehouse: wh[0], Code: "R1"}), &exists)
+	moved := &domain.Location{ID: ids[2], Warehouse: wh[0], Code: "R2"}
+	expectError(t, repo.Update(ctx, moved), &exists)
+
+	moved.Code = "R3"
+	mustNot(t, repo.Update(ctx, moved))
+	if got, err := repo.GetByID(ctx, ids[2]); err != nil || got == nil || got.Warehouse.ID != wh[0].ID || got.Code != "R3" {
+		t.Errorf("expected updated location, got %+v, %v", got, err)
+	}
+
+	var notFound *domain.LocationNotFoundError
+	expectError(t, repo.Update(ctx, &domain.Location{ID: ids[2] + 100, Warehouse: wh[0], Code: "R9"}), &notFound)
+	mustNot(t, repo.Delete(ctx, ids[2]))
+	if got, err := repo.GetByID(ctx, ids[2]); got != nil || err != nil {
+		t.Errorf("expected deleted location to be gone, got %+v, %v", got, err)
+	}
+	expectError(t, repo.Delete(ctx, ids[2]), &notFound)
+}
+
+func testTransfers(t *testing.T, newBackend NewBackend) {
+	transferID := func(tr *domain.Transfer) int64 { return tr.ID }
+
+	s := backend(t, newBackend, transfers, locations, warehouses)
+	wh := newWarehouses(t, s, "WH-A")
+	var locs []*domain.Location
+	for _, code := range []string{"R1", "R2"} {
+		location := &domain.Location{Warehouse: wh[0], Code: code}
+		mustNot(t, s.Locations.Create(ctx, location))
+		locs = append(locs, location)
+	}
+
+	var ids []int64
+	for _, notes := range []string{"first", "second"} {
+		transfer := &domain.Transfer{Source: locs[0], Destination: locs[1], Status: domain.TransferDraft, Notes: notes}
+		mustNot(t, s.Transfers.Create(ctx, transfer))
+		ids = append(ids, transfer.ID)
+	}
+
+	got, err := s.Transfers.GetByID(ctx, ids[1])
+	mustNot(t, err)
+	if got == nil || got.Notes != "second" || got.Source == nil || got.Source.ID != locs[0].ID || got.Destination == nil || got.Destination.ID != locs[1].ID {
+		t.Errorf("expected stored transfer, got %+v", got)
+	}
+	if got, err := s.Transfers.GetByID(ctx, ids[1]+100); got != nil || err != nil {
+		t.Errorf("expected nil, nil for a missing transfer, got %+v, %v", got, err)
+	}
+
+	mustNot(t, s.Transfers.UpdateStatus(ctx, &domain.Transfer{ID: ids[0], Status: domain.TransferInTransit}))
+	var notFound *domain.TransferNotFoundError
+	expectError(t, s.Transfers.UpdateStatus(ctx, &domain.Transfer{ID: ids[1] + 100, Status: domain.TransferInTransit}), &notFound)
+
+	all, err := s.Transfers.GetAll(ctx, domain.TransferFilter{})
+	mustNot(t, err)
+	expectIDs(t, "default order", idsOf(all, transferID), ids...)
+	drafts, err := s.Transfers.GetAll(ctx, domain.TransferFilter{Status: domain.TransferDraft})
+	mustNot(t, err)
+	expectIDs(t, "status filter", idsOf(drafts, transferID), ids[1])
+}
+
+func testPurchaseOrders(t *testing.T, newBackend NewBackend) {
+	orderID := func(o *domain.PurchaseOrder) int64 { return o.ID }
+
+	s := backend(t, newBackend, purchaseOrders, products, providers)
+	product := &domain.Product{Name: "cable", Code: "CBL", TrackingMode: domain.TrackingQuantity}
+	mustNot(t, s.Products.Create(ctx, product))
+	var provs []*domain.Provider
+	for _, name := range []string{"acme", "globex"} {
+		provider := &domain.Provider{Name: name, Email: "sales@" + name + ".example"}
+		mustNot(t, s.Providers.Create(ctx, provider))
+		provs = append(provs, provider)
+	}
+
+	var ids []int64
+	for _, provider := range []*domain.Provider{provs[1], provs[0]} {
+		order := &domain.PurchaseOrder{
+			Provider: provider,
+			Status:   domain.PurchaseOrderDraft,
+			Lines:    []domain.PurchaseOrderLine{{Product: product, ExpectedQuantity: 10, UnitCost: 2.5}},
+		}
+		mustNot(t, s.PurchaseOrders.Create(ctx, order))
+		if order.Lines[0].ID == 0 {
+			t.Error("expected line ID to be set")
+		}
+		ids = append(ids, order.ID)
+	}
+
+	got, err := s.PurchaseOrders.GetByID(ctx, ids[0])
+	mustNot(t, err)
+	if got == nil || got.Provider == nil || got.Provider.ID != provs[1].ID || len(got.Lines) != 1 || got.Lines[0].ExpectedQuantity != 10 {
+		t.Errorf("expected stored order with its lines, got %+v", got)
+	}
+	if got, err := s.PurchaseOrders.GetByID(ctx, ids[1]+100); got != nil || err != nil {
+		t.Errorf("expected nil, nil for a missing order, got %+v, %v", got, err)
+	}
+
+	mustNot(t, s.PurchaseOrders.UpdateStatus(ctx, &domain.PurchaseOrder{ID: ids[1], Status: domain.PurchaseOrderCancelled}))
+	var notFound *domain.PurchaseOrderNotFoundError
+	expectError(t, s.PurchaseOrders.UpdateStatus(ctx, &domain.PurchaseOrder{ID: ids[1] + 100, Status: domain.PurchaseOrderCancelled}), &notFound)
+
+	all, err := s.PurchaseOrders.GetAll(ctx, domain.PurchaseOrderFilter{})
+	mustNot(t, err)
+	expectIDs(t, "default order", idsOf(all, orderID), ids...)
+	byProvider, err := s.PurchaseOrders.GetAll(ctx, domain.PurchaseOrderFilter{ProviderID: provs[0].ID})
+	mustNot(t, err)
+	expectIDs(t, "provider filter", idsOf(byProvider, orderID), ids[1])
+	cancelled, err := s.PurchaseOrders.GetAll(ctx, domain.PurchaseOrderFilter{Status: domain.PurchaseOrderCancelled})
+	mustNot(t, err)
+	expectIDs(t, "status filter", idsOf(cancelled, orderID), ids[1])
+}
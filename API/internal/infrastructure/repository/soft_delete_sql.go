@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// notDeleted is the condition matching the live rows of a table, with prefix
+// the table alias used by the query, e.g. "s."
+func notDeleted(prefix string) string {
+	return prefix + "deleted_at IS NULL"
+}
+
+// softDelete marks the live row id of table as deleted by deletedBy, which is
+// stored as NULL when zero. It reports whether there was such a row.
+func softDelete(ctx context.Context, exec execer, table string, id, deletedBy int64, now time.Time) (bool, error) {
+	result, err := exec.ExecContext(ctx,
+		"UPDATE "+table+" SET deleted_at = ?, deleted_by_user_id = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL",
+		now, nullableID(deletedBy), id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// restoreDeleted brings back the soft-deleted row id of table. It reports
+// whether there was such a row.
+func restoreDeleted(ctx context.Context, exec execer, table string, id int64) (bool, error) {
+	result, err := exec.ExecContext(ctx,
+		"UPDATE "+table+" SET deleted_at = NULL, deleted_by_user_id = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL",
+		id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// purgeDeleted removes for good the rows of table soft deleted before the
+// given time. Rows that other records still refer to are kept for a later
+// purge, so that a product is only purged once its units are.
+func purgeDeleted(ctx context.Context, conn dbConn, table string, before time.Time) (int64, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT id FROM "+table+" WHERE deleted_at < ? ORDER BY id", before)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, id := range ids {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", id); err != nil {
+			// Any foreign key failure of a delete means the row is in use,
+			// as SQLite doesn't tell both sides apart
+			switch violatedConstraint(err) {
+			case foreignKeyConstraint, referencedConstraint:
+				continue
+			}
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
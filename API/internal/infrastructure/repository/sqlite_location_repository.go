@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+const sqliteLocationSelect = `
+	SELECT
+		l.id, l.code, l.description, l.created_at, l.updated_at,
+		w.id, w.code, w.name, w.address, w.created_at, w.updated_at
+	FROM locations l
+	JOIN warehouses w ON l.warehouse_id = w.id
+`
+
+type SQLiteLocationRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteLocationRepository(db *sql.DB) *SQLiteLocationRepository {
+	return &SQLiteLocationRepository{db: db}
+}
+
+func (r *SQLiteLocationRepository) Create(ctx context.Context, location *domain.Location) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO locations (warehouse_id, code, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, location.Warehouse.ID, location.Code, location.Description, now, now)
+	if err != nil {
+		return writeError(err, "location", location.ID, &domain.LocationAlreadyExistsError{Code: location.Code})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	location.ID = id
+	location.CreatedAt = now
+	location.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteLocationRepository) GetByID(ctx context.Context, id int64) (*domain.Location, error) {
+	location, err := scanLocation(connFor(ctx, r.db).QueryRowContext(ctx, sqliteLocationSelect+" WHERE l.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (r *SQLiteLocationRepository) GetAll(ctx context.Context) ([]domain.Location, error) {
+	return r.queryLocations(ctx, sqliteLocationSelect+" ORDER BY w.code, l.code")
+}
+
+func (r *SQLiteLocationRepository) GetByWarehouseID(ctx context.Context, warehouseID int64) ([]domain.Location, error) {
+	return r.queryLocations(ctx, sqliteLocationSelect+" WHERE l.warehouse_id = ? ORDER BY l.code", warehouseID)
+}
+
+func (r *SQLiteLocationRepository) Update(ctx context.Context, location *domain.Location) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		UPDATE locations
+		SET warehouse_id = ?, code = ?, description = ?, updated_at = ?
+		WHERE id = ?
+	`, location.Warehouse.ID, location.Code, location.Description, now, location.ID)
+	if err != nil {
+		return writeError(err, "location", location.ID, &domain.LocationAlreadyExistsError{Code: location.Code})
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.LocationNotFoundError{LocationID: location.ID}
+	}
+
+	location.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteLocationRepository) Delete(ctx context.Context, id int64) error {
+	result, err := connFor(ctx, r.db).ExecContext(ctx, "DELETE FROM locations WHERE id = ?", id)
+	if err != nil {
+		return deleteError(err, "location", id)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.LocationNotFoundError{LocationID: id}
+	}
+
+	return nil
+}
+
+func (r *SQLiteLocationRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLiteLocationRepository) queryLocations(ctx context.Context, query string, args ...interface{}) ([]domain.Location, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []domain.Location
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, *location)
+	}
+	return locations, rows.Err()
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -15,13 +17,14 @@ func NewSQLiteProductRepository(db *sql.DB) *SQLiteProductRepository {
 	return &SQLiteProductRepository{db: db}
 }
 
-func (r *SQLiteProductRepository) Create(product *domain.Product) error {
-	result, err := r.db.Exec(`
-		INSERT INTO products (name, code, image_url)
-		VALUES (?, ?, ?)
-	`, product.Name, product.Code, product.ImageURL)
+func (r *SQLiteProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO products (name, code, tracking_mode, image_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, product.Name, product.Code, product.TrackingMode, product.ImageURL, now, now)
 	if err != nil {
-		return err
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
 	}
 
 	id, err := result.LastInsertId()
@@ -30,16 +33,19 @@ func (r *SQLiteProductRepository) Create(product *domain.Product) error {
 	}
 
 	product.ID = id
+	product.Version = 1
+	product.CreatedAt = now
+	product.UpdatedAt = now
 	return nil
 }
 
-func (r *SQLiteProductRepository) GetByID(id int64) (*domain.Product, error) {
+func (r *SQLiteProductRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.QueryRow(`
-		SELECT id, name, code, image_url, created_at, updated_at
+	err := connFor(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, code, tracking_mode, image_url, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM products
-		WHERE id = ?
-	`, id).Scan(&product.ID, &product.Name, &product.Code, &product.ImageURL, &product.CreatedAt, &product.UpdatedAt)
+		WHERE id = ? AND deleted_at IS NULL
+	`, id).Scan(&product.ID, &product.Name, &product.Code, &product.TrackingMode, &product.ImageURL, &product.CreatedAt, &product.UpdatedAt, &product.Version, &product.DeletedAt, &product.DeletedBy)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -49,11 +55,13 @@ func (r *SQLiteProductRepository) GetByID(id int64) (*domain.Product, error) {
 	return &product, nil
 }
 
-func (r *SQLiteProductRepository) GetAll() ([]domain.Product, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, code, image_url, created_at, updated_at
+func (r *SQLiteProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	where, args := productFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, code, tracking_mode, image_url, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM products
-	`)
+	`+where+pageClause(filter.ListOptions, "", domain.ProductSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,23 +70,31 @@ func (r *SQLiteProductRepository) GetAll() ([]domain.Product, error) {
 	var products []domain.Product
 	for rows.Next() {
 		var product domain.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Code, &product.ImageURL, &product.CreatedAt, &product.UpdatedAt)
+		err := rows.Scan(&product.ID, &product.Name, &product.Code, &product.TrackingMode, &product.ImageURL, &product.CreatedAt, &product.UpdatedAt, &product.Version, &product.DeletedAt, &product.DeletedBy)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, product)
 	}
-	return products, nil
+	return products, rows.Err()
+}
+
+func (r *SQLiteProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	where, args := productFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *SQLiteProductRepository) Update(product *domain.Product) error {
-	result, err := r.db.Exec(`
+func (r *SQLiteProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
 		UPDATE products
-		SET name = ?, code = ?, image_url = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, product.Name, product.Code, product.ImageURL, product.ID)
+		SET name = ?, code = ?, tracking_mode = ?, image_url = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`, product.Name, product.Code, product.TrackingMode, product.ImageURL, now, product.ID, product.Version)
 	if err != nil {
-		return err
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
 	}
 
 	rows, err := result.RowsAffected()
@@ -87,17 +103,24 @@ func (r *SQLiteProductRepository) Update(product *domain.Product) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "products", "product", product.ID, product.Version, &domain.ProductNotFoundError{ProductID: product.ID})
 	}
 
+	product.Version++
 	return nil
 }
 
-func (r *SQLiteProductRepository) Delete(id int64) error {
-	result, err := r.db.Exec("DELETE FROM products WHERE id = ?", id)
+// Patch saves the given fields of the product, see Update
+func (r *SQLiteProductRepository) Patch(ctx context.Context, product *domain.Product, fields []string) error {
+	now := time.Now().UTC()
+	query, args, err := patchStatement("products", productColumns(product), fields, now, product.ID, product.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "product", product.ID, &domain.ProductAlreadyExistsError{Code: product.Code})
+	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
@@ -105,12 +128,43 @@ func (r *SQLiteProductRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "products", "product", product.ID, product.Version, &domain.ProductNotFoundError{ProductID: product.ID})
+	}
+
+	product.Version++
+	return nil
+}
+
+func (r *SQLiteProductRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "products", id, deletedBy, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return &domain.ProductNotFoundError{ProductID: id}
 	}
+	return nil
+}
 
+func (r *SQLiteProductRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "products", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.ProductNotFoundError{ProductID: id}
+	}
 	return nil
 }
 
+func (r *SQLiteProductRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "products", before)
+}
+
+func (r *SQLiteProductRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return countDependents(ctx, connFor(ctx, r.db), productReferences, id)
+}
+
 func (r *SQLiteProductRepository) Close() error {
 	return r.db.Close()
 }
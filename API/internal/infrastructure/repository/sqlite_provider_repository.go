@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type SQLiteProviderRepository struct {
@@ -13,13 +15,14 @@ func NewSQLiteProviderRepository(db *sql.DB) *SQLiteProviderRepository {
 	return &SQLiteProviderRepository{db: db}
 }
 
-func (r *SQLiteProviderRepository) Create(provider *domain.Provider) error {
-	result, err := r.db.Exec(`
-		INSERT INTO providers (name, address, phone)
-		VALUES (?, ?, ?)
-	`, provider.Name, provider.Address, provider.Phone)
+func (r *SQLiteProviderRepository) Create(ctx context.Context, provider *domain.Provider) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO providers (name, email, phone, address, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, provider.Name, provider.Email, provider.Phone, provider.Address, now, now)
 	if err != nil {
-		return err
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
 	}
 
 	id, err := result.LastInsertId()
@@ -28,16 +31,19 @@ func (r *SQLiteProviderRepository) Create(provider *domain.Provider) error {
 	}
 
 	provider.ID = id
+	provider.Version = 1
+	provider.CreatedAt = now
+	provider.UpdatedAt = now
 	return nil
 }
 
-func (r *SQLiteProviderRepository) GetByID(id int64) (*domain.Provider, error) {
+func (r *SQLiteProviderRepository) GetByID(ctx context.Context, id int64) (*domain.Provider, error) {
 	var provider domain.Provider
-	err := r.db.QueryRow(`
-		SELECT id, name, address, phone
+	err := connFor(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, email, phone, address, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM providers
-		WHERE id = ?
-	`, id).Scan(&provider.ID, &provider.Name, &provider.Address, &provider.Phone)
+		WHERE id = ? AND deleted_at IS NULL
+	`, id).Scan(&provider.ID, &provider.Name, &provider.Email, &provider.Phone, &provider.Address, &provider.CreatedAt, &provider.UpdatedAt, &provider.Version, &provider.DeletedAt, &provider.DeletedBy)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -47,11 +53,13 @@ func (r *SQLiteProviderRepository) GetByID(id int64) (*domain.Provider, error) {
 	return &provider, nil
 }
 
-func (r *SQLiteProviderRepository) GetAll() ([]domain.Provider, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, address, phone
+func (r *SQLiteProviderRepository) GetAll(ctx context.Context, filter domain.ProviderFilter) ([]domain.Provider, error) {
+	where, args := providerFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, email, phone, address, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM providers
-	`)
+	`+where+pageClause(filter.ListOptions, "", domain.ProviderSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,23 +68,31 @@ func (r *SQLiteProviderRepository) GetAll() ([]domain.Provider, error) {
 	var providers []domain.Provider
 	for rows.Next() {
 		var provider domain.Provider
-		err := rows.Scan(&provider.ID, &provider.Name, &provider.Address, &provider.Phone)
+		err := rows.Scan(&provider.ID, &provider.Name, &provider.Email, &provider.Phone, &provider.Address, &provider.CreatedAt, &provider.UpdatedAt, &provider.Version, &provider.DeletedAt, &provider.DeletedBy)
 		if err != nil {
 			return nil, err
 		}
 		providers = append(providers, provider)
 	}
-	return providers, nil
+	return providers, rows.Err()
+}
+
+func (r *SQLiteProviderRepository) Count(ctx context.Context, filter domain.ProviderFilter) (int64, error) {
+	where, args := providerFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM providers"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *SQLiteProviderRepository) Update(provider *domain.Provider) error {
-	result, err := r.db.Exec(`
+func (r *SQLiteProviderRepository) Update(ctx context.Context, provider *domain.Provider) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
 		UPDATE providers
-		SET name = ?, address = ?, phone = ?
-		WHERE id = ?
-	`, provider.Name, provider.Address, provider.Phone, provider.ID)
+		SET name = ?, email = ?, phone = ?, address = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`, provider.Name, provider.Email, provider.Phone, provider.Address, now, provider.ID, provider.Version)
 	if err != nil {
-		return err
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
 	}
 
 	rows, err := result.RowsAffected()
@@ -85,17 +101,24 @@ func (r *SQLiteProviderRepository) Update(provider *domain.Provider) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "providers", "provider", provider.ID, provider.Version, &domain.ProviderNotFoundError{ProviderID: provider.ID})
 	}
 
+	provider.Version++
 	return nil
 }
 
-func (r *SQLiteProviderRepository) Delete(id int64) error {
-	result, err := r.db.Exec("DELETE FROM providers WHERE id = ?", id)
+// Patch saves the given fields of the provider, see Update
+func (r *SQLiteProviderRepository) Patch(ctx context.Context, provider *domain.Provider, fields []string) error {
+	now := time.Now().UTC()
+	query, args, err := patchStatement("providers", providerColumns(provider), fields, now, provider.ID, provider.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "provider", provider.ID, &domain.ProviderAlreadyExistsError{Email: provider.Email})
+	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
@@ -103,12 +126,43 @@ func (r *SQLiteProviderRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "providers", "provider", provider.ID, provider.Version, &domain.ProviderNotFoundError{ProviderID: provider.ID})
+	}
+
+	provider.Version++
+	return nil
+}
+
+func (r *SQLiteProviderRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "providers", id, deletedBy, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return &domain.ProviderNotFoundError{ProviderID: id}
 	}
+	return nil
+}
 
+func (r *SQLiteProviderRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "providers", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.ProviderNotFoundError{ProviderID: id}
+	}
 	return nil
 }
 
+func (r *SQLiteProviderRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "providers", before)
+}
+
+func (r *SQLiteProviderRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return countDependents(ctx, connFor(ctx, r.db), providerReferences, id)
+}
+
 func (r *SQLiteProviderRepository) Close() error {
 	return r.db.Close()
 }
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+type SQLitePurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewSQLitePurchaseOrderRepository(db *sql.DB) *SQLitePurchaseOrderRepository {
+	return &SQLitePurchaseOrderRepository{db: db}
+}
+
+func (r *SQLitePurchaseOrderRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertPurchaseOrder(ctx, tx, order, time.Now().UTC())
+	})
+}
+
+func (r *SQLitePurchaseOrderRepository) GetByID(ctx context.Context, id int64) (*domain.PurchaseOrder, error) {
+	return getPurchaseOrder(ctx, connFor(ctx, r.db), id)
+}
+
+func (r *SQLitePurchaseOrderRepository) GetAll(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	return getAllPurchaseOrders(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLitePurchaseOrderRepository) UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error {
+	return updatePurchaseOrderStatus(ctx, connFor(ctx, r.db), order, time.Now().UTC())
+}
+
+func (r *SQLitePurchaseOrderRepository) Receive(ctx context.Context, order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return receivePurchaseOrder(ctx, tx, order, lines, reason, time.Now().UTC())
+	})
+}
+
+func (r *SQLitePurchaseOrderRepository) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+type SQLiteStockBalanceRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteStockBalanceRepository(db *sql.DB) *SQLiteStockBalanceRepository {
+	return &SQLiteStockBalanceRepository{db: db}
+}
+
+func (r *SQLiteStockBalanceRepository) GetAll(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	return getAllStockBalances(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLiteStockBalanceRepository) Apply(ctx context.Context, movement *domain.BalanceMovement) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return applyBalanceMovement(ctx, tx, movement, time.Now().UTC())
+	})
+}
+
+func (r *SQLiteStockBalanceRepository) GetMovements(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	return getBalanceMovements(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLiteStockBalanceRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	return countBalancesByLocation(ctx, connFor(ctx, r.db), productID)
+}
+
+func (r *SQLiteStockBalanceRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	return balancesOnHand(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLiteStockBalanceRepository) Close() error {
+	return r.db.Close()
+}
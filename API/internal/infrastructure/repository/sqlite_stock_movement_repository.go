@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+const sqliteStockMovementSelect = `
+	SELECT id, stock_id, serial, movement_type, actor_user_id,
+		before_state, after_state, reason, created_at
+	FROM stock_movements
+`
+
+type SQLiteStockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteStockMovementRepository(db *sql.DB) *SQLiteStockMovementRepository {
+	return &SQLiteStockMovementRepository{db: db}
+}
+
+func (r *SQLiteStockMovementRepository) Create(ctx context.Context, movement *domain.StockMovement) error {
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = time.Now().UTC()
+	}
+	return insertStockMovement(ctx, connFor(ctx, r.db), movement)
+}
+
+func (r *SQLiteStockMovementRepository) CreateMany(ctx context.Context, movements []*domain.StockMovement) error {
+	now := time.Now().UTC()
+	for _, movement := range movements {
+		if movement.CreatedAt.IsZero() {
+			movement.CreatedAt = now
+		}
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertStockMovements(ctx, tx, movements)
+	})
+}
+
+func (r *SQLiteStockMovementRepository) GetByStockID(ctx context.Context, stockID int64) ([]domain.StockMovement, error) {
+	return r.queryMovements(ctx, sqliteStockMovementSelect+" WHERE stock_id = ? ORDER BY id", stockID)
+}
+
+func (r *SQLiteStockMovementRepository) GetBySerial(ctx context.Context, serial string) ([]domain.StockMovement, error) {
+	return r.queryMovements(ctx, sqliteStockMovementSelect+`
+		WHERE stock_id IN (SELECT stock_id FROM stock_movements WHERE serial = ?)
+		ORDER BY id`, serial)
+}
+
+func (r *SQLiteStockMovementRepository) queryMovements(ctx context.Context, query string, args ...interface{}) ([]domain.StockMovement, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []domain.StockMovement
+	for rows.Next() {
+		var movement domain.StockMovement
+		var userID sql.NullInt64
+		var before, after sql.NullString
+		err := rows.Scan(
+			&movement.ID, &movement.StockID, &movement.Serial, &movement.Type, &userID,
+			&before, &after, &movement.Reason, &movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			movement.Actor = &domain.User{ID: userID.Int64}
+		}
+		if err := decodeSnapshots(&movement, before, after); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
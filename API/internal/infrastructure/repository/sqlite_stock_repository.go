@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type SQLiteStockRepository struct {
@@ -13,108 +15,100 @@ func NewSQLiteStockRepository(db *sql.DB) *SQLiteStockRepository {
 	return &SQLiteStockRepository{db: db}
 }
 
-func (r *SQLiteStockRepository) Create(stock *domain.Stock) error {
-	result, err := r.db.Exec(`
-		INSERT INTO stocks (
-			product_id, serial, created_at, updated_at, 
-			created_by_user_id, updated_by_user_id, 
-			batch, purchase_date, provider_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, stock.Product.ID, stock.Serial, stock.CreatedAt, stock.UpdatedAt,
-		stock.CreatedByUser.ID, stock.UpdatedByUser.ID,
-		stock.Batch, stock.PurchaseDate, stock.Provider.ID)
-	if err != nil {
-		return err
+func (r *SQLiteStockRepository) Create(ctx context.Context, stock *domain.Stock) error {
+	now := time.Now().UTC()
+	stock.StatusChangedAt = now
+	stock.CreatedAt = now
+	stock.UpdatedAt = now
+	if err := insertStock(ctx, connFor(ctx, r.db), stock); err != nil {
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
 	}
+	return nil
+}
 
-	id, err := result.LastInsertId()
+func (r *SQLiteStockRepository) CreateMany(ctx context.Context, stocks []*domain.Stock) error {
+	now := time.Now().UTC()
+	for _, stock := range stocks {
+		stock.StatusChangedAt = now
+		stock.CreatedAt = now
+		stock.UpdatedAt = now
+	}
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertStocks(ctx, tx, stocks)
+	})
 	if err != nil {
-		return err
+		return writeError(err, "stock", 0, &domain.StockAlreadyExistsError{})
 	}
-
-	stock.ID = id
 	return nil
 }
 
-func (r *SQLiteStockRepository) GetByID(id int64) (*domain.Stock, error) {
-	var stock domain.Stock
-	var productID, createdByUserID, updatedByUserID, providerID int64
-
-	err := r.db.QueryRow(`
-		SELECT id, product_id, serial, created_at, updated_at,
-			created_by_user_id, updated_by_user_id,
-			batch, purchase_date, provider_id
-		FROM stocks
-		WHERE id = ?
-	`, id).Scan(
-		&stock.ID, &productID, &stock.Serial, &stock.CreatedAt, &stock.UpdatedAt,
-		&createdByUserID, &updatedByUserID,
-		&stock.Batch, &stock.PurchaseDate, &providerID,
-	)
+func (r *SQLiteStockRepository) ExistingSerials(ctx context.Context, serials []string) ([]string, error) {
+	return existingSerials(ctx, connFor(ctx, r.db), serials)
+}
+
+func (r *SQLiteStockRepository) GetByID(ctx context.Context, id int64) (*domain.Stock, error) {
+	stock, err := scanStock(connFor(ctx, r.db).QueryRowContext(ctx, stockSelect+" WHERE s.id = ? AND s.deleted_at IS NULL", id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	return stock, nil
+}
 
-	// Fetch related entities
-	stock.Product = &domain.Product{ID: productID}
-	stock.CreatedByUser = &domain.User{ID: createdByUserID}
-	stock.UpdatedByUser = &domain.User{ID: updatedByUserID}
-	stock.Provider = &domain.Provider{ID: providerID}
+func (r *SQLiteStockRepository) GetAll(ctx context.Context, filter domain.StockFilter) ([]domain.Stock, error) {
+	where, args := stockFilterClause(filter, "s.")
+	where, args = withKeyset(where, args, filter.ListOptions, "s.")
+	return r.queryStocks(ctx, stockSelect+where+pageClause(filter.ListOptions, "s.", domain.StockSortFields), args...)
+}
 
-	return &stock, nil
+func (r *SQLiteStockRepository) Count(ctx context.Context, filter domain.StockFilter) (int64, error) {
+	where, args := stockFilterClause(filter, "")
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM stocks"+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *SQLiteStockRepository) GetAll() ([]domain.Stock, error) {
-	rows, err := r.db.Query(`
-		SELECT id, product_id, serial, created_at, updated_at,
-			created_by_user_id, updated_by_user_id,
-			batch, purchase_date, provider_id
-		FROM stocks
-	`)
+func (r *SQLiteStockRepository) Update(ctx context.Context, stock *domain.Stock) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		UPDATE stocks
+		SET product_id = ?, serial = ?, updated_at = ?, version = version + 1,
+			updated_by_user_id = ?, batch = ?, purchase_date = ?, provider_id = ?,
+			location_id = ?
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`, stock.Product.ID, stock.Serial, now, stock.UpdatedByUser.ID,
+		stock.Batch, stock.PurchaseDate, stock.Provider.ID, stockLocationID(stock), stock.ID, stock.Version)
 	if err != nil {
-		return nil, err
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
 	}
-	defer rows.Close()
-
-	var stocks []domain.Stock
-	for rows.Next() {
-		var stock domain.Stock
-		var productID, createdByUserID, updatedByUserID, providerID int64
-
-		err := rows.Scan(
-			&stock.ID, &productID, &stock.Serial, &stock.CreatedAt, &stock.UpdatedAt,
-			&createdByUserID, &updatedByUserID,
-			&stock.Batch, &stock.PurchaseDate, &providerID,
-		)
-		if err != nil {
-			return nil, err
-		}
 
-		// Set related entities
-		stock.Product = &domain.Product{ID: productID}
-		stock.CreatedByUser = &domain.User{ID: createdByUserID}
-		stock.UpdatedByUser = &domain.User{ID: updatedByUserID}
-		stock.Provider = &domain.Provider{ID: providerID}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		stocks = append(stocks, stock)
+	if rows == 0 {
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
 	}
-	return stocks, nil
+
+	stock.UpdatedAt = now
+	stock.Version++
+	return nil
 }
 
-func (r *SQLiteStockRepository) Update(stock *domain.Stock) error {
-	result, err := r.db.Exec(`
-		UPDATE stocks
-		SET product_id = ?, serial = ?, updated_at = CURRENT_TIMESTAMP,
-			updated_by_user_id = ?, batch = ?, purchase_date = ?, provider_id = ?
-		WHERE id = ?
-	`, stock.Product.ID, stock.Serial, stock.UpdatedByUser.ID,
-		stock.Batch, stock.PurchaseDate, stock.Provider.ID, stock.ID)
+// Patch saves the given fields of the unit and who updated it, see Update
+func (r *SQLiteStockRepository) Patch(ctx context.Context, stock *domain.Stock, fields []string) error {
+	now := time.Now().UTC()
+	query, args, err := patchStatement("stocks", stockColumns(stock), append([]string{"updated_by_user_id"}, fields...), now, stock.ID, stock.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "stock", stock.ID, &domain.StockAlreadyExistsError{Serial: stock.Serial})
+	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
@@ -122,16 +116,23 @@ func (r *SQLiteStockRepository) Update(stock *domain.Stock) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
 	}
 
+	stock.UpdatedAt = now
+	stock.Version++
 	return nil
 }
 
-func (r *SQLiteStockRepository) Delete(id int64) error {
-	result, err := r.db.Exec("DELETE FROM stocks WHERE id = ?", id)
+func (r *SQLiteStockRepository) UpdateStatus(ctx context.Context, stock *domain.Stock) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		UPDATE stocks
+		SET status = ?, status_changed_at = ?, updated_at = ?, updated_by_user_id = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`, stock.Status, now, now, stock.UpdatedByUser.ID, stock.ID, stock.Version)
 	if err != nil {
-		return err
+		return writeError(err, "stock", stock.ID, nil)
 	}
 
 	rows, err := result.RowsAffected()
@@ -140,81 +141,117 @@ func (r *SQLiteStockRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "stocks", "stock", stock.ID, stock.Version, &domain.StockNotFoundError{StockID: stock.ID})
 	}
 
+	stock.StatusChangedAt = now
+	stock.UpdatedAt = now
+	stock.Version++
 	return nil
 }
 
-func (r *SQLiteStockRepository) GetByProductID(productID int64) ([]domain.Stock, error) {
-	rows, err := r.db.Query(`
-		SELECT id, product_id, serial, created_at, updated_at,
-			created_by_user_id, updated_by_user_id,
-			batch, purchase_date, provider_id
-		FROM stocks
-		WHERE product_id = ?
-	`, productID)
+func (r *SQLiteStockRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "stocks", id, deletedBy, time.Now().UTC())
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var stocks []domain.Stock
-	for rows.Next() {
-		var stock domain.Stock
-		var productID, createdByUserID, updatedByUserID, providerID int64
-
-		err := rows.Scan(
-			&stock.ID, &productID, &stock.Serial, &stock.CreatedAt, &stock.UpdatedAt,
-			&createdByUserID, &updatedByUserID,
-			&stock.Batch, &stock.PurchaseDate, &providerID,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		// Set related entities
-		stock.Product = &domain.Product{ID: productID}
-		stock.CreatedByUser = &domain.User{ID: createdByUserID}
-		stock.UpdatedByUser = &domain.User{ID: updatedByUserID}
-		stock.Provider = &domain.Provider{ID: providerID}
+	if !deleted {
+		return &domain.StockNotFoundError{StockID: id}
+	}
+	return nil
+}
 
-		stocks = append(stocks, stock)
+func (r *SQLiteStockRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "stocks", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.StockNotFoundError{StockID: id}
 	}
-	return stocks, nil
+	return nil
+}
+
+func (r *SQLiteStockRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "stocks", before)
 }
 
-func (r *SQLiteStockRepository) GetBySerial(serial string) (*domain.Stock, error) {
-	var stock domain.Stock
-	var productID, createdByUserID, updatedByUserID, providerID int64
+func (r *SQLiteStockRepository) GetByProductID(ctx context.Context, productID int64, filter domain.StockFilter) ([]domain.Stock, error) {
+	filter.ProductID = productID
+	return r.GetAll(ctx, filter)
+}
 
-	err := r.db.QueryRow(`
-		SELECT id, product_id, serial, created_at, updated_at,
-			created_by_user_id, updated_by_user_id,
-			batch, purchase_date, provider_id
-		FROM stocks
-		WHERE serial = ?
-	`, serial).Scan(
-		&stock.ID, &productID, &stock.Serial, &stock.CreatedAt, &stock.UpdatedAt,
-		&createdByUserID, &updatedByUserID,
-		&stock.Batch, &stock.PurchaseDate, &providerID,
-	)
+func (r *SQLiteStockRepository) GetBySerial(ctx context.Context, serial string) (*domain.Stock, error) {
+	stock, err := scanStock(connFor(ctx, r.db).QueryRowContext(ctx, stockSelect+" WHERE s.serial = ? AND s.deleted_at IS NULL", serial))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	return stock, nil
+}
 
-	// Set related entities
-	stock.Product = &domain.Product{ID: productID}
-	stock.CreatedByUser = &domain.User{ID: createdByUserID}
-	stock.UpdatedByUser = &domain.User{ID: updatedByUserID}
-	stock.Provider = &domain.Provider{ID: providerID}
+func (r *SQLiteStockRepository) CountByLocation(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, `
+		SELECT l.id, l.code, l.description, w.id, w.code, w.name, COUNT(*)
+		FROM stocks s
+		LEFT JOIN locations l ON s.location_id = l.id
+		LEFT JOIN warehouses w ON l.warehouse_id = w.id
+		WHERE s.product_id = ? AND s.status NOT IN (?, ?) AND s.deleted_at IS NULL
+		GROUP BY l.id, l.code, l.description, w.id, w.code, w.name
+		ORDER BY w.code, l.code
+	`, productID, domain.StockSold, domain.StockScrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return &stock, nil
+	var counts []domain.LocationStockCount
+	for rows.Next() {
+		var count domain.LocationStockCount
+		var loc nullableLocation
+		if err := rows.Scan(loc.dest(&count.Quantity)...); err != nil {
+			return nil, err
+		}
+		count.Location = loc.location()
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+func (r *SQLiteStockRepository) OnHandByProduct(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	return stocksOnHand(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLiteStockRepository) Reassign(ctx context.Context, ref domain.StockReference, fromID, toID int64, actor *domain.User, reason string) (int64, error) {
+	var changed int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		changed, err = reassignStocks(ctx, tx, ref, fromID, toID, actor, reason, time.Now().UTC())
+		return err
+	})
+	return changed, err
 }
 
 func (r *SQLiteStockRepository) Close() error {
 	return r.db.Close()
 }
+
+func (r *SQLiteStockRepository) queryStocks(ctx context.Context, query string, args ...interface{}) ([]domain.Stock, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocks []domain.Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *stock)
+	}
+	return stocks, rows.Err()
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+type SQLiteTransferRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteTransferRepository(db *sql.DB) *SQLiteTransferRepository {
+	return &SQLiteTransferRepository{db: db}
+}
+
+func (r *SQLiteTransferRepository) Create(ctx context.Context, transfer *domain.Transfer) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertTransfer(ctx, tx, transfer, time.Now().UTC())
+	})
+}
+
+func (r *SQLiteTransferRepository) GetByID(ctx context.Context, id int64) (*domain.Transfer, error) {
+	return getTransfer(ctx, connFor(ctx, r.db), id)
+}
+
+func (r *SQLiteTransferRepository) GetAll(ctx context.Context, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	return getAllTransfers(ctx, connFor(ctx, r.db), filter)
+}
+
+func (r *SQLiteTransferRepository) UpdateStatus(ctx context.Context, transfer *domain.Transfer) error {
+	return updateTransferStatus(ctx, connFor(ctx, r.db), transfer, time.Now().UTC())
+}
+
+func (r *SQLiteTransferRepository) Receive(ctx context.Context, transfer *domain.Transfer, actor *domain.User, movements []*domain.StockMovement) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return receiveTransfer(ctx, tx, transfer, actor, movements, time.Now().UTC())
+	})
+}
+
+func (r *SQLiteTransferRepository) Close() error {
+	return r.db.Close()
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"inventario/internal/domain"
+	"time"
 )
 
 type SQLiteUserRepository struct {
@@ -13,26 +15,14 @@ func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
 	return &SQLiteUserRepository{db: db}
 }
 
-func (r *SQLiteUserRepository) Create(user *domain.User) error {
-	// Check if user with same email exists
-	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", user.Email).Scan(&exists)
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO users (name, email, password, role, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.Name, user.Email, user.Password, user.Role, now, now)
 	if err != nil {
-		return err
-	}
-
-	if exists {
-		return &domain.UserAlreadyExistsError{
-			Email: user.Email,
-		}
-	}
-
-	result, err := r.db.Exec(`
-		INSERT INTO users (name, email, password, role)
-		VALUES (?, ?, ?, ?)
-	`, user.Name, user.Email, user.Password, user.Role)
-	if err != nil {
-		return err
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
 	}
 
 	id, err := result.LastInsertId()
@@ -41,16 +31,19 @@ func (r *SQLiteUserRepository) Create(user *domain.User) error {
 	}
 
 	user.ID = id
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
 	return nil
 }
 
-func (r *SQLiteUserRepository) GetByID(id int64) (*domain.User, error) {
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(`
-		SELECT id, name, email, password, role, created_at, updated_at
+	err := connFor(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-		WHERE id = ?
-	`, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		WHERE id = ? AND deleted_at IS NULL
+	`, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt, &user.DeletedBy)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -60,13 +53,13 @@ func (r *SQLiteUserRepository) GetByID(id int64) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *SQLiteUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (r *SQLiteUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(`
-		SELECT id, name, email, password, role, created_at, updated_at
+	err := connFor(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-		WHERE email = ?
-	`, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		WHERE email = ? AND deleted_at IS NULL
+	`, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt, &user.DeletedBy)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -76,11 +69,13 @@ func (r *SQLiteUserRepository) GetByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *SQLiteUserRepository) GetAll() ([]domain.User, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, email, password, role, created_at, updated_at
+func (r *SQLiteUserRepository) GetAll(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	where, args := userFilterClause(filter)
+	where, args = withKeyset(where, args, filter.ListOptions, "")
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, email, password, role, created_at, updated_at, version, deleted_at, deleted_by_user_id
 		FROM users
-	`)
+	`+where+pageClause(filter.ListOptions, "", domain.UserSortFields), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -89,23 +84,31 @@ func (r *SQLiteUserRepository) GetAll() ([]domain.User, error) {
 	var users []domain.User
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt, &user.DeletedBy)
 		if err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
-	return users, nil
+	return users, rows.Err()
 }
 
-func (r *SQLiteUserRepository) Update(user *domain.User) error {
-	result, err := r.db.Exec(`
+func (r *SQLiteUserRepository) Count(ctx context.Context, filter domain.UserFilter) (int64, error) {
+	where, args := userFilterClause(filter)
+	var count int64
+	err := connFor(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *SQLiteUserRepository) Update(ctx context.Context, user *domain.User) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
-		SET name = ?, email = ?, password = ?, role = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, user.Name, user.Email, user.Password, user.Role, user.ID)
+		SET name = ?, email = ?, password = ?, role = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`, user.Name, user.Email, user.Password, user.Role, now, user.ID, user.Version)
 	if err != nil {
-		return err
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
 	}
 
 	rows, err := result.RowsAffected()
@@ -114,17 +117,24 @@ func (r *SQLiteUserRepository) Update(user *domain.User) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "users", "user", user.ID, user.Version, &domain.UserNotFoundError{UserID: user.ID})
 	}
 
+	user.Version++
 	return nil
 }
 
-func (r *SQLiteUserRepository) Delete(id int64) error {
-	result, err := r.db.Exec("DELETE FROM users WHERE id = ?", id)
+// Patch saves the given fields of the user, see Update
+func (r *SQLiteUserRepository) Patch(ctx context.Context, user *domain.User, fields []string) error {
+	now := time.Now().UTC()
+	query, args, err := patchStatement("users", userColumns(user), fields, now, user.ID, user.Version)
 	if err != nil {
 		return err
 	}
+	result, err := connFor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return writeError(err, "user", user.ID, &domain.UserAlreadyExistsError{Email: user.Email})
+	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
@@ -132,12 +142,43 @@ func (r *SQLiteUserRepository) Delete(id int64) error {
 	}
 
 	if rows == 0 {
-		return sql.ErrNoRows
+		return versionConflict(ctx, connFor(ctx, r.db), "users", "user", user.ID, user.Version, &domain.UserNotFoundError{UserID: user.ID})
 	}
 
+	user.Version++
 	return nil
 }
 
+func (r *SQLiteUserRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	deleted, err := softDelete(ctx, connFor(ctx, r.db), "users", id, deletedBy, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return &domain.UserNotFoundError{UserID: id}
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) Restore(ctx context.Context, id int64) error {
+	restored, err := restoreDeleted(ctx, connFor(ctx, r.db), "users", id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return &domain.UserNotFoundError{UserID: id}
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, connFor(ctx, r.db), "users", before)
+}
+
+func (r *SQLiteUserRepository) Dependents(ctx context.Context, id int64) ([]domain.Dependent, error) {
+	return countDependents(ctx, connFor(ctx, r.db), userReferences, id)
+}
+
 func (r *SQLiteUserRepository) Close() error {
 	return r.db.Close()
 }
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+type SQLiteWarehouseRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteWarehouseRepository(db *sql.DB) *SQLiteWarehouseRepository {
+	return &SQLiteWarehouseRepository{db: db}
+}
+
+func (r *SQLiteWarehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO warehouses (code, name, address, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, warehouse.Code, warehouse.Name, warehouse.Address, now, now)
+	if err != nil {
+		return writeError(err, "warehouse", warehouse.ID, &domain.WarehouseAlreadyExistsError{Code: warehouse.Code})
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	warehouse.ID = id
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteWarehouseRepository) GetByID(ctx context.Context, id int64) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	err := connFor(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, code, name, address, created_at, updated_at
+		FROM warehouses
+		WHERE id = ?
+	`, id).Scan(&warehouse.ID, &warehouse.Code, &warehouse.Name, &warehouse.Address,
+		&warehouse.CreatedAt, &warehouse.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r *SQLiteWarehouseRepository) GetAll(ctx context.Context) ([]domain.Warehouse, error) {
+	rows, err := connFor(ctx, r.db).QueryContext(ctx, `
+		SELECT id, code, name, address, created_at, updated_at
+		FROM warehouses
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []domain.Warehouse
+	for rows.Next() {
+		var warehouse domain.Warehouse
+		err := rows.Scan(&warehouse.ID, &warehouse.Code, &warehouse.Name, &warehouse.Address,
+			&warehouse.CreatedAt, &warehouse.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	return warehouses, rows.Err()
+}
+
+func (r *SQLiteWarehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	now := time.Now().UTC()
+	result, err := connFor(ctx, r.db).ExecContext(ctx, `
+		UPDATE warehouses
+		SET code = ?, name = ?, address = ?, updated_at = ?
+		WHERE id = ?
+	`, warehouse.Code, warehouse.Name, warehouse.Address, now, warehouse.ID)
+	if err != nil {
+		return writeError(err, "warehouse", warehouse.ID, &domain.WarehouseAlreadyExistsError{Code: warehouse.Code})
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.WarehouseNotFoundError{WarehouseID: warehouse.ID}
+	}
+
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteWarehouseRepository) Delete(ctx context.Context, id int64) error {
+	result, err := connFor(ctx, r.db).ExecContext(ctx, "DELETE FROM warehouses WHERE id = ?", id)
+	if err != nil {
+		return deleteError(err, "warehouse", id)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.WarehouseNotFoundError{WarehouseID: id}
+	}
+
+	return nil
+}
+
+func (r *SQLiteWarehouseRepository) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"strings"
+	"time"
+)
+
+// stockBalanceSelect loads a balance together with its product and location.
+// MySQL and SQLite share it, as they share the balance helpers below.
+const stockBalanceSelect = `
+	SELECT
+		b.id, b.batch, b.quantity, b.updated_at,
+		p.id, p.name, p.code, p.tracking_mode,
+		l.id, l.code, l.description,
+		w.id, w.code, w.name
+	FROM stock_balances b
+	JOIN products p ON b.product_id = p.id
+	JOIN locations l ON b.location_id = l.id
+	JOIN warehouses w ON l.warehouse_id = w.id
+`
+
+const balanceMovementSelect = `
+	SELECT
+		m.id, m.batch, m.movement_type, m.quantity, m.balance_after,
+		m.reason, m.actor_user_id, m.created_at,
+		p.id, p.name, p.code, p.tracking_mode,
+		l.id, l.code, l.description,
+		w.id, w.code, w.name
+	FROM stock_balance_movements m
+	JOIN products p ON m.product_id = p.id
+	JOIN locations l ON m.location_id = l.id
+	JOIN warehouses w ON l.warehouse_id = w.id
+`
+
+// balanceFilterClause builds the WHERE clause for a balance filter. Stocks,
+// balances and balance movements share the filtered columns, so prefix may
+// point at any of them.
+func balanceFilterClause(filter domain.StockBalanceFilter, prefix string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ProductID != 0 {
+		conditions = append(conditions, prefix+"product_id = ?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.WarehouseID != 0 {
+		conditions = append(conditions, prefix+"location_id IN (SELECT id FROM locations WHERE warehouse_id = ?)")
+		args = append(args, filter.WarehouseID)
+	}
+	if filter.LocationID != 0 {
+		conditions = append(conditions, prefix+"location_id = ?")
+		args = append(args, filter.LocationID)
+	}
+	if filter.Batch != "" {
+		conditions = append(conditions, prefix+"batch = ?")
+		args = append(args, filter.Batch)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func scanStockBalance(row rowScanner) (*domain.StockBalance, error) {
+	balance := domain.StockBalance{Product: &domain.Product{}}
+	var loc nullableLocation
+
+	err := row.Scan(
+		&balance.ID, &balance.Batch, &balance.Quantity, &balance.UpdatedAt,
+		&balance.Product.ID, &balance.Product.Name, &balance.Product.Code, &balance.Product.TrackingMode,
+		&loc.id, &loc.code, &loc.description,
+		&loc.warehouseID, &loc.warehouseCode, &loc.warehouseName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	balance.Location = loc.location()
+	return &balance, nil
+}
+
+func getAllStockBalances(ctx context.Context, q queryer, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	where, args := balanceFilterClause(filter, "b.")
+	rows, err := q.QueryContext(ctx, stockBalanceSelect+where+" ORDER BY b.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []domain.StockBalance
+	for rows.Next() {
+		balance, err := scanStockBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *balance)
+	}
+	return balances, rows.Err()
+}
+
+func getBalanceMovements(ctx context.Context, q queryer, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	where, args := balanceFilterClause(filter, "m.")
+	rows, err := q.QueryContext(ctx, balanceMovementSelect+where+" ORDER BY m.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []domain.BalanceMovement
+	for rows.Next() {
+		movement := domain.BalanceMovement{Product: &domain.Product{}}
+		var actorUserID sql.NullInt64
+		var loc nullableLocation
+		err := rows.Scan(
+			&movement.ID, &movement.Batch, &movement.Type, &movement.Quantity, &movement.BalanceAfter,
+			&movement.Reason, &actorUserID, &movement.CreatedAt,
+			&movement.Product.ID, &movement.Product.Name, &movement.Product.Code, &movement.Product.TrackingMode,
+			&loc.id, &loc.code, &loc.description,
+			&loc.warehouseID, &loc.warehouseCode, &loc.warehouseName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if actorUserID.Valid {
+			movement.Actor = &domain.User{ID: actorUserID.Int64}
+		}
+		movement.Location = loc.location()
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
+
+// applyBalanceMovement adds movement.Quantity to its balance, creating the
+// balance on the first receipt, and appends the movement to the ledger. The
+// guarded UPDATE keeps concurrent issues from taking a balance below zero.
+func applyBalanceMovement(ctx context.Context, tx *sql.Tx, movement *domain.BalanceMovement, now time.Time) error {
+	productID, locationID, batch := movement.Product.ID, movement.Location.ID, movement.Batch
+
+	query := `
+		UPDATE stock_balances
+		SET quantity = quantity + ?, updated_at = ?
+		WHERE product_id = ? AND location_id = ? AND batch = ?
+	`
+	args := []interface{}{movement.Quantity, now, productID, locationID, batch}
+	if movement.Quantity < 0 {
+		query += " AND quantity + ? >= 0"
+		args = append(args, movement.Quantity)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		if movement.Quantity < 0 {
+			available, err := balanceQuantity(ctx, tx, productID, locationID, batch)
+			if err != nil {
+				return err
+			}
+			return &domain.InsufficientQuantityError{
+				ProductID: productID,
+				Available: available,
+				Requested: -movement.Quantity,
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO stock_balances (product_id, location_id, batch, quantity, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, productID, locationID, batch, movement.Quantity, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if movement.BalanceAfter, err = balanceQuantity(ctx, tx, productID, locationID, batch); err != nil {
+		return err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO stock_balance_movements (
+			product_id, location_id, batch, movement_type, quantity,
+			balance_after, reason, actor_user_id, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, productID, locationID, batch, movement.Type, movement.Quantity,
+		movement.BalanceAfter, movement.Reason, actorID(movement.Actor), now)
+	if err != nil {
+		return err
+	}
+
+	if movement.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	movement.CreatedAt = now
+	return nil
+}
+
+// balanceQuantity returns the current quantity of a balance, zero when the
+// balance does not exist yet
+func balanceQuantity(ctx context.Context, q queryer, productID, locationID int64, batch string) (int64, error) {
+	var quantity int64
+	err := q.QueryRowContext(ctx,
+		"SELECT quantity FROM stock_balances WHERE product_id = ? AND location_id = ? AND batch = ?",
+		productID, locationID, batch,
+	).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return quantity, err
+}
+
+// countBalancesByLocation sums the batches of a product held at each location
+func countBalancesByLocation(ctx context.Context, q queryer, productID int64) ([]domain.LocationStockCount, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.id, l.code, l.description, w.id, w.code, w.name, SUM(b.quantity)
+		FROM stock_balances b
+		JOIN locations l ON b.location_id = l.id
+		JOIN warehouses w ON l.warehouse_id = w.id
+		WHERE b.product_id = ? AND b.quantity > 0
+		GROUP BY l.id, l.code, l.description, w.id, w.code, w.name
+		ORDER BY w.code, l.code
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []domain.LocationStockCount
+	for rows.Next() {
+		var count domain.LocationStockCount
+		var loc nullableLocation
+		if err := rows.Scan(loc.dest(&count.Quantity)...); err != nil {
+			return nil, err
+		}
+		count.Location = loc.location()
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// balancesOnHand sums the balances of every quantity-tracked product
+// matching filter
+func balancesOnHand(ctx context.Context, q queryer, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	where, args := balanceFilterClause(filter, "b.")
+	return queryProductsOnHand(ctx, q, `
+		SELECT p.id, p.name, p.code, p.tracking_mode, SUM(b.quantity)
+		FROM stock_balances b
+		JOIN products p ON b.product_id = p.id
+	`+where+`
+		GROUP BY p.id, p.name, p.code, p.tracking_mode
+		HAVING SUM(b.quantity) > 0
+		ORDER BY p.id
+	`, args...)
+}
+
+// stocksOnHand counts the units of every serialized product matching filter
+// that have not been sold or scrapped
+func stocksOnHand(ctx context.Context, q queryer, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	where, args := balanceFilterClause(filter, "s.")
+	args = append([]interface{}{domain.StockSold, domain.StockScrapped}, args...)
+	return queryProductsOnHand(ctx, q, `
+		SELECT p.id, p.name, p.code, p.tracking_mode, COUNT(*)
+		FROM stocks s
+		JOIN products p ON s.product_id = p.id
+		WHERE s.status NOT IN (?, ?) AND s.deleted_at IS NULL`+andClause(where)+`
+		GROUP BY p.id, p.name, p.code, p.tracking_mode
+		ORDER BY p.id
+	`, args...)
+}
+
+func queryProductsOnHand(ctx context.Context, q queryer, query string, args ...interface{}) ([]domain.ProductOnHand, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var onHand []domain.ProductOnHand
+	for rows.Next() {
+		item := domain.ProductOnHand{Product: &domain.Product{}}
+		err := rows.Scan(
+			&item.Product.ID, &item.Product.Name, &item.Product.Code,
+			&item.Product.TrackingMode, &item.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		onHand = append(onHand, item)
+	}
+	return onHand, rows.Err()
+}
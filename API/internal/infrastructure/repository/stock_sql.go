@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"strings"
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// stockSelect loads a stock together with its product, audit users,
+// provider and, when it has been put away, its location. It is plain SQL
+// shared by the MySQL and SQLite repositories.
+const stockSelect = `
+	SELECT
+		s.id, s.serial, s.status, s.status_changed_at,
+		s.created_at, s.updated_at, s.version,
+		s.batch, s.purchase_date,
+		s.deleted_at, s.deleted_by_user_id,
+		p.id, p.name, p.code, p.image_url,
+		u1.id, u1.name, u1.email, u1.role,
+		u2.id, u2.name, u2.email, u2.role,
+		pr.id, pr.name, pr.email, pr.phone, pr.address,
+		l.id, l.code, l.description,
+		w.id, w.code, w.name
+	FROM stocks s
+	JOIN products p ON s.product_id = p.id
+	JOIN users u1 ON s.created_by_user_id = u1.id
+	JOIN users u2 ON s.updated_by_user_id = u2.id
+	JOIN providers pr ON s.provider_id = pr.id
+	LEFT JOIN locations l ON s.location_id = l.id
+	LEFT JOIN warehouses w ON l.warehouse_id = w.id
+`
+
+// stockFilterClause builds the WHERE clause for a stock filter. prefix is the
+// table alias used by the query, e.g. "s.". Both MySQL and SQLite use "?"
+// placeholders, so the clause is shared between them.
+func stockFilterClause(filter domain.StockFilter, prefix string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, notDeleted(prefix))
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, prefix+"status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.WarehouseID != 0 {
+		conditions = append(conditions, prefix+"location_id IN (SELECT id FROM locations WHERE warehouse_id = ?)")
+		args = append(args, filter.WarehouseID)
+	}
+	if filter.LocationID != 0 {
+		conditions = append(conditions, prefix+"location_id = ?")
+		args = append(args, filter.LocationID)
+	}
+	if filter.ProductID != 0 {
+		conditions = append(conditions, prefix+"product_id = ?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.ProviderID != 0 {
+		conditions = append(conditions, prefix+"provider_id = ?")
+		args = append(args, filter.ProviderID)
+	}
+	if filter.Batch != "" {
+		conditions = append(conditions, prefix+"batch = ?")
+		args = append(args, filter.Batch)
+	}
+	if !filter.PurchasedAfter.IsZero() {
+		conditions = append(conditions, prefix+"purchase_date >= ?")
+		args = append(args, filter.PurchasedAfter)
+	}
+	if !filter.PurchasedBefore.IsZero() {
+		conditions = append(conditions, prefix+"purchase_date <= ?")
+		args = append(args, filter.PurchasedBefore)
+	}
+
+	return whereClause(conditions), args
+}
+
+// nullableID stores a zero ID as NULL
+func nullableID(id int64) sql.NullInt64 {
+	if id == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: id, Valid: true}
+}
+
+// stockLocationID returns the ID of the location holding stock, or NULL
+func stockLocationID(stock *domain.Stock) sql.NullInt64 {
+	if stock.Location == nil {
+		return sql.NullInt64{}
+	}
+	return nullableID(stock.Location.ID)
+}
+
+// insertStock stores a new unit through exec, which may be a transaction.
+// Timestamps are taken from stock as given. It is shared by the MySQL and
+// SQLite repositories.
+func insertStock(ctx context.Context, exec execer, stock *domain.Stock) error {
+	if stock.Status == "" {
+		stock.Status = domain.StockAvailable
+	}
+
+	result, err := exec.ExecContext(ctx, `
+		INSERT INTO stocks (
+			product_id, serial, status, status_changed_at,
+			created_at, updated_at,
+			created_by_user_id, updated_by_user_id,
+			batch, purchase_date, provider_id, location_id
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, stock.Product.ID, stock.Serial, stock.Status, stock.StatusChangedAt,
+		stock.CreatedAt, stock.UpdatedAt,
+		stock.CreatedByUser.ID, stock.UpdatedByUser.ID,
+		stock.Batch, stock.PurchaseDate, stock.Provider.ID, stockLocationID(stock))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	stock.ID = id
+	stock.Version = 1
+	return nil
+}
+
+// bulkRows is how many rows a multi-row insert or an IN list carries at most,
+// keeping the placeholders of a statement under SQLite's limit of 999
+const bulkRows = 50
+
+// chunks splits n items into consecutive [start, end) ranges of at most
+// bulkRows items
+func chunks(n int) [][2]int {
+	var ranges [][2]int
+	for start := 0; start < n; start += bulkRows {
+		end := start + bulkRows
+		if end > n {
+			end = n
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// placeholders returns n comma separated copies of row, e.g. "(?, ?)"
+func placeholders(row string, n int) string {
+	return strings.TrimSuffix(strings.Repeat(row+", ", n), ", ")
+}
+
+// insertStocks stores new units through conn with multi-row inserts, which
+// should run in a transaction, and sets their IDs and versions. Timestamps
+// are taken from the units as given. It is shared by the MySQL and SQLite
+// repositories.
+func insertStocks(ctx context.Context, conn dbConn, stocks []*domain.Stock) error {
+	for _, r := range chunks(len(stocks)) {
+		chunk := stocks[r[0]:r[1]]
+		args := make([]interface{}, 0, len(chunk)*12)
+		serials := make([]string, len(chunk))
+		for i, stock := range chunk {
+			if stock.Status == "" {
+				stock.Status = domain.StockAvailable
+			}
+			args = append(args, stock.Product.ID, stock.Serial, stock.Status, stock.StatusChangedAt,
+				stock.CreatedAt, stock.UpdatedAt,
+				stock.CreatedByUser.ID, stock.UpdatedByUser.ID,
+				stock.Batch, stock.PurchaseDate, stock.Provider.ID, stockLocationID(stock))
+			serials[i] = stock.Serial
+		}
+
+		_, err := conn.ExecContext(ctx, `
+			INSERT INTO stocks (
+				product_id, serial, status, status_changed_at,
+				created_at, updated_at,
+				created_by_user_id, updated_by_user_id,
+				batch, purchase_date, provider_id, location_id
+			)
+			VALUES `+placeholders("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", len(chunk)), args...)
+		if err != nil {
+			return err
+		}
+
+		// The IDs generated by a multi-row insert are reported differently by
+		// MySQL and SQLite, so they are read back through the unique serials
+		ids, err := stockIDsBySerial(ctx, conn, serials)
+		if err != nil {
+			return err
+		}
+		for _, stock := range chunk {
+			stock.ID = ids[stock.Serial]
+			stock.Version = 1
+		}
+	}
+	return nil
+}
+
+// stockIDsBySerial maps each of serials, at most bulkRows of them, to the ID of
+// the unit carrying it
+func stockIDsBySerial(ctx context.Context, q queryer, serials []string) (map[string]int64, error) {
+	args := make([]interface{}, len(serials))
+	for i, serial := range serials {
+		args[i] = serial
+	}
+	rows, err := q.QueryContext(ctx, "SELECT id, serial FROM stocks WHERE serial IN ("+placeholders("?", len(serials))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64, len(serials))
+	for rows.Next() {
+		var id int64
+		var serial string
+		if err := rows.Scan(&id, &serial); err != nil {
+			return nil, err
+		}
+		ids[serial] = id
+	}
+	return ids, rows.Err()
+}
+
+// existingSerials returns those of serials carried by a unit, deleted or not.
+// It is shared by the MySQL and SQLite repositories.
+func existingSerials(ctx context.Context, q queryer, serials []string) ([]string, error) {
+	var existing []string
+	for _, r := range chunks(len(serials)) {
+		ids, err := stockIDsBySerial(ctx, q, serials[r[0]:r[1]])
+		if err != nil {
+			return nil, err
+		}
+		for _, serial := range serials[r[0]:r[1]] {
+			if _, ok := ids[serial]; ok {
+				existing = append(existing, serial)
+			}
+		}
+	}
+	return existing, nil
+}
+
+// andClause turns a WHERE clause into one that can follow an existing condition
+func andClause(where string) string {
+	return strings.Replace(where, " WHERE ", " AND ", 1)
+}
+
+// nullableLocation holds the LEFT JOINed location columns of a stock row
+type nullableLocation struct {
+	id            sql.NullInt64
+	code          sql.NullString
+	description   sql.NullString
+	warehouseID   sql.NullInt64
+	warehouseCode sql.NullString
+	warehouseName sql.NullString
+}
+
+func (l *nullableLocation) dest(extra ...interface{}) []interface{} {
+	return append([]interface{}{
+		&l.id, &l.code, &l.description,
+		&l.warehouseID, &l.warehouseCode, &l.warehouseName,
+	}, extra...)
+}
+
+func (l *nullableLocation) location() *domain.Location {
+	if !l.id.Valid {
+		return nil
+	}
+	return &domain.Location{
+		ID:          l.id.Int64,
+		Code:        l.code.String,
+		Description: l.description.String,
+		Warehouse: &domain.Warehouse{
+			ID:   l.warehouseID.Int64,
+			Code: l.warehouseCode.String,
+			Name: l.warehouseName.String,
+		},
+	}
+}
+
+func scanStock(row rowScanner) (*domain.Stock, error) {
+	stock := domain.Stock{
+		Product:       &domain.Product{},
+		CreatedByUser: &domain.User{},
+		UpdatedByUser: &domain.User{},
+		Provider:      &domain.Provider{},
+	}
+	var loc nullableLocation
+	err := row.Scan(
+		&stock.ID,
+		&stock.Serial,
+		&stock.Status,
+		&stock.StatusChangedAt,
+		&stock.CreatedAt,
+		&stock.UpdatedAt,
+		&stock.Version,
+		&stock.Batch,
+		&stock.PurchaseDate,
+		&stock.DeletedAt,
+		&stock.DeletedBy,
+		&stock.Product.ID,
+		&stock.Product.Name,
+		&stock.Product.Code,
+		&stock.Product.ImageURL,
+		&stock.CreatedByUser.ID,
+		&stock.CreatedByUser.Name,
+		&stock.CreatedByUser.Email,
+		&stock.CreatedByUser.Role,
+		&stock.UpdatedByUser.ID,
+		&stock.UpdatedByUser.Name,
+		&stock.UpdatedByUser.Email,
+		&stock.UpdatedByUser.Role,
+		&stock.Provider.ID,
+		&stock.Provider.Name,
+		&stock.Provider.Email,
+		&stock.Provider.Phone,
+		&stock.Provider.Address,
+		&loc.id,
+		&loc.code,
+		&loc.description,
+		&loc.warehouseID,
+		&loc.warehouseCode,
+		&loc.warehouseName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stock.Location = loc.location()
+	return &stock, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"inventario/internal/domain"
+	"time"
+)
+
+// transferSelect loads a transfer together with its source and destination
+// locations. MySQL and SQLite share it, as they share the transfer helpers
+// below: everything here runs either on *sql.DB or inside a *sql.Tx.
+const transferSelect = `
+	SELECT
+		t.id, t.status, t.notes, t.created_by_user_id,
+		t.dispatched_at, t.received_at, t.created_at, t.updated_at,
+		ls.id, ls.code, ls.description, ws.id, ws.code, ws.name,
+		ld.id, ld.code, ld.description, wd.id, wd.code, wd.name
+	FROM transfers t
+	JOIN locations ls ON t.source_location_id = ls.id
+	JOIN warehouses ws ON ls.warehouse_id = ws.id
+	JOIN locations ld ON t.destination_location_id = ld.id
+	JOIN warehouses wd ON ld.warehouse_id = wd.id
+`
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func scanTransfer(row rowScanner) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	var createdByUserID sql.NullInt64
+	var dispatchedAt, receivedAt sql.NullTime
+	var source, destination nullableLocation
+
+	dest := []interface{}{
+		&transfer.ID, &transfer.Status, &transfer.Notes, &createdByUserID,
+		&dispatchedAt, &receivedAt, &transfer.CreatedAt, &transfer.UpdatedAt,
+	}
+	dest = append(dest, source.dest()...)
+	dest = append(dest, destination.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	transfer.Source = source.location()
+	transfer.Destination = destination.location()
+	if createdByUserID.Valid {
+		transfer.CreatedByUser = &domain.User{ID: createdByUserID.Int64}
+	}
+	if dispatchedAt.Valid {
+		transfer.DispatchedAt = &dispatchedAt.Time
+	}
+	if receivedAt.Valid {
+		transfer.ReceivedAt = &receivedAt.Time
+	}
+	return &transfer, nil
+}
+
+func getTransfer(ctx context.Context, q queryer, id int64) (*domain.Transfer, error) {
+	transfer, err := scanTransfer(q.QueryRowContext(ctx, transferSelect+" WHERE t.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.Items, err = loadTransferItems(ctx, q, transfer.ID); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func getAllTransfers(ctx context.Context, q queryer, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	query := transferSelect
+	var args []interface{}
+	if filter.Status != "" {
+		query += " WHERE t.status = ?"
+		args = append(args, filter.Status)
+	}
+
+	rows, err := q.QueryContext(ctx, query+" ORDER BY t.id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []domain.Transfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range transfers {
+		if transfers[i].Items, err = loadTransferItems(ctx, q, transfers[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return transfers, nil
+}
+
+func loadTransferItems(ctx context.Context, q queryer, transferID int64) ([]domain.TransferItem, error) {
+	rows, err := q.QueryContext(ctx, "SELECT stock_id, serial FROM transfer_items WHERE transfer_id = ? ORDER BY id", transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.TransferItem
+	for rows.Next() {
+		var item domain.TransferItem
+		if err := rows.Scan(&item.StockID, &item.Serial); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// insertTransfer stores a transfer document and its items
+func insertTransfer(ctx context.Context, tx *sql.Tx, transfer *domain.Transfer, now time.Time) error {
+	var createdBy sql.NullInt64
+	if transfer.CreatedByUser != nil {
+		createdBy = nullableID(transfer.CreatedByUser.ID)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO transfers (
+			source_location_id, destination_location_id, status, notes,
+			created_by_user_id, created_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, transfer.Source.ID, transfer.Destination.ID, transfer.Status, transfer.Notes,
+		createdBy, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range transfer.Items {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO transfer_items (transfer_id, stock_id, serial) VALUES (?, ?, ?)",
+			id, item.StockID, item.Serial,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	transfer.ID = id
+	transfer.CreatedAt = now
+	transfer.UpdatedAt = now
+	return nil
+}
+
+func updateTransferStatus(ctx context.Context, exec execer, transfer *domain.Transfer, now time.Time) error {
+	result, err := exec.ExecContext(ctx, `
+		UPDATE transfers
+		SET status = ?, dispatched_at = ?, received_at = ?, updated_at = ?
+		WHERE id = ?
+	`, transfer.Status, transfer.DispatchedAt, transfer.ReceivedAt, now, transfer.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return &domain.TransferNotFoundError{TransferID: transfer.ID}
+	}
+
+	transfer.UpdatedAt = now
+	return nil
+}
+
+// receiveTransfer moves the units of an in-transit transfer to its
+// destination, records their movements and marks the transfer received
+func receiveTransfer(ctx context.Context, tx *sql.Tx, transfer *domain.Transfer, actor *domain.User, movements []*domain.StockMovement, now time.Time) error {
+	for _, item := range transfer.Items {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE stocks
+			SET location_id = ?, updated_at = ?, updated_by_user_id = ?, version = version + 1
+			WHERE id = ? AND location_id = ? AND deleted_at IS NULL
+		`, transfer.Destination.ID, now, actor.ID, item.StockID, transfer.Source.ID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return &domain.TransferConflictError{Serial: item.Serial}
+		}
+	}
+
+	for _, movement := range movements {
+		movement.CreatedAt = now
+		if err := insertStockMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+	}
+
+	// Guard against the same transfer being received twice concurrently
+	result, err := tx.ExecContext(ctx, `
+		UPDATE transfers
+		SET status = ?, received_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`, domain.TransferReceived, now, now, transfer.ID, domain.TransferInTransit)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.InvalidTransferTransitionError{From: transfer.Status, To: domain.TransferReceived}
+	}
+
+	transfer.Status = domain.TransferReceived
+	transfer.ReceivedAt = &now
+	transfer.UpdatedAt = now
+	return nil
+}
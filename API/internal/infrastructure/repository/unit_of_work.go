@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// dbConn is what the SQL repositories run their statements on: the database,
+// or the transaction of the unit of work in progress
+type dbConn interface {
+	execer
+	queryer
+}
+
+// txKey is the context key of the transaction of a unit of work
+type txKey struct{}
+
+// contextTx is a transaction of a unit of work and the database it belongs to,
+// with the number of units of work nested in it
+type contextTx struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	depth int
+}
+
+// SQLUnitOfWork runs units of work in a transaction of a MySQL or SQLite
+// database. The repositories built on the same *sql.DB join it through the
+// context.
+type SQLUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewSQLUnitOfWork(db *sql.DB) *SQLUnitOfWork {
+	return &SQLUnitOfWork{db: db}
+}
+
+func (u *SQLUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if current, ok := ctx.Value(txKey{}).(*contextTx); ok && current.db == u.db {
+		return inSavepoint(ctx, current, fn)
+	}
+	return inTx(ctx, u.db, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, &contextTx{db: u.db, tx: tx}))
+	})
+}
+
+func (u *SQLUnitOfWork) CanRollBack() bool {
+	return true
+}
+
+// inSavepoint runs fn in a savepoint of the transaction of outer, which is
+// rolled back to when fn returns an error or panics. Both MySQL and SQLite
+// keep the savepoint after rolling back to it, so it is released either way.
+func inSavepoint(ctx context.Context, outer *contextTx, fn func(ctx context.Context) error) (err error) {
+	inner := &contextTx{db: outer.db, tx: outer.tx, depth: outer.depth + 1}
+	name := "uow_" + strconv.Itoa(inner.depth)
+	if _, err := inner.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	rollback := func() {
+		inner.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		inner.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, inner)); err != nil {
+		rollback()
+		return err
+	}
+	_, err = inner.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// MemoryUnitOfWork is the unit of work of the in-memory repositories. It only
+// calls the function: their writes are not transactional and are kept when a
+// later step fails.
+type MemoryUnitOfWork struct{}
+
+func NewMemoryUnitOfWork() *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{}
+}
+
+func (u *MemoryUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (u *MemoryUnitOfWork) CanRollBack() bool {
+	return false
+}
+
+// txFromContext returns the transaction on db of the unit of work running in
+// ctx, if any
+func txFromContext(ctx context.Context, db *sql.DB) *sql.Tx {
+	if current, ok := ctx.Value(txKey{}).(*contextTx); ok && current.db == db {
+		return current.tx
+	}
+	return nil
+}
+
+// connFor returns the transaction on db of the unit of work running in ctx,
+// or db itself outside of one
+func connFor(ctx context.Context, db *sql.DB) dbConn {
+	if tx := txFromContext(ctx, db); tx != nil {
+		return tx
+	}
+	return db
+}
+
+// inTx runs fn in the transaction on db of the unit of work running in ctx, or
+// in a transaction of its own outside of one
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	if tx := txFromContext(ctx, db); tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"inventario/internal/domain"
+)
+
+// versionConflict explains why an update of the row id of table, guarded by
+// version, changed nothing: a live row was modified since, anything else
+// gives notFound
+func versionConflict(ctx context.Context, q queryer, table, entity string, id, version int64, notFound error) error {
+	var current int64
+	err := q.QueryRowContext(ctx, "SELECT version FROM "+table+" WHERE id = ? AND deleted_at IS NULL", id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	return &domain.VersionMismatchError{Entity: entity, ID: id, Version: version}
+}
@@ -0,0 +1,128 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"inventario/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMACTokenService issues and verifies JWTs signed with HS256
+type HMACTokenService struct {
+	secret []byte
+	now    func() time.Time
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type tokenPayload struct {
+	Sub string `json:"sub"`
+	Typ string `json:"typ"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+}
+
+var encodedHeader = mustEncodeSegment(tokenHeader{Alg: "HS256", Typ: "JWT"})
+
+func NewHMACTokenService(secret []byte) (*HMACTokenService, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("token secret must be at least 32 bytes long")
+	}
+	return &HMACTokenService{
+		secret: secret,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *HMACTokenService) Generate(userID int64, tokenType domain.TokenType, ttl time.Duration) (string, error) {
+	now := s.now()
+	payload, err := encodeSegment(tokenPayload{
+		Sub: strconv.FormatInt(userID, 10),
+		Typ: string(tokenType),
+		Iat: now.Unix(),
+		Exp: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodedHeader + "." + payload
+	return signingInput + "." + s.sign(signingInput), nil
+}
+
+func (s *HMACTokenService) Parse(token string) (*domain.TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, &domain.InvalidTokenError{Reason: "malformed token"}
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, &domain.InvalidTokenError{Reason: "unsupported token header"}
+	}
+
+	expected := s.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, &domain.InvalidTokenError{Reason: "signature mismatch"}
+	}
+
+	var payload tokenPayload
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, &domain.InvalidTokenError{Reason: "malformed payload"}
+	}
+
+	userID, err := strconv.ParseInt(payload.Sub, 10, 64)
+	if err != nil {
+		return nil, &domain.InvalidTokenError{Reason: "malformed subject"}
+	}
+
+	expiresAt := time.Unix(payload.Exp, 0)
+	if !s.now().Before(expiresAt) {
+		return nil, &domain.InvalidTokenError{Reason: "token expired"}
+	}
+
+	return &domain.TokenClaims{
+		UserID:    userID,
+		Type:      domain.TokenType(payload.Typ),
+		IssuedAt:  time.Unix(payload.Iat, 0),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *HMACTokenService) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func mustEncodeSegment(v interface{}) string {
+	segment, err := encodeSegment(v)
+	if err != nil {
+		panic(err)
+	}
+	return segment
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,38 @@
+package security
+
+import "testing"
+
+func TestPBKDF2HasherVerify(t *testing.T) {
+	hasher := NewPBKDF2Hasher(1000)
+	hash, err := hasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("failed to hash: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		hash          string
+		password      string
+		expectedMatch bool
+		expectedError bool
+	}{
+		{name: "matching password", hash: hash, password: "secret", expectedMatch: true},
+		{name: "wrong password", hash: hash, password: "other"},
+		{name: "legacy plain password", hash: "secret", password: "secret", expectedMatch: true},
+		{name: "empty legacy password", hash: "", password: ""},
+		{name: "empty key", hash: "$pbkdf2-sha256$v=1$i=1000$c2FsdA$", password: "anything", expectedError: true},
+		{name: "unknown scheme", hash: "$md5$v=1$i=1$c2FsdA$a2V5", password: "secret", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, _, err := hasher.Verify(tt.hash, tt.password)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if match != tt.expectedMatch {
+				t.Errorf("expected match %v, got %v", tt.expectedMatch, match)
+			}
+		})
+	}
+}
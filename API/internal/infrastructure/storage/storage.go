@@ -0,0 +1,210 @@
+// Package storage builds the repositories of the configured database backend
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/migration"
+	"inventario/internal/infrastructure/repository"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Supported drivers
+const (
+	DriverMySQL        = "mysql"
+	DriverSQLite       = "sqlite"
+	DriverSQLiteMemory = "sqlite-memory"
+	DriverMemory       = "memory"
+)
+
+// Config selects the storage backend. DSN is a MySQL data source name for
+// mysql and a file path or SQLite URI for sqlite; sqlite-memory and memory
+// ignore it.
+type Config struct {
+	Driver string
+	DSN    string
+	// Migrate applies pending migrations on open instead of only checking them.
+	// The sqlite-memory backend always starts empty and is always migrated;
+	// memory has no schema.
+	Migrate bool
+}
+
+// Storage holds the repositories of one backend. DB is nil for the memory
+// backend.
+type Storage struct {
+	DB      *sql.DB
+	Dialect migration.Dialect
+
+	Products       domain.IProductRepository
+	Users          domain.IUserRepository
+	Providers      domain.IProviderRepository
+	Stocks         domain.IStockRepository
+	StockMovements domain.IStockMovementRepository
+	Warehouses     domain.IWarehouseRepository
+	Locations      domain.ILocationRepository
+	Transfers      domain.ITransferRepository
+	PurchaseOrders domain.IPurchaseOrderRepository
+	StockBalances  domain.IStockBalanceRepository
+
+	// UnitOfWork runs calls to several of the repositories above in one
+	// transaction
+	UnitOfWork domain.UnitOfWork
+}
+
+// memoryDatabases numbers the in-memory databases so that each Open gets its own
+var memoryDatabases int64
+
+// Open connects to the configured backend, checks or applies its migrations
+// and builds its repositories
+func Open(cfg Config) (*Storage, error) {
+	switch cfg.Driver {
+	case DriverMySQL:
+		db, err := sql.Open("mysql", cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return open(db, migration.MySQL, cfg.Migrate)
+	case DriverSQLite:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("the sqlite driver requires a DSN")
+		}
+		db, err := openSQLite(cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return open(db, migration.SQLite, cfg.Migrate)
+	case DriverSQLiteMemory:
+		// A private in-memory SQLite database keeps every repository, including
+		// those whose writes span several tables, consistent with each other
+		name := atomic.AddInt64(&memoryDatabases, 1)
+		db, err := openSQLite(fmt.Sprintf("file:inventario-%d?mode=memory", name))
+		if err != nil {
+			return nil, err
+		}
+		return open(db, migration.SQLite, true)
+	case DriverMemory:
+		return openMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// openSQLite opens a SQLite database on a single connection, which serializes
+// writers instead of failing with "database is locked" and keeps in-memory
+// databases alive, and enforces foreign keys like MySQL does
+func openSQLite(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func open(db *sql.DB, dialect migration.Dialect, apply bool) (*Storage, error) {
+	if err := migrate(db, dialect, apply); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+
+	s := &Storage{DB: db, Dialect: dialect, UnitOfWork: repository.NewSQLUnitOfWork(db)}
+	if dialect == migration.MySQL {
+		s.Products = repository.NewMySQLProductRepository(db)
+		s.Users = repository.NewMySQLUserRepository(db)
+		s.Providers = repository.NewMySQLProviderRepository(db)
+		s.Stocks = repository.NewMySQLStockRepository(db)
+		s.StockMovements = repository.NewMySQLStockMovementRepository(db)
+		s.Warehouses = repository.NewMySQLWarehouseRepository(db)
+		s.Locations = repository.NewMySQLLocationRepository(db)
+		s.Transfers = repository.NewMySQLTransferRepository(db)
+		s.PurchaseOrders = repository.NewMySQLPurchaseOrderRepository(db)
+		s.StockBalances = repository.NewMySQLStockBalanceRepository(db)
+	} else {
+		s.Products = repository.NewSQLiteProductRepository(db)
+		s.Users = repository.NewSQLiteUserRepository(db)
+		s.Providers = repository.NewSQLiteProviderRepository(db)
+		s.Stocks = repository.NewSQLiteStockRepository(db)
+		s.StockMovements = repository.NewSQLiteStockMovementRepository(db)
+		s.Warehouses = repository.NewSQLiteWarehouseRepository(db)
+		s.Locations = repository.NewSQLiteLocationRepository(db)
+		s.Transfers = repository.NewSQLiteTransferRepository(db)
+		s.PurchaseOrders = repository.NewSQLitePurchaseOrderRepository(db)
+		s.StockBalances = repository.NewSQLiteStockBalanceRepository(db)
+	}
+	return s, nil
+}
+
+// openMemory builds the in-memory repositories. They do not enforce
+// references, and their unit of work does not roll back.
+func openMemory() *Storage {
+	products := repository.NewMemoryProductRepository()
+	users := repository.NewMemoryUserRepository()
+	providers := repository.NewMemoryProviderRepository()
+	warehouses := repository.NewMemoryWarehouseRepository()
+	locations := repository.NewMemoryLocationRepository(warehouses)
+	movements := repository.NewMemoryStockMovementRepository(users)
+	stocks := repository.NewMemoryStockRepository(products, users, providers, locations, movements)
+
+	return &Storage{
+		Products:       products,
+		Users:          users,
+		Providers:      providers,
+		Stocks:         stocks,
+		StockMovements: movements,
+		Warehouses:     warehouses,
+		Locations:      locations,
+		Transfers:      repository.NewMemoryTransferRepository(locations, stocks, movements),
+		PurchaseOrders: repository.NewMemoryPurchaseOrderRepository(products, providers, stocks, movements),
+		StockBalances:  repository.NewMemoryStockBalanceRepository(products, locations),
+		UnitOfWork:     repository.NewMemoryUnitOfWork(),
+	}
+}
+
+// migrate applies the pending migrations when apply is set. Otherwise it only
+// verifies the applied ones and warns about those still pending.
+func migrate(db *sql.DB, dialect migration.Dialect, apply bool) error {
+	migrator, err := migration.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	if apply {
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+		}
+		return err
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		log.Printf("Warning: %d pending migrations; run cmd/migrate or set MIGRATE_ON_STARTUP=true", len(pending))
+	}
+	return nil
+}
+
+// Close closes the underlying database, if any
+func (s *Storage) Close() error {
+	if s.DB == nil {
+		return nil
+	}
+	return s.DB.Close()
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"inventario/internal/domain"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenSQLiteMemory(t *testing.T) {
+	first, err := Open(Config{Driver: DriverSQLiteMemory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer first.Close()
+
+	product := &domain.Product{Name: "Cable", Code: "CBL", TrackingMode: domain.TrackingQuantity}
+	if err := first.Products.Create(context.Background(), product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := first.Products.GetByID(context.Background(), product.ID)
+	if err != nil || got == nil || got.Code != "CBL" {
+		t.Fatalf("expected product to be stored, got %v, %v", got, err)
+	}
+
+	second, err := Open(Config{Driver: DriverSQLiteMemory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer second.Close()
+
+	got, err = second.Products.GetByID(context.Background(), product.ID)
+	if err != nil || got != nil {
+		t.Errorf("expected memory databases to be independent, got %v, %v", got, err)
+	}
+}
+
+func TestOpenMemory(t *testing.T) {
+	s, err := Open(Config{Driver: DriverMemory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	if s.DB != nil {
+		t.Errorf("expected no database, got %v", s.DB)
+	}
+	ctx := context.Background()
+
+	// Receiving a transfer moves its units through the stock repository
+	user := &domain.User{Name: "Ana", Email: "ana@example.com", Password: "x", Role: domain.RoleAdmin}
+	product := &domain.Product{Name: "Monitor", Code: "MON", TrackingMode: domain.TrackingSerialized}
+	provider := &domain.Provider{Name: "acme", Email: "sales@acme.example"}
+	warehouse := &domain.Warehouse{Code: "WH-A"}
+	for _, create := range []error{
+		s.Users.Create(ctx, user),
+		s.Products.Create(ctx, product),
+		s.Providers.Create(ctx, provider),
+		s.Warehouses.Create(ctx, warehouse),
+	} {
+		if create != nil {
+			t.Fatalf("unexpected error: %v", create)
+		}
+	}
+	var locations []*domain.Location
+	for _, code := range []string{"R1", "R2"} {
+		location := &domain.Location{Warehouse: warehouse, Code: code}
+		if err := s.Locations.Create(ctx, location); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		locations = append(locations, location)
+	}
+	stock := &domain.Stock{Product: product, Serial: "SN-1", Provider: provider, CreatedByUser: user, UpdatedByUser: user, Location: locations[0]}
+	if err := s.Stocks.Create(ctx, stock); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transfer := &domain.Transfer{
+		Source:      locations[0],
+		Destination: locations[1],
+		Status:      domain.TransferInTransit,
+		Items:       []domain.TransferItem{{StockID: stock.ID, Serial: stock.Serial}},
+	}
+	if err := s.Transfers.Create(ctx, transfer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved := *stock
+	moved.Location = locations[1]
+	movement := domain.NewStockMovement(domain.MovementTransfer, user, stock, &moved, "")
+	if err := s.Transfers.Receive(ctx, transfer, user, []*domain.StockMovement{movement}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := s.Stocks.GetByID(ctx, stock.ID)
+	if err != nil || got == nil || got.Location == nil || got.Location.Code != "R2" || got.Location.Warehouse.Code != "WH-A" {
+		t.Fatalf("expected the unit at R2, got %+v, %v", got, err)
+	}
+	history, err := s.StockMovements.GetBySerial(ctx, "SN-1")
+	if err != nil || len(history) != 1 || history[0].Actor == nil || history[0].Actor.Name != "Ana" {
+		t.Errorf("expected the transfer in the ledger, got %+v, %v", history, err)
+	}
+
+	// The unit has left the source of a second transfer
+	again := &domain.Transfer{Source: locations[0], Destination: locations[1], Status: domain.TransferInTransit, Items: transfer.Items}
+	if err := s.Transfers.Create(ctx, again); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var conflict *domain.TransferConflictError
+	if err := s.Transfers.Receive(ctx, again, user, nil); !errors.As(err, &conflict) {
+		t.Errorf("expected a transfer conflict, got %v", err)
+	}
+
+	other, err := Open(Config{Driver: DriverMemory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := other.Products.GetByID(ctx, product.ID); err != nil || got != nil {
+		t.Errorf("expected memory backends to be independent, got %v, %v", got, err)
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "inventario.db")
+
+	if _, err := Open(Config{Driver: DriverSQLite}); err == nil {
+		t.Error("expected error without DSN")
+	}
+
+	s, err := Open(Config{Driver: DriverSQLite, DSN: dsn, Migrate: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user := &domain.User{Name: "Ana", Email: "ana@example.com", Password: "x", Role: domain.RoleAdmin}
+	if err := s.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Close()
+
+	// Reopening without migrating keeps the data and the applied schema
+	s, err = Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	got, err := s.Users.GetByEmail(context.Background(), "ana@example.com")
+	if err != nil || got == nil {
+		t.Fatalf("expected user to persist, got %v, %v", got, err)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Error("expected created_at to be set")
+	}
+}
+
+func TestOpenUnknownDriver(t *testing.T) {
+	if _, err := Open(Config{Driver: "postgres"}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+}
+
+func TestUnitOfWork(t *testing.T) {
+	s, err := Open(Config{Driver: DriverSQLiteMemory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	// createBoth stores a product and a provider named after code in the
+	// context's unit of work, reading the product back inside it
+	createBoth := func(ctx context.Context, code string) error {
+		product := &domain.Product{Name: code, Code: code, TrackingMode: domain.TrackingQuantity}
+		if err := s.Products.Create(ctx, product); err != nil {
+			return err
+		}
+		if got, err := s.Products.GetByID(ctx, product.ID); err != nil || got == nil {
+			t.Errorf("expected product to be visible inside the unit of work, got %v, %v", got, err)
+		}
+		return s.Providers.Create(ctx, &domain.Provider{Name: code, Email: code + "@example.com"})
+	}
+	stored := func(code string) bool {
+		t.Helper()
+		products, err := s.Products.GetAll(ctx, domain.ProductFilter{Code: code})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		providers, err := s.Providers.GetAll(ctx, domain.ProviderFilter{Email: code + "@example.com"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(products) != len(providers) {
+			t.Fatalf("expected product and provider %s to be stored together, got %d and %d", code, len(products), len(providers))
+		}
+		return len(products) == 1
+	}
+
+	if err := s.UnitOfWork.Do(ctx, func(ctx context.Context) error { return createBoth(ctx, "committed") }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stored("committed") {
+		t.Error("expected committed writes to be stored")
+	}
+
+	failure := errors.New("step failed")
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := createBoth(ctx, "failed"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("expected the error of the function, got %v", err)
+	}
+	if stored("failed") {
+		t.Error("expected writes to be rolled back on error")
+	}
+
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.UnitOfWork.Do(ctx, func(ctx context.Context) error { return createBoth(ctx, "nested") }); err != nil {
+			return err
+		}
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("expected the error of the function, got %v", err)
+	}
+	if stored("nested") {
+		t.Error("expected a nested unit of work to be rolled back with the outer one")
+	}
+
+	// A failing nested unit of work only rolls back its own writes
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := createBoth(ctx, "outer"); err != nil {
+			return err
+		}
+		err := s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := createBoth(ctx, "undone"); err != nil {
+				return err
+			}
+			return failure
+		})
+		if err != failure {
+			t.Errorf("expected the error of the nested function, got %v", err)
+		}
+		return s.UnitOfWork.Do(ctx, func(ctx context.Context) error { return createBoth(ctx, "kept") })
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stored("outer") || !stored("kept") || stored("undone") {
+		t.Error("expected only the failed nested unit of work to be rolled back")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to be propagated")
+			}
+		}()
+		s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := createBoth(ctx, "panicked"); err != nil {
+				return err
+			}
+			panic("step panicked")
+		})
+	}()
+	if stored("panicked") {
+		t.Error("expected writes to be rolled back on panic")
+	}
+}
@@ -0,0 +1,420 @@
+// Package xlsx writes Office Open XML workbooks with the standard library
+// only. Rows are written to the zip archive as they are given, so the size of
+// a workbook does not bound the memory used to write it.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSheetName is the longest name a sheet may have
+const MaxSheetName = 31
+
+// Styles of styles.xml, by their index in cellXfs
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleDateTime
+)
+
+type cellKind int
+
+const (
+	cellEmpty cellKind = iota
+	cellString
+	cellNumber
+	cellDate
+	cellDateTime
+)
+
+// Cell is the value of a cell, made by String, Int, Number, Date or DateTime.
+// The zero Cell is empty.
+type Cell struct {
+	kind   cellKind
+	text   string
+	number float64
+}
+
+// String is a text cell
+func String(s string) Cell {
+	return Cell{kind: cellString, text: s}
+}
+
+// Int is a number cell holding an integer
+func Int(n int64) Cell {
+	return Cell{kind: cellNumber, text: strconv.FormatInt(n, 10)}
+}
+
+// Number is a number cell
+func Number(f float64) Cell {
+	return Cell{kind: cellNumber, text: strconv.FormatFloat(f, 'f', -1, 64)}
+}
+
+// Date is a cell holding the day of t, shown as YYYY-MM-DD, or an empty cell
+// when t is zero
+func Date(t time.Time) Cell {
+	if t.IsZero() {
+		return Cell{}
+	}
+	y, m, d := t.Date()
+	return Cell{kind: cellDate, number: serial(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))}
+}
+
+// DateTime is a cell holding t in UTC, shown as YYYY-MM-DD hh:mm:ss, or an
+// empty cell when t is zero
+func DateTime(t time.Time) Cell {
+	if t.IsZero() {
+		return Cell{}
+	}
+	return Cell{kind: cellDateTime, number: serial(t.UTC())}
+}
+
+// excelEpoch is day 0 of the 1900 date system, which counts 1900 as a leap
+// year; starting on the day before lets the count be right from March 1900
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serial is t as the number of days since excelEpoch, to the second, as
+// spreadsheets store dates
+func serial(t time.Time) float64 {
+	return float64(t.Sub(excelEpoch)/time.Second) / 86400
+}
+
+// Column is a column of a sheet: the text of its header cell and its width in
+// characters, or 0 for the default width
+type Column struct {
+	Header string
+	Width  float64
+}
+
+// Writer writes a workbook to an io.Writer, one sheet after another
+type Writer struct {
+	zip    *zip.Writer
+	sheets []sheetInfo
+	names  map[string]bool
+	sheet  *Sheet
+	err    error
+}
+
+// sheetInfo is what the workbook part lists about a written sheet
+type sheetInfo struct {
+	name string
+	// filter is the range of the autofilter, such as A1:D10
+	filter string
+}
+
+// Sheet is the sheet being written. Its first row holds the headers of its
+// columns, stays in view when scrolling and has an autofilter over the rows.
+type Sheet struct {
+	w       *Writer
+	buf     *bufio.Writer
+	columns int
+	rows    int
+}
+
+// NewWriter starts a workbook written to w. Close must be called for it to
+// be complete.
+func NewWriter(w io.Writer) (*Writer, error) {
+	writer := &Writer{zip: zip.NewWriter(w), names: make(map[string]bool)}
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/styles.xml", stylesXML},
+	} {
+		if err := writer.writePart(part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	return writer, nil
+}
+
+func (w *Writer) writePart(name, content string) error {
+	part, err := w.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// NewSheet ends the sheet being written and starts the next one, with a
+// header row naming columns. The name is made valid for a sheet: characters
+// not allowed are replaced, it is cut to MaxSheetName and made unique, and an
+// empty name becomes SheetN.
+func (w *Writer) NewSheet(name string, columns []Column) (*Sheet, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("xlsx: a sheet needs a column")
+	}
+	if err := w.endSheet(); err != nil {
+		return nil, err
+	}
+
+	name = w.sheetName(name)
+	part, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		return nil, w.fail(err)
+	}
+	w.sheets = append(w.sheets, sheetInfo{name: name})
+	w.names[strings.ToLower(name)] = true
+	sheet := &Sheet{w: w, buf: bufio.NewWriter(part), columns: len(columns)}
+	w.sheet = sheet
+
+	sheet.buf.WriteString(xml.Header)
+	sheet.buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sheet.buf.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	sheet.buf.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	sheet.buf.WriteString(`<selection pane="bottomLeft" activeCell="A2" sqref="A2"/>`)
+	sheet.buf.WriteString(`</sheetView></sheetViews><cols>`)
+	for i, c := range columns {
+		width := c.Width
+		if width <= 0 {
+			width = 10
+		}
+		fmt.Fprintf(sheet.buf, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+	}
+	sheet.buf.WriteString(`</cols><sheetData>`)
+
+	headers := make([]Cell, len(columns))
+	for i, c := range columns {
+		headers[i] = String(c.Header)
+	}
+	if err := sheet.writeRow(headers, styleHeader); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// sheetName makes name valid and unique among the sheets of the workbook,
+// which compares names case-insensitively
+func (w *Writer) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(w.sheets)+1)
+	}
+	name = truncate(name, MaxSheetName)
+	unique := name
+	for i := 2; w.names[strings.ToLower(unique)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		unique = truncate(name, MaxSheetName-len(suffix)) + suffix
+	}
+	return unique
+}
+
+// truncate cuts s to n characters at most
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// WriteRow appends a row to the sheet. Cells past the columns of the sheet
+// are dropped.
+func (s *Sheet) WriteRow(cells ...Cell) error {
+	if s.w.sheet != s {
+		return errors.New("xlsx: the sheet has been ended")
+	}
+	return s.writeRow(cells, styleDefault)
+}
+
+func (s *Sheet) writeRow(cells []Cell, style int) error {
+	if s.w.err != nil {
+		return s.w.err
+	}
+	if len(cells) > s.columns {
+		cells = cells[:s.columns]
+	}
+	s.rows++
+	fmt.Fprintf(s.buf, `<row r="%d">`, s.rows)
+	for i, c := range cells {
+		ref := columnName(i) + strconv.Itoa(s.rows)
+		switch c.kind {
+		case cellEmpty:
+			continue
+		case cellString:
+			if style == styleDefault {
+				fmt.Fprintf(s.buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			} else {
+				fmt.Fprintf(s.buf, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			}
+			xml.EscapeText(s.buf, []byte(c.text))
+			s.buf.WriteString(`</t></is></c>`)
+		case cellNumber:
+			fmt.Fprintf(s.buf, `<c r="%s"><v>%s</v></c>`, ref, c.text)
+		case cellDate, cellDateTime:
+			dateStyle := styleDate
+			if c.kind == cellDateTime {
+				dateStyle = styleDateTime
+			}
+			fmt.Fprintf(s.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, dateStyle, strconv.FormatFloat(c.number, 'f', -1, 64))
+		}
+	}
+	_, err := s.buf.WriteString(`</row>`)
+	return s.w.fail(err)
+}
+
+// Flush sends the rows written so far to the underlying writer
+func (s *Sheet) Flush() error {
+	if s.w.err != nil {
+		return s.w.err
+	}
+	if err := s.buf.Flush(); err != nil {
+		return s.w.fail(err)
+	}
+	return s.w.fail(s.w.zip.Flush())
+}
+
+// endSheet closes the sheet being written, if any, with its autofilter
+func (w *Writer) endSheet() error {
+	s := w.sheet
+	if s == nil {
+		return nil
+	}
+	w.sheet = nil
+	filter := fmt.Sprintf("A1:%s%d", columnName(s.columns-1), s.rows)
+	w.sheets[len(w.sheets)-1].filter = filter
+	fmt.Fprintf(s.buf, `</sheetData><autoFilter ref="%s"/></worksheet>`, filter)
+	return w.fail(s.buf.Flush())
+}
+
+// Close ends the last sheet and writes the workbook part listing the sheets.
+// A workbook without sheets gets an empty one, as a workbook needs a sheet.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.sheets) == 0 {
+		if _, err := w.NewSheet("", []Column{{}}); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	var workbook, rels strings.Builder
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, s := range w.sheets {
+		workbook.WriteString(`<sheet name="`)
+		xml.EscapeText(&workbook, []byte(s.name))
+		fmt.Fprintf(&workbook, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	workbook.WriteString(`</sheets><definedNames>`)
+	for i, s := range w.sheets {
+		// Spreadsheets keep the range of an autofilter in this hidden name
+		fmt.Fprintf(&workbook, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">`, i)
+		xml.EscapeText(&workbook, []byte(quoteSheetName(s.name)+"!"+absoluteRange(s.filter)))
+		workbook.WriteString(`</definedName>`)
+	}
+	workbook.WriteString(`</definedNames></workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	rels.WriteString(`</Relationships>`)
+
+	if err := w.writePart("xl/workbook.xml", workbook.String()); err != nil {
+		return w.fail(err)
+	}
+	if err := w.writePart("xl/_rels/workbook.xml.rels", rels.String()); err != nil {
+		return w.fail(err)
+	}
+	if err := w.zip.Close(); err != nil {
+		return w.fail(err)
+	}
+	w.err = errors.New("xlsx: the workbook is closed")
+	return nil
+}
+
+// fail keeps the first error of the writer, as the archive cannot be
+// written further after it
+func (w *Writer) fail(err error) error {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return err
+}
+
+// columnName is the letters naming the column at index i, from A
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// quoteSheetName quotes a sheet name for a formula
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// absoluteRange makes a range such as A1:D10 absolute, as $A$1:$D$10
+func absoluteRange(ref string) string {
+	var b strings.Builder
+	letters := false
+	for _, r := range ref {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if !letters {
+				b.WriteByte('$')
+			}
+			letters = true
+		case r >= '0' && r <= '9':
+			if letters {
+				b.WriteByte('$')
+			}
+			letters = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+const contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// stylesXML holds the styles in the order of the style constants: the
+// default, bold headers, dates and date-times
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
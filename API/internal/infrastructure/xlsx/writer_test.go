@@ -0,0 +1,169 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// worksheet is the part of a sheet the tests read back
+type worksheet struct {
+	Pane struct {
+		YSplit      int    `xml:"ySplit,attr"`
+		TopLeftCell string `xml:"topLeftCell,attr"`
+		State       string `xml:"state,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Style  int    `xml:"s,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+	DefinedNames []string `xml:"definedNames>definedName"`
+}
+
+// readPart decodes the XML part name of the archive in data into v
+func readPart(t *testing.T, data []byte, name string, v interface{}) {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open the archive: %v", err)
+	}
+	part, err := archive.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", name, err)
+	}
+	defer part.Close()
+	content, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	if err := xml.Unmarshal(content, v); err != nil {
+		t.Fatalf("failed to decode %s: %v", name, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("failed to start the workbook: %v", err)
+	}
+	columns := []Column{{Header: "serial", Width: 20}, {Header: "purchase_date"}, {Header: "created_at"}, {Header: "count"}}
+	for _, name := range []string{"LPT/01", "lpt_01", "", "a very long product code that does not fit"} {
+		sheet, err := w.NewSheet(name, columns)
+		if err != nil {
+			t.Fatalf("failed to start sheet %q: %v", name, err)
+		}
+		if name != "LPT/01" {
+			continue
+		}
+		purchased := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+		created := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+		if err := sheet.WriteRow(String("SN-1 <a&b>"), Date(purchased), DateTime(created), Int(3)); err != nil {
+			t.Fatalf("failed to write a row: %v", err)
+		}
+		if err := sheet.WriteRow(String("SN-2"), Date(time.Time{}), DateTime(created), Number(1.5)); err != nil {
+			t.Fatalf("failed to write a row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close the workbook: %v", err)
+	}
+
+	var book workbook
+	readPart(t, buf.Bytes(), "xl/workbook.xml", &book)
+	var names []string
+	for _, s := range book.Sheets {
+		names = append(names, s.Name)
+	}
+	expected := []string{"LPT_01", "lpt_01 (2)", "Sheet3", "a very long product code that d"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected sheets %v, got %v", expected, names)
+	}
+	if len(book.DefinedNames) != 4 || book.DefinedNames[0] != "'LPT_01'!$A$1:$D$3" {
+		t.Errorf("expected the filter range of every sheet, got %v", book.DefinedNames)
+	}
+
+	var sheet worksheet
+	readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml", &sheet)
+	if sheet.Pane.YSplit != 1 || sheet.Pane.TopLeftCell != "A2" || sheet.Pane.State != "frozen" {
+		t.Errorf("expected the header row to be frozen, got %+v", sheet.Pane)
+	}
+	if sheet.AutoFilter.Ref != "A1:D3" {
+		t.Errorf("expected an autofilter over A1:D3, got %q", sheet.AutoFilter.Ref)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d rows", len(sheet.Rows))
+	}
+	header, first, second := sheet.Rows[0].Cells, sheet.Rows[1].Cells, sheet.Rows[2].Cells
+	if header[1].Inline != "purchase_date" || header[1].Style != styleHeader {
+		t.Errorf("expected a bold header, got %+v", header[1])
+	}
+	if first[0].Inline != "SN-1 <a&b>" || first[0].Type != "inlineStr" {
+		t.Errorf("expected the serial as text, got %+v", first[0])
+	}
+	// 2024-03-01 is day 45352 of the 1900 date system
+	if first[1].Ref != "B2" || first[1].Value != "45352" || first[1].Style != styleDate {
+		t.Errorf("expected the purchase date as a date, got %+v", first[1])
+	}
+	if first[2].Value != "45352.75" || first[2].Style != styleDateTime {
+		t.Errorf("expected the creation time as a date-time, got %+v", first[2])
+	}
+	if first[3].Value != "3" || first[3].Type != "" {
+		t.Errorf("expected the count as a number, got %+v", first[3])
+	}
+	if len(second) != 3 || second[1].Ref != "C3" || second[2].Value != "1.5" {
+		t.Errorf("expected the zero date to leave its cell empty, got %+v", second)
+	}
+
+	var empty worksheet
+	readPart(t, buf.Bytes(), "xl/worksheets/sheet2.xml", &empty)
+	if len(empty.Rows) != 1 || empty.AutoFilter.Ref != "A1:D1" {
+		t.Errorf("expected a sheet with only its header, got %+v", empty)
+	}
+}
+
+func TestWriterWithoutSheets(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("failed to start the workbook: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close the workbook: %v", err)
+	}
+
+	var book workbook
+	readPart(t, buf.Bytes(), "xl/workbook.xml", &book)
+	if len(book.Sheets) != 1 || book.Sheets[0].Name != "Sheet1" {
+		t.Errorf("expected an empty sheet, got %+v", book.Sheets)
+	}
+	if _, err := w.NewSheet("late", []Column{{Header: "id"}}); err == nil {
+		t.Error("expected a closed workbook to refuse sheets")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if name := columnName(i); name != expected {
+			t.Errorf("expected column %d to be %s, got %s", i, expected, name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type AuthHandler struct {
+	authUseCase *usecase.AuthUseCase
+}
+
+func NewAuthHandler(authUseCase *usecase.AuthUseCase) *AuthHandler {
+	return &AuthHandler{
+		authUseCase: authUseCase,
+	}
+}
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// ContextWithUser returns a copy of ctx carrying the authenticated user
+func ContextWithUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the authenticated user stored by the Authenticate middleware
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*domain.User)
+	return user, ok && user != nil
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		http.Error(w, "Email and password are required fields", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authUseCase.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidCredentialsError:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			writeFallbackError(w, err, "Error logging in")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authUseCase.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidTokenError:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			writeFallbackError(w, err, "Error refreshing token")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Authenticate rejects requests without a valid bearer access token and
+// stores the authenticated user in the request context
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="inventario"`)
+			http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := h.authUseCase.Authenticate(r.Context(), token)
+		if err != nil {
+			switch err.(type) {
+			case *domain.InvalidTokenError:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="inventario", error="invalid_token"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			default:
+				writeFallbackError(w, err, "Error authenticating request")
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/infrastructure/security"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestAuthHandler(t *testing.T, mockRepo *repository.MockUserRepository) (*AuthHandler, *security.HMACTokenService) {
+	t.Helper()
+	tokenService, err := security.NewHMACTokenService([]byte("test-secret-test-secret-test-secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	useCase := usecase.NewAuthUseCase(mockRepo, security.NewPBKDF2Hasher(1000), tokenService, time.Minute, time.Hour)
+	return NewAuthHandler(useCase), tokenService
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "successful login",
+			requestBody:    map[string]string{"email": "test@example.com", "password": "password123"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong password",
+			requestBody:    map[string]string{"email": "test@example.com", "password": "wrong"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing password",
+			requestBody:    map[string]string{"email": "test@example.com"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockUserRepository{
+				GetByEmailFunc: func(email string) (*domain.User, error) {
+					return &domain.User{ID: 1, Email: email, Role: "admin", Password: "password123"}, nil
+				},
+			}
+			handler, _ := newTestAuthHandler(t, mockRepo)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			handler.Login(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var tokens domain.AuthTokens
+				if err := json.NewDecoder(w.Body).Decode(&tokens); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if tokens.AccessToken == "" || tokens.TokenType != "Bearer" {
+					t.Errorf("unexpected token response: %+v", tokens)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticateMiddleware(t *testing.T) {
+	mockRepo := &repository.MockUserRepository{
+		GetByIDFunc: func(id int64) (*domain.User, error) {
+			return &domain.User{ID: id, Name: "Test User", Role: "admin"}, nil
+		},
+	}
+	handler, tokenService := newTestAuthHandler(t, mockRepo)
+	accessToken, _ := tokenService.Generate(7, domain.AccessToken, time.Minute)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "valid token",
+			authorization:  "Bearer " + accessToken,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing header",
+			authorization:  "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			authorization:  "Bearer not-a-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser *domain.User
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, _ = UserFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/api/products", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.Authenticate(next).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusOK && (gotUser == nil || gotUser.ID != 7) {
+				t.Errorf("expected user 7 in context, got %+v", gotUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"inventario/internal/domain"
+	"net/http"
+)
+
+// RequirePermission only lets requests through when the authenticated user's
+// role grants permission. It must run after AuthHandler.Authenticate.
+func RequirePermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			if !domain.HasPermission(user.Role, permission) {
+				http.Error(w, (&domain.ForbiddenError{Permission: permission}).Error(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
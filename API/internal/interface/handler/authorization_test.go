@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"inventario/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           *domain.User
+		permission     domain.Permission
+		expectedStatus int
+	}{
+		{
+			name:           "admin may delete users",
+			user:           &domain.User{ID: 1, Role: domain.RoleAdmin},
+			permission:     domain.PermUsersDelete,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "warehouse may not delete users",
+			user:           &domain.User{ID: 2, Role: domain.RoleWarehouse},
+			permission:     domain.PermUsersDelete,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "viewer may read stocks",
+			user:           &domain.User{ID: 3, Role: domain.RoleViewer},
+			permission:     domain.PermStocksRead,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "viewer may not write stocks",
+			user:           &domain.User{ID: 3, Role: domain.RoleViewer},
+			permission:     domain.PermStocksWrite,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown role is denied",
+			user:           &domain.User{ID: 4, Role: "user"},
+			permission:     domain.PermProductsRead,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unauthenticated request",
+			user:           nil,
+			permission:     domain.PermProductsRead,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.user != nil {
+				req = req.WithContext(ContextWithUser(req.Context(), tt.user))
+			}
+			w := httptest.NewRecorder()
+
+			RequirePermission(tt.permission)(next).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"inventario/internal/domain"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvContentType is the media type of CSV exports
+const csvContentType = "text/csv; charset=utf-8"
+
+// utf8BOM starts the CSV files saved by some spreadsheets
+const utf8BOM = "\ufeff"
+
+// csvDelimiters are the delimiters ?delimiter= may name rather than give,
+// as a bare ";" is not allowed in a query string
+var csvDelimiters = map[string]rune{"": ',', "comma": ',', "semicolon": ';', "tab": '\t'}
+
+// csvDelimiterFromQuery reads ?delimiter=, the character separating the
+// fields of a CSV file, such as the ";" of spreadsheets in locales with a
+// decimal comma. It defaults to ",".
+func csvDelimiterFromQuery(r *http.Request) (rune, error) {
+	v := r.URL.Query().Get("delimiter")
+	if delimiter, ok := csvDelimiters[v]; ok {
+		return delimiter, nil
+	}
+	delimiter, size := utf8.DecodeRuneInString(v)
+	if size != len(v) || delimiter == utf8.RuneError || strings.ContainsRune("\"\r\n", delimiter) {
+		return 0, errors.New("Invalid delimiter")
+	}
+	return delimiter, nil
+}
+
+// csvRecords reads the records of an uploaded CSV file as values by field.
+// The header row names the field of each column, case-insensitively, and
+// ?map=Header:field makes a column hold another field. Columns holding no
+// known field are ignored.
+type csvRecords struct {
+	reader *csv.Reader
+	// fields holds the field of each column, empty for the ignored ones
+	fields  []string
+	ignored []string
+}
+
+// newCSVRecords reads the header row of the CSV file in the body of r. It
+// fails when a required field has no column or a field has several.
+func newCSVRecords(r *http.Request, known, required []string) (*csvRecords, error) {
+	delimiter, err := csvDelimiterFromQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	mapping := make(map[string]string)
+	for _, m := range r.URL.Query()["map"] {
+		header, field, ok := strings.Cut(m, ":")
+		if !ok {
+			return nil, fmt.Errorf("Invalid map %q, expected Header:field", m)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(header))] = strings.TrimSpace(field)
+	}
+
+	reader := csv.NewReader(r.Body)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("The file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid header row: %v", err)
+	}
+
+	records := &csvRecords{reader: reader, fields: make([]string, len(header))}
+	columns := make(map[string]bool, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, utf8BOM)
+		}
+		field := strings.ToLower(strings.TrimSpace(name))
+		if mapped, ok := mapping[field]; ok {
+			field = mapped
+		}
+		if !domain.ContainsField(known, field) {
+			records.ignored = append(records.ignored, name)
+			continue
+		}
+		if columns[field] {
+			return nil, fmt.Errorf("More than one column holds %s", field)
+		}
+		columns[field] = true
+		records.fields[i] = field
+	}
+	for _, field := range required {
+		if !columns[field] {
+			return nil, fmt.Errorf("Missing column %s", field)
+		}
+	}
+	return records, nil
+}
+
+// next returns the line and the values of the next record, or nil values at
+// the end of the file. A record that cannot be read gives a *csv.ParseError,
+// after which the following records can still be read.
+func (c *csvRecords) next() (int, map[string]string, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	values := make(map[string]string, len(c.fields))
+	for i, value := range record {
+		if i < len(c.fields) && c.fields[i] != "" {
+			values[c.fields[i]] = strings.TrimSpace(value)
+		}
+	}
+	return line, values, nil
+}
+
+// writeCSV streams as CSV every record of a listing, read through list by
+// eachPage. The error of the first page is returned for the caller to
+// answer, as nothing has been written yet; an error on a later page aborts
+// the response, so that the client does not take the file as complete.
+func writeCSV[T any](w http.ResponseWriter, filename string, delimiter rune, opts domain.ListOptions, header []string, list func(domain.ListOptions) ([]T, error), id func(T) int64, row func(T) []string) error {
+	var writer *csv.Writer
+	err := eachPage(opts, list, id, func(page []T) error {
+		if writer == nil {
+			w.Header().Set("Content-Type", csvContentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+			w.WriteHeader(http.StatusOK)
+			writer = csv.NewWriter(w)
+			writer.Comma = delimiter
+			writer.Write(header)
+		}
+		for _, item := range page {
+			values := row(item)
+			for i, value := range values {
+				values[i] = csvCell(value)
+			}
+			writer.Write(values)
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil && writer != nil {
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// csvCell keeps a value from being taken as a formula by spreadsheets, which
+// evaluate cells starting with "=", "+", "-" or "@", and may skip a leading
+// tab or carriage return before them, by prefixing it with a quote
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
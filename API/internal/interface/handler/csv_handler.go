@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CSVHandler imports and exports products, providers and units as CSV files
+type CSVHandler struct {
+	importUseCase   *usecase.ImportUseCase
+	productUseCase  *usecase.ProductUseCase
+	providerUseCase *usecase.ProviderUseCase
+	stockUseCase    *usecase.StockUseCase
+}
+
+func NewCSVHandler(importUseCase *usecase.ImportUseCase, productUseCase *usecase.ProductUseCase, providerUseCase *usecase.ProviderUseCase, stockUseCase *usecase.StockUseCase) *CSVHandler {
+	return &CSVHandler{
+		importUseCase:   importUseCase,
+		productUseCase:  productUseCase,
+		providerUseCase: providerUseCase,
+		stockUseCase:    stockUseCase,
+	}
+}
+
+// Columns of the CSV files, named as the fields of the JSON bodies
+var (
+	productImportColumns  = []string{"name", "code", "image_url", "tracking_mode"}
+	productExportColumns  = []string{"id", "name", "code", "image_url", "tracking_mode", "version", "created_at", "updated_at", "deleted_at"}
+	providerImportColumns = []string{"name", "email", "phone", "address"}
+	providerExportColumns = []string{"id", "name", "email", "phone", "address", "version", "created_at", "updated_at", "deleted_at"}
+	stockImportColumns    = []string{"product_id", "serial", "provider_id", "batch", "purchase_date", "location_id"}
+	stockExportColumns    = []string{
+		"id", "serial", "status", "product_id", "product_code", "provider_id", "provider_name",
+		"location_id", "location_code", "warehouse_code", "batch", "purchase_date",
+		"version", "created_at", "updated_at", "deleted_at",
+	}
+)
+
+// ImportProducts creates a product for every record of the CSV file in the body
+func (h *CSVHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	h.importCSV(w, r, productImportColumns, []string{"name", "code"}, func(ctx context.Context, values map[string]string) error {
+		switch {
+		case values["name"] == "":
+			return &domain.RequiredFieldError{Entity: "product", Field: "name"}
+		case values["code"] == "":
+			return &domain.RequiredFieldError{Entity: "product", Field: "code"}
+		}
+		_, err := h.productUseCase.CreateProduct(ctx, values["name"], values["code"], values["image_url"], domain.TrackingMode(values["tracking_mode"]))
+		if _, ok := err.(*domain.ProductAlreadyExistsError); ok {
+			return duplicateError("product", "code", values["code"])
+		}
+		return err
+	})
+}
+
+// ImportProviders creates a provider for every record of the CSV file in the body
+func (h *CSVHandler) ImportProviders(w http.ResponseWriter, r *http.Request) {
+	h.importCSV(w, r, providerImportColumns, []string{"name", "email"}, func(ctx context.Context, values map[string]string) error {
+		switch {
+		case values["name"] == "":
+			return &domain.RequiredFieldError{Entity: "provider", Field: "name"}
+		case values["email"] == "":
+			return &domain.RequiredFieldError{Entity: "provider", Field: "email"}
+		}
+		_, err := h.providerUseCase.CreateProvider(ctx, values["name"], values["email"], values["phone"], values["address"])
+		if _, ok := err.(*domain.ProviderAlreadyExistsError); ok {
+			return duplicateError("provider", "email", values["email"])
+		}
+		return err
+	})
+}
+
+// ImportStocks creates a unit for every record of the CSV file in the body,
+// attributed to the authenticated user
+func (h *CSVHandler) ImportStocks(w http.ResponseWriter, r *http.Request) {
+	actor, ok := UserFromContext(r.Context())
+	if !ok {
+		writeAuditUserError(w, &domain.MissingAuditUserError{})
+		return
+	}
+
+	h.importCSV(w, r, stockImportColumns, []string{"product_id", "serial", "provider_id"}, func(ctx context.Context, values map[string]string) error {
+		if values["serial"] == "" {
+			return &domain.RequiredFieldError{Entity: "stock", Field: "serial"}
+		}
+		var ids [3]int64
+		for i, field := range []string{"product_id", "provider_id", "location_id"} {
+			if values[field] == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(values[field], 10, 64)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid %s %q", field, values[field])
+			}
+			ids[i] = id
+		}
+		switch {
+		case ids[0] == 0:
+			return &domain.RequiredFieldError{Entity: "stock", Field: "product_id"}
+		case ids[1] == 0:
+			return &domain.RequiredFieldError{Entity: "stock", Field: "provider_id"}
+		}
+		var purchaseDate time.Time
+		if values["purchase_date"] != "" {
+			var err error
+			if purchaseDate, err = time.Parse("2006-01-02", values["purchase_date"]); err != nil {
+				return fmt.Errorf("invalid purchase_date %q, expected YYYY-MM-DD", values["purchase_date"])
+			}
+		}
+
+		_, err := h.stockUseCase.CreateStock(ctx, actor, ids[0], values["serial"], values["batch"], purchaseDate, ids[1], ids[2], 0)
+		if _, ok := err.(*domain.StockAlreadyExistsError); ok {
+			return duplicateError("stock", "serial", values["serial"])
+		}
+		return err
+	})
+}
+
+// duplicateError reports a record of an import whose unique field is already
+// taken, naming the value the same way for every entity
+func duplicateError(entity, field, value string) error {
+	return fmt.Errorf("%s with %s %s already exists", entity, field, value)
+}
+
+// importCSV imports the records of the CSV file in the body through create,
+// which receives the values of a record by field, and answers with the
+// report: 201 when every record was created, 422 when none was and 200
+// otherwise or for a dry run (?dry_run=true) without errors. Dry runs answer
+// 501 on a storage backend that cannot roll them back.
+func (h *CSVHandler) importCSV(w http.ResponseWriter, r *http.Request, known, required []string, create func(ctx context.Context, values map[string]string) error) {
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+	records, err := newCSVRecords(r, known, required)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.importUseCase.Import(r.Context(), dryRun, func() (*usecase.ImportRecord, error) {
+		line, values, err := records.next()
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			return &usecase.ImportRecord{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+		case err != nil:
+			return nil, err
+		case values == nil:
+			return nil, nil
+		}
+		return &usecase.ImportRecord{Line: line, Create: func(ctx context.Context) error {
+			return create(ctx, values)
+		}}, nil
+	})
+	if err != nil {
+		switch err.(type) {
+		case *domain.DryRunUnsupportedError:
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		default:
+			writeFallbackError(w, err, "Error importing records")
+		}
+		return
+	}
+	report.IgnoredColumns = records.ignored
+
+	status := http.StatusOK
+	switch {
+	case report.Failed > 0 && report.Created == 0:
+		status = http.StatusUnprocessableEntity
+	case report.Failed == 0 && !dryRun && report.Created > 0:
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportProducts writes the products matching the filters of the product
+// listing as a CSV file
+func (h *CSVHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	delimiter, err := csvDelimiterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = writeCSV(w, "products.csv", delimiter, filter.ListOptions, productExportColumns,
+		func(opts domain.ListOptions) ([]*domain.Product, error) {
+			filter.ListOptions = opts
+			products, _, err := h.productUseCase.GetAllProducts(r.Context(), filter)
+			return products, err
+		},
+		func(p *domain.Product) int64 { return p.ID },
+		func(p *domain.Product) []string {
+			return []string{
+				strconv.FormatInt(p.ID, 10), p.Name, p.Code, p.ImageURL, string(p.TrackingMode),
+				strconv.FormatInt(p.Version, 10), csvTime(p.CreatedAt), csvTime(p.UpdatedAt), csvDeletedAt(p.SoftDeleted),
+			}
+		})
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidListOptionsError, *domain.InvalidTrackingModeError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "Error exporting products")
+		}
+	}
+}
+
+// ExportProviders writes the providers matching the filters of the provider
+// listing as a CSV file
+func (h *CSVHandler) ExportProviders(w http.ResponseWriter, r *http.Request) {
+	filter, err := providerFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	delimiter, err := csvDelimiterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = writeCSV(w, "providers.csv", delimiter, filter.ListOptions, providerExportColumns,
+		func(opts domain.ListOptions) ([]domain.Provider, error) {
+			filter.ListOptions = opts
+			providers, _, err := h.providerUseCase.GetAllProviders(r.Context(), filter)
+			return providers, err
+		},
+		func(p domain.Provider) int64 { return p.ID },
+		func(p domain.Provider) []string {
+			return []string{
+				strconv.FormatInt(p.ID, 10), p.Name, p.Email, p.Phone, p.Address,
+				strconv.FormatInt(p.Version, 10), csvTime(p.CreatedAt), csvTime(p.UpdatedAt), csvDeletedAt(p.SoftDeleted),
+			}
+		})
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidListOptionsError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "Error exporting providers")
+		}
+	}
+}
+
+// ExportStocks writes the units matching the filters of the stock listing as
+// a CSV file
+func (h *CSVHandler) ExportStocks(w http.ResponseWriter, r *http.Request) {
+	filter, err := stockFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	delimiter, err := csvDelimiterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = writeCSV(w, "stocks.csv", delimiter, filter.ListOptions, stockExportColumns,
+		func(opts domain.ListOptions) ([]*domain.Stock, error) {
+			filter.ListOptions = opts
+			stocks, _, err := h.stockUseCase.GetAllStocks(r.Context(), filter)
+			return stocks, err
+		},
+		func(s *domain.Stock) int64 { return s.ID },
+		stockCSVRow)
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidStockStatusError, *domain.InvalidListOptionsError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "Error exporting stocks")
+		}
+	}
+}
+
+// stockCSVRow lists the values of a unit in the order of stockExportColumns
+func stockCSVRow(s *domain.Stock) []string {
+	var productID, productCode, providerID, providerName string
+	if s.Product != nil {
+		productID, productCode = strconv.FormatInt(s.Product.ID, 10), s.Product.Code
+	}
+	if s.Provider != nil {
+		providerID, providerName = strconv.FormatInt(s.Provider.ID, 10), s.Provider.Name
+	}
+	var locationID, locationCode, warehouseCode string
+	if s.Location != nil {
+		locationID, locationCode = strconv.FormatInt(s.Location.ID, 10), s.Location.Code
+		if s.Location.Warehouse != nil {
+			warehouseCode = s.Location.Warehouse.Code
+		}
+	}
+	var purchaseDate string
+	if !s.PurchaseDate.IsZero() {
+		purchaseDate = s.PurchaseDate.Format("2006-01-02")
+	}
+	return []string{
+		strconv.FormatInt(s.ID, 10), s.Serial, string(s.Status), productID, productCode, providerID, providerName,
+		locationID, locationCode, warehouseCode, s.Batch, purchaseDate,
+		strconv.FormatInt(s.Version, 10), csvTime(s.CreatedAt), csvTime(s.UpdatedAt), csvDeletedAt(s.SoftDeleted),
+	}
+}
+
+// csvTime formats a timestamp of an export, leaving zero ones empty
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvDeletedAt is the deletion time of an exported record, empty while it is live
+func csvDeletedAt(s domain.SoftDeleted) string {
+	if s.DeletedAt == nil {
+		return ""
+	}
+	return csvTime(*s.DeletedAt)
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/storage"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newCSVTestHandler returns a CSVHandler on an empty in-memory SQLite
+// database, whose transactions roll back like MySQL ones
+func newCSVTestHandler(t *testing.T) (*CSVHandler, *storage.Storage) {
+	return newCSVTestHandlerOn(t, storage.DriverSQLiteMemory)
+}
+
+// newCSVTestHandlerOn returns a CSVHandler on an empty database of driver
+func newCSVTestHandlerOn(t *testing.T, driver string) (*CSVHandler, *storage.Storage) {
+	t.Helper()
+	s, err := storage.Open(storage.Config{Driver: driver})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return NewCSVHandler(
+		usecase.NewImportUseCase(s.UnitOfWork),
+		usecase.NewProductUseCase(s.Products, s.Stocks, s.UnitOfWork),
+		usecase.NewProviderUseCase(s.Providers, s.Stocks, s.UnitOfWork),
+		usecase.NewStockUseCase(s.Stocks, s.StockMovements, s.Products, s.StockBalances, s.UnitOfWork, false),
+	), s
+}
+
+func TestImportProducts(t *testing.T) {
+	const file = utf8BOM + "Nombre;Código;Notas\n" +
+		"Laptop;LPT;\n" +
+		"Monitor;MON;ok\n" +
+		"Otro monitor;MON;repeated code\n" +
+		";KBD;no name\n" +
+		"Mouse;\"MS\"E;bad quote\n"
+	const query = "?delimiter=semicolon&map=Nombre:name&map=C%C3%B3digo:code"
+
+	tests := []struct {
+		name            string
+		query           string
+		body            string
+		expectedStatus  int
+		expectedCreated int
+		expectedStored  int64
+	}{
+		{
+			name:            "import",
+			query:           query,
+			body:            file,
+			expectedStatus:  http.StatusOK,
+			expectedCreated: 2,
+			expectedStored:  2,
+		},
+		{
+			name:            "dry run",
+			query:           query + "&dry_run=true",
+			body:            file,
+			expectedStatus:  http.StatusOK,
+			expectedCreated: 2,
+		},
+		{
+			name:           "missing column",
+			query:          "?delimiter=%3B",
+			body:           file,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty file",
+			body:           "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:            "every record created",
+			body:            "name,code,tracking_mode\nScrews,SCR,quantity\n",
+			expectedStatus:  http.StatusCreated,
+			expectedCreated: 1,
+			expectedStored:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, s := newCSVTestHandler(t)
+
+			req := httptest.NewRequest("POST", "/api/products/import"+tt.query, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ImportProducts(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			stored, err := s.Products.Count(context.Background(), domain.ProductFilter{})
+			if err != nil {
+				t.Fatalf("failed to count products: %v", err)
+			}
+			if stored != tt.expectedStored {
+				t.Errorf("expected %d products to be stored, got %d", tt.expectedStored, stored)
+			}
+			if w.Code == http.StatusBadRequest {
+				return
+			}
+
+			var report domain.ImportReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode report: %v", err)
+			}
+			if report.Created != tt.expectedCreated {
+				t.Errorf("expected %d records created, got %+v", tt.expectedCreated, report)
+			}
+			if tt.body != file {
+				return
+			}
+			lines := make([]int, len(report.Errors))
+			for i, e := range report.Errors {
+				lines[i] = e.Line
+			}
+			if !reflect.DeepEqual(lines, []int{4, 5, 6}) || report.Rows != 5 || report.Failed != 3 {
+				t.Errorf("expected lines 4 to 6 to fail, got %+v", report)
+			}
+			if len(report.Errors) == 3 && report.Errors[0].Error != "product with code MON already exists" {
+				t.Errorf("expected the repeated code to be reported, got %q", report.Errors[0].Error)
+			}
+			if !reflect.DeepEqual(report.IgnoredColumns, []string{"Notas"}) {
+				t.Errorf("expected the Notas column to be ignored, got %v", report.IgnoredColumns)
+			}
+		})
+	}
+}
+
+func TestImportDryRunWithoutRollback(t *testing.T) {
+	handler, s := newCSVTestHandlerOn(t, storage.DriverMemory)
+
+	req := httptest.NewRequest("POST", "/api/products/import?dry_run=true", strings.NewReader("name,code\nLaptop,LPT\n"))
+	w := httptest.NewRecorder()
+	handler.ImportProducts(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d: %s", http.StatusNotImplemented, w.Code, w.Body.String())
+	}
+	stored, err := s.Products.Count(context.Background(), domain.ProductFilter{})
+	if err != nil {
+		t.Fatalf("failed to count products: %v", err)
+	}
+	if stored != 0 {
+		t.Errorf("expected the dry run to store nothing, got %d products", stored)
+	}
+}
+
+func TestImportStocks(t *testing.T) {
+	handler, s := newCSVTestHandler(t)
+	ctx := context.Background()
+	actor := &domain.User{Name: "ana", Email: "ana@example.com", Password: "secret-hash", Role: domain.RoleWarehouse}
+	product := &domain.Product{Name: "Laptop", Code: "LPT", TrackingMode: domain.TrackingSerialized}
+	provider := &domain.Provider{Name: "acme", Email: "sales@acme.example"}
+	for _, err := range []error{s.Users.Create(ctx, actor), s.Products.Create(ctx, product), s.Providers.Create(ctx, provider)} {
+		if err != nil {
+			t.Fatalf("failed to create fixture: %v", err)
+		}
+	}
+
+	body := "serial,product_id,provider_id,purchase_date,batch\n" +
+		"SN-1,1,1,2024-03-01,B1\n" +
+		"SN-1,1,1,2024-03-01,B1\n" +
+		"SN-2,1,99,2024-03-01,B1\n" +
+		"SN-3,1,1,01/03/2024,B1\n"
+	req := httptest.NewRequest("POST", "/api/stocks/import", strings.NewReader(body))
+	req = req.WithContext(ContextWithUser(req.Context(), actor))
+	w := httptest.NewRecorder()
+	handler.ImportStocks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var report domain.ImportReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	expected := []domain.ImportRowError{
+		{Line: 3, Error: "stock with serial SN-1 already exists"},
+		{Line: 4, Error: "stock refers to a record that does not exist"},
+		{Line: 5, Error: `invalid purchase_date "01/03/2024", expected YYYY-MM-DD`},
+	}
+	if report.Created != 1 || !reflect.DeepEqual(report.Errors, expected) {
+		t.Errorf("expected only SN-1 to be created, got %+v", report)
+	}
+
+	stock, err := s.Stocks.GetBySerial(ctx, "SN-1")
+	if err != nil || stock == nil || stock.CreatedByUser.ID != actor.ID || stock.Batch != "B1" {
+		t.Errorf("expected SN-1 to be stored by the caller, got %+v (%v)", stock, err)
+	}
+}
+
+func TestExportProviders(t *testing.T) {
+	handler, s := newCSVTestHandler(t)
+	for _, name := range []string{"acme", "globex", "acme labs"} {
+		provider := &domain.Provider{Name: name, Email: strings.ReplaceAll(name, " ", ".") + "@example.com", Phone: "555"}
+		if err := s.Providers.Create(context.Background(), provider); err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/providers/export?name=acme&sort=-name&delimiter=semicolon", nil)
+	w := httptest.NewRecorder()
+	handler.ExportProviders(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != csvContentType {
+		t.Errorf("expected a CSV file, got %q", w.Header().Get("Content-Type"))
+	}
+	reader := csv.NewReader(w.Body)
+	reader.Comma = ';'
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if len(records) != 3 || !reflect.DeepEqual(records[0], providerExportColumns) {
+		t.Fatalf("expected a header and 2 providers, got %v", records)
+	}
+	if records[1][1] != "acme labs" || records[2][1] != "acme" || records[1][4] != "" || records[1][5] != "1" {
+		t.Errorf("expected the matching providers sorted by name, got %v", records[1:])
+	}
+
+	req = httptest.NewRequest("GET", "/api/providers/export?sort=phone", nil)
+	w = httptest.NewRecorder()
+	handler.ExportProviders(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown sort, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "formula", value: "=HYPERLINK(\"http://evil.example\")", expected: "'=HYPERLINK(\"http://evil.example\")"},
+		{name: "plus", value: "+54 11 5555", expected: "'+54 11 5555"},
+		{name: "minus", value: "-2+3", expected: "'-2+3"},
+		{name: "at", value: "@home", expected: "'@home"},
+		{name: "tab", value: "\t=1+1", expected: "'\t=1+1"},
+		{name: "carriage return", value: "\r=1+1", expected: "'\r=1+1"},
+		{name: "plain", value: "acme", expected: "acme"},
+	}
+
+	handler, s := newCSVTestHandler(t)
+	for i, tt := range tests {
+		provider := &domain.Provider{Name: tt.value, Email: strconv.Itoa(i) + "@example.com"}
+		if err := s.Providers.Create(context.Background(), provider); err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/providers/export", nil)
+	w := httptest.NewRecorder()
+	handler.ExportProviders(w, req)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if len(records) != len(tests)+1 {
+		t.Fatalf("expected a header and %d providers, got %v", len(tests), records)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := records[i+1][1]; got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"inventario/internal/domain"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the non-standard status logged when the client
+// went away before the response was written
+const StatusClientClosedRequest = 499
+
+// writeFallbackError answers an error the handler has no specific response
+// for. Constraint violations caught by the database give 409 for a record
+// still in use and 422 for a missing reference or field, a change based on a
+// stale version 412, a request that ran out of time 504 and one cancelled by
+// the client 499. Anything else is a 500 with message.
+func writeFallbackError(w http.ResponseWriter, err error, message string) {
+	var foreignKeyErr *domain.ForeignKeyError
+	var requiredErr *domain.RequiredFieldError
+	var versionErr *domain.VersionMismatchError
+	switch {
+	case errors.As(err, &foreignKeyErr) && foreignKeyErr.InUse:
+		http.Error(w, foreignKeyErr.Error(), http.StatusConflict)
+	case errors.As(err, &foreignKeyErr):
+		http.Error(w, foreignKeyErr.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &requiredErr):
+		http.Error(w, requiredErr.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &versionErr):
+		http.Error(w, versionErr.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, "Request timed out", http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+		http.Error(w, "Request cancelled", StatusClientClosedRequest)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// writeInUseError answers 409 with the records that block a deletion
+func writeInUseError(w http.ResponseWriter, e *domain.EntityInUseError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(struct {
+		Error      string             `json:"error"`
+		Dependents []domain.Dependent `json:"dependents"`
+	}{e.Error(), e.Dependents})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFallbackErrorStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		repoErr        error
+		expectedStatus int
+	}{
+		{name: "deadline exceeded", repoErr: context.DeadlineExceeded, expectedStatus: http.StatusGatewayTimeout},
+		{name: "wrapped deadline", repoErr: fmt.Errorf("querying product: %w", context.DeadlineExceeded), expectedStatus: http.StatusGatewayTimeout},
+		{name: "client cancelled", repoErr: context.Canceled, expectedStatus: StatusClientClosedRequest},
+		{name: "still in use", repoErr: &domain.ForeignKeyError{Entity: "product", ID: 1, InUse: true}, expectedStatus: http.StatusConflict},
+		{name: "missing reference", repoErr: &domain.ForeignKeyError{Entity: "product"}, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "missing field", repoErr: &domain.RequiredFieldError{Entity: "product"}, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "other failure", repoErr: fmt.Errorf("connection refused"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockProductRepository{
+				DeleteFunc: func(int64, int64) error { return tt.repoErr },
+			}
+			handler := NewProductHandler(usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork()))
+
+			req := httptest.NewRequest("DELETE", "/api/products/1", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.DeleteProduct(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestDeleteWithDependents(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantDependents bool
+	}{
+		{name: "blocked", query: "", expectedStatus: http.StatusConflict, wantDependents: true},
+		{name: "reassigned", query: "?force=reassign&to=2", expectedStatus: http.StatusNoContent},
+		{name: "missing target", query: "?force=reassign", expectedStatus: http.StatusBadRequest},
+		{name: "unknown mode", query: "?force=cascade&to=2", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reassigned := false
+			mockRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					return &domain.Product{ID: id, TrackingMode: domain.TrackingSerialized}, nil
+				},
+				DependentsFunc: func(int64) ([]domain.Dependent, error) {
+					if reassigned {
+						return nil, nil
+					}
+					return []domain.Dependent{{Table: "stocks", Count: 2}}, nil
+				},
+			}
+			mockStockRepo := &repository.MockStockRepository{
+				ReassignFunc: func(domain.StockReference, int64, int64, *domain.User, string) (int64, error) {
+					reassigned = true
+					return 2, nil
+				},
+			}
+			handler := NewProductHandler(usecase.NewProductUseCase(mockRepo, mockStockRepo, repository.NewMemoryUnitOfWork()))
+
+			req := httptest.NewRequest("DELETE", "/api/products/1"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.DeleteProduct(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.wantDependents {
+				var body struct {
+					Dependents []domain.Dependent `json:"dependents"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+					t.Fatalf("decoding response: %v", err)
+				}
+				if len(body.Dependents) != 1 || body.Dependents[0] != (domain.Dependent{Table: "stocks", Count: 2}) {
+					t.Errorf("unexpected dependents %+v", body.Dependents)
+				}
+			}
+		})
+	}
+}
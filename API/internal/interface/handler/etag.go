@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be the ETag of a version")
+
+// setETag advertises the version of the entity in the response, so that
+// clients can send it back in If-Match
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion returns the version named by the If-Match header, or zero
+// when it is missing or "*" so that any version matches. Anything but a
+// single strong ETag given by setETag can never match.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	value := r.Header.Get("If-Match")
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// RequireIfMatch answers 428 to the requests without an If-Match header when
+// required is set, so that clients cannot overwrite changes they haven't
+// seen. Otherwise every request goes through.
+func RequireIfMatch(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-Match") == "" {
+				http.Error(w, "If-Match header required", http.StatusPreconditionRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
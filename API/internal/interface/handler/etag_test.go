@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		want    int64
+		wantErr bool
+	}{
+		{ifMatch: "", want: 0},
+		{ifMatch: "*", want: 0},
+		{ifMatch: `"7"`, want: 7},
+		{ifMatch: "7", wantErr: true},
+		{ifMatch: `W/"7"`, wantErr: true},
+		{ifMatch: `"0"`, wantErr: true},
+		{ifMatch: `"7", "8"`, wantErr: true},
+		{ifMatch: `"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/api/products/1", nil)
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		got, err := ifMatchVersion(req)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("If-Match %s: expected %d, error %v, got %d, %v", tt.ifMatch, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		required       bool
+		ifMatch        string
+		expectedStatus int
+	}{
+		{name: "required and sent", required: true, ifMatch: `"1"`, expectedStatus: http.StatusNoContent},
+		{name: "required and any version", required: true, ifMatch: "*", expectedStatus: http.StatusNoContent},
+		{name: "required and missing", required: true, expectedStatus: http.StatusPreconditionRequired},
+		{name: "optional and missing", required: false, expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+			req := httptest.NewRequest("DELETE", "/api/products/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			RequireIfMatch(tt.required)(next).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
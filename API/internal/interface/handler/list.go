@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"inventario/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listOptionsFromQuery reads the ?limit=, ?offset=, ?after_id= and ?sort=
+// parameters of a listing
+func listOptionsFromQuery(r *http.Request) (domain.ListOptions, error) {
+	query := r.URL.Query()
+	opts := domain.ListOptions{
+		Sort: domain.ParseSort(query.Get("sort")),
+	}
+
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil {
+			return opts, errors.New("Invalid limit")
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if opts.Offset, err = strconv.Atoi(v); err != nil {
+			return opts, errors.New("Invalid offset")
+		}
+	}
+	if v := query.Get("after_id"); v != "" {
+		if opts.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return opts, errors.New("Invalid after_id")
+		}
+	}
+	return opts, nil
+}
+
+// dateFromQuery reads an optional YYYY-MM-DD date parameter
+func dateFromQuery(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid %s format", name)
+	}
+	return date, nil
+}
+
+// includeDeletedFromQuery reads ?include_deleted=, which only users allowed
+// to see soft-deleted records may set
+func includeDeletedFromQuery(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include_deleted")
+	if v == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New("Invalid include_deleted")
+	}
+	if include {
+		user, ok := UserFromContext(r.Context())
+		if !ok || !domain.HasPermission(user.Role, domain.PermDeletedRead) {
+			return false, &domain.ForbiddenError{Permission: domain.PermDeletedRead}
+		}
+	}
+	return include, nil
+}
+
+// writeQueryError answers a listing whose query parameters cannot be used:
+// 403 for those the caller may not set, 400 for malformed ones
+func writeQueryError(w http.ResponseWriter, err error) {
+	if _, ok := err.(*domain.ForbiddenError); ok {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// reassignTarget reads the ?force=reassign&to={id} parameters of a deletion.
+// It returns 0 when the request does not ask to reassign references.
+func reassignTarget(r *http.Request) (int64, error) {
+	query := r.URL.Query()
+	switch query.Get("force") {
+	case "":
+		return 0, nil
+	case "reassign":
+		to, err := strconv.ParseInt(query.Get("to"), 10, 64)
+		if err != nil || to <= 0 {
+			return 0, errors.New("Invalid to ID")
+		}
+		return to, nil
+	default:
+		return 0, errors.New("Invalid force mode")
+	}
+}
+
+// writePageHeaders sets X-Total-Count to the number of items matching the
+// listing and adds Link headers pointing at the next and previous pages.
+// count is the number of items in this page and lastID the ID of its last one.
+func writePageHeaders(w http.ResponseWriter, r *http.Request, opts domain.ListOptions, total int64, count int, lastID int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	limit := opts.PageLimit()
+	var links []string
+	if opts.AfterID != 0 {
+		// Keyset paging has no way back, and any full page may be followed by
+		// more rows
+		if count == limit && lastID != 0 {
+			links = append(links, pageLink(r, limit, "after_id", lastID, "next"))
+		}
+	} else {
+		if int64(opts.Offset+count) < total {
+			links = append(links, pageLink(r, limit, "offset", int64(opts.Offset+count), "next"))
+		}
+		if opts.Offset > 0 {
+			prev := opts.Offset - limit
+			if prev < 0 {
+				prev = 0
+			}
+			links = append(links, pageLink(r, limit, "offset", int64(prev), "prev"))
+		}
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageLink builds a Link header entry for the request URL with the paging
+// parameters replaced
+func pageLink(r *http.Request, limit int, param string, value int64, rel string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Del("offset")
+	query.Del("after_id")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set(param, strconv.FormatInt(value, 10))
+	u.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel)
+}
+
+// eachPage calls fn on every page of a listing, read through list
+// MaxPageLimit records at a time, until a page is short or fn fails. Pages
+// follow each other by ID when the listing is sorted by ID only, and by
+// offset otherwise. The paging of opts is ignored and its sort kept. fn is
+// called on the first page even when it is empty.
+func eachPage[T any](opts domain.ListOptions, list func(domain.ListOptions) ([]T, error), id func(T) int64, fn func([]T) error) error {
+	opts.Limit, opts.Offset, opts.AfterID = domain.MaxPageLimit, 0, 0
+	byID := len(opts.Sort) == 0 || (len(opts.Sort) == 1 && opts.Sort[0].Field == "id")
+	for {
+		page, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil || len(page) < opts.Limit {
+			return err
+		}
+
+		if byID {
+			opts.AfterID = id(page[len(page)-1])
+		} else {
+			opts.Offset += len(page)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type LocationHandler struct {
+	locationUseCase *usecase.LocationUseCase
+}
+
+func NewLocationHandler(useCase *usecase.LocationUseCase) *LocationHandler {
+	return &LocationHandler{
+		locationUseCase: useCase,
+	}
+}
+
+type locationRequest struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// writeLocationError maps location errors to responses
+func writeLocationError(w http.ResponseWriter, err error, fallback string) {
+	switch e := err.(type) {
+	case *domain.LocationNotFoundError:
+		http.Error(w, e.Error(), http.StatusNotFound)
+	case *domain.WarehouseNotFoundError:
+		http.Error(w, e.Error(), http.StatusUnprocessableEntity)
+	case *domain.LocationAlreadyExistsError:
+		http.Error(w, e.Error(), http.StatusConflict)
+	default:
+		writeFallbackError(w, err, fallback)
+	}
+}
+
+func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var req locationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.WarehouseID == 0 || req.Code == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationUseCase.CreateLocation(r.Context(), req.WarehouseID, req.Code, req.Description)
+	if err != nil {
+		writeLocationError(w, err, "Error creating location")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationUseCase.GetLocation(r.Context(), id)
+	if err != nil {
+		writeLocationError(w, err, "Error fetching location")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) GetAllLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.locationUseCase.GetAllLocations(r.Context())
+	if err != nil {
+		writeFallbackError(w, err, "Error fetching locations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
+
+func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	var req locationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.WarehouseID == 0 || req.Code == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location := &domain.Location{
+		ID:          id,
+		Warehouse:   &domain.Warehouse{ID: req.WarehouseID},
+		Code:        req.Code,
+		Description: req.Description,
+	}
+	if err := h.locationUseCase.UpdateLocation(r.Context(), location); err != nil {
+		writeLocationError(w, err, "Error updating location")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+func (h *LocationHandler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.locationUseCase.DeleteLocation(r.Context(), id); err != nil {
+		writeLocationError(w, err, "Error deleting location")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
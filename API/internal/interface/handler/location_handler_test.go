@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateLocation(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		mockCreate     func(*domain.Location) error
+		expectedStatus int
+	}{
+		{
+			name: "successful creation",
+			requestBody: map[string]interface{}{
+				"warehouse_id": 1,
+				"code":         "A-01",
+				"description":  "Aisle A, shelf 1",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "unknown warehouse",
+			requestBody: map[string]interface{}{
+				"warehouse_id": 999,
+				"code":         "A-01",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "duplicate code in warehouse",
+			requestBody: map[string]interface{}{
+				"warehouse_id": 1,
+				"code":         "A-01",
+			},
+			mockCreate: func(l *domain.Location) error {
+				return &domain.LocationAlreadyExistsError{Code: l.Code}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "missing warehouse",
+			requestBody: map[string]interface{}{
+				"code": "A-01",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warehouseRepo := &repository.MockWarehouseRepository{
+				GetByIDFunc: func(id int64) (*domain.Warehouse, error) {
+					if id == 1 {
+						return &domain.Warehouse{ID: 1, Code: "MAIN"}, nil
+					}
+					return nil, nil
+				},
+			}
+			locationRepo := &repository.MockLocationRepository{
+				CreateFunc: tt.mockCreate,
+			}
+			handler := NewLocationHandler(usecase.NewLocationUseCase(locationRepo, warehouseRepo))
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/locations", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			handler.CreateLocation(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				var location domain.Location
+				if err := json.NewDecoder(w.Body).Decode(&location); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if location.Warehouse == nil || location.Warehouse.Code != "MAIN" {
+					t.Errorf("expected location to embed its warehouse, got %+v", location.Warehouse)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteLocation(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "successful deletion",
+			path:           "/1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "unknown location",
+			path:           "/999",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locationRepo := &repository.MockLocationRepository{
+				GetByIDFunc: func(id int64) (*domain.Location, error) {
+					if id == 1 {
+						return &domain.Location{ID: 1, Code: "A-01"}, nil
+					}
+					return nil, nil
+				},
+			}
+			handler := NewLocationHandler(usecase.NewLocationUseCase(locationRepo, &repository.MockWarehouseRepository{}))
+
+			r := chi.NewRouter()
+			r.Delete("/{id}", handler.DeleteLocation)
+
+			req := httptest.NewRequest("DELETE", tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// mergePatchContentType is the media type of RFC 7396 merge patches
+const mergePatchContentType = "application/merge-patch+json"
+
+// patchError is a merge patch whose result is not a valid document, such as
+// one giving a number to a text field
+type patchError struct {
+	reason string
+}
+
+func (e *patchError) Error() string {
+	return "invalid patch: " + e.reason
+}
+
+// readMergePatch decodes the merge patch in the body of r. Patches must be
+// JSON objects sent as application/merge-patch+json or application/json;
+// otherwise it answers 415 or 400 and returns false.
+func readMergePatch(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchContentType && mediaType != "application/json" {
+		w.Header().Set("Accept-Patch", mergePatchContentType)
+		http.Error(w, "Content-Type must be "+mergePatchContentType, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	var patch map[string]interface{}
+	if err := decoder.Decode(&patch); err != nil || patch == nil {
+		http.Error(w, "Request body must be a JSON object", http.StatusBadRequest)
+		return nil, false
+	}
+	return patch, true
+}
+
+// applyMergePatch applies patch to the JSON of doc and decodes the result
+// into a new document. Members the document doesn't have are ignored, as in
+// the bodies of PUT.
+func applyMergePatch[T any](doc T, patch map[string]interface{}) (T, error) {
+	var patched T
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return patched, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var target interface{}
+	if err := decoder.Decode(&target); err != nil {
+		return patched, err
+	}
+
+	if data, err = json.Marshal(mergePatch(target, patch)); err != nil {
+		return patched, err
+	}
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return patched, &patchError{reason: err.Error()}
+	}
+	return patched, nil
+}
+
+// mergePatch merges patch into target as RFC 7396 describes: the members of
+// an object patch replace those of the target recursively, null ones remove
+// them, and any other patch replaces the target whole
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A
+	tests := []struct {
+		target, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		var target, patch, expected interface{}
+		for _, doc := range []struct {
+			raw  string
+			into *interface{}
+		}{{tt.target, &target}, {tt.patch, &patch}, {tt.expected, &expected}} {
+			if err := json.Unmarshal([]byte(doc.raw), doc.into); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := mergePatch(target, patch); !reflect.DeepEqual(got, expected) {
+			t.Errorf("merging %s into %s: expected %s, got %v", tt.patch, tt.target, tt.expected, got)
+		}
+	}
+}
+
+func TestReadMergePatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "merge patch", contentType: "application/merge-patch+json", body: `{"name":"Cable"}`, expectedStatus: http.StatusOK},
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"name":null}`, expectedStatus: http.StatusOK},
+		{name: "other media type", contentType: "text/plain", body: `{"name":"Cable"}`, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "no media type", body: `{"name":"Cable"}`, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "not an object", contentType: "application/merge-patch+json", body: `["name"]`, expectedStatus: http.StatusBadRequest},
+		{name: "null", contentType: "application/merge-patch+json", body: `null`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/api/products/1", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			status := http.StatusOK
+			if _, ok := readMergePatch(w, req); !ok {
+				status = w.Code
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
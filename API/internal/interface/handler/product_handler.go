@@ -21,9 +21,10 @@ func NewProductHandler(useCase *usecase.ProductUseCase) *ProductHandler {
 }
 
 type createProductRequest struct {
-	Name     string `json:"name"`
-	Code     string `json:"code"`
-	ImageURL string `json:"image_url"`
+	Name         string              `json:"name"`
+	Code         string              `json:"code"`
+	ImageURL     string              `json:"image_url"`
+	TrackingMode domain.TrackingMode `json:"tracking_mode,omitempty"`
 }
 
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -39,17 +40,20 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.productUseCase.CreateProduct(req.Name, req.Code, req.ImageURL)
+	product, err := h.productUseCase.CreateProduct(r.Context(), req.Name, req.Code, req.ImageURL, req.TrackingMode)
 	if err != nil {
 		switch e := err.(type) {
+		case *domain.InvalidTrackingModeError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
 		case *domain.ProductAlreadyExistsError:
 			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error creating product", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error creating product")
 		}
 		return
 	}
 
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(product)
@@ -68,29 +72,70 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.productUseCase.GetProduct(id)
+	product, err := h.productUseCase.GetProduct(r.Context(), id)
 	if err != nil {
 		switch e := err.(type) {
 		case *domain.ProductNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
 		default:
-			http.Error(w, "Error fetching product", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error fetching product")
 		}
 		return
 	}
 
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(product)
 }
 
+// productFilterFromQuery reads the optional ?name=, ?code=,
+// ?tracking_mode= and ?include_deleted= filters and the paging of a product
+// listing
+func productFilterFromQuery(r *http.Request) (domain.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := domain.ProductFilter{
+		Name:         query.Get("name"),
+		Code:         query.Get("code"),
+		TrackingMode: domain.TrackingMode(query.Get("tracking_mode")),
+	}
+
+	var err error
+	if filter.ListOptions, err = listOptionsFromQuery(r); err != nil {
+		return filter, err
+	}
+	if filter.IncludeDeleted, err = includeDeletedFromQuery(r); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// GetAllProducts lists a page of the products matching the optional ?name=,
+// ?code= and ?tracking_mode= filters
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.productUseCase.GetAllProducts()
+	filter, err := productFilterFromQuery(r)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeQueryError(w, err)
 		return
 	}
+	opts := filter.ListOptions
 
+	products, total, err := h.productUseCase.GetAllProducts(r.Context(), filter)
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidListOptionsError, *domain.InvalidTrackingModeError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "internal server error")
+		}
+		return
+	}
+
+	var lastID int64
+	if len(products) > 0 {
+		lastID = products[len(products)-1].ID
+	}
+	writePageHeaders(w, r, opts, total, len(products), lastID)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(products)
@@ -122,16 +167,82 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product.ID = id
-	if err := h.productUseCase.UpdateProduct(&product); err != nil {
+	// Only If-Match chooses the version the update is based on
+	if product.Version, err = ifMatchVersion(r); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err := h.productUseCase.UpdateProduct(r.Context(), &product); err != nil {
 		switch e := err.(type) {
+		case *domain.InvalidTrackingModeError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
 		case *domain.ProductNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.TrackingModeChangeError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		case *domain.ProductAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error updating product", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error updating product")
 		}
 		return
 	}
 
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// productDocument is the part of a product that merge patches apply to
+type productDocument struct {
+	Name         string              `json:"name"`
+	Code         string              `json:"code"`
+	TrackingMode domain.TrackingMode `json:"tracking_mode"`
+	ImageURL     string              `json:"image_url"`
+}
+
+// PatchProduct applies a merge patch to a product and saves the fields it
+// changed
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.productUseCase.PatchProduct(r.Context(), id, version, func(product *domain.Product) error {
+		doc, err := applyMergePatch(productDocument{product.Name, product.Code, product.TrackingMode, product.ImageURL}, patch)
+		if err != nil {
+			return err
+		}
+		product.Name, product.Code, product.TrackingMode, product.ImageURL = doc.Name, doc.Code, doc.TrackingMode, doc.ImageURL
+		return nil
+	})
+	if err != nil {
+		switch e := err.(type) {
+		case *patchError, *domain.InvalidTrackingModeError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ProductNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.TrackingModeChangeError, *domain.ProductAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error updating product")
+		}
+		return
+	}
+
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(product)
@@ -149,16 +260,62 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
 	}
+	to, err := reassignTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.productUseCase.DeleteProduct(id); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	if to != 0 {
+		err = h.productUseCase.ReassignAndDeleteProduct(r.Context(), actor, id, version, to)
+	} else {
+		err = h.productUseCase.DeleteProduct(r.Context(), actor, id, version)
+	}
+	if err != nil {
 		switch e := err.(type) {
 		case *domain.ProductNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.EntityInUseError:
+			writeInUseError(w, e)
+		case *domain.InvalidReassignmentError:
+			http.Error(w, e.Error(), http.StatusUnprocessableEntity)
 		default:
-			http.Error(w, "Error deleting product", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error deleting product")
 		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreProduct brings back a soft-deleted product
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.productUseCase.RestoreProduct(r.Context(), id)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ProductNotFoundError:
+			http.Error(w, "deleted "+e.Error(), http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error restoring product")
+		}
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
 	"inventario/internal/usecase"
@@ -66,7 +67,7 @@ func TestCreateProduct(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := usecase.NewProductUseCase(mockRepo)
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProductHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -121,6 +122,15 @@ func TestGetProduct(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "product with ID 999 not found",
 		},
+		{
+			name:      "no product with the ID",
+			productID: "999",
+			mockGetByID: func(id int64) (*domain.Product, error) {
+				return nil, nil
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "product with ID 999 not found",
+		},
 		{
 			name:           "invalid product ID",
 			productID:      "invalid",
@@ -135,7 +145,7 @@ func TestGetProduct(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := usecase.NewProductUseCase(mockRepo)
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProductHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/products/"+tt.productID, nil)
@@ -206,7 +216,7 @@ func TestGetAllProducts(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := usecase.NewProductUseCase(mockRepo)
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProductHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/products", nil)
@@ -278,6 +288,22 @@ func TestUpdateProduct(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "product with ID 999 not found",
 		},
+		{
+			name:      "duplicate code",
+			productID: "1",
+			requestBody: map[string]string{
+				"name": "Updated Product",
+				"code": "TAKEN",
+			},
+			mockGetByID: func(id int64) (*domain.Product, error) {
+				return &domain.Product{ID: 1, Name: "Original Product", Code: "ORIG123"}, nil
+			},
+			mockUpdate: func(p *domain.Product) error {
+				return &domain.ProductAlreadyExistsError{Code: "TAKEN"}
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "product with code TAKEN already exists",
+		},
 		{
 			name:      "invalid request body",
 			productID: "1",
@@ -298,7 +324,7 @@ func TestUpdateProduct(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				UpdateFunc:  tt.mockUpdate,
 			}
-			useCase := usecase.NewProductUseCase(mockRepo)
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProductHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -328,7 +354,7 @@ func TestDeleteProduct(t *testing.T) {
 		name           string
 		productID      string
 		mockGetByID    func(int64) (*domain.Product, error)
-		mockDelete     func(int64) error
+		mockDelete     func(int64, int64) error
 		expectedStatus int
 		expectedError  string
 	}{
@@ -345,7 +371,7 @@ func TestDeleteProduct(t *testing.T) {
 					UpdatedAt: time.Now(),
 				}, nil
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedStatus: http.StatusNoContent,
@@ -357,7 +383,7 @@ func TestDeleteProduct(t *testing.T) {
 			mockGetByID: func(id int64) (*domain.Product, error) {
 				return nil, &domain.ProductNotFoundError{ProductID: 999}
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return &domain.ProductNotFoundError{ProductID: 999}
 			},
 			expectedStatus: http.StatusNotFound,
@@ -379,7 +405,7 @@ func TestDeleteProduct(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				DeleteFunc:  tt.mockDelete,
 			}
-			useCase := usecase.NewProductUseCase(mockRepo)
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProductHandler(useCase)
 
 			req := httptest.NewRequest("DELETE", "/api/products/"+tt.productID, nil)
@@ -402,3 +428,179 @@ func TestDeleteProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoreProduct(t *testing.T) {
+	tests := []struct {
+		name           string
+		productID      string
+		mockRestore    func(int64) error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "successful restore",
+			productID:      "1",
+			mockRestore:    func(id int64) error { return nil },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "product not deleted",
+			productID: "999",
+			mockRestore: func(id int64) error {
+				return &domain.ProductNotFoundError{ProductID: 999}
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "deleted product with ID 999 not found",
+		},
+		{
+			name:           "invalid product ID",
+			productID:      "invalid",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid product ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockProductRepository{
+				RestoreFunc: tt.mockRestore,
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					return &domain.Product{ID: id, Name: "Test Product", Code: "TEST123"}, nil
+				},
+			}
+			useCase := usecase.NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+			handler := NewProductHandler(useCase)
+
+			req := httptest.NewRequest("POST", "/api/products/"+tt.productID+"/restore", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.productID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.RestoreProduct(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				if w.Body.String() != tt.expectedError+"\n" {
+					t.Errorf("expected error %s, got %s", tt.expectedError, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestProductVersions(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	if err := repo.Create(context.Background(), &domain.Product{Name: "Cable", Code: "CAB01", TrackingMode: domain.TrackingSerialized}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewProductHandler(usecase.NewProductUseCase(repo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork()))
+	r := chi.NewRouter()
+	r.Get("/{id}", handler.GetProduct)
+	r.Put("/{id}", handler.UpdateProduct)
+	r.Delete("/{id}", handler.DeleteProduct)
+
+	steps := []struct {
+		name           string
+		method         string
+		ifMatch        string
+		expectedStatus int
+		expectedETag   string
+	}{
+		{name: "get", method: "GET", expectedStatus: http.StatusOK, expectedETag: `"1"`},
+		{name: "update current version", method: "PUT", ifMatch: `"1"`, expectedStatus: http.StatusOK, expectedETag: `"2"`},
+		{name: "update stale version", method: "PUT", ifMatch: `"1"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "weak tag", method: "PUT", ifMatch: `W/"2"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "any version", method: "PUT", ifMatch: "*", expectedStatus: http.StatusOK, expectedETag: `"3"`},
+		{name: "delete stale version", method: "DELETE", ifMatch: `"2"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "delete current version", method: "DELETE", ifMatch: `"3"`, expectedStatus: http.StatusNoContent},
+	}
+
+	for _, step := range steps {
+		body, _ := json.Marshal(map[string]string{"name": "Cable", "code": "CAB01"})
+		req := httptest.NewRequest(step.method, "/1", bytes.NewBuffer(body))
+		if step.ifMatch != "" {
+			req.Header.Set("If-Match", step.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != step.expectedStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", step.name, step.expectedStatus, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("ETag"); got != step.expectedETag {
+			t.Errorf("%s: expected ETag %s, got %s", step.name, step.expectedETag, got)
+		}
+	}
+}
+
+func TestPatchProduct(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          string
+		ifMatch        string
+		expectedStatus int
+		expected       domain.Product
+	}{
+		{
+			name:           "only the given fields",
+			patch:          `{"name": "Patch cable", "image_url": null}`,
+			expectedStatus: http.StatusOK,
+			expected:       domain.Product{Name: "Patch cable", Code: "CAB01", TrackingMode: domain.TrackingSerialized, Version: 2},
+		},
+		{
+			name:           "nothing changed",
+			patch:          `{"name": "Cable", "id": 9}`,
+			expectedStatus: http.StatusOK,
+			expected:       domain.Product{Name: "Cable", Code: "CAB01", TrackingMode: domain.TrackingSerialized, ImageURL: "http://example.com/cable.jpg", Version: 1},
+		},
+		{name: "required field removed", patch: `{"code": null}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "wrong type", patch: `{"name": 5}`, expectedStatus: http.StatusBadRequest},
+		{name: "tracking mode change", patch: `{"tracking_mode": "quantity"}`, expectedStatus: http.StatusConflict},
+		{name: "duplicate code", patch: `{"code": "MON01"}`, expectedStatus: http.StatusConflict},
+		{name: "stale version", patch: `{"name": "Patch cable"}`, ifMatch: `"2"`, expectedStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryProductRepository()
+			for _, product := range []*domain.Product{
+				{Name: "Cable", Code: "CAB01", TrackingMode: domain.TrackingSerialized, ImageURL: "http://example.com/cable.jpg"},
+				{Name: "Monitor", Code: "MON01", TrackingMode: domain.TrackingSerialized},
+			} {
+				if err := repo.Create(context.Background(), product); err != nil {
+					t.Fatal(err)
+				}
+			}
+			handler := NewProductHandler(usecase.NewProductUseCase(repo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork()))
+			r := chi.NewRouter()
+			r.Patch("/{id}", handler.PatchProduct)
+
+			req := httptest.NewRequest("PATCH", "/1", bytes.NewBufferString(tt.patch))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			stored, _ := repo.GetByID(context.Background(), 1)
+			got := domain.Product{Name: stored.Name, Code: stored.Code, TrackingMode: stored.TrackingMode, ImageURL: stored.ImageURL, Version: stored.Version}
+			if got != tt.expected {
+				t.Errorf("expected %+v to be stored, got %+v", tt.expected, got)
+			}
+			if etag, want := w.Header().Get("ETag"), fmt.Sprintf("%q", fmt.Sprint(tt.expected.Version)); etag != want {
+				t.Errorf("expected ETag %s, got %s", want, etag)
+			}
+		})
+	}
+}
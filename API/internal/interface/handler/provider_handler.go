@@ -40,17 +40,18 @@ func (h *ProviderHandler) CreateProvider(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	provider, err := h.providerUseCase.CreateProvider(req.Name, req.Email, req.Phone, req.Address)
+	provider, err := h.providerUseCase.CreateProvider(r.Context(), req.Name, req.Email, req.Phone, req.Address)
 	if err != nil {
 		switch e := err.(type) {
 		case *domain.ProviderAlreadyExistsError:
 			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error creating provider", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error creating provider")
 		}
 		return
 	}
 
+	setETag(w, provider.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(provider)
@@ -64,28 +65,67 @@ func (h *ProviderHandler) GetProvider(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	provider, err := h.providerUseCase.GetProvider(id)
+	provider, err := h.providerUseCase.GetProvider(r.Context(), id)
 	if err != nil {
 		switch e := err.(type) {
 		case *domain.ProviderNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
 		default:
-			http.Error(w, "Error fetching provider", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error fetching provider")
 		}
 		return
 	}
 
+	setETag(w, provider.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(provider)
 }
 
+// providerFilterFromQuery reads the optional ?name=, ?email= and
+// ?include_deleted= filters and the paging of a provider listing
+func providerFilterFromQuery(r *http.Request) (domain.ProviderFilter, error) {
+	query := r.URL.Query()
+	filter := domain.ProviderFilter{
+		Name:  query.Get("name"),
+		Email: query.Get("email"),
+	}
+
+	var err error
+	if filter.ListOptions, err = listOptionsFromQuery(r); err != nil {
+		return filter, err
+	}
+	if filter.IncludeDeleted, err = includeDeletedFromQuery(r); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// GetAllProviders lists a page of the providers matching the optional ?name=
+// and ?email= filters
 func (h *ProviderHandler) GetAllProviders(w http.ResponseWriter, r *http.Request) {
-	providers, err := h.providerUseCase.GetAllProviders()
+	filter, err := providerFilterFromQuery(r)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeQueryError(w, err)
 		return
 	}
+	opts := filter.ListOptions
 
+	providers, total, err := h.providerUseCase.GetAllProviders(r.Context(), filter)
+	if err != nil {
+		switch err.(type) {
+		case *domain.InvalidListOptionsError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "internal server error")
+		}
+		return
+	}
+
+	var lastID int64
+	if len(providers) > 0 {
+		lastID = providers[len(providers)-1].ID
+	}
+	writePageHeaders(w, r, opts, total, len(providers), lastID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(providers)
 }
@@ -111,16 +151,77 @@ func (h *ProviderHandler) UpdateProvider(w http.ResponseWriter, r *http.Request)
 	}
 
 	provider.ID = id
-	if err := h.providerUseCase.UpdateProvider(&provider); err != nil {
+	// Only If-Match chooses the version the update is based on
+	if provider.Version, err = ifMatchVersion(r); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err := h.providerUseCase.UpdateProvider(r.Context(), &provider); err != nil {
+		switch e := err.(type) {
+		case *domain.ProviderNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ProviderAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error updating provider")
+		}
+		return
+	}
+
+	setETag(w, provider.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(provider)
+}
+
+// providerDocument is the part of a provider that merge patches apply to
+type providerDocument struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+// PatchProvider applies a merge patch to a provider and saves the fields it
+// changed
+func (h *ProviderHandler) PatchProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid provider ID", http.StatusBadRequest)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	provider, err := h.providerUseCase.PatchProvider(r.Context(), id, version, func(provider *domain.Provider) error {
+		doc, err := applyMergePatch(providerDocument{provider.Name, provider.Email, provider.Phone, provider.Address}, patch)
+		if err != nil {
+			return err
+		}
+		provider.Name, provider.Email, provider.Phone, provider.Address = doc.Name, doc.Email, doc.Phone, doc.Address
+		return nil
+	})
+	if err != nil {
 		switch e := err.(type) {
+		case *patchError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
 		case *domain.ProviderNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ProviderAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error updating provider", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error updating provider")
 		}
 		return
 	}
 
+	setETag(w, provider.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(provider)
 }
@@ -132,16 +233,62 @@ func (h *ProviderHandler) DeleteProvider(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Invalid provider ID", http.StatusBadRequest)
 		return
 	}
+	to, err := reassignTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 
-	if err := h.providerUseCase.DeleteProvider(id); err != nil {
+	actor, _ := UserFromContext(r.Context())
+	if to != 0 {
+		err = h.providerUseCase.ReassignAndDeleteProvider(r.Context(), actor, id, version, to)
+	} else {
+		err = h.providerUseCase.DeleteProvider(r.Context(), actor, id, version)
+	}
+	if err != nil {
 		switch e := err.(type) {
 		case *domain.ProviderNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.EntityInUseError:
+			writeInUseError(w, e)
+		case *domain.InvalidReassignmentError:
+			http.Error(w, e.Error(), http.StatusUnprocessableEntity)
 		default:
-			http.Error(w, "Error deleting provider", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error deleting provider")
 		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreProvider brings back a soft-deleted provider
+func (h *ProviderHandler) RestoreProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid provider ID", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := h.providerUseCase.RestoreProvider(r.Context(), id)
+	if err != nil {
+		switch err.(type) {
+		case *domain.ProviderNotFoundError:
+			http.Error(w, "deleted provider not found", http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error restoring provider")
+		}
+		return
+	}
+
+	setETag(w, provider.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(provider)
+}
@@ -68,7 +68,7 @@ func TestCreateProvider(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := usecase.NewProviderUseCase(mockRepo)
+			useCase := usecase.NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProviderHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -132,7 +132,7 @@ func TestGetProvider(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := usecase.NewProviderUseCase(mockRepo)
+			useCase := usecase.NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProviderHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/providers/"+tt.providerID, nil)
@@ -199,7 +199,7 @@ func TestGetAllProviders(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := usecase.NewProviderUseCase(mockRepo)
+			useCase := usecase.NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProviderHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/providers", nil)
@@ -288,7 +288,7 @@ func TestUpdateProvider(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				UpdateFunc:  tt.mockUpdate,
 			}
-			useCase := usecase.NewProviderUseCase(mockRepo)
+			useCase := usecase.NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProviderHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -312,7 +312,7 @@ func TestDeleteProvider(t *testing.T) {
 		name           string
 		providerID     string
 		mockGetByID    func(int64) (*domain.Provider, error)
-		mockDelete     func(int64) error
+		mockDelete     func(int64, int64) error
 		expectedStatus int
 		expectedError  string
 	}{
@@ -330,7 +330,7 @@ func TestDeleteProvider(t *testing.T) {
 					UpdatedAt: time.Now(),
 				}, nil
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedStatus: http.StatusNoContent,
@@ -342,7 +342,7 @@ func TestDeleteProvider(t *testing.T) {
 			mockGetByID: func(id int64) (*domain.Provider, error) {
 				return nil, &domain.ProviderNotFoundError{ProviderID: 999}
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedStatus: http.StatusNotFound,
@@ -364,7 +364,7 @@ func TestDeleteProvider(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				DeleteFunc:  tt.mockDelete,
 			}
-			useCase := usecase.NewProviderUseCase(mockRepo)
+			useCase := usecase.NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 			handler := NewProviderHandler(useCase)
 
 			req := httptest.NewRequest("DELETE", "/api/providers/"+tt.providerID, nil)
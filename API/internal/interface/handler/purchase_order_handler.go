@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderUseCase *usecase.PurchaseOrderUseCase
+}
+
+func NewPurchaseOrderHandler(useCase *usecase.PurchaseOrderUseCase) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderUseCase: useCase,
+	}
+}
+
+// CreatePurchaseOrderRequest is the body of POST /api/purchase-orders
+type CreatePurchaseOrderRequest struct {
+	ProviderID int64  `json:"provider_id"`
+	Notes      string `json:"notes"`
+	Lines      []struct {
+		ProductID        int64   `json:"product_id"`
+		ExpectedQuantity int64   `json:"expected_quantity"`
+		UnitCost         float64 `json:"unit_cost"`
+	} `json:"lines"`
+}
+
+// ReceivePurchaseOrderRequest is the body of POST /api/purchase-orders/{id}/receive
+type ReceivePurchaseOrderRequest struct {
+	PurchaseDate string `json:"purchase_date"`
+	LocationID   int64  `json:"location_id,omitempty"`
+	Lines        []struct {
+		LineID  int64    `json:"line_id"`
+		Batch   string   `json:"batch"`
+		Serials []string `json:"serials"`
+	} `json:"lines"`
+}
+
+// writePurchaseOrderError maps purchase order errors to responses
+func writePurchaseOrderError(w http.ResponseWriter, err error, fallback string) {
+	if writeAuditUserError(w, err) {
+		return
+	}
+	switch e := err.(type) {
+	case *domain.InvalidPurchaseOrderError, *domain.InvalidPurchaseOrderStatusError:
+		http.Error(w, e.Error(), http.StatusBadRequest)
+	case *domain.PurchaseOrderNotFoundError:
+		http.Error(w, e.Error(), http.StatusNotFound)
+	case *domain.ProviderNotFoundError:
+		http.Error(w, "provider with ID "+strconv.FormatInt(e.ProviderID, 10)+" not found", http.StatusUnprocessableEntity)
+	case *domain.ProductNotFoundError, *domain.TrackingModeMismatchError:
+		http.Error(w, e.Error(), http.StatusUnprocessableEntity)
+	case *domain.StockAlreadyExistsError:
+		http.Error(w, "stock with serial "+e.Serial+" already exists", http.StatusConflict)
+	case *domain.InvalidPurchaseOrderTransitionError, *domain.OverReceiptError:
+		http.Error(w, e.Error(), http.StatusConflict)
+	default:
+		writeFallbackError(w, err, fallback)
+	}
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.ProviderID == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	lines := make([]usecase.PurchaseOrderLineInput, len(req.Lines))
+	for i, line := range req.Lines {
+		lines[i] = usecase.PurchaseOrderLineInput{
+			ProductID:        line.ProductID,
+			ExpectedQuantity: line.ExpectedQuantity,
+			UnitCost:         line.UnitCost,
+		}
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	order, err := h.purchaseOrderUseCase.CreatePurchaseOrder(r.Context(), actor, req.ProviderID, req.Notes, lines)
+	if err != nil {
+		writePurchaseOrderError(w, err, "Error creating purchase order")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.purchaseOrderUseCase.GetPurchaseOrder(r.Context(), id)
+	if err != nil {
+		writePurchaseOrderError(w, err, "Error fetching purchase order")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) GetAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.PurchaseOrderFilter{
+		Status: domain.PurchaseOrderStatus(query.Get("status")),
+	}
+	if v := query.Get("provider_id"); v != "" {
+		providerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid provider ID", http.StatusBadRequest)
+			return
+		}
+		filter.ProviderID = providerID
+	}
+
+	orders, err := h.purchaseOrderUseCase.GetAllPurchaseOrders(r.Context(), filter)
+	if err != nil {
+		writePurchaseOrderError(w, err, "Error fetching purchase orders")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *PurchaseOrderHandler) SubmitPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.purchaseOrderUseCase.SubmitPurchaseOrder, "Error submitting purchase order")
+}
+
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.purchaseOrderUseCase.CancelPurchaseOrder, "Error cancelling purchase order")
+}
+
+func (h *PurchaseOrderHandler) transition(w http.ResponseWriter, r *http.Request, apply func(context.Context, *domain.User, int64) (*domain.PurchaseOrder, error), fallback string) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	order, err := apply(r.Context(), actor, id)
+	if err != nil {
+		writePurchaseOrderError(w, err, fallback)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReceivePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Parse purchase date
+	var purchaseDate time.Time
+	if req.PurchaseDate != "" {
+		purchaseDate, err = time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			http.Error(w, "Invalid purchase date format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lines := make([]usecase.ReceiptLineInput, len(req.Lines))
+	for i, line := range req.Lines {
+		lines[i] = usecase.ReceiptLineInput{
+			LineID:  line.LineID,
+			Serials: line.Serials,
+			Batch:   line.Batch,
+		}
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	order, err := h.purchaseOrderUseCase.ReceivePurchaseOrder(r.Context(), actor, id, purchaseDate, req.LocationID, lines)
+	if err != nil {
+		writePurchaseOrderError(w, err, "Error receiving purchase order")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		requestBody    map[string]interface{}
+		expectedStatus int
+	}{
+		{
+			name: "partial receipt",
+			path: "/1/receive",
+			requestBody: map[string]interface{}{
+				"purchase_date": "2024-03-01",
+				"lines": []map[string]interface{}{
+					{"line_id": 10, "batch": "B1", "serials": []string{"S1"}},
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "over receipt",
+			path: "/1/receive",
+			requestBody: map[string]interface{}{
+				"lines": []map[string]interface{}{
+					{"line_id": 10, "serials": []string{"S1", "S2", "S3"}},
+				},
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "invalid purchase date",
+			path: "/1/receive",
+			requestBody: map[string]interface{}{
+				"purchase_date": "01/03/2024",
+				"lines": []map[string]interface{}{
+					{"line_id": 10, "serials": []string{"S1"}},
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown order",
+			path: "/999/receive",
+			requestBody: map[string]interface{}{
+				"lines": []map[string]interface{}{
+					{"line_id": 10, "serials": []string{"S1"}},
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepo := &repository.MockPurchaseOrderRepository{
+				GetByIDFunc: func(id int64) (*domain.PurchaseOrder, error) {
+					if id != 1 {
+						return nil, nil
+					}
+					return &domain.PurchaseOrder{
+						ID:       1,
+						Provider: &domain.Provider{ID: 3},
+						Status:   domain.PurchaseOrderOrdered,
+						Lines: []domain.PurchaseOrderLine{
+							{ID: 10, Product: &domain.Product{ID: 1}, ExpectedQuantity: 2},
+						},
+					}, nil
+				},
+			}
+			useCase := usecase.NewPurchaseOrderUseCase(orderRepo, &repository.MockProviderRepository{}, &repository.MockProductRepository{}, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+			handler := NewPurchaseOrderHandler(useCase)
+
+			r := chi.NewRouter()
+			r.Post("/{id}/receive", handler.ReceivePurchaseOrder)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", tt.path, bytes.NewBuffer(body))
+			req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var order domain.PurchaseOrder
+				if err := json.NewDecoder(w.Body).Decode(&order); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if order.Status != domain.PurchaseOrderPartiallyReceived || order.Lines[0].ReceivedQuantity != 1 {
+					t.Errorf("unexpected order %+v", order)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestTimeout gives every request a deadline of d, after which its
+// database calls are cancelled. A zero or negative d disables it.
+func RequestTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(context.WithValue(r.Context(), untimedKey{}, r.Context()), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// untimedKey is the context key of the context a request had before
+// RequestTimeout gave it a deadline
+type untimedKey struct{}
+
+// untimedContext keeps the values of a request context while taking its
+// deadline and cancellation from the context the request had before
+// RequestTimeout
+type untimedContext struct {
+	context.Context
+	untimed context.Context
+}
+
+func (c untimedContext) Deadline() (time.Time, bool) { return c.untimed.Deadline() }
+func (c untimedContext) Done() <-chan struct{}       { return c.untimed.Done() }
+func (c untimedContext) Err() error                  { return c.untimed.Err() }
+
+// WithoutRequestTimeout lifts the deadline of RequestTimeout for routes that
+// stream for as long as they have rows to send, such as the exports. The
+// request is still cancelled when the client goes away.
+func WithoutRequestTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if untimed, ok := r.Context().Value(untimedKey{}).(context.Context); ok {
+			r = r.WithContext(untimedContext{Context: r.Context(), untimed: untimed})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
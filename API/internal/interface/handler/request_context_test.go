@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{name: "deadline set", timeout: time.Minute, wantDeadline: true},
+		{name: "disabled", timeout: 0, wantDeadline: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hasDeadline = r.Context().Deadline()
+			})
+
+			RequestTimeout(tt.timeout)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/products", nil))
+
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("expected deadline %v, got %v", tt.wantDeadline, hasDeadline)
+			}
+		})
+	}
+}
+
+func TestWithoutRequestTimeout(t *testing.T) {
+	type key struct{}
+	var ctx context.Context
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+	// A value added after the timeout, like the authenticated user, is kept
+	withValue := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key{}, "ana")))
+		})
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/api/stocks/export", nil).WithContext(parent)
+	RequestTimeout(time.Nanosecond)(withValue(WithoutRequestTimeout(next))).ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || ctx.Err() != nil {
+		t.Errorf("expected no deadline, got %v", ctx.Err())
+	}
+	if ctx.Value(key{}) != "ana" {
+		t.Errorf("expected the values of the request to be kept, got %v", ctx.Value(key{}))
+	}
+	cancel()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("expected the request to be cancelled with the client, got %v", ctx.Err())
+	}
+}
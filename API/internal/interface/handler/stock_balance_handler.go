@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+)
+
+type StockBalanceHandler struct {
+	balanceUseCase *usecase.StockBalanceUseCase
+}
+
+func NewStockBalanceHandler(useCase *usecase.StockBalanceUseCase) *StockBalanceHandler {
+	return &StockBalanceHandler{
+		balanceUseCase: useCase,
+	}
+}
+
+// BalanceMovementRequest is the body of the receive, issue and adjust
+// endpoints. Quantity is a signed delta for adjustments and a positive
+// amount otherwise.
+type BalanceMovementRequest struct {
+	ProductID  int64  `json:"product_id"`
+	LocationID int64  `json:"location_id"`
+	Batch      string `json:"batch"`
+	Quantity   int64  `json:"quantity"`
+	Reason     string `json:"reason"`
+}
+
+// balanceFilterFromQuery reads the optional ?product_id=, ?warehouse_id=,
+// ?location_id= and ?batch= filters of balance and on-hand listings
+func balanceFilterFromQuery(r *http.Request) (domain.StockBalanceFilter, error) {
+	query := r.URL.Query()
+	filter := domain.StockBalanceFilter{
+		Batch: query.Get("batch"),
+	}
+
+	var err error
+	if v := query.Get("product_id"); v != "" {
+		if filter.ProductID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid product ID")
+		}
+	}
+	if v := query.Get("warehouse_id"); v != "" {
+		if filter.WarehouseID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid warehouse ID")
+		}
+	}
+	if v := query.Get("location_id"); v != "" {
+		if filter.LocationID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid location ID")
+		}
+	}
+	return filter, nil
+}
+
+// writeStockBalanceError maps balance errors to responses
+func writeStockBalanceError(w http.ResponseWriter, err error, fallback string) {
+	if writeAuditUserError(w, err) {
+		return
+	}
+	switch e := err.(type) {
+	case *domain.InvalidQuantityError:
+		http.Error(w, e.Error(), http.StatusBadRequest)
+	case *domain.ProductNotFoundError, *domain.LocationNotFoundError:
+		http.Error(w, e.Error(), http.StatusUnprocessableEntity)
+	case *domain.TrackingModeMismatchError, *domain.InsufficientQuantityError:
+		http.Error(w, e.Error(), http.StatusConflict)
+	default:
+		writeFallbackError(w, err, fallback)
+	}
+}
+
+// applyMovement decodes a balance movement request and runs it through apply
+func applyMovement(w http.ResponseWriter, r *http.Request, fallback string, apply func(actor *domain.User, req BalanceMovementRequest) (*domain.BalanceMovement, error)) {
+	var req BalanceMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.ProductID == 0 || req.LocationID == 0 {
+		http.Error(w, "product_id and location_id are required fields", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	movement, err := apply(actor, req)
+	if err != nil {
+		writeStockBalanceError(w, err, fallback)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movement)
+}
+
+// ReceiveQuantity adds units of a quantity-tracked product to a location
+func (h *StockBalanceHandler) ReceiveQuantity(w http.ResponseWriter, r *http.Request) {
+	applyMovement(w, r, "Error receiving stock", func(actor *domain.User, req BalanceMovementRequest) (*domain.BalanceMovement, error) {
+		return h.balanceUseCase.ReceiveQuantity(r.Context(), actor, req.ProductID, req.LocationID, req.Batch, req.Quantity, req.Reason)
+	})
+}
+
+// IssueQuantity takes units of a quantity-tracked product out of a location
+func (h *StockBalanceHandler) IssueQuantity(w http.ResponseWriter, r *http.Request) {
+	applyMovement(w, r, "Error issuing stock", func(actor *domain.User, req BalanceMovementRequest) (*domain.BalanceMovement, error) {
+		return h.balanceUseCase.IssueQuantity(r.Context(), actor, req.ProductID, req.LocationID, req.Batch, req.Quantity, req.Reason)
+	})
+}
+
+// AdjustQuantity corrects the balance of a location by a signed quantity
+func (h *StockBalanceHandler) AdjustQuantity(w http.ResponseWriter, r *http.Request) {
+	applyMovement(w, r, "Error adjusting stock", func(actor *domain.User, req BalanceMovementRequest) (*domain.BalanceMovement, error) {
+		return h.balanceUseCase.AdjustQuantity(r.Context(), actor, req.ProductID, req.LocationID, req.Batch, req.Quantity, req.Reason)
+	})
+}
+
+func (h *StockBalanceHandler) GetStockBalances(w http.ResponseWriter, r *http.Request) {
+	filter, err := balanceFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	balances, err := h.balanceUseCase.GetStockBalances(r.Context(), filter)
+	if err != nil {
+		writeStockBalanceError(w, err, "Error fetching stock balances")
+		return
+	}
+
+	if balances == nil {
+		balances = []domain.StockBalance{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+// GetBalanceMovements returns the ledger of the balances matching the query
+func (h *StockBalanceHandler) GetBalanceMovements(w http.ResponseWriter, r *http.Request) {
+	filter, err := balanceFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.balanceUseCase.GetBalanceMovements(r.Context(), filter)
+	if err != nil {
+		writeStockBalanceError(w, err, "Error fetching balance movements")
+		return
+	}
+
+	if movements == nil {
+		movements = []domain.BalanceMovement{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
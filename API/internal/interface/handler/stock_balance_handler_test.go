@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIssueQuantity(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		applyErr       error
+		expectedStatus int
+	}{
+		{
+			name:           "issue from balance",
+			requestBody:    map[string]interface{}{"product_id": 1, "location_id": 7, "batch": "B1", "quantity": 5},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "insufficient quantity",
+			requestBody:    map[string]interface{}{"product_id": 1, "location_id": 7, "quantity": 500},
+			applyErr:       &domain.InsufficientQuantityError{ProductID: 1, Available: 20, Requested: 500},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "serialized product",
+			requestBody:    map[string]interface{}{"product_id": 2, "location_id": 7, "quantity": 1},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "unknown product",
+			requestBody:    map[string]interface{}{"product_id": 9, "location_id": 7, "quantity": 1},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "non-positive quantity",
+			requestBody:    map[string]interface{}{"product_id": 1, "location_id": 7, "quantity": -5},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing location",
+			requestBody:    map[string]interface{}{"product_id": 1, "quantity": 5},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balanceRepo := &repository.MockStockBalanceRepository{
+				ApplyFunc: func(movement *domain.BalanceMovement) error {
+					if tt.applyErr != nil {
+						return tt.applyErr
+					}
+					movement.ID = 1
+					movement.BalanceAfter = 15
+					return nil
+				},
+			}
+			productRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					switch id {
+					case 1:
+						return &domain.Product{ID: 1, TrackingMode: domain.TrackingQuantity}, nil
+					case 2:
+						return &domain.Product{ID: 2, TrackingMode: domain.TrackingSerialized}, nil
+					}
+					return nil, nil
+				},
+			}
+			locationRepo := &repository.MockLocationRepository{
+				GetByIDFunc: func(id int64) (*domain.Location, error) {
+					return &domain.Location{ID: id}, nil
+				},
+			}
+			handler := NewStockBalanceHandler(usecase.NewStockBalanceUseCase(balanceRepo, productRepo, locationRepo))
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/stock-balances/issue", bytes.NewBuffer(body))
+			req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
+			w := httptest.NewRecorder()
+			handler.IssueQuantity(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var movement domain.BalanceMovement
+				if err := json.NewDecoder(w.Body).Decode(&movement); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if movement.Type != domain.BalanceIssue || movement.Quantity != -5 || movement.BalanceAfter != 15 {
+					t.Errorf("unexpected movement %+v", movement)
+				}
+			}
+		})
+	}
+}
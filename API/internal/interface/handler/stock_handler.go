@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"inventario/internal/domain"
 	"inventario/internal/usecase"
 	"net/http"
@@ -21,14 +22,30 @@ func NewStockHandler(stockUseCase *usecase.StockUseCase) *StockHandler {
 	}
 }
 
+// CreateStockRequest is the body of POST /api/stocks. The audit user IDs are
+// optional: the authenticated caller is recorded unless legacy mode is enabled.
 type CreateStockRequest struct {
 	ProductID       int64  `json:"product_id"`
 	Serial          string `json:"serial"`
 	Batch           string `json:"batch"`
 	PurchaseDate    string `json:"purchase_date"`
 	ProviderID      int64  `json:"provider_id"`
-	CreatedByUserID int64  `json:"created_by_user_id"`
-	UpdatedByUserID int64  `json:"updated_by_user_id"`
+	LocationID      int64  `json:"location_id,omitempty"`
+	CreatedByUserID int64  `json:"created_by_user_id,omitempty"`
+	UpdatedByUserID int64  `json:"updated_by_user_id,omitempty"`
+}
+
+// writeAuditUserError maps audit user errors to responses and reports whether err was handled
+func writeAuditUserError(w http.ResponseWriter, err error) bool {
+	switch err.(type) {
+	case *domain.AuditUserMismatchError:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case *domain.MissingAuditUserError:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		return false
+	}
+	return true
 }
 
 func (h *StockHandler) CreateStock(w http.ResponseWriter, r *http.Request) {
@@ -39,7 +56,7 @@ func (h *StockHandler) CreateStock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if req.ProductID == 0 || req.Serial == "" || req.ProviderID == 0 || req.CreatedByUserID == 0 || req.UpdatedByUserID == 0 {
+	if req.ProductID == 0 || req.Serial == "" || req.ProviderID == 0 {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -55,29 +72,120 @@ func (h *StockHandler) CreateStock(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.UpdatedByUserID != 0 && req.UpdatedByUserID != req.CreatedByUserID {
+		http.Error(w, "created_by_user_id and updated_by_user_id must match", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
 	createdStock, err := h.stockUseCase.CreateStock(
+		r.Context(),
+		actor,
 		req.ProductID,
 		req.Serial,
 		req.Batch,
 		purchaseDate,
 		req.ProviderID,
+		req.LocationID,
 		req.CreatedByUserID,
 	)
 	if err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
 		switch e := err.(type) {
 		case *domain.StockAlreadyExistsError:
 			http.Error(w, "stock with serial "+e.Serial+" already exists", http.StatusConflict)
+		case *domain.TrackingModeMismatchError:
+			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error creating stock", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error creating stock")
 		}
 		return
 	}
 
+	setETag(w, createdStock.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdStock)
 }
 
+// CreateStocksRequest is the body of POST /api/stocks/bulk: serials received
+// together, sharing every other field. Mode is all_or_nothing, the default,
+// or best_effort.
+type CreateStocksRequest struct {
+	ProductID    int64    `json:"product_id"`
+	ProviderID   int64    `json:"provider_id"`
+	LocationID   int64    `json:"location_id,omitempty"`
+	Batch        string   `json:"batch"`
+	PurchaseDate string   `json:"purchase_date"`
+	Serials      []string `json:"serials"`
+	Mode         string   `json:"mode,omitempty"`
+	Reason       string   `json:"reason,omitempty"`
+}
+
+// CreateStocks creates many units at once and answers with the outcome of
+// every serial: 201 when all were created, 422 when none was and 200 when
+// only some were
+func (h *StockHandler) CreateStocks(w http.ResponseWriter, r *http.Request) {
+	var req CreateStocksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ProductID == 0 || req.ProviderID == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var purchaseDate time.Time
+	if req.PurchaseDate != "" {
+		var err error
+		purchaseDate, err = time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			http.Error(w, "Invalid purchase date format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	report, err := h.stockUseCase.CreateStocks(r.Context(), actor, usecase.BulkStockInput{
+		ProductID:    req.ProductID,
+		ProviderID:   req.ProviderID,
+		LocationID:   req.LocationID,
+		Batch:        req.Batch,
+		PurchaseDate: purchaseDate,
+		Serials:      req.Serials,
+		Mode:         domain.BulkMode(req.Mode),
+		Reason:       req.Reason,
+	})
+	if err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
+		switch e := err.(type) {
+		case *domain.InvalidBulkStockError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.StockAlreadyExistsError, *domain.TrackingModeMismatchError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error creating stocks")
+		}
+		return
+	}
+
+	status := http.StatusOK
+	switch report.Created {
+	case len(report.Items):
+		status = http.StatusCreated
+	case 0:
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
 func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -86,13 +194,13 @@ func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stock, err := h.stockUseCase.GetStock(id)
+	stock, err := h.stockUseCase.GetStock(r.Context(), id)
 	if err != nil {
 		switch e := err.(type) {
 		case *domain.StockNotFoundError:
 			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
 		default:
-			http.Error(w, "Error fetching stock", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error fetching stock")
 		}
 		return
 	}
@@ -102,28 +210,149 @@ func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, stock.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stock)
 }
 
-func (h *StockHandler) GetAllStocks(w http.ResponseWriter, r *http.Request) {
-	stocks, err := h.stockUseCase.GetAllStocks()
+// stockFilterFromQuery reads the optional ?status=, ?warehouse_id=,
+// ?location_id=, ?product_id=, ?provider_id=, ?batch=, ?purchased_after= and
+// ?purchased_before= filters of stock listings, along with ?include_deleted=
+// and the paging options
+func stockFilterFromQuery(r *http.Request) (domain.StockFilter, error) {
+	query := r.URL.Query()
+	filter := domain.StockFilter{
+		Status: domain.StockStatus(query.Get("status")),
+		Batch:  query.Get("batch"),
+	}
+
+	var err error
+	if filter.ListOptions, err = listOptionsFromQuery(r); err != nil {
+		return filter, err
+	}
+	if v := query.Get("product_id"); v != "" {
+		if filter.ProductID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid product ID")
+		}
+	}
+	if v := query.Get("provider_id"); v != "" {
+		if filter.ProviderID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid provider ID")
+		}
+	}
+	if filter.PurchasedAfter, err = dateFromQuery(r, "purchased_after"); err != nil {
+		return filter, err
+	}
+	if filter.PurchasedBefore, err = dateFromQuery(r, "purchased_before"); err != nil {
+		return filter, err
+	}
+	if v := query.Get("warehouse_id"); v != "" {
+		if filter.WarehouseID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid warehouse ID")
+		}
+	}
+	if v := query.Get("location_id"); v != "" {
+		if filter.LocationID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, errors.New("Invalid location ID")
+		}
+	}
+	if filter.IncludeDeleted, err = includeDeletedFromQuery(r); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// locationRef returns a reference to the location with the given ID, or nil
+// when id is zero
+func locationRef(id int64) *domain.Location {
+	if id == 0 {
+		return nil
+	}
+	return &domain.Location{ID: id}
+}
+
+// writeStocks runs a stock listing and writes the page as JSON, along with
+// the paging headers
+func writeStocks(w http.ResponseWriter, r *http.Request, filter domain.StockFilter, list func() ([]*domain.Stock, int64, error)) {
+	stocks, total, err := list()
 	if err != nil {
-		http.Error(w, "Error fetching stocks", http.StatusInternalServerError)
+		switch err.(type) {
+		case *domain.InvalidStockStatusError, *domain.InvalidListOptionsError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "Error fetching stocks")
+		}
 		return
 	}
 
+	var lastID int64
+	if len(stocks) > 0 {
+		lastID = stocks[len(stocks)-1].ID
+	}
+	writePageHeaders(w, r, filter.ListOptions, total, len(stocks), lastID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stocks)
 }
 
+func (h *StockHandler) GetAllStocks(w http.ResponseWriter, r *http.Request) {
+	filter, err := stockFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	writeStocks(w, r, filter, func() ([]*domain.Stock, int64, error) {
+		return h.stockUseCase.GetAllStocks(r.Context(), filter)
+	})
+}
+
+// GetStocksByWarehouse lists the units stored at any location of a warehouse
+func (h *StockHandler) GetStocksByWarehouse(w http.ResponseWriter, r *http.Request) {
+	filter, err := stockFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	filter.WarehouseID, err = strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	writeStocks(w, r, filter, func() ([]*domain.Stock, int64, error) {
+		return h.stockUseCase.GetAllStocks(r.Context(), filter)
+	})
+}
+
+// GetStocksByLocation lists the units stored at a location
+func (h *StockHandler) GetStocksByLocation(w http.ResponseWriter, r *http.Request) {
+	filter, err := stockFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	filter.LocationID, err = strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	writeStocks(w, r, filter, func() ([]*domain.Stock, int64, error) {
+		return h.stockUseCase.GetAllStocks(r.Context(), filter)
+	})
+}
+
 type UpdateStockRequest struct {
 	ProductID       int64  `json:"product_id"`
 	Serial          string `json:"serial"`
 	Batch           string `json:"batch"`
 	PurchaseDate    string `json:"purchase_date"`
 	ProviderID      int64  `json:"provider_id"`
-	UpdatedByUserID int64  `json:"updated_by_user_id"`
+	LocationID      int64  `json:"location_id,omitempty"`
+	UpdatedByUserID int64  `json:"updated_by_user_id,omitempty"`
+	Reason          string `json:"reason"`
 }
 
 func (h *StockHandler) UpdateStock(w http.ResponseWriter, r *http.Request) {
@@ -141,7 +370,7 @@ func (h *StockHandler) UpdateStock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if req.ProductID == 0 || req.Serial == "" || req.ProviderID == 0 || req.UpdatedByUserID == 0 {
+	if req.ProductID == 0 || req.Serial == "" || req.ProviderID == 0 {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -156,26 +385,181 @@ func (h *StockHandler) UpdateStock(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
 	stock := &domain.Stock{
 		ID:            id,
+		Version:       version,
 		Serial:        req.Serial,
 		Batch:         req.Batch,
 		PurchaseDate:  purchaseDate,
 		Product:       &domain.Product{ID: req.ProductID},
 		Provider:      &domain.Provider{ID: req.ProviderID},
+		Location:      locationRef(req.LocationID),
 		UpdatedByUser: &domain.User{ID: req.UpdatedByUserID},
 	}
 
-	if err := h.stockUseCase.UpdateStock(stock); err != nil {
+	actor, _ := UserFromContext(r.Context())
+	if err := h.stockUseCase.UpdateStock(r.Context(), actor, stock, req.Reason); err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
+		switch e := err.(type) {
+		case *domain.StockNotFoundError:
+			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
+		case *domain.StockLocationChangeError, *domain.TrackingModeMismatchError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		case *domain.StockAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error updating stock")
+		}
+		return
+	}
+
+	setETag(w, stock.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stock)
+}
+
+// stockDocument is the part of a unit that merge patches apply to, shaped
+// like the body of UpdateStock
+type stockDocument struct {
+	ProductID    int64  `json:"product_id"`
+	Serial       string `json:"serial"`
+	Batch        string `json:"batch"`
+	PurchaseDate string `json:"purchase_date,omitempty"`
+	ProviderID   int64  `json:"provider_id"`
+	LocationID   int64  `json:"location_id,omitempty"`
+}
+
+// PatchStock applies a merge patch to a unit and saves the fields it changed.
+// A "reason" member is not a field: it is recorded in the history of the
+// unit.
+func (h *StockHandler) PatchStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stock ID", http.StatusBadRequest)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+	reason, _ := patch["reason"].(string)
+
+	actor, _ := UserFromContext(r.Context())
+	stock, err := h.stockUseCase.PatchStock(r.Context(), actor, id, version, func(stock *domain.Stock) error {
+		doc := stockDocument{
+			ProductID:  stock.Product.ID,
+			Serial:     stock.Serial,
+			Batch:      stock.Batch,
+			ProviderID: stock.Provider.ID,
+		}
+		if !stock.PurchaseDate.IsZero() {
+			doc.PurchaseDate = stock.PurchaseDate.Format("2006-01-02")
+		}
+		if stock.Location != nil {
+			doc.LocationID = stock.Location.ID
+		}
+		doc, err := applyMergePatch(doc, patch)
+		if err != nil {
+			return err
+		}
+
+		var purchaseDate time.Time
+		if doc.PurchaseDate != "" {
+			if purchaseDate, err = time.Parse("2006-01-02", doc.PurchaseDate); err != nil {
+				return &patchError{reason: "invalid purchase date format"}
+			}
+		}
+		stock.Product = &domain.Product{ID: doc.ProductID}
+		stock.Serial = doc.Serial
+		stock.Batch = doc.Batch
+		stock.PurchaseDate = purchaseDate
+		stock.Provider = &domain.Provider{ID: doc.ProviderID}
+		stock.Location = locationRef(doc.LocationID)
+		return nil
+	}, reason)
+	if err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
+		switch e := err.(type) {
+		case *patchError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.StockNotFoundError:
+			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
+		case *domain.StockLocationChangeError, *domain.TrackingModeMismatchError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		case *domain.StockAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error updating stock")
+		}
+		return
+	}
+
+	setETag(w, stock.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stock)
+}
+
+type TransitionStockRequest struct {
+	Status domain.StockStatus `json:"status"`
+	Reason string             `json:"reason"`
+}
+
+func (h *StockHandler) TransitionStock(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stock ID", http.StatusBadRequest)
+		return
+	}
+
+	var req TransitionStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Status == "" {
+		http.Error(w, "Status is a required field", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	stock, err := h.stockUseCase.TransitionStock(r.Context(), actor, id, req.Status, req.Reason)
+	if err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
 		switch e := err.(type) {
+		case *domain.InvalidStockStatusError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ForbiddenError:
+			http.Error(w, e.Error(), http.StatusForbidden)
 		case *domain.StockNotFoundError:
 			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
+		case *domain.InvalidStockTransitionError:
+			http.Error(w, e.Error(), http.StatusConflict)
 		default:
-			http.Error(w, "Error updating stock", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error changing stock status")
 		}
 		return
 	}
 
+	setETag(w, stock.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stock)
 }
@@ -188,12 +572,22 @@ func (h *StockHandler) DeleteStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.stockUseCase.DeleteStock(id); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	if err := h.stockUseCase.DeleteStock(r.Context(), actor, id, version, r.URL.Query().Get("reason")); err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
 		switch e := err.(type) {
 		case *domain.StockNotFoundError:
 			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
 		default:
-			http.Error(w, "Error deleting stock", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error deleting stock")
 		}
 		return
 	}
@@ -201,6 +595,36 @@ func (h *StockHandler) DeleteStock(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreStock brings back a soft-deleted unit. An optional ?reason= is
+// recorded in its history.
+func (h *StockHandler) RestoreStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stock ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	stock, err := h.stockUseCase.RestoreStock(r.Context(), actor, id, r.URL.Query().Get("reason"))
+	if err != nil {
+		if writeAuditUserError(w, err) {
+			return
+		}
+		switch e := err.(type) {
+		case *domain.StockNotFoundError:
+			http.Error(w, "deleted stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error restoring stock")
+		}
+		return
+	}
+
+	setETag(w, stock.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stock)
+}
+
 func (h *StockHandler) GetStocksByProductID(w http.ResponseWriter, r *http.Request) {
 	productIDStr := chi.URLParam(r, "productId")
 	productID, err := strconv.ParseInt(productIDStr, 10, 64)
@@ -209,14 +633,68 @@ func (h *StockHandler) GetStocksByProductID(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	stocks, err := h.stockUseCase.GetStocksByProductID(productID)
+	filter, err := stockFilterFromQuery(r)
 	if err != nil {
-		http.Error(w, "Error fetching stocks", http.StatusInternalServerError)
+		writeQueryError(w, err)
 		return
 	}
 
+	writeStocks(w, r, filter, func() ([]*domain.Stock, int64, error) {
+		return h.stockUseCase.GetStocksByProductID(r.Context(), productID, filter)
+	})
+}
+
+// GetStockLocationsByProductID reports the on-hand quantity of a product at
+// each location, whichever way the product is tracked
+func (h *StockHandler) GetStockLocationsByProductID(w http.ResponseWriter, r *http.Request) {
+	productIDStr := chi.URLParam(r, "productId")
+	productID, err := strconv.ParseInt(productIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	counts, err := h.stockUseCase.GetStockLocationsByProductID(r.Context(), productID)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ProductNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error fetching stock locations")
+		}
+		return
+	}
+
+	if counts == nil {
+		counts = []domain.LocationStockCount{}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stocks)
+	json.NewEncoder(w).Encode(counts)
+}
+
+// GetOnHand reports the on-hand quantity of every product, serialized or
+// tracked by quantity, optionally narrowed down by product, warehouse,
+// location and batch
+func (h *StockHandler) GetOnHand(w http.ResponseWriter, r *http.Request) {
+	filter, err := balanceFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	onHand, err := h.stockUseCase.GetOnHand(r.Context(), filter)
+	if err != nil {
+		writeFallbackError(w, err, "Error fetching on-hand stock")
+		return
+	}
+
+	if onHand == nil {
+		onHand = []domain.ProductOnHand{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(onHand)
 }
 
 func (h *StockHandler) GetStockBySerial(w http.ResponseWriter, r *http.Request) {
@@ -226,13 +704,13 @@ func (h *StockHandler) GetStockBySerial(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	stock, err := h.stockUseCase.GetStockBySerial(serial)
+	stock, err := h.stockUseCase.GetStockBySerial(r.Context(), serial)
 	if err != nil {
 		switch e := err.(type) {
 		case *domain.StockNotFoundError:
 			http.Error(w, "stock with serial "+e.Serial+" not found", http.StatusNotFound)
 		default:
-			http.Error(w, "Error fetching stock", http.StatusInternalServerError)
+			writeFallbackError(w, err, "Error fetching stock")
 		}
 		return
 	}
@@ -242,6 +720,60 @@ func (h *StockHandler) GetStockBySerial(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	setETag(w, stock.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stock)
 }
+
+func (h *StockHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stock ID", http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.stockUseCase.GetStockHistory(r.Context(), id)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.StockNotFoundError:
+			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error fetching stock history")
+		}
+		return
+	}
+
+	if movements == nil {
+		movements = []domain.StockMovement{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+func (h *StockHandler) GetStockHistoryBySerial(w http.ResponseWriter, r *http.Request) {
+	serial := chi.URLParam(r, "serial")
+	if serial == "" {
+		http.Error(w, "Serial number is required", http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.stockUseCase.GetStockHistoryBySerial(r.Context(), serial)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.StockNotFoundError:
+			http.Error(w, "stock with serial "+e.Serial+" not found", http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error fetching stock history")
+		}
+		return
+	}
+
+	if movements == nil {
+		movements = []domain.StockMovement{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
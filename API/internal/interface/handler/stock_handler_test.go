@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
 	"inventario/internal/usecase"
@@ -16,11 +17,13 @@ import (
 
 func TestCreateStock(t *testing.T) {
 	tests := []struct {
-		name           string
-		requestBody    map[string]interface{}
-		mockCreate     func(*domain.Stock) error
-		expectedStatus int
-		expectedError  string
+		name                 string
+		requestBody          map[string]interface{}
+		mockCreate           func(*domain.Stock) error
+		actor                *domain.User
+		allowClientAuditUser bool
+		expectedStatus       int
+		expectedError        string
 	}{
 		{
 			name: "successful creation",
@@ -36,9 +39,61 @@ func TestCreateStock(t *testing.T) {
 			mockCreate: func(s *domain.Stock) error {
 				return nil
 			},
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
 			expectedStatus: http.StatusCreated,
 			expectedError:  "",
 		},
+		{
+			name: "audit user taken from caller",
+			requestBody: map[string]interface{}{
+				"product_id":    1,
+				"serial":        "SERIAL124",
+				"batch":         "BATCH001",
+				"purchase_date": time.Now().Format("2006-01-02"),
+				"provider_id":   1,
+			},
+			mockCreate: func(s *domain.Stock) error {
+				if s.CreatedByUser.ID != 7 || s.UpdatedByUser.ID != 7 {
+					return errors.New("audit user not stamped from caller")
+				}
+				return nil
+			},
+			actor:          &domain.User{ID: 7, Role: domain.RoleWarehouse},
+			expectedStatus: http.StatusCreated,
+			expectedError:  "",
+		},
+		{
+			name: "spoofed audit user",
+			requestBody: map[string]interface{}{
+				"product_id":         1,
+				"serial":             "SERIAL125",
+				"provider_id":        1,
+				"created_by_user_id": 2,
+			},
+			mockCreate:     nil,
+			actor:          &domain.User{ID: 7, Role: domain.RoleWarehouse},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "audit user 2 does not match the authenticated user",
+		},
+		{
+			name: "client audit user trusted in legacy mode",
+			requestBody: map[string]interface{}{
+				"product_id":         1,
+				"serial":             "SERIAL126",
+				"provider_id":        1,
+				"created_by_user_id": 2,
+			},
+			mockCreate: func(s *domain.Stock) error {
+				if s.CreatedByUser.ID != 2 {
+					return errors.New("client audit user not kept")
+				}
+				return nil
+			},
+			actor:                &domain.User{ID: 7, Role: domain.RoleWarehouse},
+			allowClientAuditUser: true,
+			expectedStatus:       http.StatusCreated,
+			expectedError:        "",
+		},
 		{
 			name: "stock already exists",
 			requestBody: map[string]interface{}{
@@ -53,6 +108,7 @@ func TestCreateStock(t *testing.T) {
 			mockCreate: func(s *domain.Stock) error {
 				return &domain.StockAlreadyExistsError{Serial: "EXISTING123"}
 			},
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
 			expectedStatus: http.StatusConflict,
 			expectedError:  "stock with serial EXISTING123 already exists",
 		},
@@ -73,11 +129,14 @@ func TestCreateStock(t *testing.T) {
 			mockRepo := &repository.MockStockRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), tt.allowClientAuditUser)
 			handler := NewStockHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest("POST", "/api/stocks", bytes.NewBuffer(body))
+			if tt.actor != nil {
+				req = req.WithContext(ContextWithUser(req.Context(), tt.actor))
+			}
 			w := httptest.NewRecorder()
 
 			handler.CreateStock(w, req)
@@ -95,6 +154,100 @@ func TestCreateStock(t *testing.T) {
 	}
 }
 
+func TestCreateStocks(t *testing.T) {
+	actor := &domain.User{ID: 1, Role: domain.RoleWarehouse}
+	body := func(mode string, serials ...string) CreateStocksRequest {
+		return CreateStocksRequest{ProductID: 1, ProviderID: 1, Batch: "B1", PurchaseDate: "2024-03-01", Serials: serials, Mode: mode}
+	}
+
+	tests := []struct {
+		name            string
+		requestBody     CreateStocksRequest
+		actor           *domain.User
+		expectedStatus  int
+		expectedCreated int
+	}{
+		{
+			name:            "all created",
+			requestBody:     body("", "SN-1", "SN-2"),
+			actor:           actor,
+			expectedStatus:  http.StatusCreated,
+			expectedCreated: 2,
+		},
+		{
+			name:           "all or nothing with a serial in use",
+			requestBody:    body("all_or_nothing", "SN-1", "SN-TAKEN"),
+			actor:          actor,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:            "best effort with a serial in use",
+			requestBody:     body("best_effort", "SN-1", "SN-TAKEN"),
+			actor:           actor,
+			expectedStatus:  http.StatusOK,
+			expectedCreated: 1,
+		},
+		{
+			name:           "unknown mode",
+			requestBody:    body("some", "SN-1"),
+			actor:          actor,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid purchase date",
+			requestBody:    CreateStocksRequest{ProductID: 1, ProviderID: 1, PurchaseDate: "01/03/2024", Serials: []string{"SN-1"}},
+			actor:          actor,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unauthenticated",
+			requestBody:    body("", "SN-1"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockStockRepository{
+				ExistingSerialsFunc: func(serials []string) ([]string, error) {
+					return []string{"SN-TAKEN"}, nil
+				},
+				CreateManyFunc: func(stocks []*domain.Stock) error {
+					for i, stock := range stocks {
+						stock.ID = int64(i + 1)
+					}
+					return nil
+				},
+			}
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+			handler := NewStockHandler(useCase)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/stocks/bulk", bytes.NewBuffer(body))
+			if tt.actor != nil {
+				req = req.WithContext(ContextWithUser(req.Context(), tt.actor))
+			}
+			w := httptest.NewRecorder()
+
+			handler.CreateStocks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				return
+			}
+			var report domain.BulkStockReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode report: %v", err)
+			}
+			if report.Created != tt.expectedCreated || len(report.Items) != len(tt.requestBody.Serials) {
+				t.Errorf("expected %d of %d serials created, got %+v", tt.expectedCreated, len(tt.requestBody.Serials), report)
+			}
+		})
+	}
+}
+
 func TestGetStock(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -165,7 +318,7 @@ func TestGetStock(t *testing.T) {
 			mockRepo := &repository.MockStockRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			// Create a new chi router and add the URL parameter
@@ -194,13 +347,13 @@ func TestGetStock(t *testing.T) {
 func TestGetAllStocks(t *testing.T) {
 	tests := []struct {
 		name           string
-		mockGetAll     func() ([]domain.Stock, error)
+		mockGetAll     func(domain.StockFilter) ([]domain.Stock, error)
 		expectedStatus int
 		expectedError  string
 	}{
 		{
 			name: "successful retrieval",
-			mockGetAll: func() ([]domain.Stock, error) {
+			mockGetAll: func(filter domain.StockFilter) ([]domain.Stock, error) {
 				return []domain.Stock{
 					{
 						ID:           1,
@@ -240,7 +393,7 @@ func TestGetAllStocks(t *testing.T) {
 		},
 		{
 			name: "database error",
-			mockGetAll: func() ([]domain.Stock, error) {
+			mockGetAll: func(filter domain.StockFilter) ([]domain.Stock, error) {
 				return nil, &domain.StockNotFoundError{StockID: 1}
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -253,7 +406,7 @@ func TestGetAllStocks(t *testing.T) {
 			mockRepo := &repository.MockStockRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/stocks", nil)
@@ -278,14 +431,14 @@ func TestGetStocksByProductID(t *testing.T) {
 	tests := []struct {
 		name             string
 		productID        string
-		mockGetByProduct func(int64) ([]domain.Stock, error)
+		mockGetByProduct func(int64, domain.StockFilter) ([]domain.Stock, error)
 		expectedStatus   int
 		expectedError    string
 	}{
 		{
 			name:      "successful retrieval",
 			productID: "1",
-			mockGetByProduct: func(id int64) ([]domain.Stock, error) {
+			mockGetByProduct: func(id int64, filter domain.StockFilter) ([]domain.Stock, error) {
 				return []domain.Stock{
 					{
 						ID:           1,
@@ -318,7 +471,7 @@ func TestGetStocksByProductID(t *testing.T) {
 			mockRepo := &repository.MockStockRepository{
 				GetByProductIDFunc: tt.mockGetByProduct,
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			r := chi.NewRouter()
@@ -386,7 +539,7 @@ func TestGetStockBySerial(t *testing.T) {
 			mockRepo := &repository.MockStockRepository{
 				GetBySerialFunc: tt.mockGetBySerial,
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			r := chi.NewRouter()
@@ -453,6 +606,19 @@ func TestUpdateStock(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "stock with ID 999 not found",
 		},
+		{
+			name:    "spoofed audit user",
+			stockID: "1",
+			requestBody: map[string]interface{}{
+				"product_id":         1,
+				"serial":             "SERIAL123",
+				"provider_id":        1,
+				"updated_by_user_id": 2,
+			},
+			mockUpdate:     nil,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "audit user 2 does not match the authenticated user",
+		},
 		{
 			name:    "invalid request body",
 			stockID: "1",
@@ -484,7 +650,7 @@ func TestUpdateStock(t *testing.T) {
 					return nil, &domain.StockNotFoundError{StockID: id}
 				},
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			r := chi.NewRouter()
@@ -492,6 +658,7 @@ func TestUpdateStock(t *testing.T) {
 
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest("PUT", "/"+tt.stockID, bytes.NewBuffer(body))
+			req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -513,14 +680,14 @@ func TestDeleteStock(t *testing.T) {
 	tests := []struct {
 		name           string
 		stockID        string
-		mockDelete     func(int64) error
+		mockDelete     func(int64, int64) error
 		expectedStatus int
 		expectedError  string
 	}{
 		{
 			name:    "successful deletion",
 			stockID: "1",
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedStatus: http.StatusNoContent,
@@ -529,7 +696,7 @@ func TestDeleteStock(t *testing.T) {
 		{
 			name:    "stock not found",
 			stockID: "999",
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return &domain.StockNotFoundError{StockID: 999}
 			},
 			expectedStatus: http.StatusNotFound,
@@ -562,13 +729,14 @@ func TestDeleteStock(t *testing.T) {
 					return nil, &domain.StockNotFoundError{StockID: id}
 				},
 			}
-			useCase := usecase.NewStockUseCase(mockRepo)
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
 			handler := NewStockHandler(useCase)
 
 			r := chi.NewRouter()
 			r.Delete("/{id}", handler.DeleteStock)
 
 			req := httptest.NewRequest("DELETE", "/"+tt.stockID, nil)
+			req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
 			w := httptest.NewRecorder()
 
 			r.ServeHTTP(w, req)
@@ -585,3 +753,467 @@ func TestDeleteStock(t *testing.T) {
 		})
 	}
 }
+
+func TestTransitionStock(t *testing.T) {
+	tests := []struct {
+		name           string
+		stockID        string
+		actor          *domain.User
+		requestBody    map[string]interface{}
+		currentStatus  domain.StockStatus
+		expectedStatus int
+	}{
+		{
+			name:           "successful transition",
+			stockID:        "1",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			requestBody:    map[string]interface{}{"status": "reserved"},
+			currentStatus:  domain.StockAvailable,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "transition not allowed",
+			stockID:        "1",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			requestBody:    map[string]interface{}{"status": "available"},
+			currentStatus:  domain.StockScrapped,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "missing permission",
+			stockID:        "1",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			requestBody:    map[string]interface{}{"status": "scrapped"},
+			currentStatus:  domain.StockInRepair,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown status",
+			stockID:        "1",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			requestBody:    map[string]interface{}{"status": "lost"},
+			currentStatus:  domain.StockAvailable,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockStockRepository{
+				GetByIDFunc: func(id int64) (*domain.Stock, error) {
+					return &domain.Stock{ID: id, Serial: "SERIAL123", Status: tt.currentStatus}, nil
+				},
+			}
+			useCase := usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+			handler := NewStockHandler(useCase)
+
+			r := chi.NewRouter()
+			r.Post("/{id}/transitions", handler.TransitionStock)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/"+tt.stockID+"/transitions", bytes.NewBuffer(body))
+			req = req.WithContext(ContextWithUser(req.Context(), tt.actor))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetAllStocksStatusFilter(t *testing.T) {
+	var gotFilter domain.StockFilter
+	mockRepo := &repository.MockStockRepository{
+		GetAllFunc: func(filter domain.StockFilter) ([]domain.Stock, error) {
+			gotFilter = filter
+			return nil, nil
+		},
+	}
+	handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+	req := httptest.NewRequest("GET", "/api/stocks?status=in_repair", nil)
+	w := httptest.NewRecorder()
+	handler.GetAllStocks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if gotFilter.Status != domain.StockInRepair {
+		t.Errorf("expected status filter %q, got %q", domain.StockInRepair, gotFilter.Status)
+	}
+
+	req = httptest.NewRequest("GET", "/api/stocks?status=lost", nil)
+	w = httptest.NewRecorder()
+	handler.GetAllStocks(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetStockHistory(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		route          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "history by ID",
+			path:           "/1/history",
+			route:          "/{id}/history",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "history by serial",
+			path:           "/serial/SERIAL123/history",
+			route:          "/serial/{serial}/history",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "unknown stock",
+			path:           "/999/history",
+			route:          "/{id}/history",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := []domain.StockMovement{
+				{ID: 1, StockID: 1, Serial: "SERIAL123", Type: domain.MovementCreate},
+				{ID: 2, StockID: 1, Serial: "SERIAL123", Type: domain.MovementTransition},
+			}
+			mockMovements := &repository.MockStockMovementRepository{
+				GetByStockIDFunc: func(id int64) ([]domain.StockMovement, error) {
+					if id == 1 {
+						return history, nil
+					}
+					return nil, nil
+				},
+				GetBySerialFunc: func(serial string) ([]domain.StockMovement, error) {
+					return history, nil
+				},
+			}
+			useCase := usecase.NewStockUseCase(&repository.MockStockRepository{}, mockMovements, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+			handler := NewStockHandler(useCase)
+
+			r := chi.NewRouter()
+			r.Get("/{id}/history", handler.GetStockHistory)
+			r.Get("/serial/{serial}/history", handler.GetStockHistoryBySerial)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var movements []domain.StockMovement
+				if err := json.NewDecoder(w.Body).Decode(&movements); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(movements) != tt.expectedCount {
+					t.Errorf("expected %d movements, got %d", tt.expectedCount, len(movements))
+				}
+			}
+		})
+	}
+}
+
+func TestGetStocksByLocationFilters(t *testing.T) {
+	tests := []struct {
+		name              string
+		path              string
+		expectedStatus    int
+		expectedWarehouse int64
+		expectedLocation  int64
+	}{
+		{
+			name:              "stocks in warehouse",
+			path:              "/warehouses/3/stocks",
+			expectedStatus:    http.StatusOK,
+			expectedWarehouse: 3,
+		},
+		{
+			name:             "stocks in location",
+			path:             "/locations/7/stocks",
+			expectedStatus:   http.StatusOK,
+			expectedLocation: 7,
+		},
+		{
+			name:              "query filters on stock listing",
+			path:              "/stocks?warehouse_id=3&location_id=7",
+			expectedStatus:    http.StatusOK,
+			expectedWarehouse: 3,
+			expectedLocation:  7,
+		},
+		{
+			name:           "invalid warehouse filter",
+			path:           "/stocks?warehouse_id=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotFilter domain.StockFilter
+			mockRepo := &repository.MockStockRepository{
+				GetAllFunc: func(filter domain.StockFilter) ([]domain.Stock, error) {
+					gotFilter = filter
+					return nil, nil
+				},
+			}
+			handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+			r := chi.NewRouter()
+			r.Get("/stocks", handler.GetAllStocks)
+			r.Get("/warehouses/{id}/stocks", handler.GetStocksByWarehouse)
+			r.Get("/locations/{id}/stocks", handler.GetStocksByLocation)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if gotFilter.WarehouseID != tt.expectedWarehouse {
+				t.Errorf("expected warehouse filter %d, got %d", tt.expectedWarehouse, gotFilter.WarehouseID)
+			}
+			if gotFilter.LocationID != tt.expectedLocation {
+				t.Errorf("expected location filter %d, got %d", tt.expectedLocation, gotFilter.LocationID)
+			}
+		})
+	}
+}
+
+func TestGetStockLocationsByProductID(t *testing.T) {
+	mockRepo := &repository.MockStockRepository{
+		CountByLocationFunc: func(productID int64) ([]domain.LocationStockCount, error) {
+			if productID != 1 {
+				t.Errorf("expected product 1, got %d", productID)
+			}
+			return []domain.LocationStockCount{
+				{Location: &domain.Location{ID: 7, Code: "A-01"}, Quantity: 4},
+				{Location: nil, Quantity: 2},
+			}, nil
+		},
+	}
+	productRepo := &repository.MockProductRepository{
+		GetByIDFunc: func(id int64) (*domain.Product, error) {
+			return &domain.Product{ID: id, TrackingMode: domain.TrackingSerialized}, nil
+		},
+	}
+	handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, productRepo, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+	r := chi.NewRouter()
+	r.Get("/product/{productId}/locations", handler.GetStockLocationsByProductID)
+
+	req := httptest.NewRequest("GET", "/product/1/locations", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var counts []domain.LocationStockCount
+	if err := json.NewDecoder(w.Body).Decode(&counts); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(counts) != 2 || counts[0].Quantity != 4 || counts[1].Location != nil {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+}
+
+func TestGetAllStocksPaging(t *testing.T) {
+	var gotFilter domain.StockFilter
+	mockRepo := &repository.MockStockRepository{
+		GetAllFunc: func(filter domain.StockFilter) ([]domain.Stock, error) {
+			gotFilter = filter
+			return []domain.Stock{{ID: 21}, {ID: 22}}, nil
+		},
+		CountFunc: func(filter domain.StockFilter) (int64, error) {
+			return 5, nil
+		},
+	}
+	handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedLink   string
+	}{
+		{
+			name:           "offset paging",
+			url:            "/api/stocks?limit=2&offset=2&sort=-purchase_date&provider_id=3&purchased_after=2024-01-01",
+			expectedStatus: http.StatusOK,
+			expectedLink: `</api/stocks?limit=2&offset=4&provider_id=3&purchased_after=2024-01-01&sort=-purchase_date>; rel="next", ` +
+				`</api/stocks?limit=2&offset=0&provider_id=3&purchased_after=2024-01-01&sort=-purchase_date>; rel="prev"`,
+		},
+		{
+			name:           "keyset paging",
+			url:            "/api/stocks?limit=2&after_id=20",
+			expectedStatus: http.StatusOK,
+			expectedLink:   `</api/stocks?after_id=22&limit=2>; rel="next"`,
+		},
+		{
+			name:           "unknown sort field",
+			url:            "/api/stocks?sort=price",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "keyset with another sort",
+			url:            "/api/stocks?after_id=20&sort=serial",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			url:            "/api/stocks?purchased_before=01/02/2024",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			handler.GetAllStocks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("X-Total-Count"); got != "5" {
+				t.Errorf("expected X-Total-Count 5, got %q", got)
+			}
+			if got := w.Header().Get("Link"); got != tt.expectedLink {
+				t.Errorf("expected Link %q, got %q", tt.expectedLink, got)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/api/stocks?limit=2&offset=2&sort=-purchase_date&provider_id=3&purchased_after=2024-01-01", nil)
+	handler.GetAllStocks(httptest.NewRecorder(), req)
+	if gotFilter.ProviderID != 3 || gotFilter.Limit != 2 || gotFilter.Offset != 2 {
+		t.Errorf("unexpected filter %+v", gotFilter)
+	}
+	if len(gotFilter.Sort) != 1 || gotFilter.Sort[0] != (domain.SortField{Field: "purchase_date", Desc: true}) {
+		t.Errorf("unexpected sort %+v", gotFilter.Sort)
+	}
+	if !gotFilter.PurchasedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected purchased_after %v", gotFilter.PurchasedAfter)
+	}
+}
+
+func TestGetAllStocksIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		user           *domain.User
+		expectedStatus int
+		expectDeleted  bool
+	}{
+		{name: "hidden by default", query: "", user: &domain.User{ID: 1, Role: domain.RoleAdmin}, expectedStatus: http.StatusOK},
+		{name: "admin includes deleted", query: "?include_deleted=true", user: &domain.User{ID: 1, Role: domain.RoleAdmin}, expectedStatus: http.StatusOK, expectDeleted: true},
+		{name: "forbidden to others", query: "?include_deleted=true", user: &domain.User{ID: 2, Role: domain.RoleWarehouse}, expectedStatus: http.StatusForbidden},
+		{name: "invalid value", query: "?include_deleted=maybe", user: &domain.User{ID: 1, Role: domain.RoleAdmin}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotFilter *domain.StockFilter
+			mockRepo := &repository.MockStockRepository{
+				GetAllFunc: func(filter domain.StockFilter) ([]domain.Stock, error) {
+					gotFilter = &filter
+					return nil, nil
+				},
+			}
+			handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+			req := httptest.NewRequest("GET", "/api/stocks"+tt.query, nil)
+			req = req.WithContext(ContextWithUser(req.Context(), tt.user))
+			w := httptest.NewRecorder()
+			handler.GetAllStocks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if gotFilter != nil {
+					t.Error("expected the units not to be listed")
+				}
+				return
+			}
+			if gotFilter == nil || gotFilter.IncludeDeleted != tt.expectDeleted {
+				t.Errorf("expected include deleted %v, got %+v", tt.expectDeleted, gotFilter)
+			}
+		})
+	}
+}
+
+func TestRestoreStock(t *testing.T) {
+	tests := []struct {
+		name           string
+		actor          *domain.User
+		mockRestore    func(int64) error
+		expectedStatus int
+	}{
+		{
+			name:           "successful restore",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			mockRestore:    func(id int64) error { return nil },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not deleted",
+			actor:          &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			mockRestore:    func(id int64) error { return &domain.StockNotFoundError{StockID: id} },
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing audit user",
+			mockRestore:    func(id int64) error { return nil },
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockStockRepository{
+				RestoreFunc: tt.mockRestore,
+				GetByIDFunc: func(id int64) (*domain.Stock, error) {
+					return &domain.Stock{ID: id, Serial: "SERIAL123", Product: &domain.Product{ID: 1}, Status: domain.StockAvailable}, nil
+				},
+			}
+			handler := NewStockHandler(usecase.NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false))
+
+			r := chi.NewRouter()
+			r.Post("/{id}/restore", handler.RestoreStock)
+
+			req := httptest.NewRequest("POST", "/1/restore?reason=mistake", nil)
+			if tt.actor != nil {
+				req = req.WithContext(ContextWithUser(req.Context(), tt.actor))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TransferHandler struct {
+	transferUseCase *usecase.TransferUseCase
+}
+
+func NewTransferHandler(useCase *usecase.TransferUseCase) *TransferHandler {
+	return &TransferHandler{
+		transferUseCase: useCase,
+	}
+}
+
+// CreateTransferRequest is the body of POST /api/transfers
+type CreateTransferRequest struct {
+	SourceLocationID      int64    `json:"source_location_id"`
+	DestinationLocationID int64    `json:"destination_location_id"`
+	Serials               []string `json:"serials"`
+	Notes                 string   `json:"notes"`
+}
+
+// writeTransferError maps transfer errors to responses
+func writeTransferError(w http.ResponseWriter, err error, fallback string) {
+	if writeAuditUserError(w, err) {
+		return
+	}
+	switch e := err.(type) {
+	case *domain.InvalidTransferError, *domain.InvalidTransferStatusError:
+		http.Error(w, e.Error(), http.StatusBadRequest)
+	case *domain.TransferNotFoundError:
+		http.Error(w, e.Error(), http.StatusNotFound)
+	case *domain.LocationNotFoundError:
+		http.Error(w, e.Error(), http.StatusUnprocessableEntity)
+	case *domain.StockNotFoundError:
+		if e.Serial != "" {
+			http.Error(w, "stock with serial "+e.Serial+" not found", http.StatusUnprocessableEntity)
+		} else {
+			http.Error(w, "stock with ID "+strconv.FormatInt(e.StockID, 10)+" not found", http.StatusUnprocessableEntity)
+		}
+	case *domain.InvalidTransferTransitionError, *domain.TransferConflictError:
+		http.Error(w, e.Error(), http.StatusConflict)
+	default:
+		writeFallbackError(w, err, fallback)
+	}
+}
+
+func (h *TransferHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.SourceLocationID == 0 || req.DestinationLocationID == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	transfer, err := h.transferUseCase.CreateTransfer(r.Context(), actor, req.SourceLocationID, req.DestinationLocationID, req.Serials, req.Notes)
+	if err != nil {
+		writeTransferError(w, err, "Error creating transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *TransferHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.transferUseCase.GetTransfer(r.Context(), id)
+	if err != nil {
+		writeTransferError(w, err, "Error fetching transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *TransferHandler) GetAllTransfers(w http.ResponseWriter, r *http.Request) {
+	filter := domain.TransferFilter{
+		Status: domain.TransferStatus(r.URL.Query().Get("status")),
+	}
+
+	transfers, err := h.transferUseCase.GetAllTransfers(r.Context(), filter)
+	if err != nil {
+		writeTransferError(w, err, "Error fetching transfers")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+func (h *TransferHandler) DispatchTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	transfer, err := h.transferUseCase.DispatchTransfer(r.Context(), actor, id)
+	if err != nil {
+		writeTransferError(w, err, "Error dispatching transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *TransferHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	transfer, err := h.transferUseCase.ReceiveTransfer(r.Context(), actor, id)
+	if err != nil {
+		writeTransferError(w, err, "Error receiving transfer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
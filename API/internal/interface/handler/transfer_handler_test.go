@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		withUser       bool
+		expectedStatus int
+	}{
+		{
+			name: "successful creation",
+			requestBody: map[string]interface{}{
+				"source_location_id":      1,
+				"destination_location_id": 2,
+				"serials":                 []string{"SERIAL1"},
+			},
+			withUser:       true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "unit not at source",
+			requestBody: map[string]interface{}{
+				"source_location_id":      2,
+				"destination_location_id": 1,
+				"serials":                 []string{"SERIAL1"},
+			},
+			withUser:       true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "unknown serial",
+			requestBody: map[string]interface{}{
+				"source_location_id":      1,
+				"destination_location_id": 2,
+				"serials":                 []string{"MISSING"},
+			},
+			withUser:       true,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "no serials",
+			requestBody: map[string]interface{}{
+				"source_location_id":      1,
+				"destination_location_id": 2,
+			},
+			withUser:       true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unauthenticated",
+			requestBody: map[string]interface{}{
+				"source_location_id":      1,
+				"destination_location_id": 2,
+				"serials":                 []string{"SERIAL1"},
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stockRepo := &repository.MockStockRepository{
+				GetBySerialFunc: func(serial string) (*domain.Stock, error) {
+					if serial == "SERIAL1" {
+						return &domain.Stock{ID: 1, Serial: serial, Location: &domain.Location{ID: 1}}, nil
+					}
+					return nil, nil
+				},
+			}
+			locationRepo := &repository.MockLocationRepository{
+				GetByIDFunc: func(id int64) (*domain.Location, error) {
+					return &domain.Location{ID: id}, nil
+				},
+			}
+			useCase := usecase.NewTransferUseCase(&repository.MockTransferRepository{}, stockRepo, locationRepo, repository.NewMemoryUnitOfWork())
+			handler := NewTransferHandler(useCase)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/transfers", bytes.NewBuffer(body))
+			if tt.withUser {
+				req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
+			}
+			w := httptest.NewRecorder()
+
+			handler.CreateTransfer(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestReceiveTransferConflict(t *testing.T) {
+	stockRepo := &repository.MockStockRepository{
+		GetByIDFunc: func(id int64) (*domain.Stock, error) {
+			return &domain.Stock{ID: id, Serial: "SERIAL1", Location: &domain.Location{ID: 1}}, nil
+		},
+	}
+	transferRepo := &repository.MockTransferRepository{
+		GetByIDFunc: func(id int64) (*domain.Transfer, error) {
+			return &domain.Transfer{
+				ID:          id,
+				Source:      &domain.Location{ID: 1},
+				Destination: &domain.Location{ID: 2},
+				Status:      domain.TransferInTransit,
+				Items:       []domain.TransferItem{{StockID: 1, Serial: "SERIAL1"}},
+			}, nil
+		},
+		ReceiveFunc: func(*domain.Transfer, *domain.User, []*domain.StockMovement) error {
+			// Another transfer moved the unit between the read and the transaction
+			return &domain.TransferConflictError{Serial: "SERIAL1"}
+		},
+	}
+	handler := NewTransferHandler(usecase.NewTransferUseCase(transferRepo, stockRepo, &repository.MockLocationRepository{}, repository.NewMemoryUnitOfWork()))
+
+	r := chi.NewRouter()
+	r.Post("/{id}/receive", handler.ReceiveTransfer)
+
+	req := httptest.NewRequest("POST", "/1/receive", nil)
+	req = req.WithContext(ContextWithUser(req.Context(), &domain.User{ID: 1, Role: domain.RoleWarehouse}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
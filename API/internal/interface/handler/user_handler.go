@@ -27,6 +27,11 @@ type CreateUserRequest struct {
 	Password string `json:"password" required:"true" min:"1"`
 }
 
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
 type UpdateUserRequest struct {
 	Name     string `json:"name" required:"true" min:"1"`
 	Email    string `json:"email" required:"true" min:"1"`
@@ -46,17 +51,20 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userUseCase.CreateUser(req.Name, req.Email, req.Role, req.Password)
+	user, err := h.userUseCase.CreateUser(r.Context(), req.Name, req.Email, req.Role, req.Password)
 	if err != nil {
 		switch err.(type) {
+		case *domain.InvalidRoleError, *domain.WeakPasswordError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		case *domain.UserAlreadyExistsError:
 			http.Error(w, err.Error(), http.StatusConflict)
 		default:
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeFallbackError(w, err, err.Error())
 		}
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(user)
@@ -70,28 +78,58 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userUseCase.GetUser(id)
+	user, err := h.userUseCase.GetUser(r.Context(), id)
 	if err != nil {
 		switch err.(type) {
 		case *domain.UserNotFoundError:
 			http.Error(w, err.Error(), http.StatusNotFound)
 		default:
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeFallbackError(w, err, err.Error())
 		}
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
 
+// GetAllUsers lists a page of the users matching the optional ?name=, ?email=
+// and ?role= filters
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userUseCase.GetAllUsers()
+	opts, err := listOptionsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	filter := domain.UserFilter{
+		Name:        query.Get("name"),
+		Email:       query.Get("email"),
+		Role:        query.Get("role"),
+		ListOptions: opts,
+	}
+	if filter.IncludeDeleted, err = includeDeletedFromQuery(r); err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	users, total, err := h.userUseCase.GetAllUsers(r.Context(), filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch err.(type) {
+		case *domain.InvalidListOptionsError, *domain.InvalidRoleError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, err.Error())
+		}
 		return
 	}
 
+	var lastID int64
+	if len(users) > 0 {
+		lastID = users[len(users)-1].ID
+	}
+	writePageHeaders(w, r, opts, total, len(users), lastID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
 }
@@ -115,27 +153,92 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
 	user := &domain.User{
 		ID:       id,
+		Version:  version,
 		Name:     req.Name,
 		Email:    req.Email,
 		Role:     req.Role,
 		Password: req.Password,
 	}
 
-	if err := h.userUseCase.UpdateUser(user); err != nil {
+	if err := h.userUseCase.UpdateUser(r.Context(), user); err != nil {
 		switch err.(type) {
+		case *domain.InvalidRoleError, *domain.WeakPasswordError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		case *domain.UserNotFoundError:
 			http.Error(w, err.Error(), http.StatusNotFound)
+		case *domain.UserAlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
 		default:
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeFallbackError(w, err, err.Error())
 		}
 		return
 	}
 
+	setETag(w, user.Version)
 	w.WriteHeader(http.StatusOK)
 }
 
+// userDocument is the part of a user that merge patches apply to. The
+// password is never part of it, but a patch may set a new one.
+type userDocument struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"`
+}
+
+// PatchUser applies a merge patch to a user and saves the fields it changed
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userUseCase.PatchUser(r.Context(), id, version, func(user *domain.User) error {
+		doc, err := applyMergePatch(userDocument{user.Name, user.Email, user.Role, user.Password}, patch)
+		if err != nil {
+			return err
+		}
+		user.Name, user.Email, user.Role, user.Password = doc.Name, doc.Email, doc.Role, doc.Password
+		return nil
+	})
+	if err != nil {
+		switch err.(type) {
+		case *patchError, *domain.InvalidRoleError, *domain.WeakPasswordError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.UserNotFoundError:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case *domain.UserAlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, err.Error())
+		}
+		return
+	}
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -143,13 +246,106 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
+	to, err := reassignTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+	if to != 0 {
+		err = h.userUseCase.ReassignAndDeleteUser(r.Context(), actor, id, version, to)
+	} else {
+		err = h.userUseCase.DeleteUser(r.Context(), actor, id, version)
+	}
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.UserNotFoundError:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case *domain.EntityInUseError:
+			writeInUseError(w, e)
+		case *domain.InvalidReassignmentError:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			writeFallbackError(w, err, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreUser brings back a soft-deleted user, who can log in again
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userUseCase.RestoreUser(r.Context(), id)
+	if err != nil {
+		switch err.(type) {
+		case *domain.UserNotFoundError:
+			http.Error(w, "deleted user not found", http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error restoring user")
+		}
+		return
+	}
+
+	setETag(w, user.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword lets the authenticated user replace their own password
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if actor.ID != id {
+		http.Error(w, "Users may only change their own password", http.StatusForbidden)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.OldPassword == "" || req.NewPassword == "" {
+		http.Error(w, "Old and new password are required fields", http.StatusBadRequest)
+		return
+	}
 
-	if err := h.userUseCase.DeleteUser(id); err != nil {
+	if err := h.userUseCase.ChangePassword(r.Context(), id, req.OldPassword, req.NewPassword); err != nil {
 		switch err.(type) {
+		case *domain.WeakPasswordError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.IncorrectPasswordError:
+			http.Error(w, err.Error(), http.StatusForbidden)
 		case *domain.UserNotFoundError:
 			http.Error(w, err.Error(), http.StatusNotFound)
 		default:
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeFallbackError(w, err, err.Error())
 		}
 		return
 	}
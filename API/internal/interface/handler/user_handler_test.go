@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
+	"inventario/internal/infrastructure/security"
 	"inventario/internal/usecase"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,10 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+func newTestUserUseCase(repo domain.IUserRepository) *usecase.UserUseCase {
+	return usecase.NewUserUseCase(repo, security.NewPBKDF2Hasher(1000), domain.DefaultPasswordPolicy(), &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+}
+
 func TestCreateUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -42,7 +47,7 @@ func TestCreateUser(t *testing.T) {
 			requestBody: map[string]string{
 				"name":     "Existing User",
 				"email":    "existing@example.com",
-				"role":     "user",
+				"role":     "viewer",
 				"password": "password123",
 			},
 			mockCreate: func(u *domain.User) error {
@@ -68,7 +73,7 @@ func TestCreateUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := usecase.NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 			handler := NewUserHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -121,6 +126,15 @@ func TestGetUser(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "user with ID 999 not found",
 		},
+		{
+			name:   "no user with the ID",
+			userID: "999",
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, nil
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "user with ID 999 not found",
+		},
 		{
 			name:           "invalid user ID",
 			userID:         "invalid",
@@ -135,7 +149,7 @@ func TestGetUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := usecase.NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 			handler := NewUserHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/users/"+tt.userID, nil)
@@ -177,7 +191,7 @@ func TestGetAllUsers(t *testing.T) {
 						ID:        2,
 						Name:      "User 2",
 						Email:     "user2@example.com",
-						Role:      "user",
+						Role:      "viewer",
 						CreatedAt: time.Now(),
 						UpdatedAt: time.Now(),
 					},
@@ -201,7 +215,7 @@ func TestGetAllUsers(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := usecase.NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 			handler := NewUserHandler(useCase)
 
 			req := httptest.NewRequest("GET", "/api/users", nil)
@@ -262,7 +276,7 @@ func TestUpdateUser(t *testing.T) {
 			requestBody: map[string]string{
 				"name":  "Non-existent User",
 				"email": "nonexistent@example.com",
-				"role":  "user",
+				"role":  "viewer",
 			},
 			mockUpdate: func(u *domain.User) error {
 				return &domain.UserNotFoundError{UserID: 999}
@@ -299,7 +313,7 @@ func TestUpdateUser(t *testing.T) {
 				UpdateFunc:  tt.mockUpdate,
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := usecase.NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 			handler := NewUserHandler(useCase)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -322,14 +336,14 @@ func TestDeleteUser(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
-		mockDelete     func(int64) error
+		mockDelete     func(int64, int64) error
 		expectedStatus int
 		expectedError  string
 	}{
 		{
 			name:   "successful deletion",
 			userID: "1",
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedStatus: http.StatusNoContent,
@@ -338,7 +352,7 @@ func TestDeleteUser(t *testing.T) {
 		{
 			name:   "user not found",
 			userID: "999",
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return &domain.UserNotFoundError{UserID: 999}
 			},
 			expectedStatus: http.StatusNotFound,
@@ -358,7 +372,7 @@ func TestDeleteUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				DeleteFunc: tt.mockDelete,
 			}
-			useCase := usecase.NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 			handler := NewUserHandler(useCase)
 
 			req := httptest.NewRequest("DELETE", "/api/users/"+tt.userID, nil)
@@ -376,3 +390,69 @@ func TestDeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestChangePassword(t *testing.T) {
+	currentHash, _ := security.NewPBKDF2Hasher(1000).Hash("oldpassword")
+
+	tests := []struct {
+		name           string
+		userID         string
+		actor          *domain.User
+		requestBody    map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "successful change",
+			userID:         "1",
+			actor:          &domain.User{ID: 1, Role: "viewer"},
+			requestBody:    map[string]string{"old_password": "oldpassword", "new_password": "newpassword"},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "wrong current password",
+			userID:         "1",
+			actor:          &domain.User{ID: 1, Role: "viewer"},
+			requestBody:    map[string]string{"old_password": "wrongpassword", "new_password": "newpassword"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "weak new password",
+			userID:         "1",
+			actor:          &domain.User{ID: 1, Role: "viewer"},
+			requestBody:    map[string]string{"old_password": "oldpassword", "new_password": "short"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "changing another user's password",
+			userID:         "2",
+			actor:          &domain.User{ID: 1, Role: "admin"},
+			requestBody:    map[string]string{"old_password": "oldpassword", "new_password": "newpassword"},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockUserRepository{
+				GetByIDFunc: func(id int64) (*domain.User, error) {
+					return &domain.User{ID: id, Role: "viewer", Password: currentHash}, nil
+				},
+			}
+			handler := NewUserHandler(newTestUserUseCase(mockRepo))
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/users/"+tt.userID+"/password", bytes.NewBuffer(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(ContextWithUser(ctx, tt.actor))
+			w := httptest.NewRecorder()
+
+			handler.ChangePassword(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
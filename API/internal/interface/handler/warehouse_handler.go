@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WarehouseHandler struct {
+	warehouseUseCase *usecase.WarehouseUseCase
+}
+
+func NewWarehouseHandler(useCase *usecase.WarehouseUseCase) *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseUseCase: useCase,
+	}
+}
+
+type warehouseRequest struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req warehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Code == "" || req.Name == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.warehouseUseCase.CreateWarehouse(r.Context(), req.Code, req.Name, req.Address)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.WarehouseAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error creating warehouse")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+func (h *WarehouseHandler) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.warehouseUseCase.GetWarehouse(r.Context(), id)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.WarehouseNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error fetching warehouse")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+func (h *WarehouseHandler) GetAllWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := h.warehouseUseCase.GetAllWarehouses(r.Context())
+	if err != nil {
+		writeFallbackError(w, err, "Error fetching warehouses")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warehouses)
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	var req warehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Code == "" || req.Name == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse := &domain.Warehouse{
+		ID:      id,
+		Code:    req.Code,
+		Name:    req.Name,
+		Address: req.Address,
+	}
+	if err := h.warehouseUseCase.UpdateWarehouse(r.Context(), warehouse); err != nil {
+		switch e := err.(type) {
+		case *domain.WarehouseNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.WarehouseAlreadyExistsError:
+			http.Error(w, e.Error(), http.StatusConflict)
+		default:
+			writeFallbackError(w, err, "Error updating warehouse")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+func (h *WarehouseHandler) DeleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.warehouseUseCase.DeleteWarehouse(r.Context(), id); err != nil {
+		switch e := err.(type) {
+		case *domain.WarehouseNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error deleting warehouse")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WarehouseHandler) GetWarehouseLocations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	locations, err := h.warehouseUseCase.GetWarehouseLocations(r.Context(), id)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.WarehouseNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		default:
+			writeFallbackError(w, err, "Error fetching locations")
+		}
+		return
+	}
+
+	if locations == nil {
+		locations = []domain.Location{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateWarehouse(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]string
+		mockCreate     func(*domain.Warehouse) error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "successful creation",
+			requestBody: map[string]string{
+				"code":    "MAIN",
+				"name":    "Main warehouse",
+				"address": "Test Address",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "duplicate code",
+			requestBody: map[string]string{
+				"code": "MAIN",
+				"name": "Main warehouse",
+			},
+			mockCreate: func(w *domain.Warehouse) error {
+				return &domain.WarehouseAlreadyExistsError{Code: w.Code}
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "warehouse with code MAIN already exists",
+		},
+		{
+			name: "missing code",
+			requestBody: map[string]string{
+				"name": "Main warehouse",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockWarehouseRepository{
+				CreateFunc: tt.mockCreate,
+			}
+			handler := NewWarehouseHandler(usecase.NewWarehouseUseCase(mockRepo, &repository.MockLocationRepository{}))
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/warehouses", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			handler.CreateWarehouse(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedError != "" && strings.TrimSpace(w.Body.String()) != tt.expectedError {
+				t.Errorf("expected error %q, got %q", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetWarehouseLocations(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "existing warehouse",
+			path:           "/1/locations",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "unknown warehouse",
+			path:           "/999/locations",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid ID",
+			path:           "/abc/locations",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warehouseRepo := &repository.MockWarehouseRepository{
+				GetByIDFunc: func(id int64) (*domain.Warehouse, error) {
+					if id == 1 {
+						return &domain.Warehouse{ID: 1, Code: "MAIN"}, nil
+					}
+					return nil, nil
+				},
+			}
+			locationRepo := &repository.MockLocationRepository{
+				GetByWarehouseIDFunc: func(id int64) ([]domain.Location, error) {
+					return []domain.Location{{ID: 1, Code: "A-01"}, {ID: 2, Code: "A-02"}}, nil
+				},
+			}
+			handler := NewWarehouseHandler(usecase.NewWarehouseUseCase(warehouseRepo, locationRepo))
+
+			r := chi.NewRouter()
+			r.Get("/{id}/locations", handler.GetWarehouseLocations)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var locations []domain.Location
+				if err := json.NewDecoder(w.Body).Decode(&locations); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(locations) != tt.expectedCount {
+					t.Errorf("expected %d locations, got %d", tt.expectedCount, len(locations))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/xlsx"
+	"inventario/internal/usecase"
+	"net/http"
+)
+
+// xlsxContentType is the media type of Excel workbooks
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// XLSXHandler exports units as Excel workbooks
+type XLSXHandler struct {
+	productUseCase *usecase.ProductUseCase
+	stockUseCase   *usecase.StockUseCase
+}
+
+func NewXLSXHandler(productUseCase *usecase.ProductUseCase, stockUseCase *usecase.StockUseCase) *XLSXHandler {
+	return &XLSXHandler{productUseCase: productUseCase, stockUseCase: stockUseCase}
+}
+
+// stockSheetColumns are the columns of the sheet of each product, named as
+// in the CSV export
+var stockSheetColumns = []xlsx.Column{
+	{Header: "id", Width: 8},
+	{Header: "serial", Width: 20},
+	{Header: "status", Width: 12},
+	{Header: "batch", Width: 14},
+	{Header: "purchase_date", Width: 14},
+	{Header: "provider_name", Width: 24},
+	{Header: "location_code", Width: 14},
+	{Header: "warehouse_code", Width: 14},
+	{Header: "version", Width: 8},
+	{Header: "created_at", Width: 20},
+	{Header: "updated_at", Width: 20},
+	{Header: "deleted_at", Width: 20},
+}
+
+// ExportStocks writes the units matching the filters of the stock listing as
+// an Excel workbook with a sheet per product, named by its code, in the
+// order of the product IDs. Products without matching units get no sheet.
+// Units are read once, by product then in the order asked for, MaxPageLimit
+// at a time and written as they are read, as in the CSV export.
+func (h *XLSXHandler) ExportStocks(w http.ResponseWriter, r *http.Request) {
+	filter, err := stockFilterFromQuery(r)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	// GetProduct leaves out deleted products, whose units only
+	// ?include_deleted= lists
+	if filter.ProductID != 0 && !filter.IncludeDeleted {
+		if _, err := h.productUseCase.GetProduct(r.Context(), filter.ProductID); err != nil {
+			switch err.(type) {
+			case *domain.ProductNotFoundError:
+				http.Error(w, err.Error(), http.StatusNotFound)
+			default:
+				writeFallbackError(w, err, "Error exporting stocks")
+			}
+			return
+		}
+	}
+
+	var workbook *xlsx.Writer
+	var sheet *xlsx.Sheet
+	var productID int64
+	start := func() (err error) {
+		if workbook == nil {
+			w.Header().Set("Content-Type", xlsxContentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="stocks.xlsx"`)
+			w.WriteHeader(http.StatusOK)
+			workbook, err = xlsx.NewWriter(w)
+		}
+		return err
+	}
+	opts := filter.ListOptions
+	opts.Sort = append([]domain.SortField{{Field: "product_id"}}, opts.Sort...)
+	err = eachPage(opts,
+		func(opts domain.ListOptions) ([]*domain.Stock, error) {
+			filter.ListOptions = opts
+			stocks, _, err := h.stockUseCase.GetAllStocks(r.Context(), filter)
+			return stocks, err
+		},
+		func(s *domain.Stock) int64 { return s.ID },
+		func(page []*domain.Stock) error {
+			if err := start(); err != nil {
+				return err
+			}
+			for _, s := range page {
+				if sheet == nil || s.Product.ID != productID {
+					var err error
+					if sheet, err = workbook.NewSheet(s.Product.Code, stockSheetColumns); err != nil {
+						return err
+					}
+					productID = s.Product.ID
+				}
+				if err := sheet.WriteRow(stockSheetRow(s)...); err != nil {
+					return err
+				}
+			}
+			if sheet == nil {
+				return nil
+			}
+			return sheet.Flush()
+		})
+	if err == nil {
+		if err = start(); err == nil {
+			err = workbook.Close()
+		}
+	}
+	if err != nil {
+		if workbook != nil {
+			panic(http.ErrAbortHandler)
+		}
+		switch err.(type) {
+		case *domain.InvalidStockStatusError, *domain.InvalidListOptionsError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeFallbackError(w, err, "Error exporting stocks")
+		}
+	}
+}
+
+// stockSheetRow lists the cells of a unit in the order of stockSheetColumns
+func stockSheetRow(s *domain.Stock) []xlsx.Cell {
+	var providerName, locationCode, warehouseCode string
+	if s.Provider != nil {
+		providerName = s.Provider.Name
+	}
+	if s.Location != nil {
+		locationCode = s.Location.Code
+		if s.Location.Warehouse != nil {
+			warehouseCode = s.Location.Warehouse.Code
+		}
+	}
+	var deletedAt xlsx.Cell
+	if s.DeletedAt != nil {
+		deletedAt = xlsx.DateTime(*s.DeletedAt)
+	}
+	return []xlsx.Cell{
+		xlsx.Int(s.ID), xlsx.String(s.Serial), xlsx.String(string(s.Status)), xlsx.String(s.Batch),
+		xlsx.Date(s.PurchaseDate), xlsx.String(providerName), xlsx.String(locationCode), xlsx.String(warehouseCode),
+		xlsx.Int(s.Version), xlsx.DateTime(s.CreatedAt), xlsx.DateTime(s.UpdatedAt), deletedAt,
+	}
+}
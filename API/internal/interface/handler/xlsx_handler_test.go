@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/storage"
+	"inventario/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportStocksXLSX(t *testing.T) {
+	s, err := storage.Open(storage.Config{Driver: storage.DriverSQLiteMemory})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	defer s.Close()
+	productUseCase := usecase.NewProductUseCase(s.Products, s.Stocks, s.UnitOfWork)
+	stockUseCase := usecase.NewStockUseCase(s.Stocks, s.StockMovements, s.Products, s.StockBalances, s.UnitOfWork, false)
+	handler := NewXLSXHandler(productUseCase, stockUseCase)
+
+	ctx := context.Background()
+	actor := &domain.User{Name: "ana", Email: "ana@example.com", Password: "secret-hash", Role: domain.RoleWarehouse}
+	provider := &domain.Provider{Name: "acme", Email: "sales@acme.example"}
+	if err := s.Users.Create(ctx, actor); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := s.Providers.Create(ctx, provider); err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	for _, code := range []string{"MON", "LPT", "KBD"} {
+		if _, err := productUseCase.CreateProduct(ctx, code, code, "", domain.TrackingSerialized); err != nil {
+			t.Fatalf("failed to create product: %v", err)
+		}
+	}
+	purchased := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, unit := range []struct {
+		productID int64
+		serial    string
+		batch     string
+	}{{1, "MON-1", "B1"}, {2, "LPT-1", "B1"}, {1, "MON-2", "B2"}, {2, "LPT-2", "B1"}} {
+		if _, err := stockUseCase.CreateStock(ctx, actor, unit.productID, unit.serial, unit.batch, purchased, provider.ID, 0, 0); err != nil {
+			t.Fatalf("failed to create stock: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/stocks/export.xlsx?batch=B1&sort=-serial", nil)
+	w := httptest.NewRecorder()
+	handler.ExportStocks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != xlsxContentType {
+		t.Errorf("expected a workbook, got %q", w.Header().Get("Content-Type"))
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to open the workbook: %v", err)
+	}
+	read := func(name string, v interface{}) {
+		t.Helper()
+		part, err := archive.Open(name)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", name, err)
+		}
+		defer part.Close()
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if err := xml.Unmarshal(content, v); err != nil {
+			t.Fatalf("failed to decode %s: %v", name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	read("xl/workbook.xml", &workbook)
+	var names []string
+	for _, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"MON", "LPT"}) {
+		t.Fatalf("expected a sheet for each product with units in batch B1, got %v", names)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	read("xl/worksheets/sheet2.xml", &sheet)
+	var serials []string
+	for _, row := range sheet.Rows[1:] {
+		serials = append(serials, row.Cells[1].Inline)
+	}
+	if !reflect.DeepEqual(serials, []string{"LPT-2", "LPT-1"}) {
+		t.Errorf("expected the LPT units sorted by serial, got %v", serials)
+	}
+	if len(sheet.Rows) > 1 && sheet.Rows[1].Cells[4].Value != "45352" {
+		t.Errorf("expected the purchase date as a date serial, got %+v", sheet.Rows[1].Cells[4])
+	}
+
+	req = httptest.NewRequest("GET", "/api/stocks/export.xlsx?sort=product", nil)
+	w = httptest.NewRecorder()
+	handler.ExportStocks(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "cannot sort by product") {
+		t.Errorf("expected status %d for an unknown sort, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/stocks/export.xlsx?product_id=99", nil)
+	w = httptest.NewRecorder()
+	handler.ExportStocks(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown product, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+}
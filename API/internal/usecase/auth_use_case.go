@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"time"
+)
+
+type AuthUseCase struct {
+	userRepo        domain.IUserRepository
+	passwordHasher  domain.IPasswordHasher
+	tokenService    domain.ITokenService
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func NewAuthUseCase(userRepo domain.IUserRepository, passwordHasher domain.IPasswordHasher, tokenService domain.ITokenService, accessTokenTTL, refreshTokenTTL time.Duration) *AuthUseCase {
+	return &AuthUseCase{
+		userRepo:        userRepo,
+		passwordHasher:  passwordHasher,
+		tokenService:    tokenService,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// Login checks the given credentials and issues a new pair of tokens. Stored
+// passwords in a legacy or outdated format are rehashed on success.
+func (u *AuthUseCase) Login(ctx context.Context, email, password string) (*domain.AuthTokens, error) {
+	user, err := u.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &domain.InvalidCredentialsError{}
+	}
+
+	match, needsRehash, err := u.passwordHasher.Verify(user.Password, password)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, &domain.InvalidCredentialsError{}
+	}
+
+	if needsRehash {
+		passwordHash, err := u.passwordHasher.Hash(password)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = passwordHash
+		if err := u.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return u.issueTokens(user)
+}
+
+// Refresh exchanges a valid refresh token for a new pair of tokens
+func (u *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (*domain.AuthTokens, error) {
+	user, err := u.userFromToken(ctx, refreshToken, domain.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return u.issueTokens(user)
+}
+
+// Authenticate resolves the user behind a valid access token
+func (u *AuthUseCase) Authenticate(ctx context.Context, accessToken string) (*domain.User, error) {
+	return u.userFromToken(ctx, accessToken, domain.AccessToken)
+}
+
+func (u *AuthUseCase) userFromToken(ctx context.Context, token string, tokenType domain.TokenType) (*domain.User, error) {
+	claims, err := u.tokenService.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != tokenType {
+		return nil, &domain.InvalidTokenError{Reason: "unexpected token type"}
+	}
+
+	user, err := u.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &domain.InvalidTokenError{Reason: "unknown user"}
+	}
+	return user, nil
+}
+
+func (u *AuthUseCase) issueTokens(user *domain.User) (*domain.AuthTokens, error) {
+	accessToken, err := u.tokenService.Generate(user.ID, domain.AccessToken, u.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := u.tokenService.Generate(user.ID, domain.RefreshToken, u.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(u.accessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"inventario/internal/infrastructure/security"
+	"testing"
+	"time"
+)
+
+func newTestTokenService(t *testing.T) *security.HMACTokenService {
+	t.Helper()
+	tokenService, err := security.NewHMACTokenService([]byte("test-secret-test-secret-test-secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return tokenService
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		email          string
+		password       string
+		mockGetByEmail func(string) (*domain.User, error)
+		expectedError  error
+	}{
+		{
+			name:     "successful login",
+			email:    "test@example.com",
+			password: "password123",
+			mockGetByEmail: func(email string) (*domain.User, error) {
+				return &domain.User{ID: 1, Email: email, Role: "admin", Password: "password123"}, nil
+			},
+			expectedError: nil,
+		},
+		{
+			name:     "wrong password",
+			email:    "test@example.com",
+			password: "wrong",
+			mockGetByEmail: func(email string) (*domain.User, error) {
+				return &domain.User{ID: 1, Email: email, Role: "admin", Password: "password123"}, nil
+			},
+			expectedError: &domain.InvalidCredentialsError{},
+		},
+		{
+			name:     "unknown email",
+			email:    "nobody@example.com",
+			password: "password123",
+			mockGetByEmail: func(email string) (*domain.User, error) {
+				return nil, nil
+			},
+			expectedError: &domain.InvalidCredentialsError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockUserRepository{
+				GetByEmailFunc: tt.mockGetByEmail,
+			}
+			useCase := NewAuthUseCase(mockRepo, testPasswordHasher, newTestTokenService(t), time.Minute, time.Hour)
+
+			tokens, err := useCase.Login(context.Background(), tt.email, tt.password)
+			if err != nil {
+				if tt.expectedError == nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if tt.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tt.expectedError)
+			}
+
+			if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+				t.Errorf("expected both tokens to be issued")
+			}
+			if tokens.ExpiresIn != 60 {
+				t.Errorf("expected expires_in 60, got %d", tokens.ExpiresIn)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	accessToken, _ := tokenService.Generate(1, domain.AccessToken, time.Minute)
+	refreshToken, _ := tokenService.Generate(1, domain.RefreshToken, time.Minute)
+	expiredToken, _ := tokenService.Generate(1, domain.AccessToken, -time.Minute)
+
+	tests := []struct {
+		name          string
+		token         string
+		mockGetByID   func(int64) (*domain.User, error)
+		expectedError bool
+	}{
+		{
+			name:  "valid access token",
+			token: accessToken,
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return &domain.User{ID: id, Role: "admin"}, nil
+			},
+			expectedError: false,
+		},
+		{
+			name:          "refresh token used as access token",
+			token:         refreshToken,
+			expectedError: true,
+		},
+		{
+			name:          "expired token",
+			token:         expiredToken,
+			expectedError: true,
+		},
+		{
+			name:          "tampered token",
+			token:         accessToken + "x",
+			expectedError: true,
+		},
+		{
+			name:  "deleted user",
+			token: accessToken,
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, nil
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &repository.MockUserRepository{
+				GetByIDFunc: tt.mockGetByID,
+			}
+			useCase := NewAuthUseCase(mockRepo, testPasswordHasher, tokenService, time.Minute, time.Hour)
+
+			user, err := useCase.Authenticate(context.Background(), tt.token)
+			if tt.expectedError {
+				if _, ok := err.(*domain.InvalidTokenError); !ok {
+					t.Errorf("expected InvalidTokenError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.ID != 1 {
+				t.Errorf("expected user ID 1, got %d", user.ID)
+			}
+		})
+	}
+}
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	var updated *domain.User
+	mockRepo := &repository.MockUserRepository{
+		GetByEmailFunc: func(email string) (*domain.User, error) {
+			return &domain.User{ID: 1, Email: email, Role: "admin", Password: "password123"}, nil
+		},
+		UpdateFunc: func(u *domain.User) error {
+			updated = u
+			return nil
+		},
+	}
+	useCase := NewAuthUseCase(mockRepo, testPasswordHasher, newTestTokenService(t), time.Minute, time.Hour)
+
+	if _, err := useCase.Login(context.Background(), "test@example.com", "password123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if updated == nil {
+		t.Fatalf("expected legacy plaintext password to be rehashed")
+	}
+	match, needsRehash, err := testPasswordHasher.Verify(updated.Password, "password123")
+	if err != nil || !match || needsRehash {
+		t.Errorf("expected current hash format, got match=%v needsRehash=%v err=%v", match, needsRehash, err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	tokenService := newTestTokenService(t)
+	accessToken, _ := tokenService.Generate(1, domain.AccessToken, time.Minute)
+	refreshToken, _ := tokenService.Generate(1, domain.RefreshToken, time.Minute)
+
+	mockRepo := &repository.MockUserRepository{
+		GetByIDFunc: func(id int64) (*domain.User, error) {
+			return &domain.User{ID: id, Role: "admin"}, nil
+		},
+	}
+	useCase := NewAuthUseCase(mockRepo, testPasswordHasher, tokenService, time.Minute, time.Hour)
+
+	if _, err := useCase.Refresh(context.Background(), refreshToken); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := useCase.Refresh(context.Background(), accessToken); err == nil {
+		t.Errorf("expected access token to be rejected as refresh token")
+	}
+}
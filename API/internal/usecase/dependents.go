@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+// deleteUnreferenced soft deletes the entity with the given ID on behalf of
+// actor unless other records still refer to it, in which case it returns an
+// EntityInUseError listing them. It is meant to run in a unit of work, in
+// which dependents locks the entity so that no reference is added between
+// the check and the deletion.
+func deleteUnreferenced(ctx context.Context, entity string, id int64, actor *domain.User,
+	dependents func(context.Context, int64) ([]domain.Dependent, error),
+	remove func(context.Context, int64, int64) error,
+) error {
+	found, err := dependents(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(found) > 0 {
+		return &domain.EntityInUseError{Entity: entity, ID: id, Dependents: found}
+	}
+	return remove(ctx, id, actorID(actor))
+}
+
+// actorID is the ID recorded as the author of a change, zero when unknown
+func actorID(actor *domain.User) int64 {
+	if actor == nil {
+		return 0
+	}
+	return actor.ID
+}
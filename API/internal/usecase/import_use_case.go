@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"inventario/internal/domain"
+)
+
+// ImportRecord is a record read from an import file: the line it starts on
+// and the call creating it through the other use cases, or the reason it
+// could not be read
+type ImportRecord struct {
+	Line   int
+	Create func(ctx context.Context) error
+	Err    error
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// ImportUseCase runs imports made of one creation per record
+type ImportUseCase struct {
+	uow domain.UnitOfWork
+}
+
+func NewImportUseCase(uow domain.UnitOfWork) *ImportUseCase {
+	return &ImportUseCase{uow: uow}
+}
+
+// Import creates the records next returns, one after another, until it
+// returns nil. A record that fails is reported and the import goes on; the
+// others are committed one by one. A dry run creates them all in one
+// transaction that is rolled back, so it reports the errors an import would
+// without keeping anything; each record runs in a nested unit of work there,
+// so that the writes of one failing partway are undone before the next. A
+// dry run fails with a DryRunUnsupportedError when the unit of work cannot
+// roll back, as with the in-memory repositories. An error of next ends the
+// import.
+func (u *ImportUseCase) Import(ctx context.Context, dryRun bool, next func() (*ImportRecord, error)) (*domain.ImportReport, error) {
+	if dryRun && !u.uow.CanRollBack() {
+		return nil, &domain.DryRunUnsupportedError{}
+	}
+	report := &domain.ImportReport{DryRun: dryRun, Errors: []domain.ImportRowError{}}
+	run := func(ctx context.Context) error {
+		for {
+			record, err := next()
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				return nil
+			}
+			report.Rows++
+			if record.Err == nil && dryRun {
+				record.Err = u.uow.Do(ctx, record.Create)
+			} else if record.Err == nil {
+				record.Err = record.Create(ctx)
+			}
+			// A cancelled request fails every record from then on
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if record.Err != nil {
+				report.AddError(record.Line, record.Err)
+				continue
+			}
+			report.Created++
+		}
+	}
+
+	if !dryRun {
+		if err := run(ctx); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	err := u.uow.Do(ctx, func(ctx context.Context) error {
+		if err := run(ctx); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+	return report, nil
+}
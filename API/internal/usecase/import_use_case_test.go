@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"inventario/internal/domain"
+	"reflect"
+	"testing"
+)
+
+// recordingUnitOfWork remembers what the last unit of work returned, which
+// rolls its transaction back when not nil
+type recordingUnitOfWork struct {
+	calls int
+	err   error
+}
+
+func (u *recordingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.calls++
+	u.err = fn(ctx)
+	return u.err
+}
+
+func (u *recordingUnitOfWork) CanRollBack() bool {
+	return true
+}
+
+func TestImport(t *testing.T) {
+	errTaken := errors.New("code taken")
+	records := func() func() (*ImportRecord, error) {
+		queue := []*ImportRecord{
+			{Line: 2, Create: func(ctx context.Context) error { return nil }},
+			{Line: 3, Err: errors.New("bad quote")},
+			{Line: 4, Create: func(ctx context.Context) error { return errTaken }},
+			{Line: 5, Create: func(ctx context.Context) error { return nil }},
+		}
+		return func() (*ImportRecord, error) {
+			if len(queue) == 0 {
+				return nil, nil
+			}
+			record := queue[0]
+			queue = queue[1:]
+			return record, nil
+		}
+	}
+	expectedErrors := []domain.ImportRowError{{Line: 3, Error: "bad quote"}, {Line: 4, Error: "code taken"}}
+
+	for _, dryRun := range []bool{false, true} {
+		uow := &recordingUnitOfWork{}
+		report, err := NewImportUseCase(uow).Import(context.Background(), dryRun, records())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.DryRun != dryRun || report.Rows != 4 || report.Created != 2 || report.Failed != 2 {
+			t.Errorf("expected 2 of 4 rows created, got %+v", report)
+		}
+		if !reflect.DeepEqual(report.Errors, expectedErrors) {
+			t.Errorf("expected errors %v, got %v", expectedErrors, report.Errors)
+		}
+		switch {
+		case dryRun && (uow.calls != 4 || uow.err == nil):
+			// One unit of work per record read, in the one rolled back
+			t.Errorf("expected a dry run to roll its transaction back, got %d calls returning %v", uow.calls, uow.err)
+		case !dryRun && uow.calls != 0:
+			t.Errorf("expected every record to be committed on its own, got %d units of work", uow.calls)
+		}
+	}
+
+	errRead := errors.New("connection reset")
+	_, err := NewImportUseCase(&recordingUnitOfWork{}).Import(context.Background(), false, func() (*ImportRecord, error) {
+		return nil, errRead
+	})
+	if err != errRead {
+		t.Errorf("expected the read error to end the import, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type LocationUseCase struct {
+	locationRepo  domain.ILocationRepository
+	warehouseRepo domain.IWarehouseRepository
+}
+
+func NewLocationUseCase(locationRepo domain.ILocationRepository, warehouseRepo domain.IWarehouseRepository) *LocationUseCase {
+	return &LocationUseCase{
+		locationRepo:  locationRepo,
+		warehouseRepo: warehouseRepo,
+	}
+}
+
+// resolveWarehouse loads the warehouse a location belongs to
+func (u *LocationUseCase) resolveWarehouse(ctx context.Context, warehouseID int64) (*domain.Warehouse, error) {
+	warehouse, err := u.warehouseRepo.GetByID(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if warehouse == nil {
+		return nil, &domain.WarehouseNotFoundError{WarehouseID: warehouseID}
+	}
+	return warehouse, nil
+}
+
+func (u *LocationUseCase) CreateLocation(ctx context.Context, warehouseID int64, code, description string) (*domain.Location, error) {
+	warehouse, err := u.resolveWarehouse(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &domain.Location{
+		Warehouse:   warehouse,
+		Code:        code,
+		Description: description,
+	}
+
+	if err := u.locationRepo.Create(ctx, location); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+func (u *LocationUseCase) GetLocation(ctx context.Context, id int64) (*domain.Location, error) {
+	location, err := u.locationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if location == nil {
+		return nil, &domain.LocationNotFoundError{LocationID: id}
+	}
+
+	return location, nil
+}
+
+func (u *LocationUseCase) GetAllLocations(ctx context.Context) ([]domain.Location, error) {
+	return u.locationRepo.GetAll(ctx)
+}
+
+func (u *LocationUseCase) UpdateLocation(ctx context.Context, location *domain.Location) error {
+	if _, err := u.GetLocation(ctx, location.ID); err != nil {
+		return err
+	}
+
+	var warehouseID int64
+	if location.Warehouse != nil {
+		warehouseID = location.Warehouse.ID
+	}
+	warehouse, err := u.resolveWarehouse(ctx, warehouseID)
+	if err != nil {
+		return err
+	}
+	location.Warehouse = warehouse
+
+	return u.locationRepo.Update(ctx, location)
+}
+
+func (u *LocationUseCase) DeleteLocation(ctx context.Context, id int64) error {
+	if _, err := u.GetLocation(ctx, id); err != nil {
+		return err
+	}
+
+	return u.locationRepo.Delete(ctx, id)
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"strconv"
+	"testing"
+)
+
+func TestUpdateLocation(t *testing.T) {
+	tests := []struct {
+		name          string
+		location      *domain.Location
+		expectedError error
+	}{
+		{
+			name:     "move to another warehouse",
+			location: &domain.Location{ID: 1, Code: "B-01", Warehouse: &domain.Warehouse{ID: 2}},
+		},
+		{
+			name:          "unknown location",
+			location:      &domain.Location{ID: 999, Code: "B-01", Warehouse: &domain.Warehouse{ID: 2}},
+			expectedError: &domain.LocationNotFoundError{LocationID: 999},
+		},
+		{
+			name:          "unknown warehouse",
+			location:      &domain.Location{ID: 1, Code: "B-01", Warehouse: &domain.Warehouse{ID: 999}},
+			expectedError: &domain.WarehouseNotFoundError{WarehouseID: 999},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *domain.Location
+			locationRepo := &repository.MockLocationRepository{
+				GetByIDFunc: func(id int64) (*domain.Location, error) {
+					if id == 1 {
+						return &domain.Location{ID: 1, Code: "A-01", Warehouse: &domain.Warehouse{ID: 1}}, nil
+					}
+					return nil, nil
+				},
+				UpdateFunc: func(location *domain.Location) error {
+					updated = location
+					return nil
+				},
+			}
+			warehouseRepo := &repository.MockWarehouseRepository{
+				GetByIDFunc: func(id int64) (*domain.Warehouse, error) {
+					if id == 1 || id == 2 {
+						return &domain.Warehouse{ID: id, Code: "W" + strconv.FormatInt(id, 10)}, nil
+					}
+					return nil, nil
+				},
+			}
+			useCase := NewLocationUseCase(locationRepo, warehouseRepo)
+
+			err := useCase.UpdateLocation(context.Background(), tt.location)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if updated != nil {
+					t.Error("expected location not to be updated")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated == nil || updated.Warehouse.Code != "W2" {
+				t.Errorf("expected location to be stored with its resolved warehouse, got %+v", updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"inventario/internal/domain"
+	"time"
+)
+
+// changes collects the fields a patch changed, which are the only ones saved
+type changes []string
+
+func (c *changes) compare(field string, changed bool) {
+	if changed {
+		*c = append(*c, field)
+	}
+}
+
+func productChanges(before, after *domain.Product) []string {
+	var c changes
+	c.compare("name", after.Name != before.Name)
+	c.compare("code", after.Code != before.Code)
+	c.compare("tracking_mode", after.TrackingMode != before.TrackingMode)
+	c.compare("image_url", after.ImageURL != before.ImageURL)
+	return c
+}
+
+func providerChanges(before, after *domain.Provider) []string {
+	var c changes
+	c.compare("name", after.Name != before.Name)
+	c.compare("email", after.Email != before.Email)
+	c.compare("phone", after.Phone != before.Phone)
+	c.compare("address", after.Address != before.Address)
+	return c
+}
+
+func userChanges(before, after *domain.User) []string {
+	var c changes
+	c.compare("name", after.Name != before.Name)
+	c.compare("email", after.Email != before.Email)
+	c.compare("role", after.Role != before.Role)
+	c.compare("password", after.Password != before.Password)
+	return c
+}
+
+// stockChanges compares the fields of the units kept in their history, which
+// are named like the patchable ones
+func stockChanges(before, after *domain.Stock) []string {
+	b, a := domain.SnapshotOf(before), domain.SnapshotOf(after)
+	var c changes
+	c.compare("product_id", a.ProductID != b.ProductID)
+	c.compare("serial", a.Serial != b.Serial)
+	c.compare("batch", a.Batch != b.Batch)
+	c.compare("purchase_date", !sameDay(a.PurchaseDate, b.PurchaseDate))
+	c.compare("provider_id", a.ProviderID != b.ProviderID)
+	c.compare("location_id", a.LocationID != b.LocationID)
+	return c
+}
+
+// sameDay reports whether a and b fall on the same calendar day, each in its
+// own location. Purchase dates are days: the API reads and writes them without
+// a time of day, so a date read back from the database at another offset has
+// not changed.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
@@ -13,7 +14,9 @@ func TestCreateProduct(t *testing.T) {
 		productName   string
 		productCode   string
 		imageURL      string
+		trackingMode  domain.TrackingMode
 		mockCreate    func(*domain.Product) error
+		expectedMode  domain.TrackingMode
 		expectedError error
 	}{
 		{
@@ -24,8 +27,26 @@ func TestCreateProduct(t *testing.T) {
 			mockCreate: func(p *domain.Product) error {
 				return nil
 			},
+			expectedMode:  domain.TrackingSerialized,
 			expectedError: nil,
 		},
+		{
+			name:         "quantity-tracked product",
+			productName:  "Cable",
+			productCode:  "CAB01",
+			trackingMode: domain.TrackingQuantity,
+			mockCreate: func(p *domain.Product) error {
+				return nil
+			},
+			expectedMode: domain.TrackingQuantity,
+		},
+		{
+			name:          "unknown tracking mode",
+			productName:   "Cable",
+			productCode:   "CAB01",
+			trackingMode:  "weight",
+			expectedError: &domain.InvalidTrackingModeError{Mode: "weight"},
+		},
 		{
 			name:        "product already exists",
 			productName: "Existing Product",
@@ -43,9 +64,9 @@ func TestCreateProduct(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := NewProductUseCase(mockRepo)
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			product, err := useCase.CreateProduct(tt.productName, tt.productCode, tt.imageURL)
+			product, err := useCase.CreateProduct(context.Background(), tt.productName, tt.productCode, tt.imageURL, tt.trackingMode)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -65,6 +86,9 @@ func TestCreateProduct(t *testing.T) {
 			if product.ImageURL != tt.imageURL {
 				t.Errorf("expected image URL %s, got %s", tt.imageURL, product.ImageURL)
 			}
+			if product.TrackingMode != tt.expectedMode {
+				t.Errorf("expected tracking mode %s, got %s", tt.expectedMode, product.TrackingMode)
+			}
 		})
 	}
 }
@@ -97,6 +121,14 @@ func TestGetProduct(t *testing.T) {
 			},
 			expectedError: &domain.ProductNotFoundError{ProductID: 999},
 		},
+		{
+			name:      "no product with the ID",
+			productID: 999,
+			mockGetByID: func(id int64) (*domain.Product, error) {
+				return nil, nil
+			},
+			expectedError: &domain.ProductNotFoundError{ProductID: 999},
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,9 +136,9 @@ func TestGetProduct(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := NewProductUseCase(mockRepo)
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			product, err := useCase.GetProduct(tt.productID)
+			product, err := useCase.GetProduct(context.Background(), tt.productID)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -164,9 +196,9 @@ func TestGetAllProducts(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := NewProductUseCase(mockRepo)
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			products, err := useCase.GetAllProducts()
+			products, _, err := useCase.GetAllProducts(context.Background(), domain.ProductFilter{})
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -224,9 +256,9 @@ func TestUpdateProduct(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
 				UpdateFunc: tt.mockUpdate,
 			}
-			useCase := NewProductUseCase(mockRepo)
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			err := useCase.UpdateProduct(tt.product)
+			err := useCase.UpdateProduct(context.Background(), tt.product)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -240,17 +272,79 @@ func TestUpdateProduct(t *testing.T) {
 	}
 }
 
-func TestDeleteProduct(t *testing.T) {
+func TestUpdateProductTrackingMode(t *testing.T) {
 	tests := []struct {
 		name          string
-		productID     int64
-		mockDelete    func(int64) error
+		trackingMode  domain.TrackingMode
+		expectedMode  domain.TrackingMode
 		expectedError error
+	}{
+		{
+			name:         "omitted mode keeps the current one",
+			expectedMode: domain.TrackingQuantity,
+		},
+		{
+			name:         "same mode",
+			trackingMode: domain.TrackingQuantity,
+			expectedMode: domain.TrackingQuantity,
+		},
+		{
+			name:          "mode change",
+			trackingMode:  domain.TrackingSerialized,
+			expectedError: &domain.TrackingModeChangeError{ProductID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *domain.Product
+			mockRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					return &domain.Product{ID: id, Name: "Cable", Code: "CAB01", TrackingMode: domain.TrackingQuantity}, nil
+				},
+				UpdateFunc: func(p *domain.Product) error {
+					updated = p
+					return nil
+				},
+			}
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+
+			err := useCase.UpdateProduct(context.Background(), &domain.Product{ID: 1, Name: "Cable", Code: "CAB01", TrackingMode: tt.trackingMode})
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if updated != nil {
+					t.Error("expected the product not to be updated")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.TrackingMode != tt.expectedMode {
+				t.Errorf("expected tracking mode %s, got %s", tt.expectedMode, updated.TrackingMode)
+			}
+		})
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	tests := []struct {
+		name           string
+		productID      int64
+		version        int64
+		mockDependents func(int64) ([]domain.Dependent, error)
+		mockDelete     func(int64, int64) error
+		expectedError  error
 	}{
 		{
 			name:      "successful deletion",
 			productID: 1,
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
+				if deletedBy != 7 {
+					t.Errorf("expected the deletion to be recorded as by user 7, got %d", deletedBy)
+				}
 				return nil
 			},
 			expectedError: nil,
@@ -258,21 +352,55 @@ func TestDeleteProduct(t *testing.T) {
 		{
 			name:      "product not found",
 			productID: 999,
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return &domain.ProductNotFoundError{ProductID: 999}
 			},
 			expectedError: &domain.ProductNotFoundError{ProductID: 999},
 		},
+		{
+			name:      "product in use",
+			productID: 1,
+			mockDependents: func(id int64) ([]domain.Dependent, error) {
+				return []domain.Dependent{{Table: "stocks", Count: 3}}, nil
+			},
+			mockDelete: func(id, deletedBy int64) error {
+				t.Error("product in use should not be deleted")
+				return nil
+			},
+			expectedError: &domain.EntityInUseError{Entity: "product", ID: 1, Dependents: []domain.Dependent{{Table: "stocks", Count: 3}}},
+		},
+		{
+			name:      "current version",
+			productID: 1,
+			version:   3,
+			mockDelete: func(id, deletedBy int64) error {
+				return nil
+			},
+		},
+		{
+			name:      "stale version",
+			productID: 1,
+			version:   2,
+			mockDelete: func(id, deletedBy int64) error {
+				t.Error("a product modified since should not be deleted")
+				return nil
+			},
+			expectedError: &domain.VersionMismatchError{Entity: "product", ID: 1, Version: 2},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := &repository.MockProductRepository{
-				DeleteFunc: tt.mockDelete,
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					return &domain.Product{ID: id, Version: 3}, nil
+				},
+				DependentsFunc: tt.mockDependents,
+				DeleteFunc:     tt.mockDelete,
 			}
-			useCase := NewProductUseCase(mockRepo)
+			useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			err := useCase.DeleteProduct(tt.productID)
+			err := useCase.DeleteProduct(context.Background(), &domain.User{ID: 7, Role: domain.RoleAdmin}, tt.productID, tt.version)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -285,3 +413,103 @@ func TestDeleteProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestReassignAndDeleteProduct(t *testing.T) {
+	products := map[int64]*domain.Product{
+		1: {ID: 1, Code: "OLD", TrackingMode: domain.TrackingSerialized},
+		2: {ID: 2, Code: "NEW", TrackingMode: domain.TrackingSerialized},
+		3: {ID: 3, Code: "BULK", TrackingMode: domain.TrackingQuantity},
+	}
+	admin := &domain.User{ID: 7, Role: domain.RoleAdmin}
+
+	tests := []struct {
+		name          string
+		toID          int64
+		wantReassign  bool
+		expectedError error
+	}{
+		{name: "reassigned and deleted", toID: 2, wantReassign: true},
+		{name: "to itself", toID: 1, expectedError: &domain.InvalidReassignmentError{Reason: "a product cannot be reassigned to itself"}},
+		{name: "target not found", toID: 999, expectedError: &domain.InvalidReassignmentError{Reason: "product with ID 999 not found"}},
+		{name: "target not serialized", toID: 3, expectedError: &domain.InvalidReassignmentError{Reason: "product with ID 3 uses quantity tracking"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reassigned, deleted bool
+			mockRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					return products[id], nil
+				},
+				DeleteFunc: func(id, deletedBy int64) error {
+					deleted = true
+					return nil
+				},
+			}
+			mockStockRepo := &repository.MockStockRepository{
+				ReassignFunc: func(ref domain.StockReference, from, to int64, actor *domain.User, reason string) (int64, error) {
+					if ref != domain.StockProductReference || from != 1 || to != tt.toID {
+						t.Errorf("unexpected reassignment of %s %d to %d", ref, from, to)
+					}
+					if actor != admin || reason != "reassigned from product 1" {
+						t.Errorf("unexpected reassignment by %+v for %q", actor, reason)
+					}
+					reassigned = true
+					return 2, nil
+				},
+			}
+			useCase := NewProductUseCase(mockRepo, mockStockRepo, repository.NewMemoryUnitOfWork())
+
+			err := useCase.ReassignAndDeleteProduct(context.Background(), admin, 1, 0, tt.toID)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if reassigned || deleted {
+					t.Error("expected nothing to be reassigned or deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reassigned != tt.wantReassign || !deleted {
+				t.Errorf("expected reassignment and deletion, got reassigned=%v deleted=%v", reassigned, deleted)
+			}
+		})
+	}
+}
+
+func TestGetAllProductsListOptions(t *testing.T) {
+	var gotFilter domain.ProductFilter
+	mockRepo := &repository.MockProductRepository{
+		CountFunc: func(filter domain.ProductFilter) (int64, error) {
+			gotFilter = filter
+			return 700, nil
+		},
+	}
+	useCase := NewProductUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+
+	_, total, err := useCase.GetAllProducts(context.Background(), domain.ProductFilter{ListOptions: domain.ListOptions{Limit: 1000}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 700 {
+		t.Errorf("expected total 700, got %d", total)
+	}
+	if gotFilter.Limit != domain.MaxPageLimit {
+		t.Errorf("expected limit capped at %d, got %d", domain.MaxPageLimit, gotFilter.Limit)
+	}
+
+	invalid := []domain.ProductFilter{
+		{ListOptions: domain.ListOptions{Limit: -1}},
+		{ListOptions: domain.ListOptions{Sort: []domain.SortField{{Field: "image_url"}}}},
+		{ListOptions: domain.ListOptions{AfterID: 3, Offset: 10}},
+		{TrackingMode: "weight"},
+	}
+	for _, filter := range invalid {
+		if _, _, err := useCase.GetAllProducts(context.Background(), filter); err == nil {
+			t.Errorf("expected error for filter %+v", filter)
+		}
+	}
+}
@@ -1,25 +1,39 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
 	"inventario/internal/domain"
 )
 
 // ProductUseCase handles the business logic for product operations
 type ProductUseCase struct {
 	productRepo domain.IProductRepository
+	stockRepo   domain.IStockRepository
+	uow         domain.UnitOfWork
 }
 
 // NewProductUseCase creates a new ProductUseCase instance
-func NewProductUseCase(repo domain.IProductRepository) *ProductUseCase {
+func NewProductUseCase(repo domain.IProductRepository, stockRepo domain.IStockRepository, uow domain.UnitOfWork) *ProductUseCase {
 	return &ProductUseCase{
 		productRepo: repo,
+		stockRepo:   stockRepo,
+		uow:         uow,
 	}
 }
 
-// CreateProduct creates a new product
-func (uc *ProductUseCase) CreateProduct(name, code, imageURL string) (*domain.Product, error) {
+// CreateProduct creates a new product. An empty trackingMode creates a
+// serialized product.
+func (uc *ProductUseCase) CreateProduct(ctx context.Context, name, code, imageURL string, trackingMode domain.TrackingMode) (*domain.Product, error) {
 	product := domain.NewProduct(name, code, imageURL)
-	err := uc.productRepo.Create(product)
+	if trackingMode != "" {
+		if !trackingMode.IsValid() {
+			return nil, &domain.InvalidTrackingModeError{Mode: trackingMode}
+		}
+		product.TrackingMode = trackingMode
+	}
+
+	err := uc.productRepo.Create(ctx, product)
 	if err != nil {
 		return nil, err
 	}
@@ -27,30 +41,181 @@ func (uc *ProductUseCase) CreateProduct(name, code, imageURL string) (*domain.Pr
 }
 
 // GetProduct retrieves a product by ID
-func (uc *ProductUseCase) GetProduct(id int64) (*domain.Product, error) {
-	return uc.productRepo.GetByID(id)
+func (uc *ProductUseCase) GetProduct(ctx context.Context, id int64) (*domain.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &domain.ProductNotFoundError{ProductID: id}
+	}
+	return product, nil
 }
 
-// GetAllProducts retrieves all products
-func (uc *ProductUseCase) GetAllProducts() ([]*domain.Product, error) {
-	products, err := uc.productRepo.GetAll()
+// GetAllProducts retrieves a page of the products matching filter, along
+// with the number of products matching it across all pages
+func (uc *ProductUseCase) GetAllProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error) {
+	if filter.TrackingMode != "" && !filter.TrackingMode.IsValid() {
+		return nil, 0, &domain.InvalidTrackingModeError{Mode: filter.TrackingMode}
+	}
+	if err := filter.ListOptions.Normalize(domain.ProductSortFields); err != nil {
+		return nil, 0, err
+	}
+
+	products, err := uc.productRepo.GetAll(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	total, err := uc.productRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	result := make([]*domain.Product, len(products))
 	for i := range products {
 		result[i] = &products[i]
 	}
-	return result, nil
+	return result, total, nil
+}
+
+// UpdateProduct updates an existing product as of product.Version, or of its
+// current version when zero. The tracking mode is fixed at creation: an empty
+// one keeps the current mode, a different one is rejected.
+func (uc *ProductUseCase) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	if product.TrackingMode != "" && !product.TrackingMode.IsValid() {
+		return &domain.InvalidTrackingModeError{Mode: product.TrackingMode}
+	}
+
+	existing, err := uc.productRepo.GetByID(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		product.Version = basedOn(product.Version, existing.Version)
+		switch product.TrackingMode {
+		case "":
+			product.TrackingMode = existing.TrackingMode
+		case existing.TrackingMode:
+		default:
+			return &domain.TrackingModeChangeError{ProductID: product.ID}
+		}
+	}
+
+	return uc.productRepo.Update(ctx, product)
+}
+
+// PatchProduct applies patch to the product id as of version, or of its
+// current version when zero, and saves the fields it changed. The result is
+// checked like in UpdateProduct, and an emptied tracking mode keeps the
+// current one.
+func (uc *ProductUseCase) PatchProduct(ctx context.Context, id, version int64, patch func(*domain.Product) error) (*domain.Product, error) {
+	var product *domain.Product
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		existing, err := uc.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &domain.ProductNotFoundError{ProductID: id}
+		}
+		if err := checkVersion("product", id, version, existing.Version); err != nil {
+			return err
+		}
+
+		patched := *existing
+		if err := patch(&patched); err != nil {
+			return err
+		}
+		patched.ID, patched.Version = existing.ID, existing.Version
+		switch {
+		case patched.Name == "":
+			return &domain.RequiredFieldError{Entity: "product", Field: "name"}
+		case patched.Code == "":
+			return &domain.RequiredFieldError{Entity: "product", Field: "code"}
+		case patched.TrackingMode == "":
+			patched.TrackingMode = existing.TrackingMode
+		case !patched.TrackingMode.IsValid():
+			return &domain.InvalidTrackingModeError{Mode: patched.TrackingMode}
+		case patched.TrackingMode != existing.TrackingMode:
+			return &domain.TrackingModeChangeError{ProductID: id}
+		}
+
+		if fields := productChanges(existing, &patched); len(fields) > 0 {
+			if err := uc.productRepo.Patch(ctx, &patched, fields); err != nil {
+				return err
+			}
+		}
+		product = &patched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-// UpdateProduct updates an existing product
-func (uc *ProductUseCase) UpdateProduct(product *domain.Product) error {
-	return uc.productRepo.Update(product)
+// DeleteProduct soft deletes a product by ID unless live units, purchase order
+// lines or balances still refer to it. A non-zero version must be the current
+// one.
+func (uc *ProductUseCase) DeleteProduct(ctx context.Context, actor *domain.User, id, version int64) error {
+	return uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := uc.checkVersion(ctx, id, version); err != nil {
+			return err
+		}
+		return deleteUnreferenced(ctx, "product", id, actor, uc.productRepo.Dependents, uc.productRepo.Delete)
+	})
 }
 
-// DeleteProduct deletes a product by ID
-func (uc *ProductUseCase) DeleteProduct(id int64) error {
-	return uc.productRepo.Delete(id)
+// ReassignAndDeleteProduct moves the units of a product to the serialized
+// product toID, recording the move in their history, and deletes it, in one
+// transaction. Other references still block the deletion.
+func (uc *ProductUseCase) ReassignAndDeleteProduct(ctx context.Context, actor *domain.User, id, version, toID int64) error {
+	if toID == id {
+		return &domain.InvalidReassignmentError{Reason: "a product cannot be reassigned to itself"}
+	}
+
+	return uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := uc.checkVersion(ctx, id, version); err != nil {
+			return err
+		}
+		target, err := uc.productRepo.GetByID(ctx, toID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return &domain.InvalidReassignmentError{Reason: fmt.Sprintf("product with ID %d not found", toID)}
+		}
+		if target.TrackingMode != domain.TrackingSerialized {
+			return &domain.InvalidReassignmentError{Reason: fmt.Sprintf("product with ID %d uses %s tracking", toID, target.TrackingMode)}
+		}
+
+		if _, err := uc.stockRepo.Reassign(ctx, domain.StockProductReference, id, toID, actor, fmt.Sprintf("reassigned from product %d", id)); err != nil {
+			return err
+		}
+		return deleteUnreferenced(ctx, "product", id, actor, uc.productRepo.Dependents, uc.productRepo.Delete)
+	})
+}
+
+// checkVersion rejects a change of product id based on version when the
+// product has been modified since. A zero version skips the check.
+func (uc *ProductUseCase) checkVersion(ctx context.Context, id, version int64) error {
+	if version == 0 {
+		return nil
+	}
+	product, err := uc.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return &domain.ProductNotFoundError{ProductID: id}
+	}
+	return checkVersion("product", id, version, product.Version)
+}
+
+// RestoreProduct brings back a soft-deleted product
+func (uc *ProductUseCase) RestoreProduct(ctx context.Context, id int64) (*domain.Product, error) {
+	if err := uc.productRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.GetProduct(ctx, id)
 }
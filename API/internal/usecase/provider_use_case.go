@@ -1,20 +1,26 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
 	"inventario/internal/domain"
 )
 
 type ProviderUseCase struct {
 	providerRepo domain.IProviderRepository
+	stockRepo    domain.IStockRepository
+	uow          domain.UnitOfWork
 }
 
-func NewProviderUseCase(repo domain.IProviderRepository) *ProviderUseCase {
+func NewProviderUseCase(repo domain.IProviderRepository, stockRepo domain.IStockRepository, uow domain.UnitOfWork) *ProviderUseCase {
 	return &ProviderUseCase{
 		providerRepo: repo,
+		stockRepo:    stockRepo,
+		uow:          uow,
 	}
 }
 
-func (u *ProviderUseCase) CreateProvider(name, email, phone, address string) (*domain.Provider, error) {
+func (u *ProviderUseCase) CreateProvider(ctx context.Context, name, email, phone, address string) (*domain.Provider, error) {
 	provider := &domain.Provider{
 		Name:    name,
 		Email:   email,
@@ -22,15 +28,15 @@ func (u *ProviderUseCase) CreateProvider(name, email, phone, address string) (*d
 		Address: address,
 	}
 
-	if err := u.providerRepo.Create(provider); err != nil {
+	if err := u.providerRepo.Create(ctx, provider); err != nil {
 		return nil, err
 	}
 
 	return provider, nil
 }
 
-func (u *ProviderUseCase) GetProvider(id int64) (*domain.Provider, error) {
-	provider, err := u.providerRepo.GetByID(id)
+func (u *ProviderUseCase) GetProvider(ctx context.Context, id int64) (*domain.Provider, error) {
+	provider, err := u.providerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -42,12 +48,26 @@ func (u *ProviderUseCase) GetProvider(id int64) (*domain.Provider, error) {
 	return provider, nil
 }
 
-func (u *ProviderUseCase) GetAllProviders() ([]domain.Provider, error) {
-	return u.providerRepo.GetAll()
+// GetAllProviders retrieves a page of the providers matching filter, along
+// with the number of providers matching it across all pages
+func (u *ProviderUseCase) GetAllProviders(ctx context.Context, filter domain.ProviderFilter) ([]domain.Provider, int64, error) {
+	if err := filter.ListOptions.Normalize(domain.ProviderSortFields); err != nil {
+		return nil, 0, err
+	}
+
+	providers, err := u.providerRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := u.providerRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return providers, total, nil
 }
 
-func (u *ProviderUseCase) UpdateProvider(provider *domain.Provider) error {
-	existingProvider, err := u.providerRepo.GetByID(provider.ID)
+func (u *ProviderUseCase) UpdateProvider(ctx context.Context, provider *domain.Provider) error {
+	existingProvider, err := u.providerRepo.GetByID(ctx, provider.ID)
 	if err != nil {
 		return err
 	}
@@ -56,18 +76,111 @@ func (u *ProviderUseCase) UpdateProvider(provider *domain.Provider) error {
 		return &domain.ProviderNotFoundError{ProviderID: provider.ID}
 	}
 
-	return u.providerRepo.Update(provider)
+	provider.Version = basedOn(provider.Version, existingProvider.Version)
+	return u.providerRepo.Update(ctx, provider)
 }
 
-func (u *ProviderUseCase) DeleteProvider(id int64) error {
-	provider, err := u.providerRepo.GetByID(id)
+// PatchProvider applies patch to the provider id as of version, or of its
+// current version when zero, and saves the fields it changed. Name and email
+// cannot be emptied.
+func (u *ProviderUseCase) PatchProvider(ctx context.Context, id, version int64, patch func(*domain.Provider) error) (*domain.Provider, error) {
+	var provider *domain.Provider
+	err := u.uow.Do(ctx, func(ctx context.Context) error {
+		existing, err := u.providerRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &domain.ProviderNotFoundError{ProviderID: id}
+		}
+		if err := checkVersion("provider", id, version, existing.Version); err != nil {
+			return err
+		}
+
+		patched := *existing
+		if err := patch(&patched); err != nil {
+			return err
+		}
+		patched.ID, patched.Version = existing.ID, existing.Version
+		switch {
+		case patched.Name == "":
+			return &domain.RequiredFieldError{Entity: "provider", Field: "name"}
+		case patched.Email == "":
+			return &domain.RequiredFieldError{Entity: "provider", Field: "email"}
+		}
+
+		if fields := providerChanges(existing, &patched); len(fields) > 0 {
+			if err := u.providerRepo.Patch(ctx, &patched, fields); err != nil {
+				return err
+			}
+		}
+		provider = &patched
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return provider, nil
+}
 
-	if provider == nil {
-		return &domain.ProviderNotFoundError{ProviderID: id}
+// DeleteProvider soft deletes a provider unless live units or purchase
+// orders still refer to it. A non-zero version must be the current one.
+func (u *ProviderUseCase) DeleteProvider(ctx context.Context, actor *domain.User, id, version int64) error {
+	return u.uow.Do(ctx, func(ctx context.Context) error {
+		provider, err := u.providerRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if provider == nil {
+			return &domain.ProviderNotFoundError{ProviderID: id}
+		}
+		if err := checkVersion("provider", id, version, provider.Version); err != nil {
+			return err
+		}
+
+		return deleteUnreferenced(ctx, "provider", id, actor, u.providerRepo.Dependents, u.providerRepo.Delete)
+	})
+}
+
+// ReassignAndDeleteProvider moves the units bought from a provider to the
+// provider toID, recording the move in their history, and deletes it, in one
+// transaction. Purchase orders still block the deletion.
+func (u *ProviderUseCase) ReassignAndDeleteProvider(ctx context.Context, actor *domain.User, id, version, toID int64) error {
+	if toID == id {
+		return &domain.InvalidReassignmentError{Reason: "a provider cannot be reassigned to itself"}
 	}
 
-	return u.providerRepo.Delete(id)
+	return u.uow.Do(ctx, func(ctx context.Context) error {
+		provider, err := u.providerRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if provider == nil {
+			return &domain.ProviderNotFoundError{ProviderID: id}
+		}
+		if err := checkVersion("provider", id, version, provider.Version); err != nil {
+			return err
+		}
+		target, err := u.providerRepo.GetByID(ctx, toID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return &domain.InvalidReassignmentError{Reason: fmt.Sprintf("provider with ID %d not found", toID)}
+		}
+
+		if _, err := u.stockRepo.Reassign(ctx, domain.StockProviderReference, id, toID, actor, fmt.Sprintf("reassigned from provider %d", id)); err != nil {
+			return err
+		}
+		return deleteUnreferenced(ctx, "provider", id, actor, u.providerRepo.Dependents, u.providerRepo.Delete)
+	})
+}
+
+// RestoreProvider brings back a soft-deleted provider
+func (u *ProviderUseCase) RestoreProvider(ctx context.Context, id int64) (*domain.Provider, error) {
+	if err := u.providerRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.GetProvider(ctx, id)
 }
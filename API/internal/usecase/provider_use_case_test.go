@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
@@ -47,9 +48,9 @@ func TestCreateProvider(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := NewProviderUseCase(mockRepo)
+			useCase := NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			provider, err := useCase.CreateProvider(tt.providerName, tt.providerEmail, tt.providerPhone, tt.providerAddr)
+			provider, err := useCase.CreateProvider(context.Background(), tt.providerName, tt.providerEmail, tt.providerPhone, tt.providerAddr)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -114,9 +115,9 @@ func TestGetProvider(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := NewProviderUseCase(mockRepo)
+			useCase := NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			provider, err := useCase.GetProvider(tt.providerID)
+			provider, err := useCase.GetProvider(context.Background(), tt.providerID)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -180,9 +181,9 @@ func TestGetAllProviders(t *testing.T) {
 			mockRepo := &repository.MockProviderRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := NewProviderUseCase(mockRepo)
+			useCase := NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			providers, err := useCase.GetAllProviders()
+			providers, _, err := useCase.GetAllProviders(context.Background(), domain.ProviderFilter{})
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -262,9 +263,9 @@ func TestUpdateProvider(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				UpdateFunc:  tt.mockUpdate,
 			}
-			useCase := NewProviderUseCase(mockRepo)
+			useCase := NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			err := useCase.UpdateProvider(tt.provider)
+			err := useCase.UpdateProvider(context.Background(), tt.provider)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -283,7 +284,7 @@ func TestDeleteProvider(t *testing.T) {
 		name          string
 		providerID    int64
 		mockGetByID   func(int64) (*domain.Provider, error)
-		mockDelete    func(int64) error
+		mockDelete    func(int64, int64) error
 		expectedError error
 	}{
 		{
@@ -300,7 +301,7 @@ func TestDeleteProvider(t *testing.T) {
 					UpdatedAt: time.Now(),
 				}, nil
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedError: nil,
@@ -311,7 +312,7 @@ func TestDeleteProvider(t *testing.T) {
 			mockGetByID: func(id int64) (*domain.Provider, error) {
 				return nil, &domain.ProviderNotFoundError{ProviderID: 999}
 			},
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedError: &domain.ProviderNotFoundError{ProviderID: 999},
@@ -324,9 +325,9 @@ func TestDeleteProvider(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				DeleteFunc:  tt.mockDelete,
 			}
-			useCase := NewProviderUseCase(mockRepo)
+			useCase := NewProviderUseCase(mockRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
 
-			err := useCase.DeleteProvider(tt.providerID)
+			err := useCase.DeleteProvider(context.Background(), nil, tt.providerID, 0)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
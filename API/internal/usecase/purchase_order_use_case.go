@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"strconv"
+	"time"
+)
+
+// PurchaseOrderLineInput describes a line of a new purchase order
+type PurchaseOrderLineInput struct {
+	ProductID        int64
+	ExpectedQuantity int64
+	UnitCost         float64
+}
+
+// ReceiptLineInput lists the serials received for a purchase order line
+type ReceiptLineInput struct {
+	LineID  int64
+	Serials []string
+	Batch   string
+}
+
+type PurchaseOrderUseCase struct {
+	orderRepo    domain.IPurchaseOrderRepository
+	providerRepo domain.IProviderRepository
+	productRepo  domain.IProductRepository
+	stockRepo    domain.IStockRepository
+	uow          domain.UnitOfWork
+}
+
+func NewPurchaseOrderUseCase(orderRepo domain.IPurchaseOrderRepository, providerRepo domain.IProviderRepository, productRepo domain.IProductRepository, stockRepo domain.IStockRepository, uow domain.UnitOfWork) *PurchaseOrderUseCase {
+	return &PurchaseOrderUseCase{
+		orderRepo:    orderRepo,
+		providerRepo: providerRepo,
+		productRepo:  productRepo,
+		stockRepo:    stockRepo,
+		uow:          uow,
+	}
+}
+
+// CreatePurchaseOrder drafts an order to a provider. Products tracked by
+// quantity are rejected, as their lines could not be received.
+func (uc *PurchaseOrderUseCase) CreatePurchaseOrder(ctx context.Context, actor *domain.User, providerID int64, notes string, lines []PurchaseOrderLineInput) (*domain.PurchaseOrder, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	if len(lines) == 0 {
+		return nil, &domain.InvalidPurchaseOrderError{Reason: "at least one line is required"}
+	}
+
+	provider, err := uc.providerRepo.GetByID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, &domain.ProviderNotFoundError{ProviderID: providerID}
+	}
+
+	order := &domain.PurchaseOrder{
+		Provider:      provider,
+		Status:        domain.PurchaseOrderDraft,
+		Notes:         notes,
+		CreatedByUser: actor,
+	}
+	for _, input := range lines {
+		if input.ExpectedQuantity <= 0 {
+			return nil, &domain.InvalidPurchaseOrderError{Reason: "expected quantity must be positive"}
+		}
+		if input.UnitCost < 0 {
+			return nil, &domain.InvalidPurchaseOrderError{Reason: "unit cost cannot be negative"}
+		}
+
+		product, err := uc.productRepo.GetByID(ctx, input.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, &domain.ProductNotFoundError{ProductID: input.ProductID}
+		}
+		if product.TrackingMode == domain.TrackingQuantity {
+			return nil, &domain.TrackingModeMismatchError{ProductID: product.ID, Mode: product.TrackingMode}
+		}
+
+		order.Lines = append(order.Lines, domain.PurchaseOrderLine{
+			Product:          product,
+			ExpectedQuantity: input.ExpectedQuantity,
+			UnitCost:         input.UnitCost,
+		})
+	}
+
+	if err := uc.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (uc *PurchaseOrderUseCase) GetPurchaseOrder(ctx context.Context, id int64) (*domain.PurchaseOrder, error) {
+	order, err := uc.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, &domain.PurchaseOrderNotFoundError{PurchaseOrderID: id}
+	}
+	return order, nil
+}
+
+func (uc *PurchaseOrderUseCase) GetAllPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]domain.PurchaseOrder, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, &domain.InvalidPurchaseOrderStatusError{Status: filter.Status}
+	}
+	return uc.orderRepo.GetAll(ctx, filter)
+}
+
+// transition moves an order to a status that does not involve receiving goods
+func (uc *PurchaseOrderUseCase) transition(ctx context.Context, actor *domain.User, id int64, to domain.PurchaseOrderStatus) (*domain.PurchaseOrder, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+
+	order, err := uc.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !order.Status.CanTransitionTo(to) {
+		return nil, &domain.InvalidPurchaseOrderTransitionError{From: order.Status, To: to}
+	}
+
+	order.Status = to
+	if to == domain.PurchaseOrderOrdered {
+		now := time.Now()
+		order.OrderedAt = &now
+	}
+	if err := uc.orderRepo.UpdateStatus(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// SubmitPurchaseOrder places a draft order with the provider
+func (uc *PurchaseOrderUseCase) SubmitPurchaseOrder(ctx context.Context, actor *domain.User, id int64) (*domain.PurchaseOrder, error) {
+	return uc.transition(ctx, actor, id, domain.PurchaseOrderOrdered)
+}
+
+// CancelPurchaseOrder cancels an order. Units already received are kept.
+func (uc *PurchaseOrderUseCase) CancelPurchaseOrder(ctx context.Context, actor *domain.User, id int64) (*domain.PurchaseOrder, error) {
+	return uc.transition(ctx, actor, id, domain.PurchaseOrderCancelled)
+}
+
+// ReceivePurchaseOrder creates a unit for every serial received against the
+// order and books it on its line. The order becomes partially_received until
+// every line is complete. Lines of products tracked by quantity have no
+// serials and are rejected; they are received through the stock balances. A
+// zero purchaseDate stands for today.
+func (uc *PurchaseOrderUseCase) ReceivePurchaseOrder(ctx context.Context, actor *domain.User, id int64, purchaseDate time.Time, locationID int64, lines []ReceiptLineInput) (*domain.PurchaseOrder, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	if len(lines) == 0 {
+		return nil, &domain.InvalidPurchaseOrderError{Reason: "at least one line is required"}
+	}
+
+	// The order is read, its serials checked and the units booked in one
+	// transaction
+	var order *domain.PurchaseOrder
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = uc.GetPurchaseOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !order.Status.CanReceive() {
+			return &domain.InvalidPurchaseOrderTransitionError{From: order.Status, To: domain.PurchaseOrderReceived}
+		}
+
+		if purchaseDate.IsZero() {
+			purchaseDate = today()
+		}
+
+		seen := make(map[string]bool)
+		received := make([]domain.ReceivedLine, 0, len(lines))
+		for _, input := range lines {
+			line := order.Line(input.LineID)
+			if line == nil {
+				return &domain.InvalidPurchaseOrderError{Reason: "line " + strconv.FormatInt(input.LineID, 10) + " does not belong to the order"}
+			}
+			if len(input.Serials) == 0 {
+				return &domain.InvalidPurchaseOrderError{Reason: "at least one serial is required per line"}
+			}
+			product, err := uc.productRepo.GetByID(ctx, line.Product.ID)
+			if err != nil {
+				return err
+			}
+			if product != nil && product.TrackingMode == domain.TrackingQuantity {
+				return &domain.TrackingModeMismatchError{ProductID: product.ID, Mode: product.TrackingMode}
+			}
+			if int64(len(input.Serials)) > line.Pending() {
+				return &domain.OverReceiptError{LineID: line.ID, Pending: line.Pending()}
+			}
+
+			receivedLine := domain.ReceivedLine{LineID: line.ID}
+			for _, serial := range input.Serials {
+				if seen[serial] {
+					return &domain.InvalidPurchaseOrderError{Reason: "serial " + serial + " is listed twice"}
+				}
+				seen[serial] = true
+
+				existing, err := uc.stockRepo.GetBySerial(ctx, serial)
+				if err != nil {
+					return err
+				}
+				if existing != nil {
+					return &domain.StockAlreadyExistsError{Serial: serial}
+				}
+
+				receivedLine.Stocks = append(receivedLine.Stocks, &domain.Stock{
+					Product:       &domain.Product{ID: line.Product.ID},
+					Serial:        serial,
+					Status:        domain.StockAvailable,
+					Batch:         input.Batch,
+					PurchaseDate:  purchaseDate,
+					Provider:      &domain.Provider{ID: order.Provider.ID},
+					Location:      locationRef(locationID),
+					CreatedByUser: actor,
+					UpdatedByUser: actor,
+				})
+			}
+			line.ReceivedQuantity += int64(len(receivedLine.Stocks))
+			received = append(received, receivedLine)
+		}
+
+		order.Status = order.ReceiptStatus()
+		reason := "purchase order " + strconv.FormatInt(order.ID, 10)
+		return uc.orderRepo.Receive(ctx, order, received, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// today is the current date in UTC at midnight, as purchase dates are parsed
+func today() time.Time {
+	y, m, d := time.Now().UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
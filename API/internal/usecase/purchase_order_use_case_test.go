@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"testing"
+	"time"
+)
+
+func TestCreatePurchaseOrder(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	tests := []struct {
+		name          string
+		providerID    int64
+		lines         []PurchaseOrderLineInput
+		expectedError error
+	}{
+		{
+			name:       "draft order",
+			providerID: 1,
+			lines:      []PurchaseOrderLineInput{{ProductID: 1, ExpectedQuantity: 3, UnitCost: 9.5}},
+		},
+		{
+			name:          "no lines",
+			providerID:    1,
+			expectedError: &domain.InvalidPurchaseOrderError{Reason: "at least one line is required"},
+		},
+		{
+			name:          "non-positive quantity",
+			providerID:    1,
+			lines:         []PurchaseOrderLineInput{{ProductID: 1, ExpectedQuantity: 0}},
+			expectedError: &domain.InvalidPurchaseOrderError{Reason: "expected quantity must be positive"},
+		},
+		{
+			name:          "unknown provider",
+			providerID:    9,
+			lines:         []PurchaseOrderLineInput{{ProductID: 1, ExpectedQuantity: 1}},
+			expectedError: &domain.ProviderNotFoundError{ProviderID: 9},
+		},
+		{
+			name:          "unknown product",
+			providerID:    1,
+			lines:         []PurchaseOrderLineInput{{ProductID: 9, ExpectedQuantity: 1}},
+			expectedError: &domain.ProductNotFoundError{ProductID: 9},
+		},
+		{
+			name:          "product tracked by quantity",
+			providerID:    1,
+			lines:         []PurchaseOrderLineInput{{ProductID: 1, ExpectedQuantity: 1}, {ProductID: 2, ExpectedQuantity: 10}},
+			expectedError: &domain.TrackingModeMismatchError{ProductID: 2, Mode: domain.TrackingQuantity},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerRepo := &repository.MockProviderRepository{
+				GetByIDFunc: func(id int64) (*domain.Provider, error) {
+					if id == 1 {
+						return &domain.Provider{ID: 1}, nil
+					}
+					return nil, nil
+				},
+			}
+			productRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					switch id {
+					case 1:
+						return &domain.Product{ID: 1, TrackingMode: domain.TrackingSerialized}, nil
+					case 2:
+						return &domain.Product{ID: 2, TrackingMode: domain.TrackingQuantity}, nil
+					}
+					return nil, nil
+				},
+			}
+			useCase := NewPurchaseOrderUseCase(&repository.MockPurchaseOrderRepository{}, providerRepo, productRepo, &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+
+			order, err := useCase.CreatePurchaseOrder(context.Background(), actor, tt.providerID, "", tt.lines)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if order.Status != domain.PurchaseOrderDraft || len(order.Lines) != len(tt.lines) {
+				t.Errorf("unexpected order %+v", order)
+			}
+		})
+	}
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	tests := []struct {
+		name           string
+		status         domain.PurchaseOrderStatus
+		receivedBefore int64
+		trackingMode   domain.TrackingMode
+		lines          []ReceiptLineInput
+		expectedStatus domain.PurchaseOrderStatus
+		expectedError  error
+	}{
+		{
+			name:           "partial receipt",
+			status:         domain.PurchaseOrderOrdered,
+			lines:          []ReceiptLineInput{{LineID: 10, Serials: []string{"S1"}, Batch: "B1"}},
+			expectedStatus: domain.PurchaseOrderPartiallyReceived,
+		},
+		{
+			name:           "receipt completes the order",
+			status:         domain.PurchaseOrderPartiallyReceived,
+			receivedBefore: 1,
+			lines:          []ReceiptLineInput{{LineID: 10, Serials: []string{"S2"}}},
+			expectedStatus: domain.PurchaseOrderReceived,
+		},
+		{
+			name:          "more than pending",
+			status:        domain.PurchaseOrderOrdered,
+			lines:         []ReceiptLineInput{{LineID: 10, Serials: []string{"S1", "S2", "S3"}}},
+			expectedError: &domain.OverReceiptError{LineID: 10, Pending: 2},
+		},
+		{
+			name:          "serial already in stock",
+			status:        domain.PurchaseOrderOrdered,
+			lines:         []ReceiptLineInput{{LineID: 10, Serials: []string{"EXISTING"}}},
+			expectedError: &domain.StockAlreadyExistsError{Serial: "EXISTING"},
+		},
+		{
+			name:          "draft orders cannot be received",
+			status:        domain.PurchaseOrderDraft,
+			lines:         []ReceiptLineInput{{LineID: 10, Serials: []string{"S1"}}},
+			expectedError: &domain.InvalidPurchaseOrderTransitionError{From: domain.PurchaseOrderDraft, To: domain.PurchaseOrderReceived},
+		},
+		{
+			name:          "line from another order",
+			status:        domain.PurchaseOrderOrdered,
+			lines:         []ReceiptLineInput{{LineID: 99, Serials: []string{"S1"}}},
+			expectedError: &domain.InvalidPurchaseOrderError{Reason: "line 99 does not belong to the order"},
+		},
+		{
+			name:          "product tracked by quantity",
+			status:        domain.PurchaseOrderOrdered,
+			trackingMode:  domain.TrackingQuantity,
+			lines:         []ReceiptLineInput{{LineID: 10, Serials: []string{"S1"}}},
+			expectedError: &domain.TrackingModeMismatchError{ProductID: 1, Mode: domain.TrackingQuantity},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedLines []domain.ReceivedLine
+			orderRepo := &repository.MockPurchaseOrderRepository{
+				GetByIDFunc: func(id int64) (*domain.PurchaseOrder, error) {
+					return &domain.PurchaseOrder{
+						ID:       id,
+						Provider: &domain.Provider{ID: 3},
+						Status:   tt.status,
+						Lines: []domain.PurchaseOrderLine{
+							{ID: 10, Product: &domain.Product{ID: 1}, ExpectedQuantity: 2, ReceivedQuantity: tt.receivedBefore},
+						},
+					}, nil
+				},
+				ReceiveFunc: func(order *domain.PurchaseOrder, lines []domain.ReceivedLine, reason string) error {
+					receivedLines = lines
+					return nil
+				},
+			}
+			stockRepo := &repository.MockStockRepository{
+				GetBySerialFunc: func(serial string) (*domain.Stock, error) {
+					if serial == "EXISTING" {
+						return &domain.Stock{ID: 1, Serial: serial}, nil
+					}
+					return nil, nil
+				},
+			}
+			productRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					product := &domain.Product{ID: id, TrackingMode: domain.TrackingSerialized}
+					if tt.trackingMode != "" {
+						product.TrackingMode = tt.trackingMode
+					}
+					return product, nil
+				},
+			}
+			useCase := NewPurchaseOrderUseCase(orderRepo, &repository.MockProviderRepository{}, productRepo, stockRepo, repository.NewMemoryUnitOfWork())
+
+			purchaseDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			order, err := useCase.ReceivePurchaseOrder(context.Background(), actor, 1, purchaseDate, 0, tt.lines)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if receivedLines != nil {
+					t.Error("expected nothing to be received")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, order.Status)
+			}
+			stock := receivedLines[0].Stocks[0]
+			if stock.Product.ID != 1 || stock.Provider.ID != 3 || !stock.PurchaseDate.Equal(purchaseDate) {
+				t.Errorf("unexpected stock %+v", stock)
+			}
+			if stock.CreatedByUser != actor {
+				t.Errorf("expected stock to be created by the actor")
+			}
+		})
+	}
+}
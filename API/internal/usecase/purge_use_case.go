@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"time"
+)
+
+// PurgeUseCase removes for good the records soft deleted longer ago than a
+// retention window
+type PurgeUseCase struct {
+	stockRepo    domain.IStockRepository
+	productRepo  domain.IProductRepository
+	providerRepo domain.IProviderRepository
+	userRepo     domain.IUserRepository
+}
+
+func NewPurgeUseCase(stockRepo domain.IStockRepository, productRepo domain.IProductRepository, providerRepo domain.IProviderRepository, userRepo domain.IUserRepository) *PurgeUseCase {
+	return &PurgeUseCase{
+		stockRepo:    stockRepo,
+		productRepo:  productRepo,
+		providerRepo: providerRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// PurgedTable is the number of rows a purge removed from one table
+type PurgedTable struct {
+	Table string
+	Count int64
+}
+
+// Purge removes the records soft deleted more than retention ago. Units go
+// first, so that the products, providers and users only they referred to
+// are removed in the same run; records still referred to are kept.
+func (uc *PurgeUseCase) Purge(ctx context.Context, retention time.Duration) ([]PurgedTable, error) {
+	before := time.Now().UTC().Add(-retention)
+	steps := []struct {
+		table string
+		purge func(context.Context, time.Time) (int64, error)
+	}{
+		{"stocks", uc.stockRepo.Purge},
+		{"products", uc.productRepo.Purge},
+		{"providers", uc.providerRepo.Purge},
+		{"users", uc.userRepo.Purge},
+	}
+
+	var purged []PurgedTable
+	for _, step := range steps {
+		count, err := step.purge(ctx, before)
+		if err != nil {
+			return purged, err
+		}
+		purged = append(purged, PurgedTable{Table: step.table, Count: count})
+	}
+	return purged, nil
+}
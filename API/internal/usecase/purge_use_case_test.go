@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"inventario/internal/infrastructure/repository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPurge(t *testing.T) {
+	var order []string
+	var cutoffs []time.Time
+	purge := func(table string, count int64, err error) func(time.Time) (int64, error) {
+		return func(before time.Time) (int64, error) {
+			order = append(order, table)
+			cutoffs = append(cutoffs, before)
+			return count, err
+		}
+	}
+
+	useCase := NewPurgeUseCase(
+		&repository.MockStockRepository{PurgeFunc: purge("stocks", 3, nil)},
+		&repository.MockProductRepository{PurgeFunc: purge("products", 1, nil)},
+		&repository.MockProviderRepository{PurgeFunc: purge("providers", 0, nil)},
+		&repository.MockUserRepository{PurgeFunc: purge("users", 2, nil)},
+	)
+
+	retention := 30 * 24 * time.Hour
+	start := time.Now()
+	purged, err := useCase.Purge(context.Background(), retention)
+	end := time.Now()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []PurgedTable{{"stocks", 3}, {"products", 1}, {"providers", 0}, {"users", 2}}
+	if !reflect.DeepEqual(purged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, purged)
+	}
+	if !reflect.DeepEqual(order, []string{"stocks", "products", "providers", "users"}) {
+		t.Errorf("expected units to be purged first, got %v", order)
+	}
+	for _, before := range cutoffs {
+		if before.Before(start.Add(-retention)) || before.After(end.Add(-retention)) {
+			t.Errorf("expected a cutoff 30 days ago, got %v", before)
+		}
+	}
+}
+
+func TestPurgeStopsOnError(t *testing.T) {
+	failure := errors.New("connection lost")
+	useCase := NewPurgeUseCase(
+		&repository.MockStockRepository{PurgeFunc: func(time.Time) (int64, error) { return 1, nil }},
+		&repository.MockProductRepository{PurgeFunc: func(time.Time) (int64, error) { return 0, failure }},
+		&repository.MockProviderRepository{PurgeFunc: func(time.Time) (int64, error) {
+			t.Error("providers should not be purged after a failure")
+			return 0, nil
+		}},
+		&repository.MockUserRepository{},
+	)
+
+	purged, err := useCase.Purge(context.Background(), time.Hour)
+	if !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+	if !reflect.DeepEqual(purged, []PurgedTable{{"stocks", 1}}) {
+		t.Errorf("expected the units purged before the failure, got %+v", purged)
+	}
+}
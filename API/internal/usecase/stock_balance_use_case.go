@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+// StockBalanceUseCase handles the inventory of products tracked by quantity
+type StockBalanceUseCase struct {
+	balanceRepo  domain.IStockBalanceRepository
+	productRepo  domain.IProductRepository
+	locationRepo domain.ILocationRepository
+}
+
+func NewStockBalanceUseCase(balanceRepo domain.IStockBalanceRepository, productRepo domain.IProductRepository, locationRepo domain.ILocationRepository) *StockBalanceUseCase {
+	return &StockBalanceUseCase{
+		balanceRepo:  balanceRepo,
+		productRepo:  productRepo,
+		locationRepo: locationRepo,
+	}
+}
+
+// ReceiveQuantity adds quantity units of a product to the balance of a
+// location and batch
+func (uc *StockBalanceUseCase) ReceiveQuantity(ctx context.Context, actor *domain.User, productID, locationID int64, batch string, quantity int64, reason string) (*domain.BalanceMovement, error) {
+	if quantity <= 0 {
+		return nil, &domain.InvalidQuantityError{Reason: "quantity must be positive"}
+	}
+	return uc.apply(ctx, actor, domain.BalanceReceive, productID, locationID, batch, quantity, reason)
+}
+
+// IssueQuantity takes quantity units of a product out of the balance of a
+// location and batch, which may not go below zero
+func (uc *StockBalanceUseCase) IssueQuantity(ctx context.Context, actor *domain.User, productID, locationID int64, batch string, quantity int64, reason string) (*domain.BalanceMovement, error) {
+	if quantity <= 0 {
+		return nil, &domain.InvalidQuantityError{Reason: "quantity must be positive"}
+	}
+	return uc.apply(ctx, actor, domain.BalanceIssue, productID, locationID, batch, -quantity, reason)
+}
+
+// AdjustQuantity corrects a balance by delta, e.g. after a stock count. A
+// reason is required, and the balance may not go below zero.
+func (uc *StockBalanceUseCase) AdjustQuantity(ctx context.Context, actor *domain.User, productID, locationID int64, batch string, delta int64, reason string) (*domain.BalanceMovement, error) {
+	if delta == 0 {
+		return nil, &domain.InvalidQuantityError{Reason: "adjustment must not be zero"}
+	}
+	if reason == "" {
+		return nil, &domain.InvalidQuantityError{Reason: "adjustments require a reason"}
+	}
+	return uc.apply(ctx, actor, domain.BalanceAdjust, productID, locationID, batch, delta, reason)
+}
+
+func (uc *StockBalanceUseCase) apply(ctx context.Context, actor *domain.User, movementType domain.BalanceMovementType, productID, locationID int64, batch string, quantity int64, reason string) (*domain.BalanceMovement, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &domain.ProductNotFoundError{ProductID: productID}
+	}
+	if product.TrackingMode != domain.TrackingQuantity {
+		return nil, &domain.TrackingModeMismatchError{ProductID: productID, Mode: product.TrackingMode}
+	}
+
+	location, err := uc.locationRepo.GetByID(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, &domain.LocationNotFoundError{LocationID: locationID}
+	}
+
+	movement := &domain.BalanceMovement{
+		Product:  product,
+		Location: location,
+		Batch:    batch,
+		Type:     movementType,
+		Quantity: quantity,
+		Reason:   reason,
+		Actor:    actor,
+	}
+	if err := uc.balanceRepo.Apply(ctx, movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// GetStockBalances lists the balances matching filter, including those that
+// have dropped to zero
+func (uc *StockBalanceUseCase) GetStockBalances(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.StockBalance, error) {
+	return uc.balanceRepo.GetAll(ctx, filter)
+}
+
+// GetBalanceMovements returns the ledger of the balances matching filter,
+// oldest entry first
+func (uc *StockBalanceUseCase) GetBalanceMovements(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.BalanceMovement, error) {
+	return uc.balanceRepo.GetMovements(ctx, filter)
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"testing"
+)
+
+func TestStockBalanceMovements(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	tests := []struct {
+		name             string
+		apply            func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error)
+		applyErr         error
+		expectedQuantity int64
+		expectedError    error
+	}{
+		{
+			name: "receive",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.ReceiveQuantity(context.Background(), actor, 1, 7, "B1", 100, "")
+			},
+			expectedQuantity: 100,
+		},
+		{
+			name: "issue is recorded as a negative quantity",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.IssueQuantity(context.Background(), actor, 1, 7, "B1", 30, "work order 12")
+			},
+			expectedQuantity: -30,
+		},
+		{
+			name: "issue more than on hand",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.IssueQuantity(context.Background(), actor, 1, 7, "B1", 300, "")
+			},
+			applyErr:      &domain.InsufficientQuantityError{ProductID: 1, Available: 100, Requested: 300},
+			expectedError: &domain.InsufficientQuantityError{ProductID: 1, Available: 100, Requested: 300},
+		},
+		{
+			name: "negative adjustment",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.AdjustQuantity(context.Background(), actor, 1, 7, "B1", -2, "cycle count")
+			},
+			expectedQuantity: -2,
+		},
+		{
+			name: "adjustment without reason",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.AdjustQuantity(context.Background(), actor, 1, 7, "B1", -2, "")
+			},
+			expectedError: &domain.InvalidQuantityError{Reason: "adjustments require a reason"},
+		},
+		{
+			name: "non-positive receipt",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.ReceiveQuantity(context.Background(), actor, 1, 7, "B1", 0, "")
+			},
+			expectedError: &domain.InvalidQuantityError{Reason: "quantity must be positive"},
+		},
+		{
+			name: "serialized product",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.ReceiveQuantity(context.Background(), actor, 2, 7, "B1", 1, "")
+			},
+			expectedError: &domain.TrackingModeMismatchError{ProductID: 2, Mode: domain.TrackingSerialized},
+		},
+		{
+			name: "unknown location",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.ReceiveQuantity(context.Background(), actor, 1, 99, "B1", 1, "")
+			},
+			expectedError: &domain.LocationNotFoundError{LocationID: 99},
+		},
+		{
+			name: "anonymous caller",
+			apply: func(uc *StockBalanceUseCase) (*domain.BalanceMovement, error) {
+				return uc.ReceiveQuantity(context.Background(), nil, 1, 7, "B1", 1, "")
+			},
+			expectedError: &domain.MissingAuditUserError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var applied *domain.BalanceMovement
+			balanceRepo := &repository.MockStockBalanceRepository{
+				ApplyFunc: func(movement *domain.BalanceMovement) error {
+					if tt.applyErr != nil {
+						return tt.applyErr
+					}
+					applied = movement
+					return nil
+				},
+			}
+			productRepo := &repository.MockProductRepository{
+				GetByIDFunc: func(id int64) (*domain.Product, error) {
+					switch id {
+					case 1:
+						return &domain.Product{ID: 1, TrackingMode: domain.TrackingQuantity}, nil
+					case 2:
+						return &domain.Product{ID: 2, TrackingMode: domain.TrackingSerialized}, nil
+					}
+					return nil, nil
+				},
+			}
+			locationRepo := &repository.MockLocationRepository{
+				GetByIDFunc: func(id int64) (*domain.Location, error) {
+					if id == 7 {
+						return &domain.Location{ID: 7, Code: "A-01"}, nil
+					}
+					return nil, nil
+				},
+			}
+			useCase := NewStockBalanceUseCase(balanceRepo, productRepo, locationRepo)
+
+			movement, err := tt.apply(useCase)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if applied != movement {
+				t.Fatal("expected the returned movement to be the applied one")
+			}
+			if movement.Quantity != tt.expectedQuantity {
+				t.Errorf("expected quantity %d, got %d", tt.expectedQuantity, movement.Quantity)
+			}
+			if movement.Actor != actor || movement.Batch != "B1" || movement.Location.ID != 7 {
+				t.Errorf("unexpected movement %+v", movement)
+			}
+		})
+	}
+}
@@ -1,50 +1,245 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
 	"inventario/internal/domain"
+	"sort"
 	"time"
 )
 
 type StockUseCase struct {
-	stockRepo domain.IStockRepository
+	stockRepo            domain.IStockRepository
+	movementRepo         domain.IStockMovementRepository
+	productRepo          domain.IProductRepository
+	balanceRepo          domain.IStockBalanceRepository
+	uow                  domain.UnitOfWork
+	allowClientAuditUser bool
 }
 
-func NewStockUseCase(stockRepo domain.IStockRepository) *StockUseCase {
+// NewStockUseCase creates a StockUseCase. When allowClientAuditUser is true the
+// audit user IDs sent by clients are trusted, which keeps legacy scripts working.
+func NewStockUseCase(stockRepo domain.IStockRepository, movementRepo domain.IStockMovementRepository, productRepo domain.IProductRepository, balanceRepo domain.IStockBalanceRepository, uow domain.UnitOfWork, allowClientAuditUser bool) *StockUseCase {
 	return &StockUseCase{
-		stockRepo: stockRepo,
+		stockRepo:            stockRepo,
+		movementRepo:         movementRepo,
+		productRepo:          productRepo,
+		balanceRepo:          balanceRepo,
+		uow:                  uow,
+		allowClientAuditUser: allowClientAuditUser,
 	}
 }
 
-func (uc *StockUseCase) CreateStock(productID int64, serial string, batch string, purchaseDate time.Time, providerID int64, createdByUserID int64) (*domain.Stock, error) {
+// recordMovement appends an entry to the stock ledger
+func (uc *StockUseCase) recordMovement(ctx context.Context, movementType domain.StockMovementType, actor *domain.User, before, after *domain.Stock, reason string) error {
+	return uc.movementRepo.Create(ctx, domain.NewStockMovement(movementType, actor, before, after, reason))
+}
+
+// resolveAuditUser returns the user a stock change is attributed to. The
+// authenticated actor always wins unless client-supplied IDs are trusted.
+func (uc *StockUseCase) resolveAuditUser(actor *domain.User, claimedUserID int64) (*domain.User, error) {
+	if claimedUserID != 0 && (actor == nil || claimedUserID != actor.ID) {
+		if !uc.allowClientAuditUser {
+			return nil, &domain.AuditUserMismatchError{ClaimedUserID: claimedUserID}
+		}
+		return &domain.User{ID: claimedUserID}, nil
+	}
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	return actor, nil
+}
+
+// requireSerialized rejects units of products tracked by quantity. Unknown
+// products are left to the repository, which reports them on insert.
+func (uc *StockUseCase) requireSerialized(ctx context.Context, productID int64) error {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if product != nil && product.TrackingMode == domain.TrackingQuantity {
+		return &domain.TrackingModeMismatchError{ProductID: productID, Mode: product.TrackingMode}
+	}
+	return nil
+}
+
+// locationRef returns a reference to the location with the given ID, or nil
+// when id is zero
+func locationRef(id int64) *domain.Location {
+	if id == 0 {
+		return nil
+	}
+	return &domain.Location{ID: id}
+}
+
+// CreateStock registers a new unit. A zero locationID leaves the unit without
+// a location until it is put away.
+func (uc *StockUseCase) CreateStock(ctx context.Context, actor *domain.User, productID int64, serial string, batch string, purchaseDate time.Time, providerID int64, locationID int64, createdByUserID int64) (*domain.Stock, error) {
+	auditUser, err := uc.resolveAuditUser(actor, createdByUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.requireSerialized(ctx, productID); err != nil {
+		return nil, err
+	}
+
 	stock := &domain.Stock{
 		Product: &domain.Product{
 			ID: productID,
 		},
 		Serial:       serial,
+		Status:       domain.StockAvailable,
 		Batch:        batch,
 		PurchaseDate: purchaseDate,
 		Provider: &domain.Provider{
 			ID: providerID,
 		},
-		CreatedByUser: &domain.User{
-			ID: createdByUserID,
-		},
-		UpdatedByUser: &domain.User{
-			ID: createdByUserID,
-		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Location:        locationRef(locationID),
+		CreatedByUser:   auditUser,
+		UpdatedByUser:   auditUser,
+		StatusChangedAt: time.Now(),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
-	if err := uc.stockRepo.Create(stock); err != nil {
+	err = uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := uc.stockRepo.Create(ctx, stock); err != nil {
+			return err
+		}
+		return uc.recordMovement(ctx, domain.MovementCreate, auditUser, nil, stock, "")
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return stock, nil
 }
 
-func (uc *StockUseCase) GetStock(id int64) (*domain.Stock, error) {
-	stock, err := uc.stockRepo.GetByID(id)
+// BulkStockInput describes units received together: they share product,
+// provider, batch, purchase date and location and differ by serial
+type BulkStockInput struct {
+	ProductID    int64
+	ProviderID   int64
+	LocationID   int64
+	Batch        string
+	PurchaseDate time.Time
+	Serials      []string
+	Mode         domain.BulkMode
+	Reason       string
+}
+
+// CreateStocks registers a unit for every serial of input in one transaction,
+// recording the creation of each. Every serial is checked up-front: empty
+// ones, repeated ones and those already in use, including by deleted units,
+// are rejected. In all-or-nothing mode a rejection creates no unit; in
+// best-effort mode the others are created anyway. The report tells the
+// outcome of every serial.
+func (uc *StockUseCase) CreateStocks(ctx context.Context, actor *domain.User, input BulkStockInput) (*domain.BulkStockReport, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	if input.Mode == "" {
+		input.Mode = domain.BulkAllOrNothing
+	}
+	switch {
+	case !input.Mode.IsValid():
+		return nil, &domain.InvalidBulkStockError{Reason: "unknown mode " + string(input.Mode)}
+	case len(input.Serials) == 0:
+		return nil, &domain.InvalidBulkStockError{Reason: "at least one serial is required"}
+	case len(input.Serials) > domain.MaxBulkStocks:
+		return nil, &domain.InvalidBulkStockError{Reason: fmt.Sprintf("at most %d serials can be created at once", domain.MaxBulkStocks)}
+	}
+	if err := uc.requireSerialized(ctx, input.ProductID); err != nil {
+		return nil, err
+	}
+
+	var report *domain.BulkStockReport
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		existing, err := uc.stockRepo.ExistingSerials(ctx, input.Serials)
+		if err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(existing))
+		for _, serial := range existing {
+			taken[serial] = true
+		}
+
+		report = &domain.BulkStockReport{Mode: input.Mode, Items: make([]domain.BulkStockItem, len(input.Serials))}
+		seen := make(map[string]bool, len(input.Serials))
+		var stocks []*domain.Stock
+		var created []int
+		for i, serial := range input.Serials {
+			item := &report.Items[i]
+			item.Serial = serial
+			switch {
+			case serial == "":
+				item.Error = "serial is required"
+			case seen[serial]:
+				item.Error = "serial " + serial + " is repeated in the request"
+			case taken[serial]:
+				item.Error = "stock with serial " + serial + " already exists"
+			default:
+				stocks = append(stocks, &domain.Stock{
+					Product: &domain.Product{
+						ID: input.ProductID,
+					},
+					Serial:       serial,
+					Status:       domain.StockAvailable,
+					Batch:        input.Batch,
+					PurchaseDate: input.PurchaseDate,
+					Provider: &domain.Provider{
+						ID: input.ProviderID,
+					},
+					Location:      locationRef(input.LocationID),
+					CreatedByUser: actor,
+					UpdatedByUser: actor,
+				})
+				created = append(created, i)
+			}
+			seen[serial] = true
+			if item.Error != "" {
+				item.Status = domain.BulkItemRejected
+				report.Rejected++
+			}
+		}
+
+		if report.Rejected > 0 && input.Mode == domain.BulkAllOrNothing {
+			for _, i := range created {
+				report.Items[i].Status = domain.BulkItemSkipped
+				report.Skipped++
+			}
+			return nil
+		}
+		if len(stocks) == 0 {
+			return nil
+		}
+
+		if err := uc.stockRepo.CreateMany(ctx, stocks); err != nil {
+			return err
+		}
+		movements := make([]*domain.StockMovement, len(stocks))
+		for j, stock := range stocks {
+			movements[j] = domain.NewStockMovement(domain.MovementCreate, actor, nil, stock, input.Reason)
+		}
+		if err := uc.movementRepo.CreateMany(ctx, movements); err != nil {
+			return err
+		}
+
+		for j, i := range created {
+			report.Items[i].Status = domain.BulkItemCreated
+			report.Items[i].StockID = stocks[j].ID
+		}
+		report.Created = len(stocks)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (uc *StockUseCase) GetStock(ctx context.Context, id int64) (*domain.Stock, error) {
+	stock, err := uc.stockRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -54,56 +249,320 @@ func (uc *StockUseCase) GetStock(id int64) (*domain.Stock, error) {
 	return stock, nil
 }
 
-func (uc *StockUseCase) GetAllStocks() ([]*domain.Stock, error) {
-	stocks, err := uc.stockRepo.GetAll()
+// GetAllStocks retrieves a page of the units matching filter, along with the
+// number of units matching it across all pages
+func (uc *StockUseCase) GetAllStocks(ctx context.Context, filter domain.StockFilter) ([]*domain.Stock, int64, error) {
+	if err := validateStockFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+
+	stocks, err := uc.stockRepo.GetAll(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	return uc.withTotal(ctx, stocks, filter)
+}
+
+// validateStockFilter checks the status and date range of a stock filter and
+// normalizes its list options
+func validateStockFilter(filter *domain.StockFilter) error {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return &domain.InvalidStockStatusError{Status: filter.Status}
+	}
+	if !filter.PurchasedAfter.IsZero() && !filter.PurchasedBefore.IsZero() && filter.PurchasedBefore.Before(filter.PurchasedAfter) {
+		return &domain.InvalidListOptionsError{Reason: "purchased_before must not be earlier than purchased_after"}
+	}
+	return filter.ListOptions.Normalize(domain.StockSortFields)
+}
+
+// withTotal counts the units matching filter and converts a page of them
+func (uc *StockUseCase) withTotal(ctx context.Context, stocks []domain.Stock, filter domain.StockFilter) ([]*domain.Stock, int64, error) {
+	total, err := uc.stockRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
 	}
 	result := make([]*domain.Stock, len(stocks))
-	for i, stock := range stocks {
-		result[i] = &stock
+	for i := range stocks {
+		result[i] = &stocks[i]
 	}
-	return result, nil
+	return result, total, nil
 }
 
-func (uc *StockUseCase) UpdateStock(stock *domain.Stock) error {
-	existingStock, err := uc.stockRepo.GetByID(stock.ID)
+// UpdateStock saves the changes to a unit on behalf of actor as of
+// stock.Version, or of its current version when zero, and records them in its
+// history
+func (uc *StockUseCase) UpdateStock(ctx context.Context, actor *domain.User, stock *domain.Stock, reason string) error {
+	var claimedUserID int64
+	if stock.UpdatedByUser != nil {
+		claimedUserID = stock.UpdatedByUser.ID
+	}
+	auditUser, err := uc.resolveAuditUser(actor, claimedUserID)
 	if err != nil {
 		return err
 	}
-	if existingStock == nil {
-		return &domain.StockNotFoundError{StockID: stock.ID}
+
+	return uc.uow.Do(ctx, func(ctx context.Context) error {
+		existingStock, err := uc.stockRepo.GetByID(ctx, stock.ID)
+		if err != nil {
+			return err
+		}
+		if existingStock == nil {
+			return &domain.StockNotFoundError{StockID: stock.ID}
+		}
+		if stock.Product != nil {
+			if err := uc.requireSerialized(ctx, stock.Product.ID); err != nil {
+				return err
+			}
+		}
+
+		// A unit may be put away here, but moving it afterwards needs a transfer
+		switch {
+		case stock.Location == nil:
+			stock.Location = existingStock.Location
+		case existingStock.Location != nil && existingStock.Location.ID != stock.Location.ID:
+			return &domain.StockLocationChangeError{StockID: stock.ID}
+		}
+		// Clients that don't send the purchase date keep it
+		if stock.PurchaseDate.IsZero() {
+			stock.PurchaseDate = existingStock.PurchaseDate
+		}
+
+		stock.Version = basedOn(stock.Version, existingStock.Version)
+		stock.Status = existingStock.Status
+		stock.StatusChangedAt = existingStock.StatusChangedAt
+		stock.UpdatedByUser = auditUser
+		stock.UpdatedAt = time.Now()
+		if err := uc.stockRepo.Update(ctx, stock); err != nil {
+			return err
+		}
+
+		return uc.recordMovement(ctx, domain.MovementUpdate, auditUser, existingStock, stock, reason)
+	})
+}
+
+// PatchStock applies patch to the unit id as of version, or of its current
+// version when zero, on behalf of actor. It saves the fields patch changed,
+// records them in the history of the unit and returns it as saved. patch
+// must replace the product, provider and location of the unit rather than
+// modify them. As in UpdateStock, a unit that has been put away keeps its
+// location.
+func (uc *StockUseCase) PatchStock(ctx context.Context, actor *domain.User, id, version int64, patch func(*domain.Stock) error, reason string) (*domain.Stock, error) {
+	auditUser, err := uc.resolveAuditUser(actor, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var stock *domain.Stock
+	err = uc.uow.Do(ctx, func(ctx context.Context) error {
+		existing, err := uc.stockRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &domain.StockNotFoundError{StockID: id}
+		}
+		if err := checkVersion("stock", id, version, existing.Version); err != nil {
+			return err
+		}
+
+		patched := *existing
+		if err := patch(&patched); err != nil {
+			return err
+		}
+		patched.ID, patched.Version = existing.ID, existing.Version
+		switch {
+		case patched.Product == nil || patched.Product.ID == 0:
+			return &domain.RequiredFieldError{Entity: "stock", Field: "product_id"}
+		case patched.Serial == "":
+			return &domain.RequiredFieldError{Entity: "stock", Field: "serial"}
+		case patched.Provider == nil || patched.Provider.ID == 0:
+			return &domain.RequiredFieldError{Entity: "stock", Field: "provider_id"}
+		case existing.Location != nil && (patched.Location == nil || patched.Location.ID != existing.Location.ID):
+			return &domain.StockLocationChangeError{StockID: id}
+		}
+
+		fields := stockChanges(existing, &patched)
+		if len(fields) == 0 {
+			stock = existing
+			return nil
+		}
+		if patched.Product.ID != existing.Product.ID {
+			if err := uc.requireSerialized(ctx, patched.Product.ID); err != nil {
+				return err
+			}
+		}
+		patched.UpdatedByUser = auditUser
+		if err := uc.stockRepo.Patch(ctx, &patched, fields); err != nil {
+			return err
+		}
+		if err := uc.recordMovement(ctx, domain.MovementUpdate, auditUser, existing, &patched, reason); err != nil {
+			return err
+		}
+		if stock, err = uc.stockRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if stock == nil {
+			return &domain.StockNotFoundError{StockID: id}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
+
+// TransitionStock moves a unit to a new lifecycle status on behalf of actor,
+// enforcing both the allowed transitions and the permission they require
+func (uc *StockUseCase) TransitionStock(ctx context.Context, actor *domain.User, id int64, to domain.StockStatus, reason string) (*domain.Stock, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	if !to.IsValid() {
+		return nil, &domain.InvalidStockStatusError{Status: to}
+	}
+
+	permission := domain.TransitionPermission(to)
+	if !domain.HasPermission(actor.Role, permission) {
+		return nil, &domain.ForbiddenError{Permission: permission}
 	}
 
-	stock.UpdatedAt = time.Now()
-	return uc.stockRepo.Update(stock)
+	var stock *domain.Stock
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		stock, err = uc.stockRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if stock == nil {
+			return &domain.StockNotFoundError{StockID: id}
+		}
+
+		if !stock.Status.CanTransitionTo(to) {
+			return &domain.InvalidStockTransitionError{From: stock.Status, To: to}
+		}
+
+		before := *stock
+		stock.Status = to
+		stock.UpdatedByUser = actor
+		if err := uc.stockRepo.UpdateStatus(ctx, stock); err != nil {
+			return err
+		}
+
+		return uc.recordMovement(ctx, domain.MovementTransition, actor, &before, stock, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
 }
 
-func (uc *StockUseCase) DeleteStock(id int64) error {
-	stock, err := uc.stockRepo.GetByID(id)
+// DeleteStock soft deletes a unit on behalf of actor and records the deletion
+// in its history. A non-zero version must be the current one.
+func (uc *StockUseCase) DeleteStock(ctx context.Context, actor *domain.User, id, version int64, reason string) error {
+	if actor == nil {
+		return &domain.MissingAuditUserError{}
+	}
+
+	return uc.uow.Do(ctx, func(ctx context.Context) error {
+		stock, err := uc.stockRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if stock == nil {
+			return &domain.StockNotFoundError{StockID: id}
+		}
+		if err := checkVersion("stock", id, version, stock.Version); err != nil {
+			return err
+		}
+		if err := uc.stockRepo.Delete(ctx, id, actor.ID); err != nil {
+			return err
+		}
+
+		return uc.recordMovement(ctx, domain.MovementDelete, actor, stock, nil, reason)
+	})
+}
+
+// RestoreStock brings back a soft-deleted unit on behalf of actor and records
+// the restoration in its history
+func (uc *StockUseCase) RestoreStock(ctx context.Context, actor *domain.User, id int64, reason string) (*domain.Stock, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+
+	var stock *domain.Stock
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := uc.stockRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		if stock, err = uc.stockRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if stock == nil {
+			return &domain.StockNotFoundError{StockID: id}
+		}
+
+		return uc.recordMovement(ctx, domain.MovementRestore, actor, nil, stock, reason)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if stock == nil {
-		return &domain.StockNotFoundError{StockID: id}
+	return stock, nil
+}
+
+func (uc *StockUseCase) GetStocksByProductID(ctx context.Context, productID int64, filter domain.StockFilter) ([]*domain.Stock, int64, error) {
+	if err := validateStockFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+
+	stocks, err := uc.stockRepo.GetByProductID(ctx, productID, filter)
+	if err != nil {
+		return nil, 0, err
 	}
-	return uc.stockRepo.Delete(id)
+	filter.ProductID = productID
+	return uc.withTotal(ctx, stocks, filter)
 }
 
-func (uc *StockUseCase) GetStocksByProductID(productID int64) ([]*domain.Stock, error) {
-	stocks, err := uc.stockRepo.GetByProductID(productID)
+// GetStockLocationsByProductID reports the on-hand quantity of a product at
+// each location, counting units or summing balances depending on how the
+// product is tracked
+func (uc *StockUseCase) GetStockLocationsByProductID(ctx context.Context, productID int64) ([]domain.LocationStockCount, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
-	result := make([]*domain.Stock, len(stocks))
-	for i, stock := range stocks {
-		result[i] = &stock
+	if product == nil {
+		return nil, &domain.ProductNotFoundError{ProductID: productID}
+	}
+
+	if product.TrackingMode == domain.TrackingQuantity {
+		return uc.balanceRepo.CountByLocation(ctx, productID)
+	}
+	return uc.stockRepo.CountByLocation(ctx, productID)
+}
+
+// GetOnHand reports the on-hand quantity of every product in stock, for both
+// serialized and quantity-tracked products, ordered by product ID
+func (uc *StockUseCase) GetOnHand(ctx context.Context, filter domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+	serialized, err := uc.stockRepo.OnHandByProduct(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	quantities, err := uc.balanceRepo.OnHandByProduct(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
-	return result, nil
+
+	onHand := append(serialized, quantities...)
+	sort.Slice(onHand, func(i, j int) bool {
+		return onHand[i].Product.ID < onHand[j].Product.ID
+	})
+	return onHand, nil
 }
 
-func (uc *StockUseCase) GetStockBySerial(serial string) (*domain.Stock, error) {
-	stock, err := uc.stockRepo.GetBySerial(serial)
+func (uc *StockUseCase) GetStockBySerial(ctx context.Context, serial string) (*domain.Stock, error) {
+	stock, err := uc.stockRepo.GetBySerial(ctx, serial)
 	if err != nil {
 		return nil, err
 	}
@@ -112,3 +571,40 @@ func (uc *StockUseCase) GetStockBySerial(serial string) (*domain.Stock, error) {
 	}
 	return stock, nil
 }
+
+// GetStockHistory returns the ledger of a unit, oldest entry first
+func (uc *StockUseCase) GetStockHistory(ctx context.Context, id int64) ([]domain.StockMovement, error) {
+	movements, err := uc.movementRepo.GetByStockID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(movements) == 0 {
+		// Units created before the ledger existed have no history yet
+		stock, err := uc.stockRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if stock == nil {
+			return nil, &domain.StockNotFoundError{StockID: id}
+		}
+	}
+	return movements, nil
+}
+
+// GetStockHistoryBySerial returns the ledger of every unit that carried serial
+func (uc *StockUseCase) GetStockHistoryBySerial(ctx context.Context, serial string) ([]domain.StockMovement, error) {
+	movements, err := uc.movementRepo.GetBySerial(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+	if len(movements) == 0 {
+		stock, err := uc.stockRepo.GetBySerial(ctx, serial)
+		if err != nil {
+			return nil, err
+		}
+		if stock == nil {
+			return nil, &domain.StockNotFoundError{Serial: serial}
+		}
+	}
+	return movements, nil
+}
@@ -0,0 +1,593 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransitionStock(t *testing.T) {
+	tests := []struct {
+		name          string
+		actor         *domain.User
+		from          domain.StockStatus
+		to            domain.StockStatus
+		expectedError error
+	}{
+		{
+			name:          "reserve an available unit",
+			actor:         &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			from:          domain.StockAvailable,
+			to:            domain.StockReserved,
+			expectedError: nil,
+		},
+		{
+			name:          "sell a reserved unit",
+			actor:         &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			from:          domain.StockReserved,
+			to:            domain.StockSold,
+			expectedError: nil,
+		},
+		{
+			name:          "scrapped units are final",
+			actor:         &domain.User{ID: 1, Role: domain.RoleAdmin},
+			from:          domain.StockScrapped,
+			to:            domain.StockAvailable,
+			expectedError: &domain.InvalidStockTransitionError{From: domain.StockScrapped, To: domain.StockAvailable},
+		},
+		{
+			name:          "reserved units cannot go to repair",
+			actor:         &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			from:          domain.StockReserved,
+			to:            domain.StockInRepair,
+			expectedError: &domain.InvalidStockTransitionError{From: domain.StockReserved, To: domain.StockInRepair},
+		},
+		{
+			name:          "only admins may scrap",
+			actor:         &domain.User{ID: 1, Role: domain.RoleWarehouse},
+			from:          domain.StockInRepair,
+			to:            domain.StockScrapped,
+			expectedError: &domain.ForbiddenError{Permission: domain.PermStocksScrap},
+		},
+		{
+			name:          "admin scraps a unit in repair",
+			actor:         &domain.User{ID: 1, Role: domain.RoleAdmin},
+			from:          domain.StockInRepair,
+			to:            domain.StockScrapped,
+			expectedError: nil,
+		},
+		{
+			name:          "unknown status",
+			actor:         &domain.User{ID: 1, Role: domain.RoleAdmin},
+			from:          domain.StockAvailable,
+			to:            "lost",
+			expectedError: &domain.InvalidStockStatusError{Status: "lost"},
+		},
+		{
+			name:          "unauthenticated caller",
+			actor:         nil,
+			from:          domain.StockAvailable,
+			to:            domain.StockReserved,
+			expectedError: &domain.MissingAuditUserError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *domain.Stock
+			mockRepo := &repository.MockStockRepository{
+				GetByIDFunc: func(id int64) (*domain.Stock, error) {
+					return &domain.Stock{ID: id, Serial: "SERIAL123", Status: tt.from}, nil
+				},
+				UpdateStatusFunc: func(s *domain.Stock) error {
+					updated = s
+					return nil
+				},
+			}
+			useCase := NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+			stock, err := useCase.TransitionStock(context.Background(), tt.actor, 1, tt.to, "")
+			if err != nil {
+				if tt.expectedError == nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if updated != nil {
+					t.Errorf("expected no status update on error")
+				}
+				return
+			}
+			if tt.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tt.expectedError)
+			}
+
+			if stock.Status != tt.to || updated == nil || updated.Status != tt.to {
+				t.Errorf("expected status %s to be persisted", tt.to)
+			}
+			if stock.UpdatedByUser.ID != tt.actor.ID {
+				t.Errorf("expected updated by user %d, got %d", tt.actor.ID, stock.UpdatedByUser.ID)
+			}
+		})
+	}
+}
+
+func TestGetAllStocksRejectsUnknownStatus(t *testing.T) {
+	useCase := NewStockUseCase(&repository.MockStockRepository{}, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	if _, _, err := useCase.GetAllStocks(context.Background(), domain.StockFilter{Status: "lost"}); err == nil {
+		t.Errorf("expected unknown status filter to be rejected")
+	}
+}
+
+func TestStockChangesAreRecordedInLedger(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	existing := &domain.Stock{
+		ID:       1,
+		Serial:   "SERIAL123",
+		Status:   domain.StockAvailable,
+		Batch:    "BATCH001",
+		Product:  &domain.Product{ID: 1},
+		Provider: &domain.Provider{ID: 1},
+	}
+
+	var movements []*domain.StockMovement
+	mockMovements := &repository.MockStockMovementRepository{
+		CreateFunc: func(m *domain.StockMovement) error {
+			movements = append(movements, m)
+			return nil
+		},
+	}
+	mockRepo := &repository.MockStockRepository{
+		CreateFunc: func(s *domain.Stock) error {
+			s.ID = 1
+			return nil
+		},
+		GetByIDFunc: func(id int64) (*domain.Stock, error) {
+			copied := *existing
+			return &copied, nil
+		},
+	}
+	useCase := NewStockUseCase(mockRepo, mockMovements, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	if _, err := useCase.CreateStock(context.Background(), actor, 1, "SERIAL123", "BATCH001", time.Time{}, 1, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := &domain.Stock{ID: 1, Serial: "SERIAL123", Batch: "BATCH002", Product: &domain.Product{ID: 1}, Provider: &domain.Provider{ID: 1}}
+	if err := useCase.UpdateStock(context.Background(), actor, updated, "relabelled"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.TransitionStock(context.Background(), actor, 1, domain.StockReserved, "customer order"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := useCase.DeleteStock(context.Background(), actor, 1, 0, "duplicate entry"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := useCase.RestoreStock(context.Background(), actor, 1, "deleted by mistake"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedTypes := []domain.StockMovementType{
+		domain.MovementCreate,
+		domain.MovementUpdate,
+		domain.MovementTransition,
+		domain.MovementDelete,
+		domain.MovementRestore,
+	}
+	if len(movements) != len(expectedTypes) {
+		t.Fatalf("expected %d movements, got %d", len(expectedTypes), len(movements))
+	}
+	for i, movement := range movements {
+		if movement.Type != expectedTypes[i] {
+			t.Errorf("movement %d: expected type %s, got %s", i, expectedTypes[i], movement.Type)
+		}
+		if movement.Actor == nil || movement.Actor.ID != actor.ID {
+			t.Errorf("movement %d: expected actor %d", i, actor.ID)
+		}
+		if movement.StockID != 1 {
+			t.Errorf("movement %d: expected stock ID 1, got %d", i, movement.StockID)
+		}
+	}
+
+	if movements[0].Before != nil || movements[0].After == nil {
+		t.Errorf("create movement should only have an after state")
+	}
+	if movements[1].Before.Batch != "BATCH001" || movements[1].After.Batch != "BATCH002" || movements[1].Reason != "relabelled" {
+		t.Errorf("update movement did not capture the change: %+v", movements[1])
+	}
+	if movements[2].Before.Status != domain.StockAvailable || movements[2].After.Status != domain.StockReserved {
+		t.Errorf("transition movement did not capture the status change: %+v", movements[2])
+	}
+	if movements[3].Before == nil || movements[3].After != nil {
+		t.Errorf("delete movement should only have a before state")
+	}
+	if movements[4].Before != nil || movements[4].After == nil || movements[4].Reason != "deleted by mistake" {
+		t.Errorf("restore movement should only have an after state: %+v", movements[4])
+	}
+}
+
+func TestGetStockHistory(t *testing.T) {
+	mockRepo := &repository.MockStockRepository{
+		GetByIDFunc: func(id int64) (*domain.Stock, error) {
+			return nil, nil
+		},
+	}
+	mockMovements := &repository.MockStockMovementRepository{
+		GetByStockIDFunc: func(id int64) ([]domain.StockMovement, error) {
+			if id == 1 {
+				return []domain.StockMovement{{ID: 1, StockID: 1, Type: domain.MovementCreate}}, nil
+			}
+			return nil, nil
+		},
+	}
+	useCase := NewStockUseCase(mockRepo, mockMovements, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	movements, err := useCase.GetStockHistory(context.Background(), 1)
+	if err != nil || len(movements) != 1 {
+		t.Errorf("expected 1 movement, got %d (err %v)", len(movements), err)
+	}
+
+	if _, err := useCase.GetStockHistory(context.Background(), 999); err == nil {
+		t.Errorf("expected not found error for unknown stock")
+	}
+}
+
+func TestCreateStockRejectsQuantityProducts(t *testing.T) {
+	created := false
+	stockRepo := &repository.MockStockRepository{
+		CreateFunc: func(stock *domain.Stock) error {
+			created = true
+			return nil
+		},
+	}
+	productRepo := &repository.MockProductRepository{
+		GetByIDFunc: func(id int64) (*domain.Product, error) {
+			return &domain.Product{ID: id, TrackingMode: domain.TrackingQuantity}, nil
+		},
+	}
+	useCase := NewStockUseCase(stockRepo, &repository.MockStockMovementRepository{}, productRepo, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	_, err := useCase.CreateStock(context.Background(), &domain.User{ID: 1}, 3, "SN-1", "B1", time.Now(), 1, 0, 0)
+	expected := &domain.TrackingModeMismatchError{ProductID: 3, Mode: domain.TrackingQuantity}
+	if err == nil || err.Error() != expected.Error() {
+		t.Fatalf("expected error %v, got %v", expected, err)
+	}
+	if created {
+		t.Error("expected no unit to be created")
+	}
+}
+
+func TestGetOnHand(t *testing.T) {
+	filter := domain.StockBalanceFilter{WarehouseID: 2}
+	stockRepo := &repository.MockStockRepository{
+		OnHandByProductFunc: func(f domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+			if f != filter {
+				t.Errorf("expected filter %+v, got %+v", filter, f)
+			}
+			return []domain.ProductOnHand{
+				{Product: &domain.Product{ID: 1, TrackingMode: domain.TrackingSerialized}, Quantity: 3},
+				{Product: &domain.Product{ID: 4, TrackingMode: domain.TrackingSerialized}, Quantity: 1},
+			}, nil
+		},
+	}
+	balanceRepo := &repository.MockStockBalanceRepository{
+		OnHandByProductFunc: func(f domain.StockBalanceFilter) ([]domain.ProductOnHand, error) {
+			return []domain.ProductOnHand{
+				{Product: &domain.Product{ID: 2, TrackingMode: domain.TrackingQuantity}, Quantity: 250},
+			}, nil
+		},
+	}
+	useCase := NewStockUseCase(stockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, balanceRepo, repository.NewMemoryUnitOfWork(), false)
+
+	onHand, err := useCase.GetOnHand(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		productID int64
+		quantity  int64
+	}{{1, 3}, {2, 250}, {4, 1}}
+	if len(onHand) != len(expected) {
+		t.Fatalf("expected %d products, got %d", len(expected), len(onHand))
+	}
+	for i, e := range expected {
+		if onHand[i].Product.ID != e.productID || onHand[i].Quantity != e.quantity {
+			t.Errorf("entry %d: expected product %d with %d, got %+v", i, e.productID, e.quantity, onHand[i])
+		}
+	}
+}
+
+func TestGetStockLocationsByProductIDUsesBalances(t *testing.T) {
+	productRepo := &repository.MockProductRepository{
+		GetByIDFunc: func(id int64) (*domain.Product, error) {
+			return &domain.Product{ID: id, TrackingMode: domain.TrackingQuantity}, nil
+		},
+	}
+	stockRepo := &repository.MockStockRepository{
+		CountByLocationFunc: func(int64) ([]domain.LocationStockCount, error) {
+			t.Error("expected units not to be counted for a quantity-tracked product")
+			return nil, nil
+		},
+	}
+	balanceRepo := &repository.MockStockBalanceRepository{
+		CountByLocationFunc: func(productID int64) ([]domain.LocationStockCount, error) {
+			return []domain.LocationStockCount{{Location: &domain.Location{ID: 7}, Quantity: 40}}, nil
+		},
+	}
+	useCase := NewStockUseCase(stockRepo, &repository.MockStockMovementRepository{}, productRepo, balanceRepo, repository.NewMemoryUnitOfWork(), false)
+
+	counts, err := useCase.GetStockLocationsByProductID(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(counts) != 1 || counts[0].Quantity != 40 {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+}
+
+func TestUpdateStockKeepsPurchaseDate(t *testing.T) {
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	var updated *domain.Stock
+	mockRepo := &repository.MockStockRepository{
+		GetByIDFunc: func(id int64) (*domain.Stock, error) {
+			return &domain.Stock{ID: id, Serial: "SERIAL123", PurchaseDate: purchased, Product: &domain.Product{ID: 1}, Provider: &domain.Provider{ID: 1}}, nil
+		},
+		UpdateFunc: func(s *domain.Stock) error {
+			updated = s
+			return nil
+		},
+	}
+	useCase := NewStockUseCase(mockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	stock := &domain.Stock{ID: 1, Serial: "SERIAL123", Batch: "BATCH002", Product: &domain.Product{ID: 1}, Provider: &domain.Provider{ID: 1}}
+	if err := useCase.UpdateStock(context.Background(), &domain.User{ID: 5}, stock, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated == nil || !updated.PurchaseDate.Equal(purchased) {
+		t.Errorf("expected the purchase date %v to be kept, got %+v", purchased, updated)
+	}
+}
+
+func TestPatchStock(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	existing := func() *domain.Stock {
+		return &domain.Stock{
+			ID:           1,
+			Serial:       "SERIAL123",
+			Batch:        "BATCH001",
+			PurchaseDate: purchased,
+			Product:      &domain.Product{ID: 1},
+			Provider:     &domain.Provider{ID: 1},
+			Location:     &domain.Location{ID: 4},
+			Version:      2,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		version        int64
+		patch          func(*domain.Stock)
+		expectedFields []string
+		expectedError  error
+	}{
+		{
+			name:           "batch and purchase date",
+			patch:          func(s *domain.Stock) { s.Batch, s.PurchaseDate = "BATCH002", time.Time{} },
+			expectedFields: []string{"batch", "purchase_date"},
+		},
+		{
+			name:           "provider as of the current version",
+			version:        2,
+			patch:          func(s *domain.Stock) { s.Provider = &domain.Provider{ID: 2} },
+			expectedFields: []string{"provider_id"},
+		},
+		{
+			name:  "nothing changed",
+			patch: func(s *domain.Stock) { s.Serial = "SERIAL123" },
+		},
+		{
+			name: "same purchase day at another offset",
+			patch: func(s *domain.Stock) {
+				s.PurchaseDate = time.Date(2024, time.March, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+			},
+		},
+		{
+			name:           "purchase date moved a day",
+			patch:          func(s *domain.Stock) { s.PurchaseDate = purchased.AddDate(0, 0, 1) },
+			expectedFields: []string{"purchase_date"},
+		},
+		{
+			name:          "location removed",
+			patch:         func(s *domain.Stock) { s.Location = nil },
+			expectedError: &domain.StockLocationChangeError{StockID: 1},
+		},
+		{
+			name:          "serial removed",
+			patch:         func(s *domain.Stock) { s.Serial = "" },
+			expectedError: &domain.RequiredFieldError{Entity: "stock", Field: "serial"},
+		},
+		{
+			name:          "stale version",
+			version:       1,
+			patch:         func(s *domain.Stock) { s.Batch = "BATCH002" },
+			expectedError: &domain.VersionMismatchError{Entity: "stock", ID: 1, Version: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			var movements []*domain.StockMovement
+			mockRepo := &repository.MockStockRepository{
+				GetByIDFunc: func(id int64) (*domain.Stock, error) {
+					return existing(), nil
+				},
+				PatchFunc: func(s *domain.Stock, f []string) error {
+					if s.UpdatedByUser != actor {
+						t.Errorf("expected the patch to be recorded as by the actor, got %+v", s.UpdatedByUser)
+					}
+					fields = f
+					return nil
+				},
+			}
+			mockMovements := &repository.MockStockMovementRepository{
+				CreateFunc: func(m *domain.StockMovement) error {
+					movements = append(movements, m)
+					return nil
+				},
+			}
+			useCase := NewStockUseCase(mockRepo, mockMovements, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+			_, err := useCase.PatchStock(context.Background(), actor, 1, tt.version, func(s *domain.Stock) error {
+				tt.patch(s)
+				return nil
+			}, "relabelled")
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(fields, tt.expectedFields) {
+				t.Errorf("expected fields %v to be saved, got %v", tt.expectedFields, fields)
+			}
+			switch {
+			case tt.expectedFields == nil && len(movements) != 0:
+				t.Errorf("expected nothing to be recorded, got %d movements", len(movements))
+			case tt.expectedFields != nil && (len(movements) != 1 || movements[0].Type != domain.MovementUpdate || movements[0].Reason != "relabelled"):
+				t.Errorf("expected the patch to be recorded as an update, got %+v", movements)
+			}
+		})
+	}
+}
+
+func TestCreateStocks(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	purchased := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		serials          []string
+		mode             domain.BulkMode
+		expectedStatuses []domain.BulkItemStatus
+		expectedCreated  []string
+		expectedError    error
+	}{
+		{
+			name:             "all created",
+			serials:          []string{"SN-1", "SN-2"},
+			expectedStatuses: []domain.BulkItemStatus{domain.BulkItemCreated, domain.BulkItemCreated},
+			expectedCreated:  []string{"SN-1", "SN-2"},
+		},
+		{
+			name:    "all or nothing with rejections",
+			serials: []string{"SN-1", "SN-1", "SN-TAKEN", "", "SN-2"},
+			expectedStatuses: []domain.BulkItemStatus{
+				domain.BulkItemSkipped, domain.BulkItemRejected, domain.BulkItemRejected, domain.BulkItemRejected, domain.BulkItemSkipped,
+			},
+		},
+		{
+			name:    "best effort with rejections",
+			serials: []string{"SN-1", "SN-1", "SN-TAKEN", "", "SN-2"},
+			mode:    domain.BulkBestEffort,
+			expectedStatuses: []domain.BulkItemStatus{
+				domain.BulkItemCreated, domain.BulkItemRejected, domain.BulkItemRejected, domain.BulkItemRejected, domain.BulkItemCreated,
+			},
+			expectedCreated: []string{"SN-1", "SN-2"},
+		},
+		{
+			name:          "no serials",
+			expectedError: &domain.InvalidBulkStockError{Reason: "at least one serial is required"},
+		},
+		{
+			name:          "unknown mode",
+			serials:       []string{"SN-1"},
+			mode:          "some",
+			expectedError: &domain.InvalidBulkStockError{Reason: "unknown mode some"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []string
+			var movements []*domain.StockMovement
+			stockRepo := &repository.MockStockRepository{
+				ExistingSerialsFunc: func(serials []string) ([]string, error) {
+					return []string{"SN-TAKEN"}, nil
+				},
+				CreateManyFunc: func(stocks []*domain.Stock) error {
+					for i, s := range stocks {
+						if s.Batch != "B1" || !s.PurchaseDate.Equal(purchased) || s.Provider.ID != 2 || s.Location.ID != 4 || s.CreatedByUser != actor {
+							t.Errorf("expected the shared fields to be set, got %+v", s)
+						}
+						s.ID = int64(i + 1)
+						created = append(created, s.Serial)
+					}
+					return nil
+				},
+			}
+			mockMovements := &repository.MockStockMovementRepository{
+				CreateManyFunc: func(m []*domain.StockMovement) error {
+					movements = m
+					return nil
+				},
+			}
+			useCase := NewStockUseCase(stockRepo, mockMovements, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+			report, err := useCase.CreateStocks(context.Background(), actor, BulkStockInput{
+				ProductID:    1,
+				ProviderID:   2,
+				LocationID:   4,
+				Batch:        "B1",
+				PurchaseDate: purchased,
+				Serials:      tt.serials,
+				Mode:         tt.mode,
+				Reason:       "shipment",
+			})
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var statuses []domain.BulkItemStatus
+			for _, item := range report.Items {
+				statuses = append(statuses, item.Status)
+				if (item.Status == domain.BulkItemRejected) == (item.Error == "") {
+					t.Errorf("expected only rejected items to carry an error, got %+v", item)
+				}
+				if (item.Status == domain.BulkItemCreated) == (item.StockID == 0) {
+					t.Errorf("expected only created items to carry a stock ID, got %+v", item)
+				}
+			}
+			if !reflect.DeepEqual(statuses, tt.expectedStatuses) {
+				t.Errorf("expected statuses %v, got %v", tt.expectedStatuses, statuses)
+			}
+			if !reflect.DeepEqual(created, tt.expectedCreated) || report.Created != len(tt.expectedCreated) {
+				t.Errorf("expected %v to be created, got %v (reported %d)", tt.expectedCreated, created, report.Created)
+			}
+			if len(movements) != len(created) {
+				t.Errorf("expected a movement per unit created, got %d", len(movements))
+			}
+			for _, m := range movements {
+				if m.Type != domain.MovementCreate || m.Reason != "shipment" || m.Actor != actor {
+					t.Errorf("expected the creation to be recorded, got %+v", m)
+				}
+			}
+		})
+	}
+}
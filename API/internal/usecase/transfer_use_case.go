@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"strconv"
+	"time"
+)
+
+type TransferUseCase struct {
+	transferRepo domain.ITransferRepository
+	stockRepo    domain.IStockRepository
+	locationRepo domain.ILocationRepository
+	uow          domain.UnitOfWork
+}
+
+func NewTransferUseCase(transferRepo domain.ITransferRepository, stockRepo domain.IStockRepository, locationRepo domain.ILocationRepository, uow domain.UnitOfWork) *TransferUseCase {
+	return &TransferUseCase{
+		transferRepo: transferRepo,
+		stockRepo:    stockRepo,
+		locationRepo: locationRepo,
+		uow:          uow,
+	}
+}
+
+func (uc *TransferUseCase) getLocation(ctx context.Context, id int64) (*domain.Location, error) {
+	location, err := uc.locationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, &domain.LocationNotFoundError{LocationID: id}
+	}
+	return location, nil
+}
+
+// atSource returns the unit behind item, checking it is still at the source
+// location of transfer
+func (uc *TransferUseCase) atSource(ctx context.Context, transfer *domain.Transfer, item domain.TransferItem) (*domain.Stock, error) {
+	stock, err := uc.stockRepo.GetByID(ctx, item.StockID)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil {
+		return nil, &domain.StockNotFoundError{StockID: item.StockID, Serial: item.Serial}
+	}
+	if stock.Location == nil || stock.Location.ID != transfer.Source.ID {
+		return nil, &domain.TransferConflictError{Serial: item.Serial}
+	}
+	return stock, nil
+}
+
+// CreateTransfer drafts a transfer of the units with the given serials. Every
+// unit must currently be at the source location.
+func (uc *TransferUseCase) CreateTransfer(ctx context.Context, actor *domain.User, sourceID, destinationID int64, serials []string, notes string) (*domain.Transfer, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+	if sourceID == destinationID {
+		return nil, &domain.InvalidTransferError{Reason: "source and destination must differ"}
+	}
+	if len(serials) == 0 {
+		return nil, &domain.InvalidTransferError{Reason: "at least one serial is required"}
+	}
+
+	// The units are checked at the source and the transfer stored in one
+	// transaction
+	var transfer *domain.Transfer
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		source, err := uc.getLocation(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+		destination, err := uc.getLocation(ctx, destinationID)
+		if err != nil {
+			return err
+		}
+
+		transfer = &domain.Transfer{
+			Source:        source,
+			Destination:   destination,
+			Status:        domain.TransferDraft,
+			Notes:         notes,
+			CreatedByUser: actor,
+		}
+
+		seen := make(map[string]bool, len(serials))
+		for _, serial := range serials {
+			if seen[serial] {
+				return &domain.InvalidTransferError{Reason: "serial " + serial + " is listed twice"}
+			}
+			seen[serial] = true
+
+			stock, err := uc.stockRepo.GetBySerial(ctx, serial)
+			if err != nil {
+				return err
+			}
+			if stock == nil {
+				return &domain.StockNotFoundError{Serial: serial}
+			}
+			if stock.Location == nil || stock.Location.ID != source.ID {
+				return &domain.TransferConflictError{Serial: serial}
+			}
+			transfer.Items = append(transfer.Items, domain.TransferItem{StockID: stock.ID, Serial: serial})
+		}
+
+		return uc.transferRepo.Create(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (uc *TransferUseCase) GetTransfer(ctx context.Context, id int64) (*domain.Transfer, error) {
+	transfer, err := uc.transferRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, &domain.TransferNotFoundError{TransferID: id}
+	}
+	return transfer, nil
+}
+
+func (uc *TransferUseCase) GetAllTransfers(ctx context.Context, filter domain.TransferFilter) ([]domain.Transfer, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, &domain.InvalidTransferStatusError{Status: filter.Status}
+	}
+	return uc.transferRepo.GetAll(ctx, filter)
+}
+
+// DispatchTransfer marks a draft transfer as in transit. The units stay at
+// the source until the transfer is received.
+func (uc *TransferUseCase) DispatchTransfer(ctx context.Context, actor *domain.User, id int64) (*domain.Transfer, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+
+	// The transfer is read, its units checked and its status changed in one
+	// transaction
+	var transfer *domain.Transfer
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		transfer, err = uc.GetTransfer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !transfer.Status.CanTransitionTo(domain.TransferInTransit) {
+			return &domain.InvalidTransferTransitionError{From: transfer.Status, To: domain.TransferInTransit}
+		}
+
+		for _, item := range transfer.Items {
+			if _, err := uc.atSource(ctx, transfer, item); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		transfer.Status = domain.TransferInTransit
+		transfer.DispatchedAt = &now
+		return uc.transferRepo.UpdateStatus(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ReceiveTransfer moves every unit of an in-transit transfer to its
+// destination in a single transaction, recording a movement per unit
+func (uc *TransferUseCase) ReceiveTransfer(ctx context.Context, actor *domain.User, id int64) (*domain.Transfer, error) {
+	if actor == nil {
+		return nil, &domain.MissingAuditUserError{}
+	}
+
+	// The movements are built from the units read in the transaction that
+	// moves them, so that they match what is stored
+	var transfer *domain.Transfer
+	err := uc.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		transfer, err = uc.GetTransfer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !transfer.Status.CanTransitionTo(domain.TransferReceived) {
+			return &domain.InvalidTransferTransitionError{From: transfer.Status, To: domain.TransferReceived}
+		}
+
+		reason := "transfer " + strconv.FormatInt(transfer.ID, 10)
+		movements := make([]*domain.StockMovement, 0, len(transfer.Items))
+		for _, item := range transfer.Items {
+			stock, err := uc.atSource(ctx, transfer, item)
+			if err != nil {
+				return err
+			}
+			moved := *stock
+			moved.Location = transfer.Destination
+			moved.UpdatedByUser = actor
+			movements = append(movements, domain.NewStockMovement(domain.MovementTransfer, actor, stock, &moved, reason))
+		}
+
+		return uc.transferRepo.Receive(ctx, transfer, actor, movements)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+	"inventario/internal/infrastructure/repository"
+	"testing"
+)
+
+// transferFixture returns repositories holding two locations and two units at location 1
+func transferFixture() (*repository.MockStockRepository, *repository.MockLocationRepository) {
+	stocks := map[int64]*domain.Stock{
+		1: {ID: 1, Serial: "SERIAL1", Location: &domain.Location{ID: 1}},
+		2: {ID: 2, Serial: "SERIAL2", Location: &domain.Location{ID: 1}},
+		3: {ID: 3, Serial: "SERIAL3", Location: &domain.Location{ID: 2}},
+	}
+	stockRepo := &repository.MockStockRepository{
+		GetByIDFunc: func(id int64) (*domain.Stock, error) {
+			if s, ok := stocks[id]; ok {
+				copied := *s
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		GetBySerialFunc: func(serial string) (*domain.Stock, error) {
+			for _, s := range stocks {
+				if s.Serial == serial {
+					copied := *s
+					return &copied, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	locationRepo := &repository.MockLocationRepository{
+		GetByIDFunc: func(id int64) (*domain.Location, error) {
+			if id == 1 || id == 2 {
+				return &domain.Location{ID: id}, nil
+			}
+			return nil, nil
+		},
+	}
+	return stockRepo, locationRepo
+}
+
+func TestCreateTransfer(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	tests := []struct {
+		name          string
+		actor         *domain.User
+		source        int64
+		destination   int64
+		serials       []string
+		expectedError error
+	}{
+		{
+			name:        "draft transfer",
+			actor:       actor,
+			source:      1,
+			destination: 2,
+			serials:     []string{"SERIAL1", "SERIAL2"},
+		},
+		{
+			name:          "same source and destination",
+			actor:         actor,
+			source:        1,
+			destination:   1,
+			serials:       []string{"SERIAL1"},
+			expectedError: &domain.InvalidTransferError{Reason: "source and destination must differ"},
+		},
+		{
+			name:          "no serials",
+			actor:         actor,
+			source:        1,
+			destination:   2,
+			expectedError: &domain.InvalidTransferError{Reason: "at least one serial is required"},
+		},
+		{
+			name:          "serial listed twice",
+			actor:         actor,
+			source:        1,
+			destination:   2,
+			serials:       []string{"SERIAL1", "SERIAL1"},
+			expectedError: &domain.InvalidTransferError{Reason: "serial SERIAL1 is listed twice"},
+		},
+		{
+			name:          "unknown destination",
+			actor:         actor,
+			source:        1,
+			destination:   9,
+			serials:       []string{"SERIAL1"},
+			expectedError: &domain.LocationNotFoundError{LocationID: 9},
+		},
+		{
+			name:          "unit at another location",
+			actor:         actor,
+			source:        1,
+			destination:   2,
+			serials:       []string{"SERIAL1", "SERIAL3"},
+			expectedError: &domain.TransferConflictError{Serial: "SERIAL3"},
+		},
+		{
+			name:          "unauthenticated caller",
+			source:        1,
+			destination:   2,
+			serials:       []string{"SERIAL1"},
+			expectedError: &domain.MissingAuditUserError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stockRepo, locationRepo := transferFixture()
+			var created *domain.Transfer
+			transferRepo := &repository.MockTransferRepository{
+				CreateFunc: func(transfer *domain.Transfer) error {
+					created = transfer
+					return nil
+				},
+			}
+			useCase := NewTransferUseCase(transferRepo, stockRepo, locationRepo, repository.NewMemoryUnitOfWork())
+
+			transfer, err := useCase.CreateTransfer(context.Background(), tt.actor, tt.source, tt.destination, tt.serials, "")
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if created != nil {
+					t.Error("expected no transfer to be stored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if transfer.Status != domain.TransferDraft {
+				t.Errorf("expected status %s, got %s", domain.TransferDraft, transfer.Status)
+			}
+			if len(transfer.Items) != len(tt.serials) {
+				t.Errorf("expected %d items, got %d", len(tt.serials), len(transfer.Items))
+			}
+		})
+	}
+}
+
+func TestReceiveTransfer(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	tests := []struct {
+		name          string
+		status        domain.TransferStatus
+		items         []domain.TransferItem
+		expectedError error
+	}{
+		{
+			name:   "in-transit transfer is received",
+			status: domain.TransferInTransit,
+			items:  []domain.TransferItem{{StockID: 1, Serial: "SERIAL1"}, {StockID: 2, Serial: "SERIAL2"}},
+		},
+		{
+			name:          "draft must be dispatched first",
+			status:        domain.TransferDraft,
+			items:         []domain.TransferItem{{StockID: 1, Serial: "SERIAL1"}},
+			expectedError: &domain.InvalidTransferTransitionError{From: domain.TransferDraft, To: domain.TransferReceived},
+		},
+		{
+			name:          "unit moved away meanwhile",
+			status:        domain.TransferInTransit,
+			items:         []domain.TransferItem{{StockID: 1, Serial: "SERIAL1"}, {StockID: 3, Serial: "SERIAL3"}},
+			expectedError: &domain.TransferConflictError{Serial: "SERIAL3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stockRepo, locationRepo := transferFixture()
+			var received []*domain.StockMovement
+			transferRepo := &repository.MockTransferRepository{
+				GetByIDFunc: func(id int64) (*domain.Transfer, error) {
+					return &domain.Transfer{
+						ID:          id,
+						Source:      &domain.Location{ID: 1},
+						Destination: &domain.Location{ID: 2},
+						Status:      tt.status,
+						Items:       tt.items,
+					}, nil
+				},
+				ReceiveFunc: func(transfer *domain.Transfer, user *domain.User, movements []*domain.StockMovement) error {
+					received = movements
+					return nil
+				},
+			}
+			useCase := NewTransferUseCase(transferRepo, stockRepo, locationRepo, repository.NewMemoryUnitOfWork())
+
+			_, err := useCase.ReceiveTransfer(context.Background(), actor, 7)
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if received != nil {
+					t.Error("expected no unit to be moved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(received) != len(tt.items) {
+				t.Fatalf("expected %d movements, got %d", len(tt.items), len(received))
+			}
+			for _, m := range received {
+				if m.Type != domain.MovementTransfer || m.Actor != actor {
+					t.Errorf("unexpected movement %+v", m)
+				}
+				if m.Before.LocationID != 1 || m.After.LocationID != 2 {
+					t.Errorf("expected move from 1 to 2, got %d to %d", m.Before.LocationID, m.After.LocationID)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateStockCannotMovePlacedUnit(t *testing.T) {
+	actor := &domain.User{ID: 5, Role: domain.RoleWarehouse}
+	stockRepo, _ := transferFixture()
+	updated := false
+	stockRepo.UpdateFunc = func(*domain.Stock) error {
+		updated = true
+		return nil
+	}
+	useCase := NewStockUseCase(stockRepo, &repository.MockStockMovementRepository{}, &repository.MockProductRepository{}, &repository.MockStockBalanceRepository{}, repository.NewMemoryUnitOfWork(), false)
+
+	stock := &domain.Stock{ID: 1, Serial: "SERIAL1", Location: &domain.Location{ID: 2}}
+	err := useCase.UpdateStock(context.Background(), actor, stock, "")
+	if _, ok := err.(*domain.StockLocationChangeError); !ok {
+		t.Fatalf("expected StockLocationChangeError, got %v", err)
+	}
+	if updated {
+		t.Error("expected unit not to be updated")
+	}
+
+	stock = &domain.Stock{ID: 1, Serial: "SERIAL1"}
+	if err := useCase.UpdateStock(context.Background(), actor, stock, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stock.Location == nil || stock.Location.ID != 1 {
+		t.Errorf("expected omitted location to be preserved, got %+v", stock.Location)
+	}
+}
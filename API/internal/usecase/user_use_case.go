@@ -1,23 +1,50 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
 	"inventario/internal/domain"
 	"time"
 )
 
 type UserUseCase struct {
-	userRepo domain.IUserRepository
+	userRepo       domain.IUserRepository
+	passwordHasher domain.IPasswordHasher
+	passwordPolicy domain.PasswordPolicy
+	stockRepo      domain.IStockRepository
+	uow            domain.UnitOfWork
 }
 
-func NewUserUseCase(userRepo domain.IUserRepository) *UserUseCase {
+func NewUserUseCase(userRepo domain.IUserRepository, passwordHasher domain.IPasswordHasher, passwordPolicy domain.PasswordPolicy, stockRepo domain.IStockRepository, uow domain.UnitOfWork) *UserUseCase {
 	return &UserUseCase{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		stockRepo:      stockRepo,
+		uow:            uow,
 	}
 }
 
-func (u *UserUseCase) CreateUser(name, email, role, password string) (*domain.User, error) {
+// hashPassword validates password against the policy and returns its hash
+func (u *UserUseCase) hashPassword(password string) (string, error) {
+	if err := u.passwordPolicy.Validate(password); err != nil {
+		return "", err
+	}
+	return u.passwordHasher.Hash(password)
+}
+
+func (u *UserUseCase) CreateUser(ctx context.Context, name, email, role, password string) (*domain.User, error) {
+	if !domain.IsValidRole(role) {
+		return nil, &domain.InvalidRoleError{Role: role}
+	}
+
+	passwordHash, err := u.hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
-	existingUser, err := u.userRepo.GetByEmail(email)
+	existingUser, err := u.userRepo.GetByEmail(ctx, email)
 	if err == nil && existingUser != nil {
 		return nil, &domain.UserAlreadyExistsError{Email: email}
 	}
@@ -27,73 +54,242 @@ func (u *UserUseCase) CreateUser(name, email, role, password string) (*domain.Us
 		Name:      name,
 		Email:     email,
 		Role:      role,
-		Password:  password, // Note: In a real application, this should be hashed
+		Password:  passwordHash,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	if err := u.userRepo.Create(user); err != nil {
+	if err := u.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (u *UserUseCase) GetUser(id int64) (*domain.User, error) {
-	user, err := u.userRepo.GetByID(id)
+func (u *UserUseCase) GetUser(ctx context.Context, id int64) (*domain.User, error) {
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	if user == nil {
 		return nil, &domain.UserNotFoundError{UserID: id}
 	}
 	return user, nil
 }
 
-func (u *UserUseCase) GetUserByEmail(email string) (*domain.User, error) {
-	user, err := u.userRepo.GetByEmail(email)
+func (u *UserUseCase) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := u.userRepo.GetByEmail(ctx, email)
 	if err != nil {
+		return nil, err
+	}
+	if user == nil {
 		return nil, &domain.UserNotFoundError{UserID: 0} // We don't have the ID in this case
 	}
 	return user, nil
 }
 
-func (u *UserUseCase) GetAllUsers() ([]*domain.User, error) {
-	users, err := u.userRepo.GetAll()
+// GetAllUsers retrieves a page of the users matching filter, along with the
+// number of users matching it across all pages
+func (u *UserUseCase) GetAllUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	if filter.Role != "" && !domain.IsValidRole(filter.Role) {
+		return nil, 0, &domain.InvalidRoleError{Role: filter.Role}
+	}
+	if err := filter.ListOptions.Normalize(domain.UserSortFields); err != nil {
+		return nil, 0, err
+	}
+
+	users, err := u.userRepo.GetAll(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	total, err := u.userRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	result := make([]*domain.User, len(users))
 	for i := range users {
 		result[i] = &users[i]
 	}
-	return result, nil
+	return result, total, nil
 }
 
-func (u *UserUseCase) UpdateUser(user *domain.User) error {
+func (u *UserUseCase) UpdateUser(ctx context.Context, user *domain.User) error {
+	if !domain.IsValidRole(user.Role) {
+		return &domain.InvalidRoleError{Role: user.Role}
+	}
+
 	// Check if user exists
-	existingUser, err := u.userRepo.GetByID(user.ID)
+	existingUser, err := u.userRepo.GetByID(ctx, user.ID)
 	if err != nil {
+		return err
+	}
+	if existingUser == nil {
 		return &domain.UserNotFoundError{UserID: user.ID}
 	}
 
 	// Update only allowed fields
+	existingUser.Version = basedOn(user.Version, existingUser.Version)
 	existingUser.Name = user.Name
 	existingUser.Email = user.Email
 	existingUser.Role = user.Role
 	existingUser.UpdatedAt = time.Now()
 
 	if user.Password != "" {
-		existingUser.Password = user.Password // Note: In a real application, this should be hashed
+		passwordHash, err := u.hashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		existingUser.Password = passwordHash
 	}
 
-	return u.userRepo.Update(existingUser)
+	if err := u.userRepo.Update(ctx, existingUser); err != nil {
+		return err
+	}
+	user.Version = existingUser.Version
+	return nil
 }
 
-func (u *UserUseCase) DeleteUser(id int64) error {
-	// Check if user exists
-	_, err := u.userRepo.GetByID(id)
+// PatchUser applies patch to the user id as of version, or of its current
+// version when zero, and saves the fields it changed. patch sees no password
+// and may set a new one, which must meet the password policy. Name, email
+// and a valid role are required.
+func (u *UserUseCase) PatchUser(ctx context.Context, id, version int64, patch func(*domain.User) error) (*domain.User, error) {
+	var user *domain.User
+	err := u.uow.Do(ctx, func(ctx context.Context) error {
+		existing, err := u.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &domain.UserNotFoundError{UserID: id}
+		}
+		if err := checkVersion("user", id, version, existing.Version); err != nil {
+			return err
+		}
+
+		patched := *existing
+		patched.Password = ""
+		if err := patch(&patched); err != nil {
+			return err
+		}
+		patched.ID, patched.Version = existing.ID, existing.Version
+		switch {
+		case patched.Name == "":
+			return &domain.RequiredFieldError{Entity: "user", Field: "name"}
+		case patched.Email == "":
+			return &domain.RequiredFieldError{Entity: "user", Field: "email"}
+		case !domain.IsValidRole(patched.Role):
+			return &domain.InvalidRoleError{Role: patched.Role}
+		}
+		if patched.Password == "" {
+			patched.Password = existing.Password
+		} else if patched.Password, err = u.hashPassword(patched.Password); err != nil {
+			return err
+		}
+
+		if fields := userChanges(existing, &patched); len(fields) > 0 {
+			if err := u.userRepo.Patch(ctx, &patched, fields); err != nil {
+				return err
+			}
+		}
+		user = &patched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the password of a user after checking the current one
+func (u *UserUseCase) ChangePassword(ctx context.Context, id int64, oldPassword, newPassword string) error {
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
+		return err
+	}
+	if user == nil {
 		return &domain.UserNotFoundError{UserID: id}
 	}
 
-	return u.userRepo.Delete(id)
+	match, _, err := u.passwordHasher.Verify(user.Password, oldPassword)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return &domain.IncorrectPasswordError{}
+	}
+
+	passwordHash, err := u.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = passwordHash
+	user.UpdatedAt = time.Now()
+	return u.userRepo.Update(ctx, user)
+}
+
+// DeleteUser soft deletes a user unless live units, transfers or purchase
+// orders still refer to them. Deleted users can no longer log in. A non-zero
+// version must be the current one.
+func (u *UserUseCase) DeleteUser(ctx context.Context, actor *domain.User, id, version int64) error {
+	return u.uow.Do(ctx, func(ctx context.Context) error {
+		if err := u.checkVersion(ctx, id, version); err != nil {
+			return err
+		}
+		return deleteUnreferenced(ctx, "user", id, actor, u.userRepo.Dependents, u.userRepo.Delete)
+	})
+}
+
+// ReassignAndDeleteUser makes the user toID the last editor of the units the
+// user last updated and deletes them, in one transaction, recording the change
+// of each unit in its history. The units keep their creator. Transfers and
+// purchase orders still block the deletion.
+func (u *UserUseCase) ReassignAndDeleteUser(ctx context.Context, actor *domain.User, id, version, toID int64) error {
+	if toID == id {
+		return &domain.InvalidReassignmentError{Reason: "a user cannot be reassigned to themselves"}
+	}
+
+	return u.uow.Do(ctx, func(ctx context.Context) error {
+		if err := u.checkVersion(ctx, id, version); err != nil {
+			return err
+		}
+		target, err := u.userRepo.GetByID(ctx, toID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return &domain.InvalidReassignmentError{Reason: fmt.Sprintf("user with ID %d not found", toID)}
+		}
+
+		if _, err := u.stockRepo.Reassign(ctx, domain.StockUserReference, id, toID, actor, fmt.Sprintf("reassigned from user %d", id)); err != nil {
+			return err
+		}
+		return deleteUnreferenced(ctx, "user", id, actor, u.userRepo.Dependents, u.userRepo.Delete)
+	})
+}
+
+// checkVersion rejects a change of user id based on version when the user has
+// been modified since. A zero version skips the check.
+func (u *UserUseCase) checkVersion(ctx context.Context, id, version int64) error {
+	if version == 0 {
+		return nil
+	}
+	user, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return &domain.UserNotFoundError{UserID: id}
+	}
+	return checkVersion("user", id, version, user.Version)
+}
+
+// RestoreUser brings back a soft-deleted user
+func (u *UserUseCase) RestoreUser(ctx context.Context, id int64) (*domain.User, error) {
+	if err := u.userRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.GetUser(ctx, id)
 }
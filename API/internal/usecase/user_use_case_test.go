@@ -1,13 +1,22 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"inventario/internal/domain"
 	"inventario/internal/infrastructure/repository"
+	"inventario/internal/infrastructure/security"
+	"reflect"
 	"testing"
 	"time"
 )
 
+var testPasswordHasher = security.NewPBKDF2Hasher(1000)
+
+func newTestUserUseCase(repo domain.IUserRepository) *UserUseCase {
+	return NewUserUseCase(repo, testPasswordHasher, domain.DefaultPasswordPolicy(), &repository.MockStockRepository{}, repository.NewMemoryUnitOfWork())
+}
+
 func TestCreateUser(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -33,13 +42,35 @@ func TestCreateUser(t *testing.T) {
 			name:         "user already exists",
 			userName:     "Existing User",
 			userEmail:    "existing@example.com",
-			userRole:     "user",
+			userRole:     "viewer",
 			userPassword: "password123",
 			mockCreate: func(u *domain.User) error {
 				return &domain.UserAlreadyExistsError{Email: "existing@example.com"}
 			},
 			expectedError: &domain.UserAlreadyExistsError{Email: "existing@example.com"},
 		},
+		{
+			name:         "invalid role",
+			userName:     "Test User",
+			userEmail:    "test@example.com",
+			userRole:     "superuser",
+			userPassword: "password123",
+			mockCreate: func(u *domain.User) error {
+				return nil
+			},
+			expectedError: &domain.InvalidRoleError{Role: "superuser"},
+		},
+		{
+			name:         "weak password",
+			userName:     "Test User",
+			userEmail:    "test@example.com",
+			userRole:     "viewer",
+			userPassword: "short",
+			mockCreate: func(u *domain.User) error {
+				return nil
+			},
+			expectedError: &domain.WeakPasswordError{Reasons: []string{"at least 8 characters"}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -47,9 +78,9 @@ func TestCreateUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				CreateFunc: tt.mockCreate,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			user, err := useCase.CreateUser(tt.userName, tt.userEmail, tt.userRole, tt.userPassword)
+			user, err := useCase.CreateUser(context.Background(), tt.userName, tt.userEmail, tt.userRole, tt.userPassword)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -69,8 +100,11 @@ func TestCreateUser(t *testing.T) {
 			if user.Role != tt.userRole {
 				t.Errorf("expected role %s, got %s", tt.userRole, user.Role)
 			}
-			if user.Password != tt.userPassword {
-				t.Errorf("expected password %s, got %s", tt.userPassword, user.Password)
+			if user.Password == tt.userPassword {
+				t.Errorf("expected password to be stored hashed")
+			}
+			if match, _, _ := testPasswordHasher.Verify(user.Password, tt.userPassword); !match {
+				t.Errorf("expected stored hash to match password %s", tt.userPassword)
 			}
 		})
 	}
@@ -107,6 +141,22 @@ func TestGetUser(t *testing.T) {
 			},
 			expectedError: &domain.UserNotFoundError{UserID: 999},
 		},
+		{
+			name:   "no user with the ID",
+			userID: 999,
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, nil
+			},
+			expectedError: &domain.UserNotFoundError{UserID: 999},
+		},
+		{
+			name:   "request cancelled",
+			userID: 1,
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, context.Canceled
+			},
+			expectedError: context.Canceled,
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,9 +164,9 @@ func TestGetUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				GetByIDFunc: tt.mockGetByID,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			user, err := useCase.GetUser(tt.userID)
+			user, err := useCase.GetUser(context.Background(), tt.userID)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -172,9 +222,9 @@ func TestGetUserByEmail(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				GetByEmailFunc: tt.mockGetByEmail,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			user, err := useCase.GetUserByEmail(tt.userEmail)
+			user, err := useCase.GetUserByEmail(context.Background(), tt.userEmail)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -215,7 +265,7 @@ func TestGetAllUsers(t *testing.T) {
 						ID:        2,
 						Name:      "User 2",
 						Email:     "user2@example.com",
-						Role:      "user",
+						Role:      "viewer",
 						Password:  "password456",
 						CreatedAt: time.Now(),
 						UpdatedAt: time.Now(),
@@ -238,9 +288,9 @@ func TestGetAllUsers(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				GetAllFunc: tt.mockGetAll,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			users, err := useCase.GetAllUsers()
+			users, _, err := useCase.GetAllUsers(context.Background(), domain.UserFilter{})
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -282,7 +332,7 @@ func TestUpdateUser(t *testing.T) {
 					ID:        1,
 					Name:      "Original User",
 					Email:     "original@example.com",
-					Role:      "user",
+					Role:      "viewer",
 					Password:  "oldpassword",
 					CreatedAt: time.Now(),
 					UpdatedAt: time.Now(),
@@ -299,7 +349,7 @@ func TestUpdateUser(t *testing.T) {
 				ID:        999,
 				Name:      "Non-existent User",
 				Email:     "nonexistent@example.com",
-				Role:      "user",
+				Role:      "viewer",
 				Password:  "password123",
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
@@ -312,6 +362,33 @@ func TestUpdateUser(t *testing.T) {
 			},
 			expectedError: &domain.UserNotFoundError{UserID: 999},
 		},
+		{
+			name: "no user with the ID",
+			user: &domain.User{
+				ID:    999,
+				Name:  "Non-existent User",
+				Email: "nonexistent@example.com",
+				Role:  "viewer",
+			},
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, nil
+			},
+			mockUpdate: func(u *domain.User) error {
+				return nil
+			},
+			expectedError: &domain.UserNotFoundError{UserID: 999},
+		},
+		{
+			name: "request cancelled",
+			user: &domain.User{ID: 1, Name: "Updated User", Email: "updated@example.com", Role: "viewer"},
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, context.Canceled
+			},
+			mockUpdate: func(u *domain.User) error {
+				return nil
+			},
+			expectedError: context.Canceled,
+		},
 	}
 
 	for _, tt := range tests {
@@ -320,9 +397,9 @@ func TestUpdateUser(t *testing.T) {
 				GetByIDFunc: tt.mockGetByID,
 				UpdateFunc:  tt.mockUpdate,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			err := useCase.UpdateUser(tt.user)
+			err := useCase.UpdateUser(context.Background(), tt.user)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -336,17 +413,107 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestPatchUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          func(*domain.User)
+		expectedFields []string
+		expectedError  error
+	}{
+		{
+			name:           "role only",
+			patch:          func(u *domain.User) { u.Role = domain.RoleAdmin },
+			expectedFields: []string{"role"},
+		},
+		{
+			name: "new password",
+			patch: func(u *domain.User) {
+				if u.Password != "" {
+					t.Error("expected the patch not to see the password hash")
+				}
+				u.Password = "n3w-Passw0rd!"
+			},
+			expectedFields: []string{"password"},
+		},
+		{
+			name:  "nothing changed",
+			patch: func(u *domain.User) { u.Name = "Original User" },
+		},
+		{
+			name:          "weak password",
+			patch:         func(u *domain.User) { u.Password = "short" },
+			expectedError: domain.DefaultPasswordPolicy().Validate("short"),
+		},
+		{
+			name:          "invalid role",
+			patch:         func(u *domain.User) { u.Role = "owner" },
+			expectedError: &domain.InvalidRoleError{Role: "owner"},
+		},
+		{
+			name:          "email removed",
+			patch:         func(u *domain.User) { u.Email = "" },
+			expectedError: &domain.RequiredFieldError{Entity: "user", Field: "email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched *domain.User
+			var fields []string
+			mockRepo := &repository.MockUserRepository{
+				GetByIDFunc: func(id int64) (*domain.User, error) {
+					return &domain.User{ID: id, Name: "Original User", Email: "original@example.com", Role: domain.RoleViewer, Password: "old-hash", Version: 3}, nil
+				},
+				PatchFunc: func(u *domain.User, f []string) error {
+					patched, fields = u, f
+					return nil
+				},
+			}
+			useCase := newTestUserUseCase(mockRepo)
+
+			user, err := useCase.PatchUser(context.Background(), 1, 3, func(u *domain.User) error {
+				tt.patch(u)
+				return nil
+			})
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(fields, tt.expectedFields) {
+				t.Errorf("expected fields %v to be saved, got %v", tt.expectedFields, fields)
+			}
+			if tt.expectedFields == nil && patched != nil {
+				t.Error("expected nothing to be saved")
+			}
+			switch {
+			case reflect.DeepEqual(fields, []string{"password"}):
+				if user.Password == "old-hash" || user.Password == "n3w-Passw0rd!" {
+					t.Errorf("expected the new password to be hashed, got %s", user.Password)
+				}
+			case user.Password != "old-hash":
+				t.Errorf("expected the password to be kept, got %s", user.Password)
+			}
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	tests := []struct {
 		name          string
 		userID        int64
-		mockDelete    func(int64) error
+		mockDelete    func(int64, int64) error
 		expectedError error
 	}{
 		{
 			name:   "successful deletion",
 			userID: 1,
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return nil
 			},
 			expectedError: nil,
@@ -354,11 +521,19 @@ func TestDeleteUser(t *testing.T) {
 		{
 			name:   "user not found",
 			userID: 999,
-			mockDelete: func(id int64) error {
+			mockDelete: func(id, deletedBy int64) error {
 				return &domain.UserNotFoundError{UserID: 999}
 			},
 			expectedError: &domain.UserNotFoundError{UserID: 999},
 		},
+		{
+			name:   "request timed out",
+			userID: 1,
+			mockDelete: func(id, deletedBy int64) error {
+				return context.DeadlineExceeded
+			},
+			expectedError: context.DeadlineExceeded,
+		},
 	}
 
 	for _, tt := range tests {
@@ -366,9 +541,9 @@ func TestDeleteUser(t *testing.T) {
 			mockRepo := &repository.MockUserRepository{
 				DeleteFunc: tt.mockDelete,
 			}
-			useCase := NewUserUseCase(mockRepo)
+			useCase := newTestUserUseCase(mockRepo)
 
-			err := useCase.DeleteUser(tt.userID)
+			err := useCase.DeleteUser(context.Background(), nil, tt.userID, 0)
 			if err != nil {
 				if tt.expectedError == nil {
 					t.Errorf("unexpected error: %v", err)
@@ -381,3 +556,84 @@ func TestDeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestChangePassword(t *testing.T) {
+	currentHash, _ := testPasswordHasher.Hash("oldpassword")
+
+	tests := []struct {
+		name          string
+		oldPassword   string
+		newPassword   string
+		mockGetByID   func(int64) (*domain.User, error)
+		expectedError error
+	}{
+		{
+			name:        "successful change",
+			oldPassword: "oldpassword",
+			newPassword: "newpassword",
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return &domain.User{ID: id, Role: "viewer", Password: currentHash}, nil
+			},
+			expectedError: nil,
+		},
+		{
+			name:        "wrong current password",
+			oldPassword: "wrongpassword",
+			newPassword: "newpassword",
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return &domain.User{ID: id, Role: "viewer", Password: currentHash}, nil
+			},
+			expectedError: &domain.IncorrectPasswordError{},
+		},
+		{
+			name:        "new password too weak",
+			oldPassword: "oldpassword",
+			newPassword: "short",
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return &domain.User{ID: id, Role: "viewer", Password: currentHash}, nil
+			},
+			expectedError: &domain.WeakPasswordError{Reasons: []string{"at least 8 characters"}},
+		},
+		{
+			name:        "user not found",
+			oldPassword: "oldpassword",
+			newPassword: "newpassword",
+			mockGetByID: func(id int64) (*domain.User, error) {
+				return nil, nil
+			},
+			expectedError: &domain.UserNotFoundError{UserID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *domain.User
+			mockRepo := &repository.MockUserRepository{
+				GetByIDFunc: tt.mockGetByID,
+				UpdateFunc: func(u *domain.User) error {
+					updated = u
+					return nil
+				},
+			}
+			useCase := newTestUserUseCase(mockRepo)
+
+			err := useCase.ChangePassword(context.Background(), 1, tt.oldPassword, tt.newPassword)
+			if err != nil {
+				if tt.expectedError == nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if tt.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tt.expectedError)
+			}
+
+			if match, _, _ := testPasswordHasher.Verify(updated.Password, tt.newPassword); !match {
+				t.Errorf("expected new password to be stored")
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package usecase
+
+import "inventario/internal/domain"
+
+// checkVersion rejects a change of the entity id based on version when the
+// entity is at another one by now. A zero version, from a client that sent no
+// If-Match, skips the check.
+func checkVersion(entity string, id, version, current int64) error {
+	if version != 0 && version != current {
+		return &domain.VersionMismatchError{Entity: entity, ID: id, Version: version}
+	}
+	return nil
+}
+
+// basedOn returns the version an update applies to: the one the client based
+// it on or, when it sent none, the current one
+func basedOn(version, current int64) int64 {
+	if version != 0 {
+		return version
+	}
+	return current
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"inventario/internal/domain"
+)
+
+type WarehouseUseCase struct {
+	warehouseRepo domain.IWarehouseRepository
+	locationRepo  domain.ILocationRepository
+}
+
+func NewWarehouseUseCase(warehouseRepo domain.IWarehouseRepository, locationRepo domain.ILocationRepository) *WarehouseUseCase {
+	return &WarehouseUseCase{
+		warehouseRepo: warehouseRepo,
+		locationRepo:  locationRepo,
+	}
+}
+
+func (u *WarehouseUseCase) CreateWarehouse(ctx context.Context, code, name, address string) (*domain.Warehouse, error) {
+	warehouse := &domain.Warehouse{
+		Code:    code,
+		Name:    name,
+		Address: address,
+	}
+
+	if err := u.warehouseRepo.Create(ctx, warehouse); err != nil {
+		return nil, err
+	}
+
+	return warehouse, nil
+}
+
+func (u *WarehouseUseCase) GetWarehouse(ctx context.Context, id int64) (*domain.Warehouse, error) {
+	warehouse, err := u.warehouseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if warehouse == nil {
+		return nil, &domain.WarehouseNotFoundError{WarehouseID: id}
+	}
+
+	return warehouse, nil
+}
+
+func (u *WarehouseUseCase) GetAllWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	return u.warehouseRepo.GetAll(ctx)
+}
+
+func (u *WarehouseUseCase) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	if _, err := u.GetWarehouse(ctx, warehouse.ID); err != nil {
+		return err
+	}
+
+	return u.warehouseRepo.Update(ctx, warehouse)
+}
+
+func (u *WarehouseUseCase) DeleteWarehouse(ctx context.Context, id int64) error {
+	if _, err := u.GetWarehouse(ctx, id); err != nil {
+		return err
+	}
+
+	return u.warehouseRepo.Delete(ctx, id)
+}
+
+// GetWarehouseLocations returns the bin locations of a warehouse
+func (u *WarehouseUseCase) GetWarehouseLocations(ctx context.Context, id int64) ([]domain.Location, error) {
+	if _, err := u.GetWarehouse(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.locationRepo.GetByWarehouseID(ctx, id)
+}